@@ -15,6 +15,9 @@ import (
 //
 //		// make and configure a mocked api.YoutubeStore
 //		mockedYoutubeStore := &YoutubeStoreMock{
+//			ListFailuresFunc: func(channelID string) ([]ytfeed.Failure, error) {
+//				panic("mock out the ListFailures method")
+//			},
 //			LoadFunc: func(channelID string, maxItems int) ([]ytfeed.Entry, error) {
 //				panic("mock out the Load method")
 //			},
@@ -25,11 +28,19 @@ import (
 //
 //	}
 type YoutubeStoreMock struct {
+	// ListFailuresFunc mocks the ListFailures method.
+	ListFailuresFunc func(channelID string) ([]ytfeed.Failure, error)
+
 	// LoadFunc mocks the Load method.
 	LoadFunc func(channelID string, maxItems int) ([]ytfeed.Entry, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// ListFailures holds details about calls to the ListFailures method.
+		ListFailures []struct {
+			// ChannelID is the channelID argument value.
+			ChannelID string
+		}
 		// Load holds details about calls to the Load method.
 		Load []struct {
 			// ChannelID is the channelID argument value.
@@ -38,7 +49,40 @@ type YoutubeStoreMock struct {
 			MaxItems int
 		}
 	}
-	lockLoad sync.RWMutex
+	lockListFailures sync.RWMutex
+	lockLoad         sync.RWMutex
+}
+
+// ListFailures calls ListFailuresFunc.
+func (mock *YoutubeStoreMock) ListFailures(channelID string) ([]ytfeed.Failure, error) {
+	if mock.ListFailuresFunc == nil {
+		panic("YoutubeStoreMock.ListFailuresFunc: method is nil but YoutubeStore.ListFailures was just called")
+	}
+	callInfo := struct {
+		ChannelID string
+	}{
+		ChannelID: channelID,
+	}
+	mock.lockListFailures.Lock()
+	mock.calls.ListFailures = append(mock.calls.ListFailures, callInfo)
+	mock.lockListFailures.Unlock()
+	return mock.ListFailuresFunc(channelID)
+}
+
+// ListFailuresCalls gets all the calls that were made to ListFailures.
+// Check the length with:
+//
+//	len(mockedYoutubeStore.ListFailuresCalls())
+func (mock *YoutubeStoreMock) ListFailuresCalls() []struct {
+	ChannelID string
+} {
+	var calls []struct {
+		ChannelID string
+	}
+	mock.lockListFailures.RLock()
+	calls = mock.calls.ListFailures
+	mock.lockListFailures.RUnlock()
+	return calls
 }
 
 // Load calls LoadFunc.
@@ -4,6 +4,8 @@
 package mocks
 
 import (
+	"context"
+	"net/url"
 	"sync"
 
 	"github.com/umputun/feed-master/app/youtube"
@@ -16,15 +18,63 @@ import (
 //
 //		// make and configure a mocked api.YoutubeSvc
 //		mockedYoutubeSvc := &YoutubeSvcMock{
+//			AddChannelFunc: func(ctx context.Context, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+//				panic("mock out the AddChannel method")
+//			},
+//			ChannelsFunc: func() []youtube.FeedInfo {
+//				panic("mock out the Channels method")
+//			},
+//			DeleteChannelFunc: func(id string, purge bool) ([]ytfeed.Entry, error) {
+//				panic("mock out the DeleteChannel method")
+//			},
+//			JobFunc: func(id string) (youtube.Job, bool) {
+//				panic("mock out the Job method")
+//			},
+//			LastReconcileFunc: func() (youtube.ReconcileReport, bool) {
+//				panic("mock out the LastReconcile method")
+//			},
+//			NewEntryFunc: func(ctx context.Context, ref string, channelID string) (ytfeed.Entry, error) {
+//				panic("mock out the NewEntry method")
+//			},
+//			NotifyFunc: func(body []byte, signature string) ([]string, error) {
+//				panic("mock out the Notify method")
+//			},
+//			PauseChannelFunc: func(id string, paused bool) (youtube.FeedInfo, error) {
+//				panic("mock out the PauseChannel method")
+//			},
+//			QueueAddFunc: func(entry ytfeed.Entry) (youtube.Job, error) {
+//				panic("mock out the QueueAdd method")
+//			},
+//			QueueRedownloadFunc: func(channelID string, videoID string) (youtube.Job, error) {
+//				panic("mock out the QueueRedownload method")
+//			},
 //			RSSFeedFunc: func(cinfo youtube.FeedInfo) (string, error) {
 //				panic("mock out the RSSFeed method")
 //			},
+//			ReconcileFunc: func(dryRun bool) (youtube.ReconcileReport, error) {
+//				panic("mock out the Reconcile method")
+//			},
+//			RefreshEntryFunc: func(ctx context.Context, channelID string, videoID string, redownload bool) (ytfeed.Entry, error) {
+//				panic("mock out the RefreshEntry method")
+//			},
 //			RemoveEntryFunc: func(entry ytfeed.Entry) error {
 //				panic("mock out the RemoveEntry method")
 //			},
+//			ResetChannelFunc: func(id string) (youtube.FeedInfo, error) {
+//				panic("mock out the ResetChannel method")
+//			},
 //			StoreRSSFunc: func(chanID string, rss string) error {
 //				panic("mock out the StoreRSS method")
 //			},
+//			UpdateChannelFunc: func(id string, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+//				panic("mock out the UpdateChannel method")
+//			},
+//			UsageFunc: func() youtube.Usage {
+//				panic("mock out the Usage method")
+//			},
+//			VerifySubscriptionFunc: func(q url.Values) (string, error) {
+//				panic("mock out the VerifySubscription method")
+//			},
 //		}
 //
 //		// use mockedYoutubeSvc in code that requires api.YoutubeSvc
@@ -32,27 +82,156 @@ import (
 //
 //	}
 type YoutubeSvcMock struct {
+	// AddChannelFunc mocks the AddChannel method.
+	AddChannelFunc func(ctx context.Context, fi youtube.FeedInfo) (youtube.FeedInfo, error)
+
+	// ChannelsFunc mocks the Channels method.
+	ChannelsFunc func() []youtube.FeedInfo
+
+	// DeleteChannelFunc mocks the DeleteChannel method.
+	DeleteChannelFunc func(id string, purge bool) ([]ytfeed.Entry, error)
+
+	// JobFunc mocks the Job method.
+	JobFunc func(id string) (youtube.Job, bool)
+
+	// LastReconcileFunc mocks the LastReconcile method.
+	LastReconcileFunc func() (youtube.ReconcileReport, bool)
+
+	// NewEntryFunc mocks the NewEntry method.
+	NewEntryFunc func(ctx context.Context, ref string, channelID string) (ytfeed.Entry, error)
+
+	// NotifyFunc mocks the Notify method.
+	NotifyFunc func(body []byte, signature string) ([]string, error)
+
+	// PauseChannelFunc mocks the PauseChannel method.
+	PauseChannelFunc func(id string, paused bool) (youtube.FeedInfo, error)
+
+	// QueueAddFunc mocks the QueueAdd method.
+	QueueAddFunc func(entry ytfeed.Entry) (youtube.Job, error)
+
+	// QueueRedownloadFunc mocks the QueueRedownload method.
+	QueueRedownloadFunc func(channelID string, videoID string) (youtube.Job, error)
+
 	// RSSFeedFunc mocks the RSSFeed method.
 	RSSFeedFunc func(cinfo youtube.FeedInfo) (string, error)
 
+	// ReconcileFunc mocks the Reconcile method.
+	ReconcileFunc func(dryRun bool) (youtube.ReconcileReport, error)
+
+	// RefreshEntryFunc mocks the RefreshEntry method.
+	RefreshEntryFunc func(ctx context.Context, channelID string, videoID string, redownload bool) (ytfeed.Entry, error)
+
 	// RemoveEntryFunc mocks the RemoveEntry method.
 	RemoveEntryFunc func(entry ytfeed.Entry) error
 
+	// ResetChannelFunc mocks the ResetChannel method.
+	ResetChannelFunc func(id string) (youtube.FeedInfo, error)
+
 	// StoreRSSFunc mocks the StoreRSS method.
 	StoreRSSFunc func(chanID string, rss string) error
 
+	// UpdateChannelFunc mocks the UpdateChannel method.
+	UpdateChannelFunc func(id string, fi youtube.FeedInfo) (youtube.FeedInfo, error)
+
+	// UsageFunc mocks the Usage method.
+	UsageFunc func() youtube.Usage
+
+	// VerifySubscriptionFunc mocks the VerifySubscription method.
+	VerifySubscriptionFunc func(q url.Values) (string, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddChannel holds details about calls to the AddChannel method.
+		AddChannel []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Fi is the fi argument value.
+			Fi youtube.FeedInfo
+		}
+		// Channels holds details about calls to the Channels method.
+		Channels []struct {
+		}
+		// DeleteChannel holds details about calls to the DeleteChannel method.
+		DeleteChannel []struct {
+			// ID is the id argument value.
+			ID string
+			// Purge is the purge argument value.
+			Purge bool
+		}
+		// Job holds details about calls to the Job method.
+		Job []struct {
+			// ID is the id argument value.
+			ID string
+		}
+		// LastReconcile holds details about calls to the LastReconcile method.
+		LastReconcile []struct {
+		}
+		// NewEntry holds details about calls to the NewEntry method.
+		NewEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Ref is the ref argument value.
+			Ref string
+			// ChannelID is the channelID argument value.
+			ChannelID string
+		}
+		// Notify holds details about calls to the Notify method.
+		Notify []struct {
+			// Body is the body argument value.
+			Body []byte
+			// Signature is the signature argument value.
+			Signature string
+		}
+		// PauseChannel holds details about calls to the PauseChannel method.
+		PauseChannel []struct {
+			// ID is the id argument value.
+			ID string
+			// Paused is the paused argument value.
+			Paused bool
+		}
+		// QueueAdd holds details about calls to the QueueAdd method.
+		QueueAdd []struct {
+			// Entry is the entry argument value.
+			Entry ytfeed.Entry
+		}
+		// QueueRedownload holds details about calls to the QueueRedownload method.
+		QueueRedownload []struct {
+			// ChannelID is the channelID argument value.
+			ChannelID string
+			// VideoID is the videoID argument value.
+			VideoID string
+		}
 		// RSSFeed holds details about calls to the RSSFeed method.
 		RSSFeed []struct {
 			// Cinfo is the cinfo argument value.
 			Cinfo youtube.FeedInfo
 		}
+		// Reconcile holds details about calls to the Reconcile method.
+		Reconcile []struct {
+			// DryRun is the dryRun argument value.
+			DryRun bool
+		}
+		// RefreshEntry holds details about calls to the RefreshEntry method.
+		RefreshEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ChannelID is the channelID argument value.
+			ChannelID string
+			// VideoID is the videoID argument value.
+			VideoID string
+			// Redownload is the redownload argument value.
+			Redownload bool
+		}
 		// RemoveEntry holds details about calls to the RemoveEntry method.
 		RemoveEntry []struct {
 			// Entry is the entry argument value.
 			Entry ytfeed.Entry
 		}
+		// ResetChannel holds details about calls to the ResetChannel method.
+		ResetChannel []struct {
+			// ID is the id argument value.
+			ID string
+		}
 		// StoreRSS holds details about calls to the StoreRSS method.
 		StoreRSS []struct {
 			// ChanID is the chanID argument value.
@@ -60,10 +239,379 @@ type YoutubeSvcMock struct {
 			// Rss is the rss argument value.
 			Rss string
 		}
+		// UpdateChannel holds details about calls to the UpdateChannel method.
+		UpdateChannel []struct {
+			// ID is the id argument value.
+			ID string
+			// Fi is the fi argument value.
+			Fi youtube.FeedInfo
+		}
+		// Usage holds details about calls to the Usage method.
+		Usage []struct {
+		}
+		// VerifySubscription holds details about calls to the VerifySubscription method.
+		VerifySubscription []struct {
+			// Q is the q argument value.
+			Q url.Values
+		}
+	}
+	lockAddChannel         sync.RWMutex
+	lockChannels           sync.RWMutex
+	lockDeleteChannel      sync.RWMutex
+	lockJob                sync.RWMutex
+	lockLastReconcile      sync.RWMutex
+	lockNewEntry           sync.RWMutex
+	lockNotify             sync.RWMutex
+	lockPauseChannel       sync.RWMutex
+	lockQueueAdd           sync.RWMutex
+	lockQueueRedownload    sync.RWMutex
+	lockRSSFeed            sync.RWMutex
+	lockReconcile          sync.RWMutex
+	lockRefreshEntry       sync.RWMutex
+	lockRemoveEntry        sync.RWMutex
+	lockResetChannel       sync.RWMutex
+	lockStoreRSS           sync.RWMutex
+	lockUpdateChannel      sync.RWMutex
+	lockUsage              sync.RWMutex
+	lockVerifySubscription sync.RWMutex
+}
+
+// AddChannel calls AddChannelFunc.
+func (mock *YoutubeSvcMock) AddChannel(ctx context.Context, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+	if mock.AddChannelFunc == nil {
+		panic("YoutubeSvcMock.AddChannelFunc: method is nil but YoutubeSvc.AddChannel was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Fi  youtube.FeedInfo
+	}{
+		Ctx: ctx,
+		Fi:  fi,
+	}
+	mock.lockAddChannel.Lock()
+	mock.calls.AddChannel = append(mock.calls.AddChannel, callInfo)
+	mock.lockAddChannel.Unlock()
+	return mock.AddChannelFunc(ctx, fi)
+}
+
+// AddChannelCalls gets all the calls that were made to AddChannel.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.AddChannelCalls())
+func (mock *YoutubeSvcMock) AddChannelCalls() []struct {
+	Ctx context.Context
+	Fi  youtube.FeedInfo
+} {
+	var calls []struct {
+		Ctx context.Context
+		Fi  youtube.FeedInfo
+	}
+	mock.lockAddChannel.RLock()
+	calls = mock.calls.AddChannel
+	mock.lockAddChannel.RUnlock()
+	return calls
+}
+
+// Channels calls ChannelsFunc.
+func (mock *YoutubeSvcMock) Channels() []youtube.FeedInfo {
+	if mock.ChannelsFunc == nil {
+		panic("YoutubeSvcMock.ChannelsFunc: method is nil but YoutubeSvc.Channels was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockChannels.Lock()
+	mock.calls.Channels = append(mock.calls.Channels, callInfo)
+	mock.lockChannels.Unlock()
+	return mock.ChannelsFunc()
+}
+
+// ChannelsCalls gets all the calls that were made to Channels.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.ChannelsCalls())
+func (mock *YoutubeSvcMock) ChannelsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockChannels.RLock()
+	calls = mock.calls.Channels
+	mock.lockChannels.RUnlock()
+	return calls
+}
+
+// DeleteChannel calls DeleteChannelFunc.
+func (mock *YoutubeSvcMock) DeleteChannel(id string, purge bool) ([]ytfeed.Entry, error) {
+	if mock.DeleteChannelFunc == nil {
+		panic("YoutubeSvcMock.DeleteChannelFunc: method is nil but YoutubeSvc.DeleteChannel was just called")
+	}
+	callInfo := struct {
+		ID    string
+		Purge bool
+	}{
+		ID:    id,
+		Purge: purge,
+	}
+	mock.lockDeleteChannel.Lock()
+	mock.calls.DeleteChannel = append(mock.calls.DeleteChannel, callInfo)
+	mock.lockDeleteChannel.Unlock()
+	return mock.DeleteChannelFunc(id, purge)
+}
+
+// DeleteChannelCalls gets all the calls that were made to DeleteChannel.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.DeleteChannelCalls())
+func (mock *YoutubeSvcMock) DeleteChannelCalls() []struct {
+	ID    string
+	Purge bool
+} {
+	var calls []struct {
+		ID    string
+		Purge bool
+	}
+	mock.lockDeleteChannel.RLock()
+	calls = mock.calls.DeleteChannel
+	mock.lockDeleteChannel.RUnlock()
+	return calls
+}
+
+// Job calls JobFunc.
+func (mock *YoutubeSvcMock) Job(id string) (youtube.Job, bool) {
+	if mock.JobFunc == nil {
+		panic("YoutubeSvcMock.JobFunc: method is nil but YoutubeSvc.Job was just called")
+	}
+	callInfo := struct {
+		ID string
+	}{
+		ID: id,
+	}
+	mock.lockJob.Lock()
+	mock.calls.Job = append(mock.calls.Job, callInfo)
+	mock.lockJob.Unlock()
+	return mock.JobFunc(id)
+}
+
+// JobCalls gets all the calls that were made to Job.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.JobCalls())
+func (mock *YoutubeSvcMock) JobCalls() []struct {
+	ID string
+} {
+	var calls []struct {
+		ID string
+	}
+	mock.lockJob.RLock()
+	calls = mock.calls.Job
+	mock.lockJob.RUnlock()
+	return calls
+}
+
+// LastReconcile calls LastReconcileFunc.
+func (mock *YoutubeSvcMock) LastReconcile() (youtube.ReconcileReport, bool) {
+	if mock.LastReconcileFunc == nil {
+		panic("YoutubeSvcMock.LastReconcileFunc: method is nil but YoutubeSvc.LastReconcile was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockLastReconcile.Lock()
+	mock.calls.LastReconcile = append(mock.calls.LastReconcile, callInfo)
+	mock.lockLastReconcile.Unlock()
+	return mock.LastReconcileFunc()
+}
+
+// LastReconcileCalls gets all the calls that were made to LastReconcile.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.LastReconcileCalls())
+func (mock *YoutubeSvcMock) LastReconcileCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockLastReconcile.RLock()
+	calls = mock.calls.LastReconcile
+	mock.lockLastReconcile.RUnlock()
+	return calls
+}
+
+// NewEntry calls NewEntryFunc.
+func (mock *YoutubeSvcMock) NewEntry(ctx context.Context, ref string, channelID string) (ytfeed.Entry, error) {
+	if mock.NewEntryFunc == nil {
+		panic("YoutubeSvcMock.NewEntryFunc: method is nil but YoutubeSvc.NewEntry was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Ref       string
+		ChannelID string
+	}{
+		Ctx:       ctx,
+		Ref:       ref,
+		ChannelID: channelID,
+	}
+	mock.lockNewEntry.Lock()
+	mock.calls.NewEntry = append(mock.calls.NewEntry, callInfo)
+	mock.lockNewEntry.Unlock()
+	return mock.NewEntryFunc(ctx, ref, channelID)
+}
+
+// NewEntryCalls gets all the calls that were made to NewEntry.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.NewEntryCalls())
+func (mock *YoutubeSvcMock) NewEntryCalls() []struct {
+	Ctx       context.Context
+	Ref       string
+	ChannelID string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Ref       string
+		ChannelID string
+	}
+	mock.lockNewEntry.RLock()
+	calls = mock.calls.NewEntry
+	mock.lockNewEntry.RUnlock()
+	return calls
+}
+
+// Notify calls NotifyFunc.
+func (mock *YoutubeSvcMock) Notify(body []byte, signature string) ([]string, error) {
+	if mock.NotifyFunc == nil {
+		panic("YoutubeSvcMock.NotifyFunc: method is nil but YoutubeSvc.Notify was just called")
+	}
+	callInfo := struct {
+		Body      []byte
+		Signature string
+	}{
+		Body:      body,
+		Signature: signature,
 	}
-	lockRSSFeed     sync.RWMutex
-	lockRemoveEntry sync.RWMutex
-	lockStoreRSS    sync.RWMutex
+	mock.lockNotify.Lock()
+	mock.calls.Notify = append(mock.calls.Notify, callInfo)
+	mock.lockNotify.Unlock()
+	return mock.NotifyFunc(body, signature)
+}
+
+// NotifyCalls gets all the calls that were made to Notify.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.NotifyCalls())
+func (mock *YoutubeSvcMock) NotifyCalls() []struct {
+	Body      []byte
+	Signature string
+} {
+	var calls []struct {
+		Body      []byte
+		Signature string
+	}
+	mock.lockNotify.RLock()
+	calls = mock.calls.Notify
+	mock.lockNotify.RUnlock()
+	return calls
+}
+
+// PauseChannel calls PauseChannelFunc.
+func (mock *YoutubeSvcMock) PauseChannel(id string, paused bool) (youtube.FeedInfo, error) {
+	if mock.PauseChannelFunc == nil {
+		panic("YoutubeSvcMock.PauseChannelFunc: method is nil but YoutubeSvc.PauseChannel was just called")
+	}
+	callInfo := struct {
+		ID     string
+		Paused bool
+	}{
+		ID:     id,
+		Paused: paused,
+	}
+	mock.lockPauseChannel.Lock()
+	mock.calls.PauseChannel = append(mock.calls.PauseChannel, callInfo)
+	mock.lockPauseChannel.Unlock()
+	return mock.PauseChannelFunc(id, paused)
+}
+
+// PauseChannelCalls gets all the calls that were made to PauseChannel.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.PauseChannelCalls())
+func (mock *YoutubeSvcMock) PauseChannelCalls() []struct {
+	ID     string
+	Paused bool
+} {
+	var calls []struct {
+		ID     string
+		Paused bool
+	}
+	mock.lockPauseChannel.RLock()
+	calls = mock.calls.PauseChannel
+	mock.lockPauseChannel.RUnlock()
+	return calls
+}
+
+// QueueAdd calls QueueAddFunc.
+func (mock *YoutubeSvcMock) QueueAdd(entry ytfeed.Entry) (youtube.Job, error) {
+	if mock.QueueAddFunc == nil {
+		panic("YoutubeSvcMock.QueueAddFunc: method is nil but YoutubeSvc.QueueAdd was just called")
+	}
+	callInfo := struct {
+		Entry ytfeed.Entry
+	}{
+		Entry: entry,
+	}
+	mock.lockQueueAdd.Lock()
+	mock.calls.QueueAdd = append(mock.calls.QueueAdd, callInfo)
+	mock.lockQueueAdd.Unlock()
+	return mock.QueueAddFunc(entry)
+}
+
+// QueueAddCalls gets all the calls that were made to QueueAdd.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.QueueAddCalls())
+func (mock *YoutubeSvcMock) QueueAddCalls() []struct {
+	Entry ytfeed.Entry
+} {
+	var calls []struct {
+		Entry ytfeed.Entry
+	}
+	mock.lockQueueAdd.RLock()
+	calls = mock.calls.QueueAdd
+	mock.lockQueueAdd.RUnlock()
+	return calls
+}
+
+// QueueRedownload calls QueueRedownloadFunc.
+func (mock *YoutubeSvcMock) QueueRedownload(channelID string, videoID string) (youtube.Job, error) {
+	if mock.QueueRedownloadFunc == nil {
+		panic("YoutubeSvcMock.QueueRedownloadFunc: method is nil but YoutubeSvc.QueueRedownload was just called")
+	}
+	callInfo := struct {
+		ChannelID string
+		VideoID   string
+	}{
+		ChannelID: channelID,
+		VideoID:   videoID,
+	}
+	mock.lockQueueRedownload.Lock()
+	mock.calls.QueueRedownload = append(mock.calls.QueueRedownload, callInfo)
+	mock.lockQueueRedownload.Unlock()
+	return mock.QueueRedownloadFunc(channelID, videoID)
+}
+
+// QueueRedownloadCalls gets all the calls that were made to QueueRedownload.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.QueueRedownloadCalls())
+func (mock *YoutubeSvcMock) QueueRedownloadCalls() []struct {
+	ChannelID string
+	VideoID   string
+} {
+	var calls []struct {
+		ChannelID string
+		VideoID   string
+	}
+	mock.lockQueueRedownload.RLock()
+	calls = mock.calls.QueueRedownload
+	mock.lockQueueRedownload.RUnlock()
+	return calls
 }
 
 // RSSFeed calls RSSFeedFunc.
@@ -98,6 +646,82 @@ func (mock *YoutubeSvcMock) RSSFeedCalls() []struct {
 	return calls
 }
 
+// Reconcile calls ReconcileFunc.
+func (mock *YoutubeSvcMock) Reconcile(dryRun bool) (youtube.ReconcileReport, error) {
+	if mock.ReconcileFunc == nil {
+		panic("YoutubeSvcMock.ReconcileFunc: method is nil but YoutubeSvc.Reconcile was just called")
+	}
+	callInfo := struct {
+		DryRun bool
+	}{
+		DryRun: dryRun,
+	}
+	mock.lockReconcile.Lock()
+	mock.calls.Reconcile = append(mock.calls.Reconcile, callInfo)
+	mock.lockReconcile.Unlock()
+	return mock.ReconcileFunc(dryRun)
+}
+
+// ReconcileCalls gets all the calls that were made to Reconcile.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.ReconcileCalls())
+func (mock *YoutubeSvcMock) ReconcileCalls() []struct {
+	DryRun bool
+} {
+	var calls []struct {
+		DryRun bool
+	}
+	mock.lockReconcile.RLock()
+	calls = mock.calls.Reconcile
+	mock.lockReconcile.RUnlock()
+	return calls
+}
+
+// RefreshEntry calls RefreshEntryFunc.
+func (mock *YoutubeSvcMock) RefreshEntry(ctx context.Context, channelID string, videoID string, redownload bool) (ytfeed.Entry, error) {
+	if mock.RefreshEntryFunc == nil {
+		panic("YoutubeSvcMock.RefreshEntryFunc: method is nil but YoutubeSvc.RefreshEntry was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		ChannelID  string
+		VideoID    string
+		Redownload bool
+	}{
+		Ctx:        ctx,
+		ChannelID:  channelID,
+		VideoID:    videoID,
+		Redownload: redownload,
+	}
+	mock.lockRefreshEntry.Lock()
+	mock.calls.RefreshEntry = append(mock.calls.RefreshEntry, callInfo)
+	mock.lockRefreshEntry.Unlock()
+	return mock.RefreshEntryFunc(ctx, channelID, videoID, redownload)
+}
+
+// RefreshEntryCalls gets all the calls that were made to RefreshEntry.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.RefreshEntryCalls())
+func (mock *YoutubeSvcMock) RefreshEntryCalls() []struct {
+	Ctx        context.Context
+	ChannelID  string
+	VideoID    string
+	Redownload bool
+} {
+	var calls []struct {
+		Ctx        context.Context
+		ChannelID  string
+		VideoID    string
+		Redownload bool
+	}
+	mock.lockRefreshEntry.RLock()
+	calls = mock.calls.RefreshEntry
+	mock.lockRefreshEntry.RUnlock()
+	return calls
+}
+
 // RemoveEntry calls RemoveEntryFunc.
 func (mock *YoutubeSvcMock) RemoveEntry(entry ytfeed.Entry) error {
 	if mock.RemoveEntryFunc == nil {
@@ -130,6 +754,38 @@ func (mock *YoutubeSvcMock) RemoveEntryCalls() []struct {
 	return calls
 }
 
+// ResetChannel calls ResetChannelFunc.
+func (mock *YoutubeSvcMock) ResetChannel(id string) (youtube.FeedInfo, error) {
+	if mock.ResetChannelFunc == nil {
+		panic("YoutubeSvcMock.ResetChannelFunc: method is nil but YoutubeSvc.ResetChannel was just called")
+	}
+	callInfo := struct {
+		ID string
+	}{
+		ID: id,
+	}
+	mock.lockResetChannel.Lock()
+	mock.calls.ResetChannel = append(mock.calls.ResetChannel, callInfo)
+	mock.lockResetChannel.Unlock()
+	return mock.ResetChannelFunc(id)
+}
+
+// ResetChannelCalls gets all the calls that were made to ResetChannel.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.ResetChannelCalls())
+func (mock *YoutubeSvcMock) ResetChannelCalls() []struct {
+	ID string
+} {
+	var calls []struct {
+		ID string
+	}
+	mock.lockResetChannel.RLock()
+	calls = mock.calls.ResetChannel
+	mock.lockResetChannel.RUnlock()
+	return calls
+}
+
 // StoreRSS calls StoreRSSFunc.
 func (mock *YoutubeSvcMock) StoreRSS(chanID string, rss string) error {
 	if mock.StoreRSSFunc == nil {
@@ -165,3 +821,98 @@ func (mock *YoutubeSvcMock) StoreRSSCalls() []struct {
 	mock.lockStoreRSS.RUnlock()
 	return calls
 }
+
+// UpdateChannel calls UpdateChannelFunc.
+func (mock *YoutubeSvcMock) UpdateChannel(id string, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+	if mock.UpdateChannelFunc == nil {
+		panic("YoutubeSvcMock.UpdateChannelFunc: method is nil but YoutubeSvc.UpdateChannel was just called")
+	}
+	callInfo := struct {
+		ID string
+		Fi youtube.FeedInfo
+	}{
+		ID: id,
+		Fi: fi,
+	}
+	mock.lockUpdateChannel.Lock()
+	mock.calls.UpdateChannel = append(mock.calls.UpdateChannel, callInfo)
+	mock.lockUpdateChannel.Unlock()
+	return mock.UpdateChannelFunc(id, fi)
+}
+
+// UpdateChannelCalls gets all the calls that were made to UpdateChannel.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.UpdateChannelCalls())
+func (mock *YoutubeSvcMock) UpdateChannelCalls() []struct {
+	ID string
+	Fi youtube.FeedInfo
+} {
+	var calls []struct {
+		ID string
+		Fi youtube.FeedInfo
+	}
+	mock.lockUpdateChannel.RLock()
+	calls = mock.calls.UpdateChannel
+	mock.lockUpdateChannel.RUnlock()
+	return calls
+}
+
+// Usage calls UsageFunc.
+func (mock *YoutubeSvcMock) Usage() youtube.Usage {
+	if mock.UsageFunc == nil {
+		panic("YoutubeSvcMock.UsageFunc: method is nil but YoutubeSvc.Usage was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockUsage.Lock()
+	mock.calls.Usage = append(mock.calls.Usage, callInfo)
+	mock.lockUsage.Unlock()
+	return mock.UsageFunc()
+}
+
+// UsageCalls gets all the calls that were made to Usage.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.UsageCalls())
+func (mock *YoutubeSvcMock) UsageCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockUsage.RLock()
+	calls = mock.calls.Usage
+	mock.lockUsage.RUnlock()
+	return calls
+}
+
+// VerifySubscription calls VerifySubscriptionFunc.
+func (mock *YoutubeSvcMock) VerifySubscription(q url.Values) (string, error) {
+	if mock.VerifySubscriptionFunc == nil {
+		panic("YoutubeSvcMock.VerifySubscriptionFunc: method is nil but YoutubeSvc.VerifySubscription was just called")
+	}
+	callInfo := struct {
+		Q url.Values
+	}{
+		Q: q,
+	}
+	mock.lockVerifySubscription.Lock()
+	mock.calls.VerifySubscription = append(mock.calls.VerifySubscription, callInfo)
+	mock.lockVerifySubscription.Unlock()
+	return mock.VerifySubscriptionFunc(q)
+}
+
+// VerifySubscriptionCalls gets all the calls that were made to VerifySubscription.
+// Check the length with:
+//
+//	len(mockedYoutubeSvc.VerifySubscriptionCalls())
+func (mock *YoutubeSvcMock) VerifySubscriptionCalls() []struct {
+	Q url.Values
+} {
+	var calls []struct {
+		Q url.Values
+	}
+	mock.lockVerifySubscription.RLock()
+	calls = mock.calls.VerifySubscription
+	mock.lockVerifySubscription.RUnlock()
+	return calls
+}
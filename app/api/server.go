@@ -4,13 +4,17 @@ package api
 import (
 	"context"
 	"crypto/subtle"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +27,7 @@ import (
 
 	"github.com/umputun/feed-master/app/config"
 	"github.com/umputun/feed-master/app/feed"
+	"github.com/umputun/feed-master/app/proc"
 	"github.com/umputun/feed-master/app/youtube"
 	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
@@ -30,6 +35,8 @@ import (
 //go:generate moq -out mocks/yt_service.go -pkg mocks -skip-ensure -fmt goimports . YoutubeSvc
 //go:generate moq -out mocks/store.go -pkg mocks -skip-ensure -fmt goimports . Store
 //go:generate moq -out mocks/youtube_store.go -pkg mocks -skip-ensure -fmt goimports . YoutubeStore
+//go:generate moq -out mocks/webhook_log.go -pkg mocks -skip-ensure -fmt goimports . WebhookLog
+//go:generate moq -out mocks/outbox.go -pkg mocks -skip-ensure -fmt goimports . Outbox
 
 // Server provides HTTP API
 type Server struct {
@@ -38,6 +45,8 @@ type Server struct {
 	Store         Store
 	YoutubeStore  YoutubeStore
 	YoutubeSvc    YoutubeSvc
+	WebhookLog    WebhookLog
+	Outbox        Outbox
 	TemplLocation string
 	AdminPasswd   string
 
@@ -51,6 +60,22 @@ type YoutubeSvc interface {
 	RSSFeed(cinfo youtube.FeedInfo) (string, error)
 	StoreRSS(chanID, rss string) error
 	RemoveEntry(entry ytfeed.Entry) error
+	NewEntry(ctx context.Context, ref, channelID string) (ytfeed.Entry, error)
+	QueueAdd(entry ytfeed.Entry) (youtube.Job, error)
+	QueueRedownload(channelID, videoID string) (youtube.Job, error)
+	Job(id string) (youtube.Job, bool)
+	RefreshEntry(ctx context.Context, channelID, videoID string, redownload bool) (ytfeed.Entry, error)
+	Usage() youtube.Usage
+	Reconcile(dryRun bool) (youtube.ReconcileReport, error)
+	LastReconcile() (youtube.ReconcileReport, bool)
+	Channels() []youtube.FeedInfo
+	AddChannel(ctx context.Context, fi youtube.FeedInfo) (youtube.FeedInfo, error)
+	UpdateChannel(id string, fi youtube.FeedInfo) (youtube.FeedInfo, error)
+	PauseChannel(id string, paused bool) (youtube.FeedInfo, error)
+	ResetChannel(id string) (youtube.FeedInfo, error)
+	DeleteChannel(id string, purge bool) ([]ytfeed.Entry, error)
+	VerifySubscription(q url.Values) (string, error)
+	Notify(body []byte, signature string) ([]string, error)
 }
 
 // Store provides access to feed data
@@ -62,6 +87,19 @@ type Store interface {
 // YoutubeStore provides access to YouTube channel data
 type YoutubeStore interface {
 	Load(channelID string, maxItems int) ([]ytfeed.Entry, error)
+	ListFailures(channelID string) ([]ytfeed.Failure, error)
+}
+
+// WebhookLog provides records of webhook deliveries
+type WebhookLog interface {
+	Deliveries() []proc.WebhookDelivery
+}
+
+// Outbox provides access to pending and failed notifications
+type Outbox interface {
+	List() ([]proc.OutboxEntry, error)
+	Retry(id string) error
+	Discard(id string) error
 }
 
 // Run starts http server for API with all routes
@@ -111,6 +149,10 @@ func (s *Server) loadTemplates() {
 		"currentYear": func() int {
 			return time.Now().Year()
 		},
+		"json": func(v any) (string, error) {
+			res, err := json.Marshal(v)
+			return string(res), err
+		},
 	}
 	s.templates = template.Must(template.New("").Funcs(funcMap).ParseGlob(s.TemplLocation))
 }
@@ -142,18 +184,36 @@ func (s *Server) router() http.Handler {
 
 	router.HandleFunc("GET /config", func(w http.ResponseWriter, _ *http.Request) { rest.RenderJSON(w, s.Conf) })
 
-	router.Mount("/yt").Route(func(r *routegroup.Bundle) {
-		auth := rest.BasicAuth(func(user, passwd string) bool {
-			return (subtle.ConstantTimeCompare([]byte(s.AdminPasswd), []byte(passwd)) +
-				subtle.ConstantTimeCompare([]byte("admin"), []byte(user))) == 2
-		})
+	auth := rest.BasicAuth(func(user, passwd string) bool {
+		return (subtle.ConstantTimeCompare([]byte(s.AdminPasswd), []byte(passwd)) +
+			subtle.ConstantTimeCompare([]byte("admin"), []byte(user))) == 2
+	})
+	router.With(auth).HandleFunc("GET /webhooks/deliveries", s.getWebhookDeliveriesCtrl)
+	router.With(auth).HandleFunc("GET /outbox", s.getOutboxPageCtrl)
+	router.With(auth).HandleFunc("GET /outbox/entries", s.getOutboxCtrl)
+	router.With(auth).HandleFunc("POST /outbox/entry/{id}/retry", s.retryOutboxCtrl)
+	router.With(auth).HandleFunc("DELETE /outbox/entry/{id}", s.discardOutboxCtrl)
 
+	router.Mount("/yt").Route(func(r *routegroup.Bundle) {
 		l := logger.New(logger.Log(log.Default()), logger.Prefix("[INFO]"), logger.IPfn(logger.AnonymizeIP))
 		r.Use(l.Handler)
 		r.HandleFunc("GET /rss/{channel}", s.getYoutubeFeedCtrl)
 		r.HandleFunc("GET /channels", s.getYoutubeChannelsPageCtrl)
+		r.HandleFunc("GET /websub", s.verifyWebSubCtrl)
+		r.HandleFunc("POST /websub", s.webSubNotifyCtrl)
 		r.With(auth).HandleFunc("POST /rss/generate", s.regenerateRSSCtrl)
 		r.With(auth).HandleFunc("DELETE /entry/{channel}/{video}", s.removeEntryCtrl)
+		r.With(auth).HandleFunc("POST /entry", s.addEntryCtrl)
+		r.With(auth).HandleFunc("POST /entry/{channel}/{video}/refresh", s.refreshEntryCtrl)
+		r.With(auth).HandleFunc("GET /job/{id}", s.getJobCtrl)
+		r.With(auth).HandleFunc("POST /reconcile", s.reconcileCtrl)
+		r.With(auth).HandleFunc("GET /reconcile", s.getReconcileCtrl)
+		r.With(auth).HandleFunc("POST /channel", s.addChannelCtrl)
+		r.With(auth).HandleFunc("PUT /channel/{channel}", s.updateChannelCtrl)
+		r.With(auth).HandleFunc("POST /channel/{channel}/pause", s.pauseChannelCtrl(true))
+		r.With(auth).HandleFunc("POST /channel/{channel}/resume", s.pauseChannelCtrl(false))
+		r.With(auth).HandleFunc("POST /channel/{channel}/reset", s.resetChannelCtrl)
+		r.With(auth).HandleFunc("DELETE /channel/{channel}", s.deleteChannelCtrl)
 	})
 
 	if s.Conf.YouTube.BaseURL != "" {
@@ -222,6 +282,13 @@ func (s *Server) getFeedCtrl(w http.ResponseWriter, r *http.Request) {
 			NsMedia:  "http://search.yahoo.com/mrss/",
 		}
 
+		for _, itm := range items {
+			if len(itm.Transcripts) > 0 {
+				rss.NsPodcast = "https://podcastindex.org/namespace/1.0"
+				break
+			}
+		}
+
 		// replace link to UI page
 		if s.Conf.System.BaseURL != "" {
 			baseURL := strings.TrimSuffix(s.Conf.System.BaseURL, "/")
@@ -242,6 +309,10 @@ func (s *Server) getFeedCtrl(w http.ResponseWriter, r *http.Request) {
 		// this hack to avoid having different items for marshal and unmarshal due to "itunes" namespace
 		res = strings.ReplaceAll(res, "<duration>", "<itunes:duration>")
 		res = strings.ReplaceAll(res, "</duration>", "</itunes:duration>")
+		res = strings.ReplaceAll(res, "<image href=", "<itunes:image href=")
+		res = strings.ReplaceAll(res, "</image>", "</itunes:image>")
+		res = strings.ReplaceAll(res, "<transcript ", "<podcast:transcript ")
+		res = strings.ReplaceAll(res, "</transcript>", "</podcast:transcript>")
 
 		return []byte(res), nil
 	})
@@ -288,12 +359,9 @@ func (s *Server) getListCtrl(w http.ResponseWriter, _ *http.Request) {
 func (s *Server) getYoutubeFeedCtrl(w http.ResponseWriter, r *http.Request) {
 	channel := r.PathValue("channel")
 
-	fi := youtube.FeedInfo{ID: channel}
-	for _, f := range s.Conf.YouTube.Channels {
-		if f.ID == channel {
-			fi = f
-			break
-		}
+	fi, ok := s.ytChannel(channel)
+	if !ok {
+		fi = youtube.FeedInfo{ID: channel}
 	}
 
 	res, err := s.YoutubeSvc.RSSFeed(fi)
@@ -309,7 +377,8 @@ func (s *Server) getYoutubeFeedCtrl(w http.ResponseWriter, r *http.Request) {
 
 // POST /yt/rss/generate - generates rss for all (each) youtube channels
 func (s *Server) regenerateRSSCtrl(w http.ResponseWriter, r *http.Request) {
-	for _, f := range s.Conf.YouTube.Channels {
+	channels := s.ytChannels()
+	for _, f := range channels {
 		res, err := s.YoutubeSvc.RSSFeed(youtube.FeedInfo{ID: f.ID})
 		if err != nil {
 			rest.SendErrorJSON(w, r, log.Default(), http.StatusInternalServerError, err, "failed to read yt rss for "+f.ID)
@@ -320,12 +389,12 @@ func (s *Server) regenerateRSSCtrl(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	rest.RenderJSON(w, rest.JSON{"status": "ok", "feeds": len(s.Conf.YouTube.Channels)})
+	rest.RenderJSON(w, rest.JSON{"status": "ok", "feeds": len(channels)})
 }
 
 // DELETE /yt/entry/{channel}/{video} - deletes entry from youtube channel and videoID
 func (s *Server) removeEntryCtrl(w http.ResponseWriter, r *http.Request) {
-	channelID := r.PathValue("channel")
+	channelID := s.ytChannelID(r.PathValue("channel")) // channel can be referenced by @handle or url used in config
 	videoID := r.PathValue("video")
 
 	// remove from youtube store (and delete audio file)
@@ -334,7 +403,316 @@ func (s *Server) removeEntryCtrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// remove from proc store (combined feed) - GUID format is "channelID::videoID"
+	s.removeFromFeeds(channelID, videoID)
+	rest.RenderJSON(w, rest.JSON{"status": "ok", "removed": videoID})
+}
+
+// POST /yt/entry - adds a single video to the channel, body is json with video id or url and optional channel,
+// i.e. {"video": "https://www.youtube.com/watch?v=abc", "channel": "UC123"}. Without channel the video added to
+// the virtual "manual" feed. Video info checked right away, 409 returned if the channel has the video already.
+// Download queued to the youtube service, 202 returned with the job to check with GET /yt/job/{id}.
+func (s *Server) addEntryCtrl(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Video   string `json:"video"`
+		Channel string `json:"channel"`
+	}
+	if err := rest.DecodeJSON(r, &req); err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, err, "failed to decode request")
+		return
+	}
+	if req.Video == "" {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, errors.New("no video"), "video is required")
+		return
+	}
+	channelID := youtube.ManualFeedID
+	if req.Channel != "" && req.Channel != youtube.ManualFeedID {
+		fi, ok := s.ytChannel(req.Channel)
+		if !ok {
+			rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, youtube.ErrChannelNotFound, "unknown channel")
+			return
+		}
+		channelID = fi.ID
+	}
+
+	entry, err := s.YoutubeSvc.NewEntry(r.Context(), req.Video, channelID)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, youtube.ErrEntryExists):
+			status = http.StatusConflict
+		case errors.Is(err, youtube.ErrChannelNotFound):
+			status = http.StatusNotFound
+		}
+		rest.SendErrorJSON(w, r, log.Default(), status, err, "can't add video")
+		return
+	}
+	job, err := s.YoutubeSvc.QueueAdd(entry)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusServiceUnavailable, err, "can't queue video")
+		return
+	}
+	renderAccepted(w, job)
+}
+
+// POST /yt/entry/{channel}/{video}/refresh?download=true - re-fetches metadata of the entry and returns it.
+// With download=true audio download queued to the youtube service, 202 returned with the job to check
+// with GET /yt/job/{id}.
+func (s *Server) refreshEntryCtrl(w http.ResponseWriter, r *http.Request) {
+	channelID := s.ytChannelID(r.PathValue("channel"))
+	videoID := r.PathValue("video")
+	download, _ := strconv.ParseBool(r.URL.Query().Get("download"))
+	if download {
+		job, err := s.YoutubeSvc.QueueRedownload(channelID, videoID)
+		if err != nil {
+			rest.SendErrorJSON(w, r, log.Default(), http.StatusServiceUnavailable, err, "can't queue download")
+			return
+		}
+		renderAccepted(w, job)
+		return
+	}
+
+	entry, err := s.YoutubeSvc.RefreshEntry(r.Context(), channelID, videoID, false)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusInternalServerError, err, "failed to refresh entry")
+		return
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, entry)
+}
+
+// GET /yt/job/{id} - returns status of the download queued with POST /yt/entry or refresh with download=true
+func (s *Server) getJobCtrl(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.YoutubeSvc.Job(r.PathValue("id"))
+	if !ok {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, errors.New("not found"), "unknown job")
+		return
+	}
+	rest.RenderJSON(w, job)
+}
+
+// renderAccepted sends the queued job with 202 status
+func renderAccepted(w http.ResponseWriter, job youtube.Job) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+// GET /yt/websub - verifies WebSub subscription, responds with the hub's challenge.
+// Called by hub, not protected as the subscription must be requested by the service before.
+func (s *Server) verifyWebSubCtrl(w http.ResponseWriter, r *http.Request) {
+	challenge, err := s.YoutubeSvc.VerifySubscription(r.URL.Query())
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, err, "subscription not verified")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(challenge))
+}
+
+// POST /yt/websub - WebSub notification with new or updated videos, triggers processing of notified channels.
+// Notifications with invalid signature ignored but acknowledged, as required by WebSub spec.
+func (s *Server) webSubNotifyCtrl(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024))
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, err, "failed to read notification")
+		return
+	}
+	ids, err := s.YoutubeSvc.Notify(body, r.Header.Get("X-Hub-Signature"))
+	if errors.Is(err, youtube.ErrWebSubDisabled) {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, err, "websub is disabled")
+		return
+	}
+	if err != nil {
+		log.Printf("[WARN] websub notification ignored, %v", err)
+		rest.RenderJSON(w, rest.JSON{"status": "ignored"})
+		return
+	}
+	rest.RenderJSON(w, rest.JSON{"status": "accepted", "channels": ids})
+}
+
+// POST /yt/reconcile?dry=true - removes orphan files and fixes entries with missing files, returns the report.
+// With dry=true nothing is changed, the report shows what would be done.
+func (s *Server) reconcileCtrl(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry"))
+	report, err := s.YoutubeSvc.Reconcile(dryRun)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusInternalServerError, err, "failed to reconcile")
+		return
+	}
+	rest.RenderJSON(w, report)
+}
+
+// GET /yt/reconcile - returns the report of the last reconciliation
+func (s *Server) getReconcileCtrl(w http.ResponseWriter, r *http.Request) {
+	report, ok := s.YoutubeSvc.LastReconcile()
+	if !ok {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, errors.New("not found"), "no reconciliation yet")
+		return
+	}
+	rest.RenderJSON(w, report)
+}
+
+// GET /webhooks/deliveries?feed=name&failed=true&limit=100 - returns recent webhook delivery attempts, newest first
+func (s *Server) getWebhookDeliveriesCtrl(w http.ResponseWriter, r *http.Request) {
+	if s.WebhookLog == nil {
+		rest.RenderJSON(w, []proc.WebhookDelivery{})
+		return
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, fmt.Errorf("invalid limit %q", v), "invalid limit")
+			return
+		}
+		limit = l
+	}
+	feedName, failedOnly := r.URL.Query().Get("feed"), r.URL.Query().Get("failed") == "true"
+	res := []proc.WebhookDelivery{}
+	for _, d := range s.WebhookLog.Deliveries() {
+		if len(res) >= limit {
+			break
+		}
+		if (feedName != "" && d.Feed != feedName) || (failedOnly && d.Delivered()) {
+			continue
+		}
+		res = append(res, d)
+	}
+	rest.RenderJSON(w, res)
+}
+
+// GET /outbox/entries?failed=true - returns pending notifications of the outbox, only failed ones with failed=true
+func (s *Server) getOutboxCtrl(w http.ResponseWriter, r *http.Request) {
+	if s.Outbox == nil {
+		rest.RenderJSON(w, []proc.OutboxEntry{})
+		return
+	}
+	entries, err := s.Outbox.List()
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusInternalServerError, err, "failed to list outbox")
+		return
+	}
+	if r.URL.Query().Get("failed") != "true" {
+		rest.RenderJSON(w, entries)
+		return
+	}
+	res := []proc.OutboxEntry{}
+	for _, e := range entries {
+		if e.Failed {
+			res = append(res, e)
+		}
+	}
+	rest.RenderJSON(w, res)
+}
+
+// POST /outbox/entry/{id}/retry - sends the notification again, failed one gets all attempts again
+func (s *Server) retryOutboxCtrl(w http.ResponseWriter, r *http.Request) {
+	s.outboxAction(w, r, "retry", Outbox.Retry)
+}
+
+// DELETE /outbox/entry/{id} - removes the notification from the outbox without sending
+func (s *Server) discardOutboxCtrl(w http.ResponseWriter, r *http.Request) {
+	s.outboxAction(w, r, "discard", Outbox.Discard)
+}
+
+func (s *Server) outboxAction(w http.ResponseWriter, r *http.Request, name string, fn func(o Outbox, id string) error) {
+	id := r.PathValue("id")
+	if s.Outbox == nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusNotFound, proc.ErrOutboxEntryNotFound, "no outbox")
+		return
+	}
+	if err := fn(s.Outbox, id); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, proc.ErrOutboxEntryNotFound) {
+			code = http.StatusNotFound
+		}
+		rest.SendErrorJSON(w, r, log.Default(), code, err, "failed to "+name+" outbox entry")
+		return
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, rest.JSON{"status": "ok", "id": id})
+}
+
+// POST /yt/channel - adds youtube channel, body is json with channel info, i.e. {"id":"@handle","name":"blah"}
+func (s *Server) addChannelCtrl(w http.ResponseWriter, r *http.Request) {
+	var fi youtube.FeedInfo
+	if err := rest.DecodeJSON(r, &fi); err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, err, "failed to decode channel")
+		return
+	}
+	res, err := s.YoutubeSvc.AddChannel(r.Context(), fi)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, err, "failed to add channel")
+		return
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, res)
+}
+
+// PUT /yt/channel/{channel} - updates youtube channel, body is json with channel info
+func (s *Server) updateChannelCtrl(w http.ResponseWriter, r *http.Request) {
+	var fi youtube.FeedInfo
+	if err := rest.DecodeJSON(r, &fi); err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), http.StatusBadRequest, err, "failed to decode channel")
+		return
+	}
+	res, err := s.YoutubeSvc.UpdateChannel(s.ytChannelID(r.PathValue("channel")), fi)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), channelErrCode(err), err, "failed to update channel")
+		return
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, res)
+}
+
+// POST /yt/channel/{channel}/pause and /resume - stops or resumes downloads of youtube channel
+func (s *Server) pauseChannelCtrl(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := s.YoutubeSvc.PauseChannel(s.ytChannelID(r.PathValue("channel")), paused)
+		if err != nil {
+			rest.SendErrorJSON(w, r, log.Default(), channelErrCode(err), err, "failed to pause or resume channel")
+			return
+		}
+		s.cache.Purge()
+		rest.RenderJSON(w, res)
+	}
+}
+
+// POST /yt/channel/{channel}/reset - reverts runtime changes of the configured channel to the config's settings
+func (s *Server) resetChannelCtrl(w http.ResponseWriter, r *http.Request) {
+	res, err := s.YoutubeSvc.ResetChannel(s.ytChannelID(r.PathValue("channel")))
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), channelErrCode(err), err, "failed to reset channel")
+		return
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, res)
+}
+
+// DELETE /yt/channel/{channel}?purge=true - deletes youtube channel. With purge=true all entries and files
+// of the channel removed as well, including items in combined feeds.
+func (s *Server) deleteChannelCtrl(w http.ResponseWriter, r *http.Request) {
+	channelID := s.ytChannelID(r.PathValue("channel"))
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	entries, err := s.YoutubeSvc.DeleteChannel(channelID, purge)
+	if err != nil {
+		rest.SendErrorJSON(w, r, log.Default(), channelErrCode(err), err, "failed to delete channel")
+		return
+	}
+	removed := map[string]bool{} // parts of the split video removed from feeds together
+	for _, entry := range entries {
+		if !removed[entry.VideoID] {
+			s.removeFromFeeds(channelID, entry.VideoID)
+			removed[entry.VideoID] = true
+		}
+	}
+	s.cache.Purge()
+	rest.RenderJSON(w, rest.JSON{"status": "ok", "deleted": channelID, "purged": len(entries)})
+}
+
+// removeFromFeeds removes youtube entry from proc store (combined feeds), GUID format is "channelID::videoID",
+// parts of the split video with "channelID::videoID::N" GUIDs removed by the store as well
+func (s *Server) removeFromFeeds(channelID, videoID string) {
 	guid := channelID + "::" + videoID
 	for _, feedName := range s.feeds() {
 		if err := s.Store.Remove(feedName, guid); err != nil {
@@ -344,8 +722,6 @@ func (s *Server) removeEntryCtrl(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-
-	rest.RenderJSON(w, rest.JSON{"status": "ok", "removed": videoID})
 }
 
 func (s *Server) feeds() []string {
@@ -362,3 +738,37 @@ func timeout(dt time.Duration) func(http.Handler) http.Handler {
 		return http.TimeoutHandler(h, dt, "timeout")
 	}
 }
+
+// ytChannels returns current youtube channels, including added at runtime
+func (s *Server) ytChannels() []youtube.FeedInfo {
+	if s.YoutubeSvc == nil {
+		return s.Conf.YouTube.Channels
+	}
+	return s.YoutubeSvc.Channels()
+}
+
+// ytChannelID returns channel id by id or by the original reference, i.e. @handle
+func (s *Server) ytChannelID(ref string) string {
+	if fi, ok := s.ytChannel(ref); ok {
+		return fi.ID
+	}
+	return ref
+}
+
+// ytChannel returns youtube channel by id or by the original reference, i.e. @handle
+func (s *Server) ytChannel(ref string) (youtube.FeedInfo, bool) {
+	for _, f := range s.ytChannels() {
+		if f.ID == ref || (f.Ref != "" && f.Ref == ref) {
+			return f, true
+		}
+	}
+	return youtube.FeedInfo{}, false
+}
+
+// channelErrCode returns http status code for channel management error
+func channelErrCode(err error) int {
+	if errors.Is(err, youtube.ErrChannelNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
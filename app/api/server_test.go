@@ -3,11 +3,15 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	"github.com/umputun/feed-master/app/api/mocks"
 	"github.com/umputun/feed-master/app/config"
 	"github.com/umputun/feed-master/app/feed"
+	"github.com/umputun/feed-master/app/proc"
 	"github.com/umputun/feed-master/app/youtube"
 	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
@@ -84,6 +89,8 @@ func TestServer_getFeedCtrl(t *testing.T) {
 					Link:        "http://example.com/feed1",
 					Author:      "Feed Master",
 					OwnerEmail:  "test@email.com",
+					Mastodon:    config.Mastodon{Server: "https://mastodon.example.com", Token: "secret-token"},
+					Webhooks:    []config.Webhook{{URL: "https://example.com/hook", Secret: "secret-key"}},
 				},
 				"feed2": {
 					Title: "feed2",
@@ -274,6 +281,9 @@ func TestServer_regenerateRSSCtrl(t *testing.T) {
 		StoreRSSFunc: func(string, string) error {
 			return nil
 		},
+		ChannelsFunc: func() []youtube.FeedInfo {
+			return []youtube.FeedInfo{{ID: "chan1"}, {ID: "chan2"}}
+		},
 	}
 
 	s := Server{
@@ -283,7 +293,6 @@ func TestServer_regenerateRSSCtrl(t *testing.T) {
 		Conf:          config.Conf{},
 		AdminPasswd:   "123456",
 	}
-	s.Conf.YouTube.Channels = []youtube.FeedInfo{{ID: "chan1"}, {ID: "chan2"}}
 	ts := httptest.NewServer(s.router())
 	defer ts.Close()
 
@@ -322,6 +331,7 @@ func TestServer_removeEntryCtrl(t *testing.T) {
 	t.Run("removes from youtube and proc store", func(t *testing.T) {
 		yt := &mocks.YoutubeSvcMock{
 			RemoveEntryFunc: func(ytfeed.Entry) error { return nil },
+			ChannelsFunc:    func() []youtube.FeedInfo { return nil },
 		}
 
 		store := &mocks.StoreMock{
@@ -375,6 +385,7 @@ func TestServer_removeEntryCtrl(t *testing.T) {
 	t.Run("auth failure", func(t *testing.T) {
 		yt := &mocks.YoutubeSvcMock{
 			RemoveEntryFunc: func(ytfeed.Entry) error { return nil },
+			ChannelsFunc:    func() []youtube.FeedInfo { return nil },
 		}
 
 		s := Server{
@@ -400,6 +411,7 @@ func TestServer_removeEntryCtrl(t *testing.T) {
 	t.Run("no matching entry in proc store", func(t *testing.T) {
 		yt := &mocks.YoutubeSvcMock{
 			RemoveEntryFunc: func(ytfeed.Entry) error { return nil },
+			ChannelsFunc:    func() []youtube.FeedInfo { return nil },
 		}
 
 		store := &mocks.StoreMock{
@@ -435,6 +447,44 @@ func TestServer_removeEntryCtrl(t *testing.T) {
 		require.Len(t, store.RemoveCalls(), 1)
 		assert.Equal(t, "chan1::vid1", store.RemoveCalls()[0].GUID)
 	})
+	t.Run("channel referenced by handle", func(t *testing.T) {
+		yt := &mocks.YoutubeSvcMock{
+			RemoveEntryFunc: func(ytfeed.Entry) error { return nil },
+			ChannelsFunc:    func() []youtube.FeedInfo { return nil },
+		}
+		store := &mocks.StoreMock{
+			RemoveFunc: func(string, string) error { return nil },
+		}
+
+		yt.ChannelsFunc = func() []youtube.FeedInfo {
+			return []youtube.FeedInfo{{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Ref: "@handle1", Name: "name1"}}
+		}
+		conf := config.Conf{Feeds: map[string]config.Feed{"feed1": {Title: "feed1"}}}
+		s := Server{
+			Version:       "1.0",
+			TemplLocation: "../webapp/templates/*",
+			YoutubeSvc:    yt,
+			Store:         store,
+			Conf:          conf,
+			AdminPasswd:   "123456",
+		}
+
+		ts := httptest.NewServer(s.router())
+		defer ts.Close()
+
+		req, err := http.NewRequest("DELETE", ts.URL+"/yt/entry/@handle1/vid1", bytes.NewBuffer(nil))
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		require.Len(t, yt.RemoveEntryCalls(), 1)
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", yt.RemoveEntryCalls()[0].Entry.ChannelID)
+		require.Len(t, store.RemoveCalls(), 1)
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa::vid1", store.RemoveCalls()[0].GUID)
+	})
 }
 
 func TestServer_configCtrl(t *testing.T) {
@@ -454,11 +504,16 @@ func TestServer_configCtrl(t *testing.T) {
 					Link:        "http://example.com/feed1",
 					Author:      "Feed Master",
 					OwnerEmail:  "test@email.com",
+					Mastodon:    config.Mastodon{Server: "https://mastodon.example.com", Token: "secret-token"},
+					Webhooks:    []config.Webhook{{URL: "https://example.com/hook", Secret: "secret-key"}},
+					Twitter:     config.Twitter{AccessToken: "twitter-token", AccessSecret: "twitter-secret"},
 				},
 				"feed2": {
 					Title: "feed2",
 				},
 			},
+			Digests: []config.Digest{{Name: "weekly", To: []string{"user@example.com"}, Schedule: "weekly mon"}},
+			SMTP:    config.SMTP{Host: "smtp.example.com", Password: "smtp-pass"},
 		},
 	}
 	ts := httptest.NewServer(s.router())
@@ -477,4 +532,535 @@ func TestServer_configCtrl(t *testing.T) {
 	assert.Contains(t, body, "feed2")
 	assert.Contains(t, body, "this is feed1")
 	assert.Contains(t, body, "http://example.com/feed1")
+	assert.Contains(t, body, "https://example.com/hook")
+	assert.NotContains(t, body, "secret-token")
+	assert.NotContains(t, body, "secret-key")
+	assert.Contains(t, body, "smtp.example.com")
+	assert.NotContains(t, body, "smtp-pass")
+	assert.NotContains(t, body, "twitter-token")
+	assert.NotContains(t, body, "twitter-secret")
+	assert.NotContains(t, body, "user@example.com")
+}
+
+func TestServer_addEntryCtrl(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		ChannelsFunc: func() []youtube.FeedInfo {
+			return []youtube.FeedInfo{{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Ref: "@handle1", Name: "name1"}}
+		},
+		NewEntryFunc: func(_ context.Context, ref, channelID string) (ytfeed.Entry, error) {
+			if ref == "dup" {
+				return ytfeed.Entry{}, fmt.Errorf("%w: dup in %s", youtube.ErrEntryExists, channelID)
+			}
+			return ytfeed.Entry{ChannelID: channelID, VideoID: ref}, nil
+		},
+		QueueAddFunc: func(entry ytfeed.Entry) (youtube.Job, error) {
+			if entry.VideoID == "busy" {
+				return youtube.Job{}, youtube.ErrQueueFull
+			}
+			return youtube.Job{ID: "1", Kind: youtube.JobAdd, ChannelID: entry.ChannelID, VideoID: entry.VideoID,
+				Status: youtube.JobQueued}, nil
+		},
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, AdminPasswd: "123456",
+		cache: lcw.NewNopCache[[]byte]()}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	send := func(body string) *http.Response {
+		req, err := http.NewRequest("POST", ts.URL+"/yt/entry", strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := send(`{"video":"vid1","channel":"@handle1"}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var job youtube.Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, youtube.Job{ID: "1", Kind: youtube.JobAdd, ChannelID: "UCaaaaaaaaaaaaaaaaaaaaaa", VideoID: "vid1",
+		Status: youtube.JobQueued}, job)
+	assert.Equal(t, "vid1", yt.NewEntryCalls()[0].Ref)
+	require.Len(t, yt.QueueAddCalls(), 1)
+	assert.Equal(t, ytfeed.Entry{ChannelID: "UCaaaaaaaaaaaaaaaaaaaaaa", VideoID: "vid1"}, yt.QueueAddCalls()[0].Entry)
+
+	resp = send(`{"video":"https://vimeo.com/123"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, yt.QueueAddCalls(), 2)
+	assert.Equal(t, youtube.ManualFeedID, yt.QueueAddCalls()[1].Entry.ChannelID, "manual feed by default")
+
+	resp = send(`{"video":"busy"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp = send(`{"video":"dup","channel":"@handle1"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = send(`{"video":"vid1","channel":"unknown"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = send(`{"channel":"@handle1"}`)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Len(t, yt.QueueAddCalls(), 3)
+}
+
+func TestServer_getJobCtrl(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		JobFunc: func(id string) (youtube.Job, bool) {
+			if id != "1" {
+				return youtube.Job{}, false
+			}
+			return youtube.Job{ID: "1", Kind: youtube.JobAdd, ChannelID: "ch1", VideoID: "vid1", Status: youtube.JobFailed,
+				Error: "failed to download"}, true
+		},
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, AdminPasswd: "123456",
+		cache: lcw.NewNopCache[[]byte]()}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	get := func(id string) *http.Response {
+		req, err := http.NewRequest("GET", ts.URL+"/yt/job/"+id, http.NoBody)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("1")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var job youtube.Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, youtube.JobFailed, job.Status)
+	assert.Equal(t, "failed to download", job.Error)
+
+	resp = get("2")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err := ts.Client().Get(ts.URL + "/yt/job/1")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_refreshEntryCtrl(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		ChannelsFunc: func() []youtube.FeedInfo {
+			return []youtube.FeedInfo{{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Ref: "@handle1", Name: "name1"}}
+		},
+		RefreshEntryFunc: func(_ context.Context, channelID, videoID string, _ bool) (ytfeed.Entry, error) {
+			if videoID == "bad" {
+				return ytfeed.Entry{}, errors.New("not found")
+			}
+			return ytfeed.Entry{ChannelID: channelID, VideoID: videoID, Title: "fixed title"}, nil
+		},
+		QueueRedownloadFunc: func(channelID, videoID string) (youtube.Job, error) {
+			return youtube.Job{ID: "1", Kind: youtube.JobRedownload, ChannelID: channelID, VideoID: videoID,
+				Status: youtube.JobQueued}, nil
+		},
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, AdminPasswd: "123456",
+		cache: lcw.NewNopCache[[]byte]()}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	send := func(path string) *http.Response {
+		req, err := http.NewRequest("POST", ts.URL+path, http.NoBody)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := send("/yt/entry/@handle1/vid1/refresh")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var entry ytfeed.Entry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+	assert.Equal(t, "fixed title", entry.Title)
+	require.Len(t, yt.RefreshEntryCalls(), 1)
+	assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", yt.RefreshEntryCalls()[0].ChannelID)
+	assert.Equal(t, "vid1", yt.RefreshEntryCalls()[0].VideoID)
+	assert.False(t, yt.RefreshEntryCalls()[0].Redownload)
+
+	resp = send("/yt/entry/@handle1/vid1/refresh?download=true")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, yt.QueueRedownloadCalls(), 1)
+	assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", yt.QueueRedownloadCalls()[0].ChannelID)
+	assert.Equal(t, "vid1", yt.QueueRedownloadCalls()[0].VideoID)
+	assert.Len(t, yt.RefreshEntryCalls(), 1, "not refreshed by api")
+
+	resp = send("/yt/entry/@handle1/bad/refresh")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestServer_websub(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		VerifySubscriptionFunc: func(q url.Values) (string, error) {
+			if q.Get("hub.topic") != "topic1" {
+				return "", errors.New("unknown topic")
+			}
+			return q.Get("hub.challenge"), nil
+		},
+		NotifyFunc: func(body []byte, signature string) ([]string, error) {
+			if signature != "sha1=123" {
+				return nil, errors.New("signature mismatch")
+			}
+			return []string{"UCaaaaaaaaaaaaaaaaaaaaaa"}, nil
+		},
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, AdminPasswd: "123456"}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/yt/websub?hub.mode=subscribe&hub.topic=topic1&hub.challenge=abc")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "abc", string(body))
+
+	resp, err = ts.Client().Get(ts.URL + "/yt/websub?hub.mode=subscribe&hub.topic=topic2&hub.challenge=abc")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	notify := func(signature string) string {
+		req, err := http.NewRequest("POST", ts.URL+"/yt/websub", strings.NewReader("<feed></feed>"))
+		require.NoError(t, err)
+		req.Header.Set("X-Hub-Signature", signature)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.JSONEq(t, `{"status":"accepted","channels":["UCaaaaaaaaaaaaaaaaaaaaaa"]}`, notify("sha1=123"))
+	assert.JSONEq(t, `{"status":"ignored"}`, notify("sha1=bad"), "acknowledged but ignored")
+	require.Len(t, yt.NotifyCalls(), 2)
+	assert.Equal(t, "<feed></feed>", string(yt.NotifyCalls()[0].Body))
+
+	yt.NotifyFunc = func([]byte, string) ([]string, error) { return nil, youtube.ErrWebSubDisabled }
+	req, err := http.NewRequest("POST", ts.URL+"/yt/websub", strings.NewReader("<feed></feed>"))
+	require.NoError(t, err)
+	resp, err = ts.Client().Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_reconcile(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		ReconcileFunc: func(dryRun bool) (youtube.ReconcileReport, error) {
+			return youtube.ReconcileReport{DryRun: dryRun, Orphans: []string{"var/yt/orphan.mp3"},
+				Requeued: []youtube.ReconcileEntry{{ChannelID: "chan1", VideoID: "vid1", File: "var/yt/missing.mp3"}}}, nil
+		},
+		LastReconcileFunc: func() (youtube.ReconcileReport, bool) { return youtube.ReconcileReport{}, false },
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, AdminPasswd: "123456"}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	req, err := http.NewRequest("GET", ts.URL+"/yt/reconcile", http.NoBody)
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "123456")
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "no reconciliation yet")
+
+	req, err = http.NewRequest("POST", ts.URL+"/yt/reconcile?dry=true", http.NoBody)
+	require.NoError(t, err)
+	resp, err = ts.Client().Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "no auth")
+	assert.Empty(t, yt.ReconcileCalls())
+
+	req.SetBasicAuth("admin", "123456")
+	resp, err = ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var report youtube.ReconcileReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"var/yt/orphan.mp3"}, report.Orphans)
+	require.Len(t, report.Requeued, 1)
+	assert.Equal(t, "vid1", report.Requeued[0].VideoID)
+	require.Len(t, yt.ReconcileCalls(), 1)
+	assert.True(t, yt.ReconcileCalls()[0].DryRun)
+}
+
+func TestServer_webhookDeliveries(t *testing.T) {
+	whLog := &mocks.WebhookLogMock{DeliveriesFunc: func() []proc.WebhookDelivery {
+		return []proc.WebhookDelivery{
+			{ID: "d2", Feed: "feed1", GUID: "guid2", Attempt: 1, Status: 500, Error: "unexpected status 500"},
+			{ID: "d1", Feed: "feed1", GUID: "guid1", Attempt: 1, Status: 200},
+			{ID: "d3", Feed: "feed2", GUID: "guid3", Attempt: 1, Status: 200},
+		}
+	}}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", WebhookLog: whLog, AdminPasswd: "123456"}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	get := func(query string) (int, []proc.WebhookDelivery) {
+		req, err := http.NewRequest("GET", ts.URL+"/webhooks/deliveries"+query, http.NoBody)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var res []proc.WebhookDelivery
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		}
+		return resp.StatusCode, res
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/webhooks/deliveries")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	code, res := get("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, res, 3)
+
+	_, res = get("?feed=feed1&limit=1")
+	require.Len(t, res, 1)
+	assert.Equal(t, "d2", res[0].ID)
+
+	_, res = get("?failed=true")
+	require.Len(t, res, 1)
+	assert.Equal(t, "unexpected status 500", res[0].Error)
+
+	_, res = get("?feed=feed2")
+	require.Len(t, res, 1)
+	assert.Equal(t, "d3", res[0].ID)
+
+	code, _ = get("?limit=bad")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServer_outbox(t *testing.T) {
+	outbox := &mocks.OutboxMock{
+		ListFunc: func() ([]proc.OutboxEntry, error) {
+			return []proc.OutboxEntry{{ID: "id1", Notifier: "telegram"}, {ID: "id2", Notifier: "twitter", Failed: true}}, nil
+		},
+		RetryFunc: func(id string) error {
+			if id != "id2" {
+				return fmt.Errorf("retry %s: %w", id, proc.ErrOutboxEntryNotFound)
+			}
+			return nil
+		},
+		DiscardFunc: func(string) error { return nil },
+	}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", Outbox: outbox, AdminPasswd: "123456",
+		cache: lcw.NewNopCache[[]byte]()}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	call := func(method, path string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, http.NoBody)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp, err := ts.Client().Post(ts.URL+"/outbox/entry/id2/retry", "", http.NoBody)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Empty(t, outbox.RetryCalls())
+
+	resp = call("GET", "/outbox/entries?failed=true")
+	var entries []proc.OutboxEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	_ = resp.Body.Close()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "id2", entries[0].ID)
+
+	resp = call("GET", "/outbox/entries")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	_ = resp.Body.Close()
+	assert.Len(t, entries, 2)
+
+	resp = call("POST", "/outbox/entry/id2/retry")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, outbox.RetryCalls(), 1)
+	assert.Equal(t, "id2", outbox.RetryCalls()[0].ID)
+
+	resp = call("POST", "/outbox/entry/bad/retry")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = call("DELETE", "/outbox/entry/id1")
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, outbox.DiscardCalls(), 1)
+	assert.Equal(t, "id1", outbox.DiscardCalls()[0].ID)
+}
+
+func TestServer_channels(t *testing.T) {
+	yt := &mocks.YoutubeSvcMock{
+		ChannelsFunc: func() []youtube.FeedInfo {
+			return []youtube.FeedInfo{{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Ref: "@handle1", Name: "name1"}}
+		},
+		AddChannelFunc: func(_ context.Context, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+			if fi.Name == "" {
+				return fi, errors.New("id and name are required")
+			}
+			fi.Ref, fi.ID = fi.ID, "UCbbbbbbbbbbbbbbbbbbbbbb"
+			return fi, nil
+		},
+		UpdateChannelFunc: func(id string, fi youtube.FeedInfo) (youtube.FeedInfo, error) {
+			if id != "UCaaaaaaaaaaaaaaaaaaaaaa" {
+				return fi, youtube.ErrChannelNotFound
+			}
+			fi.ID = id
+			return fi, nil
+		},
+		PauseChannelFunc: func(id string, paused bool) (youtube.FeedInfo, error) {
+			return youtube.FeedInfo{ID: id, Paused: paused}, nil
+		},
+		ResetChannelFunc: func(id string) (youtube.FeedInfo, error) {
+			if id != "UCaaaaaaaaaaaaaaaaaaaaaa" {
+				return youtube.FeedInfo{}, youtube.ErrChannelNotFound
+			}
+			return youtube.FeedInfo{ID: id, Name: "configured"}, nil
+		},
+		DeleteChannelFunc: func(id string, purge bool) ([]ytfeed.Entry, error) {
+			if !purge {
+				return nil, nil
+			}
+			return []ytfeed.Entry{{ChannelID: id, VideoID: "vid1"}, {ChannelID: id, VideoID: "vid2", Part: 1, Parts: 2},
+				{ChannelID: id, VideoID: "vid2", Part: 2, Parts: 2}}, nil
+		},
+	}
+	store := &mocks.StoreMock{RemoveFunc: func(string, string) error { return nil }}
+	s := Server{Version: "1.0", TemplLocation: "../webapp/templates/*", YoutubeSvc: yt, Store: store,
+		Conf: config.Conf{Feeds: map[string]config.Feed{"feed1": {Title: "feed1"}}}, AdminPasswd: "123456",
+		cache: lcw.NewNopCache[[]byte]()}
+	ts := httptest.NewServer(s.router())
+	defer ts.Close()
+
+	send := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("no auth", func(t *testing.T) {
+		resp, err := ts.Client().Post(ts.URL+"/yt/channel", "application/json", strings.NewReader(`{"id":"@handle2"}`))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Empty(t, yt.AddChannelCalls())
+	})
+
+	t.Run("add", func(t *testing.T) {
+		resp := send("POST", "/yt/channel", `{"id":"@handle2","name":"name2","keep":5,"max_size":"1G"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var fi youtube.FeedInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&fi))
+		assert.Equal(t, "UCbbbbbbbbbbbbbbbbbbbbbb", fi.ID)
+		assert.Equal(t, "@handle2", fi.Ref)
+		require.Len(t, yt.AddChannelCalls(), 1)
+		assert.Equal(t, youtube.FeedInfo{ID: "@handle2", Name: "name2", Keep: 5, MaxSize: 1 << 30},
+			yt.AddChannelCalls()[0].Fi)
+
+		resp = send("POST", "/yt/channel", `{"id":"@handle2"}`)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "no name")
+
+		resp = send("POST", "/yt/channel", `bad json`)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		resp := send("PUT", "/yt/channel/@handle1", `{"name":"new name"}`)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, yt.UpdateChannelCalls(), 1)
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", yt.UpdateChannelCalls()[0].ID, "referenced by handle")
+		assert.Equal(t, "new name", yt.UpdateChannelCalls()[0].Fi.Name)
+
+		resp = send("PUT", "/yt/channel/unknown", `{"name":"new name"}`)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		resp := send("POST", "/yt/channel/UCaaaaaaaaaaaaaaaaaaaaaa/pause", "")
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = send("POST", "/yt/channel/UCaaaaaaaaaaaaaaaaaaaaaa/resume", "")
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, yt.PauseChannelCalls(), 2)
+		assert.True(t, yt.PauseChannelCalls()[0].Paused)
+		assert.False(t, yt.PauseChannelCalls()[1].Paused)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		resp := send("POST", "/yt/channel/@handle1/reset", "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var fi youtube.FeedInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&fi))
+		assert.Equal(t, "configured", fi.Name)
+
+		resp = send("POST", "/yt/channel/UCbbbbbbbbbbbbbbbbbbbbbb/reset", "")
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := send("DELETE", "/yt/channel/@handle1", "")
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, store.RemoveCalls(), "nothing purged")
+
+		resp = send("DELETE", "/yt/channel/@handle1?purge=true", "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var res struct {
+			Deleted string `json:"deleted"`
+			Purged  int    `json:"purged"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", res.Deleted)
+		assert.Equal(t, 3, res.Purged)
+
+		require.Len(t, yt.DeleteChannelCalls(), 2)
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa", yt.DeleteChannelCalls()[1].ID)
+		assert.True(t, yt.DeleteChannelCalls()[1].Purge)
+		require.Len(t, store.RemoveCalls(), 2, "purged entries removed from combined feeds, split video once with all parts")
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa::vid1", store.RemoveCalls()[0].GUID)
+		assert.Equal(t, "UCaaaaaaaaaaaaaaaaaaaaaa::vid2", store.RemoveCalls()[1].GUID)
+	})
 }
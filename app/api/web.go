@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -13,6 +16,7 @@ import (
 
 	"github.com/umputun/feed-master/app/config"
 	"github.com/umputun/feed-master/app/feed"
+	"github.com/umputun/feed-master/app/proc"
 	"github.com/umputun/feed-master/app/youtube"
 	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
@@ -92,13 +96,15 @@ func (s *Server) getFeedSourceCtrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := s.cache.Get(feedName+sourceName, func() ([]byte, error) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	render := func() ([]byte, error) {
 		if _, ok := s.Conf.Feeds[feedName]; !ok {
 			return nil, fmt.Errorf("feed %s not found", feedName)
 		}
 
 		var feedInfo youtube.FeedInfo
-		for _, k := range s.Conf.YouTube.Channels {
+		for _, k := range s.ytChannels() {
 			if k.Name == sourceName {
 				feedInfo = k
 				break
@@ -113,8 +119,20 @@ func (s *Server) getFeedSourceCtrl(w http.ResponseWriter, r *http.Request) {
 			return nil, fmt.Errorf("load youtube feed %s: %w", feedInfo.ID, er)
 		}
 
-		// fill formatted duration and file path
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no items for youtube feed %s", feedInfo.ID)
+		}
+		lastUpdate := items[0].Published
+		if query != "" {
+			items = s.searchEntries(items, query)
+		}
+
+		// fill formatted duration, file path and transcript link
 		for i, item := range items {
+			if item.Transcript != "" && item.File != "" {
+				items[i].TranscriptURL = s.Conf.YouTube.BaseURL + "/" +
+					strings.TrimSuffix(path.Base(item.File), filepath.Ext(item.File)) + ".txt"
+			}
 			if item.Duration == 0 {
 				continue
 			}
@@ -132,25 +150,37 @@ func (s *Server) getFeedSourceCtrl(w http.ResponseWriter, r *http.Request) {
 			Feeds           int
 			Version         string
 			RSSLink         string
+			Query           string
 		}{
 			Items:           items,
 			Name:            feedInfo.Name,
 			Link:            "https://youtube.com/channel/" + feedInfo.ID,
-			LastUpdate:      items[0].Published.In(time.UTC),
-			SinceLastUpdate: humanize.Time(items[0].Published),
+			LastUpdate:      lastUpdate.In(time.UTC),
+			SinceLastUpdate: humanize.Time(lastUpdate),
 			Feeds:           len(items),
 			Version:         s.Version,
 			RSSLink:         s.Conf.System.BaseURL + "/yt/rss/" + feedInfo.ID,
+			Query:           query,
 		}
 		if feedInfo.Type == ytfeed.FTPlaylist {
 			tmplData.Link = "https://www.youtube.com/playlist?list=" + feedInfo.ID
 		}
+		if !feedInfo.Type.YouTube() {
+			tmplData.Link = feedInfo.URL
+		}
 
 		res := bytes.NewBuffer(nil)
 		err = s.templates.ExecuteTemplate(res, "source.tmpl", &tmplData)
 		return res.Bytes(), err
-	})
+	}
 
+	// search results not cached, arbitrary queries would evict the feed pages from the cache
+	var data []byte
+	if query != "" {
+		data, err = render()
+	} else {
+		data, err = s.cache.Get(feedName+sourceName, render)
+	}
 	if err != nil {
 		s.renderErrorPage(w, r, err, 400)
 		return
@@ -161,6 +191,31 @@ func (s *Server) getFeedSourceCtrl(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(data)
 }
 
+// searchEntries returns entries with title or transcript text matching the query, case-insensitive
+func (s *Server) searchEntries(items []ytfeed.Entry, query string) []ytfeed.Entry {
+	query = strings.ToLower(query)
+	res := []ytfeed.Entry{}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Title), query) {
+			res = append(res, item)
+			continue
+		}
+		if item.Transcript == "" || item.File == "" {
+			continue
+		}
+		txtFile := filepath.Join(s.Conf.YouTube.FilesLocation,
+			strings.TrimSuffix(filepath.Base(item.File), filepath.Ext(item.File))+".txt")
+		txt, err := os.ReadFile(txtFile) //nolint:gosec // file name made from stored entry
+		if err != nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(string(txt)), query) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
 // GET /feeds - renders page with list of feeds
 func (s *Server) getFeedsPageCtrl(w http.ResponseWriter, r *http.Request) {
 	data, err := s.cache.Get("feeds", func() ([]byte, error) {
@@ -221,33 +276,48 @@ func (s *Server) getYoutubeChannelsPageCtrl(w http.ResponseWriter, r *http.Reque
 			ChannelURL  string
 			LastUpdated time.Time
 			RssURL      string
+			Failures    []ytfeed.Failure
+			Size        youtube.ByteSize
 		}
 		var channelItems []channelItem
 
-		for _, k := range s.Conf.YouTube.Channels {
-			items, loadErr := s.YoutubeStore.Load(k.ID, 1)
-			if loadErr != nil {
-				continue
-			}
+		usage := youtube.Usage{Free: -1}
+		if s.YoutubeSvc != nil {
+			usage = s.YoutubeSvc.Usage()
+		}
+
+		for _, k := range s.ytChannels() {
 			item := channelItem{
-				FeedInfo:    k,
-				RssURL:      s.Conf.YouTube.BaseChanURL + k.ID,
-				ChannelURL:  "https://youtube.com/channel/" + k.ID,
-				LastUpdated: items[0].Published.In(time.UTC),
+				FeedInfo:   k,
+				RssURL:     s.Conf.YouTube.BaseChanURL + k.ID,
+				ChannelURL: "https://youtube.com/channel/" + k.ID,
+				Size:       usage.Channels[k.ID],
+			}
+			// channel without entries is listed too, it may be just added
+			if items, loadErr := s.YoutubeStore.Load(k.ID, 1); loadErr == nil && len(items) > 0 {
+				item.LastUpdated = items[0].Published.In(time.UTC)
 			}
 			if k.Type == ytfeed.FTPlaylist {
 				item.RssURL = s.Conf.YouTube.BasePlaylistURL + k.ID
 				item.ChannelURL = "https://www.youtube.com/playlist?list=" + k.ID
 			}
+			if !k.Type.YouTube() {
+				item.RssURL, item.ChannelURL = k.URL, k.URL
+			}
+			if failures, failErr := s.YoutubeStore.ListFailures(k.ID); failErr == nil {
+				item.Failures = failures
+			}
 			channelItems = append(channelItems, item)
 		}
 
 		tmplData := struct {
 			Channels []channelItem
 			Count    int
+			Usage    youtube.Usage
 		}{
 			Channels: channelItems,
 			Count:    len(channelItems),
+			Usage:    usage,
 		}
 
 		res := bytes.NewBuffer(nil)
@@ -308,6 +378,40 @@ func (s *Server) getSourcesPageCtrl(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(data)
 }
 
+// GET /outbox - renders page with pending and failed notifications, not cached as the outbox is changed by the worker
+func (s *Server) getOutboxPageCtrl(w http.ResponseWriter, r *http.Request) {
+	entries := []proc.OutboxEntry{}
+	if s.Outbox != nil {
+		var err error
+		if entries, err = s.Outbox.List(); err != nil {
+			s.renderErrorPage(w, r, err, 500)
+			return
+		}
+	}
+
+	tmplData := struct {
+		Entries []proc.OutboxEntry
+		Pending int
+		Failed  int
+	}{Entries: entries}
+	for _, e := range entries {
+		if e.Failed {
+			tmplData.Failed++
+			continue
+		}
+		tmplData.Pending++
+	}
+
+	res := bytes.NewBuffer(nil)
+	if err := s.templates.ExecuteTemplate(res, "outbox.tmpl", &tmplData); err != nil {
+		s.renderErrorPage(w, r, err, 400)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(res.Bytes())
+}
+
 func (s *Server) renderErrorPage(w http.ResponseWriter, _ *http.Request, err error, errCode int) {
 	tmplData := struct {
 		Status int
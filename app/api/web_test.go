@@ -8,6 +8,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	"github.com/umputun/feed-master/app/api/mocks"
 	"github.com/umputun/feed-master/app/config"
 	"github.com/umputun/feed-master/app/feed"
+	"github.com/umputun/feed-master/app/proc"
 	"github.com/umputun/feed-master/app/youtube"
 	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
@@ -285,27 +290,55 @@ func TestServer_getYoutubeChannelsPageCtrl(t *testing.T) {
 	conf := config.Conf{}
 	conf.YouTube.Channels = []youtube.FeedInfo{
 		{
-			ID:   "channel1",
-			Name: "Channel 1",
-			Type: ytfeed.FTChannel,
+			ID:      "channel1",
+			Ref:     "@channel1",
+			Name:    "Channel 1",
+			Type:    ytfeed.FTChannel,
+			MaxSize: 1 << 30,
 		},
 		{
 			ID:   "playlist1",
 			Name: "Playlist 1",
 			Type: ytfeed.FTPlaylist,
 		},
+		{
+			ID:     "channel3",
+			Name:   "Channel 3",
+			Type:   ytfeed.FTChannel,
+			Paused: true,
+		},
 	}
 	conf.YouTube.BaseChanURL = "https://www.youtube.com/feeds/videos.xml?channel_id="
 	conf.YouTube.BasePlaylistURL = "https://www.youtube.com/feeds/videos.xml?playlist_id="
 
 	ytStoreMock := &mocks.YoutubeStoreMock{}
 	ytStoreMock.LoadFunc = func(channelID string, maxItems int) ([]ytfeed.Entry, error) {
+		if channelID == "channel3" {
+			return nil, errors.New("no bucket")
+		}
 		return []ytfeed.Entry{
 			{Published: time.Date(2025, 8, 3, 12, 0, 0, 0, time.UTC)},
 		}, nil
 	}
+	ytStoreMock.ListFailuresFunc = func(channelID string) ([]ytfeed.Failure, error) {
+		if channelID != "channel1" {
+			return nil, nil
+		}
+		return []ytfeed.Failure{
+			{ChannelID: "channel1", VideoID: "vid1", Title: "Upcoming premiere", Kind: ytfeed.FKLive, Attempts: 2,
+				Message: "Premieres in 5 hours", NextRetry: time.Date(2025, 8, 4, 10, 30, 0, 0, time.UTC)},
+			{ChannelID: "channel1", VideoID: "vid2", Kind: ytfeed.FKPermanent, Attempts: 1, Message: "Private video"},
+		}, nil
+	}
 
 	srv := setupTestServer(t, conf, nil, ytStoreMock)
+	srv.YoutubeSvc = &mocks.YoutubeSvcMock{
+		UsageFunc: func() youtube.Usage {
+			return youtube.Usage{Total: 3 << 29, MaxTotal: 10 << 30, Free: 100 << 20, MinFree: 1 << 30,
+				Channels: map[string]youtube.ByteSize{"channel1": 512 << 20, "playlist1": 1 << 30}}
+		},
+		ChannelsFunc: func() []youtube.FeedInfo { return conf.YouTube.Channels },
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /yt/channels", srv.getYoutubeChannelsPageCtrl)
@@ -326,15 +359,87 @@ func TestServer_getYoutubeChannelsPageCtrl(t *testing.T) {
 	// check channels
 	assert.Contains(t, body, "Channel 1")
 	assert.Contains(t, body, "Playlist 1")
-	assert.Contains(t, body, "2 channels")
+	assert.Contains(t, body, "3 channels")
 	assert.Contains(t, body, "https://youtube.com/channel/channel1")
 	assert.Contains(t, body, "https://www.youtube.com/playlist?list=playlist1")
 
+	assert.Contains(t, body, "@channel1 &rarr; channel1", "resolved id shown")
+
+	// check disk usage
+	assert.Contains(t, body, "3 channels, 1.5G used of 10.0G, 100.0M free")
+	assert.Contains(t, body, "downloads paused")
+	assert.Contains(t, body, "512.0M of 1.0G, last updated")
+	assert.Contains(t, body, "1.0G, last updated")
+	assert.Equal(t, 1, strings.Count(body, "&rarr;"), "only for channel with ref")
+
+	// check runtime management
+	assert.Contains(t, body, "Channel 3", "channel without entries listed")
+	assert.Contains(t, body, "0B, no entries yet")
+	assert.Contains(t, body, `<span class="ump-feed-master__paused">paused</span>`)
+	assert.Equal(t, 1, strings.Count(body, `data-action="resume"`))
+	assert.Equal(t, 2, strings.Count(body, `data-action="pause"`))
+	assert.Equal(t, 3, strings.Count(body, `data-action="delete"`))
+	assert.Contains(t, body, `data-channel="{&#34;name&#34;:&#34;Channel 3&#34;,&#34;id&#34;:&#34;channel3&#34;`)
+
+	// check failures
+	assert.Contains(t, body, "Upcoming premiere")
+	assert.Contains(t, body, "next retry 04 Aug 2025 10:30")
+	assert.Contains(t, body, "https://www.youtube.com/watch?v=vid2")
+	assert.Contains(t, body, "not retried")
+
 	// check footer
 	currentYear := time.Now().Year()
 	assert.Contains(t, body, fmt.Sprintf("&copy; %d Umputun", currentYear))
 }
 
+func TestServer_getOutboxPageCtrl(t *testing.T) {
+	srv := setupTestServer(t, config.Conf{}, nil, nil)
+	srv.Outbox = &mocks.OutboxMock{ListFunc: func() ([]proc.OutboxEntry, error) {
+		return []proc.OutboxEntry{
+			{ID: "id1", FeedName: "feed1", Notifier: "telegram", Item: feed.Item{Title: "title1", Link: "https://example.com/1"},
+				Attempts: 1, NextTry: time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), LastError: "telegram: timeout"},
+			{ID: "id2", FeedName: "feed1", Notifier: "mastodon", Item: feed.Item{Title: "title2"}, Attempts: 12, Failed: true},
+		}, nil
+	}}
+
+	srv.AdminPasswd = "123456"
+
+	ts := httptest.NewServer(srv.router())
+	defer ts.Close()
+	get := func() *http.Response {
+		req, err := http.NewRequest("GET", ts.URL+"/outbox", http.NoBody)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "123456")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/outbox")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "errors may include urls with tokens, admin only")
+
+	resp = get()
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "1 pending, 1 failed")
+	assert.Contains(t, string(body), `data-id="id1"`)
+	assert.Contains(t, string(body), "next try 05 Mar 2024 10:00")
+	assert.Contains(t, string(body), `title="telegram: timeout"`)
+	assert.Contains(t, string(body), "mastodon")
+	assert.Contains(t, string(body), "attempts: 12,")
+
+	srv.Outbox = &mocks.OutboxMock{ListFunc: func() ([]proc.OutboxEntry, error) { return nil, errors.New("db failed") }}
+	resp = get()
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "db failed")
+}
+
 func TestServer_renderErrorPage(t *testing.T) {
 	srv := setupTestServer(t, config.Conf{}, nil, nil)
 
@@ -407,3 +512,56 @@ func TestTemplateCurrentYear(t *testing.T) {
 	assert.Contains(t, buf.String(), "Umputun")
 	assert.Contains(t, buf.String(), "Open Source, MIT License")
 }
+
+func TestServer_getFeedSourceCtrlSearch(t *testing.T) {
+	filesDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(filesDir, "file2.txt"), []byte("we talk about Golang generics\n"), 0o600))
+
+	conf := config.Conf{Feeds: map[string]config.Feed{"feed1": {}}}
+	conf.YouTube.Channels = []youtube.FeedInfo{{ID: "channel1", Name: "Test Channel", Type: ytfeed.FTChannel}}
+	conf.YouTube.BaseURL = "http://localhost/yt"
+	conf.YouTube.FilesLocation = filesDir
+
+	ytStoreMock := &mocks.YoutubeStoreMock{
+		LoadFunc: func(string, int) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{
+				{Title: "Video 1", VideoID: "vid1", ChannelID: "channel1", File: "/path/to/file1.mp3", Published: time.Now()},
+				{Title: "Video 2", VideoID: "vid2", ChannelID: "channel1", File: "/path/to/file2.mp3", Transcript: "en", Published: time.Now()},
+				{Title: "Generics in Go", VideoID: "vid3", ChannelID: "channel1", File: "/path/to/file3.mp3", Published: time.Now()},
+			}, nil
+		},
+	}
+	srv := setupTestServer(t, conf, nil, ytStoreMock)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /feed/{name}/source/{source}", srv.getFeedSourceCtrl)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	get := func(q string) string {
+		client := http.Client{Timeout: time.Second}
+		resp, err := client.Get(ts.URL + "/feed/feed1/source/Test%20Channel?q=" + url.QueryEscape(q))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	body := get("")
+	assert.Contains(t, body, "Video 1")
+	assert.Contains(t, body, "Video 2")
+	assert.Contains(t, body, "http://localhost/yt/file2.txt", "transcript link")
+	assert.NotContains(t, body, "http://localhost/yt/file1.txt")
+
+	body = get("generics")
+	assert.NotContains(t, body, "Video 1")
+	assert.Contains(t, body, "Video 2", "match in transcript")
+	assert.Contains(t, body, "Generics in Go", "match in title")
+	assert.Contains(t, body, `value="generics"`)
+
+	body = get("nothing like this")
+	assert.NotContains(t, body, "Video")
+	assert.Equal(t, []string{"feed1Test Channel"}, srv.cache.Keys(), "search results not cached")
+}
@@ -3,10 +3,13 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"time"
 
+	log "github.com/go-pkgz/lgr"
 	"gopkg.in/yaml.v3"
 
 	"github.com/umputun/feed-master/app/feed"
@@ -15,8 +18,10 @@ import (
 
 // Conf for feeds config yml
 type Conf struct {
-	Feeds  map[string]Feed `yaml:"feeds"`
-	System struct {
+	Feeds   map[string]Feed `yaml:"feeds"`
+	Digests []Digest        `yaml:"digests"`
+	SMTP    SMTP            `yaml:"smtp"`
+	System  struct {
 		UpdateInterval      time.Duration `yaml:"update"`
 		HTTPResponseTimeout time.Duration `yaml:"http_response_timeout"`
 		MaxItems            int           `yaml:"max_per_feed"`
@@ -28,6 +33,13 @@ type Conf struct {
 
 	YouTube struct {
 		DlTemplate      string             `yaml:"dl_template"`
+		SubsTemplate    string             `yaml:"subs_template"`
+		ProbeTemplate   string             `yaml:"probe_template"`
+		Probe           bool               `yaml:"probe"`
+		ResolverCommand string             `yaml:"resolver_command"`
+		ListingTemplate string             `yaml:"listing_template"`
+		FFmpeg          string             `yaml:"ffmpeg"`
+		SponsorBlockURL string             `yaml:"sponsorblock_url"`
 		BaseChanURL     string             `yaml:"base_chan_url"`
 		BasePlaylistURL string             `yaml:"base_playlist_url"`
 		Channels        []youtube.FeedInfo `yaml:"channels"`
@@ -35,17 +47,56 @@ type Conf struct {
 		UpdateInterval  time.Duration      `yaml:"update"`
 		MaxItems        int                `yaml:"max_per_channel"`
 		FilesLocation   string             `yaml:"files_location"`
+		MaxSize         youtube.ByteSize   `yaml:"max_size"`
+		MinFreeSpace    youtube.ByteSize   `yaml:"min_free_space"`
 		RSSLocation     string             `yaml:"rss_location"`
 		SkipShorts      time.Duration      `yaml:"skip_shorts"`
 		DisableUpdates  bool               `yaml:"disable_updates"`
+		RuntimeChannels bool               `yaml:"runtime_channels"`
 		YtDlpUpdate     struct {
 			Interval       time.Duration `yaml:"interval"`
 			Command        string        `yaml:"command"`
 			ForceOnStartup bool          `yaml:"force_on_startup"`
 		} `yaml:"ytdlp_update"`
+		Reconcile struct {
+			Interval time.Duration `yaml:"interval"`
+			DryRun   bool          `yaml:"dry_run"`
+		} `yaml:"reconcile"`
+		Refresh struct {
+			Interval time.Duration `yaml:"interval"`
+			MaxAge   time.Duration `yaml:"max_age"`
+		} `yaml:"refresh"`
+		WebSub struct {
+			Enabled     bool          `yaml:"enabled"`
+			HubURL      string        `yaml:"hub_url"`
+			CallbackURL string        `yaml:"callback_url"`
+			Secret      string        `yaml:"secret" json:"-"`
+			Lease       time.Duration `yaml:"lease"`
+		} `yaml:"websub"`
 	} `yaml:"youtube"`
 }
 
+// Digest defines email digest of new items of feeds, sent on schedule
+type Digest struct {
+	Name     string   `yaml:"name"`
+	Feeds    []string `yaml:"feeds"`       // feeds included in the digest, all if empty
+	To       []string `yaml:"to" json:"-"` // recipients, not exposed by api
+	Schedule string   `yaml:"schedule"`    // "daily 08:00" or "weekly mon 08:00", local time, 00:00 if time not set
+	Subject  string   `yaml:"subject"`     // subject template, "{{.Name}}: {{.Count}} new items" if empty
+	Template string   `yaml:"template"`    // html template file, built-in one if empty
+}
+
+// SMTP defines mail server to send digests with
+type SMTP struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`              // 25 if not set
+	Username string        `yaml:"username"`          // no auth if empty
+	Password string        `yaml:"password" json:"-"` // password of the username
+	From     string        `yaml:"from"`              // sender address, i.e. "Feed Master <feeds@example.com>"
+	TLS      bool          `yaml:"tls"`               // implicit tls, usually on port 465. STARTTLS used if the server supports it otherwise
+	Timeout  time.Duration `yaml:"timeout"`           // 30s if not set
+}
+
 // Source defines config section for source
 type Source struct {
 	Name string `yaml:"name"`
@@ -54,17 +105,98 @@ type Source struct {
 
 // Feed defines config section for a feed~
 type Feed struct {
-	Title           string   `yaml:"title"`
-	Description     string   `yaml:"description"`
-	Link            string   `yaml:"link"`
-	Image           string   `yaml:"image"`
-	Language        string   `yaml:"language"`
-	TelegramChannel string   `yaml:"telegram_channel"`
-	Filter          Filter   `yaml:"filter"`
-	Sources         []Source `yaml:"sources"`
-	ExtendDateTitle string   `yaml:"ext_date"`
-	Author          string   `yaml:"author"`
-	OwnerEmail      string   `yaml:"owner_email"`
+	Title           string    `yaml:"title"`
+	Description     string    `yaml:"description"`
+	Link            string    `yaml:"link"`
+	Image           string    `yaml:"image"`
+	Language        string    `yaml:"language"`
+	TelegramChannel string    `yaml:"telegram_channel"`
+	Notify          []string  `yaml:"notify"` // names of notifiers for the feed, i.e. [telegram, twitter], all if empty
+	Telegram        Telegram  `yaml:"telegram"`
+	Twitter         Twitter   `yaml:"twitter"`
+	Mastodon        Mastodon  `yaml:"mastodon"`
+	Bluesky         Bluesky   `yaml:"bluesky"`
+	Discord         Discord   `yaml:"discord"`
+	Slack           Slack     `yaml:"slack"`
+	Matrix          Matrix    `yaml:"matrix"`
+	Webhooks        []Webhook `yaml:"webhooks"`
+	Filter          Filter    `yaml:"filter"`
+	Sources         []Source  `yaml:"sources"`
+	ExtendDateTitle string    `yaml:"ext_date"`
+	Author          string    `yaml:"author"`
+	OwnerEmail      string    `yaml:"owner_email"`
+}
+
+// Telegram defines feed's additional telegram channels and message template
+type Telegram struct {
+	Channels []string `yaml:"channels"` // channels to post to in addition to telegram_channel
+	Template string   `yaml:"template"` // html message template, title linked to the item and description if empty
+}
+
+// Twitter defines feed's twitter account and template, global ones used for unset fields
+type Twitter struct {
+	ConsumerKey    string `yaml:"consumer_key" json:"-"`
+	ConsumerSecret string `yaml:"consumer_secret" json:"-"`
+	AccessToken    string `yaml:"access_token" json:"-"`
+	AccessSecret   string `yaml:"access_secret" json:"-"`
+	Template       string `yaml:"template"` // tweet template
+}
+
+// Mastodon defines feed's mastodon account to post new items to
+type Mastodon struct {
+	Server     string `yaml:"server"`         // instance url, i.e. https://mastodon.social
+	Token      string `yaml:"token" json:"-"` // access token with write:statuses and write:media scopes
+	Template   string `yaml:"template"`       // status template, "{{.Title}} - {{.Link}}" if empty
+	Visibility string `yaml:"visibility"`     // public, unlisted, private or direct, account's default if empty
+	Spoiler    string `yaml:"spoiler"`        // content warning, the status is hidden behind it if set
+	Media      bool   `yaml:"media"`          // upload item's image as media attachment
+	MaxChars   int    `yaml:"max_chars"`      // status length limit of the instance, 500 if not set
+}
+
+// Bluesky defines feed's bluesky account to post new items to
+type Bluesky struct {
+	PDS         string `yaml:"pds"`                   // personal data server url, https://bsky.social if empty
+	Handle      string `yaml:"handle"`                // account handle or did, i.e. feed.bsky.social
+	AppPassword string `yaml:"app_password" json:"-"` // app password of the account, not the main one
+	Template    string `yaml:"template"`              // post template, "{{.Title}} - {{.Link}}" if empty
+}
+
+// Discord defines feed's discord webhook to announce new items with
+type Discord struct {
+	Webhook  string `yaml:"webhook" json:"-"` // webhook url of the channel, includes the token
+	Username string `yaml:"username"`         // overrides the default name of the webhook
+	Template string `yaml:"template"`         // embed description template, item's description if empty
+}
+
+// Slack defines feed's slack incoming webhook to announce new items with
+type Slack struct {
+	Webhook  string `yaml:"webhook" json:"-"` // incoming webhook url of the channel, includes the token
+	Template string `yaml:"template"`         // message text template, item's description if empty
+}
+
+// Matrix defines feed's matrix room to announce new items to
+type Matrix struct {
+	Homeserver string `yaml:"homeserver"`     // homeserver url, i.e. https://matrix.org
+	Token      string `yaml:"token" json:"-"` // access token of the account joined to the room
+	Room       string `yaml:"room"`           // room id, i.e. !abcdef:matrix.org
+	Template   string `yaml:"template"`       // message text template, item's description if empty
+}
+
+// Webhook defines url to post new items of the feed to
+type Webhook struct {
+	URL    string `yaml:"url"`
+	Secret string `yaml:"secret" json:"-"` // hmac-sha256 key of the payload signature, not signed if empty
+}
+
+// TelegramChannels returns telegram_channel and additional channels of the feed, without duplicates
+func (f Feed) TelegramChannels() []string {
+	res := []string{}
+	for _, ch := range append([]string{f.TelegramChannel}, f.Telegram.Channels...) {
+		if ch != "" && !slices.Contains(res, ch) {
+			res = append(res, ch)
+		}
+	}
+	return res
 }
 
 // Filter defines feed section for a feed filter~
@@ -176,7 +308,28 @@ func (c *Conf) setDefaults() {
 	}
 
 	if c.YouTube.DlTemplate == "" {
-		c.YouTube.DlTemplate = `yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio "https://www.youtube.com/watch?v={{.ID}}" --no-progress -o {{.FileName}} --match-filter "!is_live & availability=public"`
+		// youtube videos must be public, as before, other sites may not report availability. Filters are OR-ed.
+		c.YouTube.DlTemplate = `yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio "{{.URL}}" --no-progress -o {{.FileName}} --match-filter "!is_live & availability=public" --match-filter "!is_live & extractor!=youtube"`
+	}
+
+	if c.YouTube.ProbeTemplate == "" {
+		c.YouTube.ProbeTemplate = `yt-dlp --skip-download --dump-json --no-warnings "{{.URL}}"`
+	}
+
+	if c.YouTube.ListingTemplate == "" {
+		c.YouTube.ListingTemplate = `yt-dlp --flat-playlist --dump-json --playlist-end 50 --no-warnings "{{.URL}}"`
+	}
+
+	if c.YouTube.SubsTemplate == "" {
+		c.YouTube.SubsTemplate = `yt-dlp --skip-download --write-subs --write-auto-subs --sub-langs {{.Langs}} --sub-format vtt/best --convert-subs vtt "{{.URL}}" --no-progress -o {{.FileName}}`
+	}
+
+	if c.YouTube.FFmpeg == "" {
+		c.YouTube.FFmpeg = "ffmpeg"
+	}
+
+	if c.YouTube.SponsorBlockURL == "" {
+		c.YouTube.SponsorBlockURL = "https://sponsor.ajay.app"
 	}
 
 	if c.YouTube.BaseChanURL == "" {
@@ -187,6 +340,24 @@ func (c *Conf) setDefaults() {
 		c.YouTube.BasePlaylistURL = "https://www.youtube.com/feeds/videos.xml?playlist_id="
 	}
 
+	if c.YouTube.Refresh.MaxAge == 0 {
+		c.YouTube.Refresh.MaxAge = 48 * time.Hour
+	}
+
+	if c.YouTube.WebSub.HubURL == "" {
+		c.YouTube.WebSub.HubURL = "https://pubsubhubbub.appspot.com/subscribe"
+	}
+
+	if c.YouTube.WebSub.CallbackURL == "" {
+		c.YouTube.WebSub.CallbackURL = c.System.BaseURL + "/yt/websub"
+	}
+	if c.YouTube.WebSub.Enabled && !absoluteURL(c.YouTube.WebSub.CallbackURL) {
+		// hub can't call the relative url back, subscriptions would fail, polling used instead
+		log.Printf("[ERROR] websub disabled, callback url %q is not absolute, set system.base_url or websub.callback_url",
+			c.YouTube.WebSub.CallbackURL)
+		c.YouTube.WebSub.Enabled = false
+	}
+
 	if c.YouTube.FilesLocation == "" {
 		c.YouTube.FilesLocation = "var/yt"
 	}
@@ -195,3 +366,9 @@ func (c *Conf) setDefaults() {
 		c.YouTube.RSSLocation = "var/rss"
 	}
 }
+
+// absoluteURL checks if the url is http(s) with a host
+func absoluteURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -11,6 +11,7 @@ import (
 
 	rssfeed "github.com/umputun/feed-master/app/feed"
 	ytfdeed "github.com/umputun/feed-master/app/youtube"
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
 
 func TestLoad(t *testing.T) {
@@ -24,15 +25,47 @@ func TestLoad(t *testing.T) {
 	assert.Len(t, r.Feeds["second"].Sources, 1, "1 feed in second")
 	assert.Equal(t, "https://bbb.com/u1", r.Feeds["second"].Sources[0].URL)
 	assert.Equal(t, "^filterme*", r.Feeds["filtered"].Filter.Title)
+	assert.Equal(t, []string{"telegram", "webhook"}, r.Feeds["filtered"].Notify)
+	assert.Empty(t, r.Feeds["first"].Notify, "all notifiers")
+	assert.Equal(t, Telegram{Channels: []string{"chan2", "chan1"}, Template: "<b>{{.Title}}</b>"}, r.Feeds["filtered"].Telegram)
+	assert.Equal(t, []string{"chan1", "chan2"}, r.Feeds["filtered"].TelegramChannels())
+	assert.Empty(t, r.Feeds["first"].TelegramChannels())
+	assert.Equal(t, Twitter{AccessToken: "token1", AccessSecret: "secret1", Template: "{{.Title}} {{.Link}}"}, r.Feeds["filtered"].Twitter)
+	assert.Equal(t, Mastodon{Server: "https://mastodon.example.com", Token: "token1", Visibility: "unlisted", Media: true},
+		r.Feeds["filtered"].Mastodon)
+	assert.Equal(t, Bluesky{Handle: "feed.bsky.social", AppPassword: "pass1"}, r.Feeds["filtered"].Bluesky)
+	assert.Equal(t, Discord{Webhook: "https://discord.com/api/webhooks/1/token1", Username: "feed"}, r.Feeds["filtered"].Discord)
+	assert.Equal(t, Slack{Webhook: "https://hooks.slack.com/services/T1/B1/token1", Template: "{{.Title}}"}, r.Feeds["filtered"].Slack)
+	assert.Equal(t, Matrix{Homeserver: "https://matrix.org", Token: "token1", Room: "!room1:matrix.org"}, r.Feeds["filtered"].Matrix)
+	assert.Equal(t, []Webhook{{URL: "https://example.com/hook1", Secret: "secret1"}, {URL: "https://example.com/hook2"}},
+		r.Feeds["filtered"].Webhooks)
+	assert.Equal(t, []Digest{
+		{Name: "weekly", Feeds: []string{"first", "second"}, To: []string{"user@example.com"}, Schedule: "weekly mon 08:00"},
+		{Name: "daily", To: []string{"user@example.com", "user2@example.com"}, Schedule: "daily", Subject: "{{.Count}} items"},
+	}, r.Digests)
+	assert.Equal(t, SMTP{Host: "smtp.example.com", Port: 465, Username: "user", Password: "smtp-pass",
+		From: "Feed Master <feeds@example.com>", TLS: true}, r.SMTP)
 	assert.Equal(t, time.Second*600, r.System.UpdateInterval)
 	assert.Equal(t, time.Second*10, r.System.HTTPResponseTimeout)
-	assert.Equal(t, []ytfdeed.FeedInfo{{Name: "name1", ID: "id1", Type: "playlist", Keep: 15},
-		{Name: "name2", ID: "id2", Type: "channel", Language: "ru-ru", Keep: 5}},
+	assert.Equal(t, []ytfdeed.FeedInfo{{Name: "name1", ID: "id1", Type: "playlist", Keep: 15, MaxSize: 3 << 29},
+		{Name: "name2", ID: "id2", Type: "channel", Language: "ru-ru", Keep: 5,
+			PostProcess: ytfeed.PostProcOpts{Loudnorm: true, Bitrate: "64k"}}},
 		r.YouTube.Channels, "2 yt")
 	assert.Equal(t, "yt-dlp --extract-audio --audio-format=mp3 -f m4a/bestaudio \"https://www.youtube.com/watch?v={{.ID}}\" --no-progress -o {{.Filename}}", r.YouTube.DlTemplate)
 	assert.Equal(t, "https://www.youtube.com/videos.xml?channel_id=", r.YouTube.BaseChanURL)
 	assert.Equal(t, "https://www.youtube.com/videos.xml?playlist_id=", r.YouTube.BasePlaylistURL)
 	assert.Equal(t, "./var/rss", r.YouTube.RSSLocation)
+	assert.Equal(t, ytfdeed.ByteSize(20<<30), r.YouTube.MaxSize)
+	assert.Equal(t, ytfdeed.ByteSize(500<<20), r.YouTube.MinFreeSpace)
+	assert.Equal(t, 12*time.Hour, r.YouTube.Reconcile.Interval)
+	assert.True(t, r.YouTube.Reconcile.DryRun)
+	assert.Equal(t, 6*time.Hour, r.YouTube.Refresh.Interval)
+	assert.Equal(t, 72*time.Hour, r.YouTube.Refresh.MaxAge)
+	assert.True(t, r.YouTube.WebSub.Enabled)
+	assert.Equal(t, "http://localhost:8181/hub", r.YouTube.WebSub.HubURL)
+	assert.Equal(t, "https://example.com/yt/websub", r.YouTube.WebSub.CallbackURL)
+	assert.Equal(t, "websub-secret", r.YouTube.WebSub.Secret)
+	assert.Equal(t, 120*time.Hour, r.YouTube.WebSub.Lease)
 
 	assert.Equal(t, "Feed Master", r.Feeds["first"].Author)
 	assert.Equal(t, "author 2", r.Feeds["second"].Author)
@@ -111,12 +144,35 @@ func TestSetDefault(t *testing.T) {
 	assert.Equal(t, time.Minute*5, c.YouTube.UpdateInterval)
 	assert.Equal(t, "/yt/media", c.YouTube.BaseURL)
 	assert.Equal(t, "var/yt", c.YouTube.FilesLocation)
+	assert.Equal(t, 48*time.Hour, c.YouTube.Refresh.MaxAge)
+	assert.False(t, c.YouTube.WebSub.Enabled)
+	assert.Equal(t, "https://pubsubhubbub.appspot.com/subscribe", c.YouTube.WebSub.HubURL)
 	assert.Equal(t, "var/rss", c.YouTube.RSSLocation)
-	assert.Equal(t, "yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio \"https://www.youtube.com/watch?v={{.ID}}\" --no-progress -o {{.FileName}} --match-filter \"!is_live & availability=public\"", c.YouTube.DlTemplate)
+	assert.Equal(t, "ffmpeg", c.YouTube.FFmpeg)
+	assert.Contains(t, c.YouTube.SubsTemplate, "--sub-langs {{.Langs}}")
+	assert.Contains(t, c.YouTube.ProbeTemplate, "--dump-json")
+	assert.Contains(t, c.YouTube.ListingTemplate, "--flat-playlist")
+	assert.Equal(t, "https://sponsor.ajay.app", c.YouTube.SponsorBlockURL)
+	assert.Equal(t, "yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio \"{{.URL}}\" --no-progress -o {{.FileName}} --match-filter \"!is_live & availability=public\" --match-filter \"!is_live & extractor!=youtube\"", c.YouTube.DlTemplate)
 	assert.Equal(t, "https://www.youtube.com/feeds/videos.xml?channel_id=", c.YouTube.BaseChanURL)
 	assert.Equal(t, "https://www.youtube.com/feeds/videos.xml?playlist_id=", c.YouTube.BasePlaylistURL)
 }
 
+func TestSetDefaultWebSub(t *testing.T) {
+	c := Conf{}
+	c.YouTube.WebSub.Enabled = true
+	c.setDefaults()
+	assert.False(t, c.YouTube.WebSub.Enabled, "disabled without base url")
+	assert.Equal(t, "/yt/websub", c.YouTube.WebSub.CallbackURL)
+
+	c = Conf{}
+	c.YouTube.WebSub.Enabled = true
+	c.System.BaseURL = "https://example.com"
+	c.setDefaults()
+	assert.True(t, c.YouTube.WebSub.Enabled)
+	assert.Equal(t, "https://example.com/yt/websub", c.YouTube.WebSub.CallbackURL)
+}
+
 func TestFilter(t *testing.T) {
 	tbl := []struct {
 		filter Filter
@@ -26,12 +26,28 @@ type Item struct {
 	Comments string        `xml:"comments,omitempty"`
 	Author   string        `xml:"author,omitempty"`
 	Duration string        `xml:"duration,omitempty"`
+	Image    *ItemImage    `xml:"image,omitempty"` // per-item itunes:image
+	// podcast:transcript, https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md#transcript
+	Transcripts []Transcript `xml:"transcript,omitempty"`
 	// internal
 	DT          time.Time `xml:"-"`
 	Junk        bool      `xml:"-"`
 	DurationFmt string    `xml:"-"` // used for ui only in
 }
 
+// ItemImage is per-item artwork element, marshaled as itunes:image
+type ItemImage struct {
+	URL string `xml:"href,attr"`
+}
+
+// Transcript is per-item transcript element, marshaled as podcast:transcript
+type Transcript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"`
+}
+
 // DownloadAudio return httpBody for Item's Enclosure.URL
 func (item Item) DownloadAudio(timeout time.Duration) (res io.ReadCloser, err error) {
 	clientHTTP := &http.Client{Timeout: timeout}
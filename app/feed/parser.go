@@ -22,6 +22,7 @@ type Rss2 struct {
 	Version        string          `xml:"version,attr"`
 	NsItunes       string          `xml:"xmlns:itunes,attr"`
 	NsMedia        string          `xml:"xmlns:media,attr"`
+	NsPodcast      string          `xml:"xmlns:podcast,attr,omitempty"`
 	Title          string          `xml:"channel>title"`
 	Language       string          `xml:"channel>language"`
 	Link           string          `xml:"channel>link"`
@@ -1,15 +1,15 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
 	"path"
+	"slices"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/ChimeraCoder/anaconda"
@@ -82,13 +82,23 @@ func main() {
 	}
 	procStore := &proc.BoltDB{DB: db}
 
-	telegramNotif, err := proc.NewTelegramClient(opts.TelegramToken, opts.TelegramServer, opts.TelegramTimeout,
-		&duration.Service{}, &proc.TelegramSenderImpl{})
+	webhooks := &proc.WebhookClient{Client: &http.Client{Timeout: 30 * time.Second}}
+	digests := &proc.Digests{DB: db, Conf: conf, Sender: proc.SMTPSender{Conf: conf.SMTP}}
+	if err = digests.Validate(); err != nil {
+		log.Fatalf("[ERROR] invalid digests, %v", err)
+	}
+	notifiers, err := makeNotifiers(opts, conf, webhooks, digests)
 	if err != nil {
-		log.Fatalf("[ERROR] failed to initialize telegram client %s, %v", opts.TelegramToken, err)
+		log.Fatalf("[ERROR] failed to initialize notifiers, %v", err)
 	}
 
-	p := &proc.Processor{Conf: conf, Store: procStore, TelegramNotif: telegramNotif, TwitterNotif: makeTwitter(opts)}
+	outbox := &proc.Outbox{DB: db, Conf: conf, Notifiers: notifiers}
+	go outbox.Run(context.Background())
+	if len(conf.Digests) > 0 {
+		go digests.Run(context.Background())
+	}
+
+	p := &proc.Processor{Conf: conf, Store: procStore, Notifiers: notifiers, Outbox: outbox}
 	go func() {
 		if err := p.Do(context.Background()); err != nil {
 			log.Printf("[ERROR] processor failed: %v", err)
@@ -97,21 +107,17 @@ func main() {
 
 	var ytSvc youtube.Service
 	var ytStore *store.BoltDB
-	if len(conf.YouTube.Channels) > 0 {
-		log.Printf("[INFO] starting youtube processor for %d channels", len(conf.YouTube.Channels))
+	// started for configured channels, channels added at runtime before, or to add them if runtime channels enabled
+	if len(conf.YouTube.Channels) > 0 || conf.YouTube.RuntimeChannels || hasRuntimeChannels(db) {
+		log.Printf("[INFO] starting youtube processor for %d configured channels", len(conf.YouTube.Channels))
 		outWr := log.ToWriter(log.Default(), "DEBUG")
 		errWr := log.ToWriter(log.Default(), "INFO")
-		dwnl := ytfeed.NewDownloader(conf.YouTube.DlTemplate, outWr, errWr, conf.YouTube.FilesLocation)
+		dwnl := ytfeed.NewDownloader(conf.YouTube.DlTemplate, outWr, errWr, conf.YouTube.FilesLocation).
+			WithProbe(conf.YouTube.ProbeTemplate)
 		fd := ytfeed.Feed{Client: &http.Client{Timeout: 10 * time.Second},
 			ChannelBaseURL: conf.YouTube.BaseChanURL, PlaylistBaseURL: conf.YouTube.BasePlaylistURL}
 
-		channels := make([]string, 0, len(conf.YouTube.Channels))
-		for _, c := range conf.YouTube.Channels {
-			channels = append(channels, c.ID)
-		}
-		log.Printf("[DEBUG] buckets for youtube store: %s", strings.Join(channels, ", "))
-
-		ytStore = &store.BoltDB{DB: db, Channels: channels}
+		ytStore = &store.BoltDB{DB: db}
 		ytSvc = youtube.Service{
 			Feeds:          conf.YouTube.Channels,
 			Downloader:     dwnl,
@@ -119,14 +125,48 @@ func main() {
 			Store:          ytStore,
 			CheckDuration:  conf.YouTube.UpdateInterval,
 			KeepPerChannel: conf.YouTube.MaxItems,
+			FilesLocation:  conf.YouTube.FilesLocation,
+			ReservedIDs:    slices.Collect(maps.Keys(conf.Feeds)),
+			MaxTotalSize:   conf.YouTube.MaxSize,
+			MinFreeSpace:   conf.YouTube.MinFreeSpace,
+			DiskSpace:      ytfeed.DiskSpace{},
 			RootURL:        conf.YouTube.BaseURL,
 			RSSFileStore: youtube.RSSFileStore{
 				Location: conf.YouTube.RSSLocation,
 				Enabled:  conf.YouTube.RSSLocation != "",
 			},
 			DurationService: &duration.Service{},
-			SkipShorts:      conf.YouTube.SkipShorts,
+			Thumbnails: &ytfeed.Thumbnail{Client: &http.Client{Timeout: 30 * time.Second},
+				Destination: conf.YouTube.FilesLocation},
+			PostProcessor: ytfeed.NewPostProcessor(conf.YouTube.FFmpeg, outWr, errWr),
+			SponsorBlock: &ytfeed.SponsorBlock{Client: &http.Client{Timeout: 10 * time.Second},
+				BaseURL: conf.YouTube.SponsorBlockURL},
+			Subtitles:  ytfeed.NewSubtitles(conf.YouTube.SubsTemplate, outWr, errWr, conf.YouTube.FilesLocation),
+			SkipShorts: conf.YouTube.SkipShorts,
+			Probe:      conf.YouTube.Probe,
+			Resolver: &ytfeed.Resolver{Client: &http.Client{Timeout: 30 * time.Second},
+				BaseURL: "https://www.youtube.com", Command: conf.YouTube.ResolverCommand, LogErr: errWr},
+			Listing: &ytfeed.Listing{Client: &http.Client{Timeout: 30 * time.Second},
+				Command: conf.YouTube.ListingTemplate, LogErr: errWr},
 		}
+
+		// resolve @handles and urls to ids, the rest of the app uses resolved channels
+		ytSvc.ResolveFeeds(context.Background())
+		// merge channels added, changed or deleted at runtime
+		if err := ytSvc.LoadChannels(); err != nil {
+			log.Printf("[WARN] can't load runtime channels, %v", err)
+		}
+		if err := ytSvc.ValidateChannels(); err != nil {
+			log.Fatalf("[ERROR] invalid youtube channels, %v", err)
+		}
+		conf.YouTube.Channels = ytSvc.Channels()
+
+		channels := make([]string, 0, len(conf.YouTube.Channels))
+		for _, c := range conf.YouTube.Channels {
+			channels = append(channels, c.ID)
+		}
+		log.Printf("[DEBUG] buckets for youtube store: %s", strings.Join(channels, ", "))
+		ytStore.Channels = channels
 		ytSvc.YtDlpUpdCommand = conf.YouTube.YtDlpUpdate.Command
 		ytSvc.YtDlpUpdOnStart = conf.YouTube.YtDlpUpdate.ForceOnStartup
 		if conf.YouTube.YtDlpUpdate.Interval > 0 {
@@ -135,6 +175,30 @@ func main() {
 		} else {
 			log.Printf("[INFO] yt-dlp periodic updater is disabled")
 		}
+		if conf.YouTube.Reconcile.Interval > 0 {
+			log.Printf("[INFO] files reconciler enabled, interval %s, dry run: %v",
+				conf.YouTube.Reconcile.Interval, conf.YouTube.Reconcile.DryRun)
+			ytSvc.ReconcileInterval = conf.YouTube.Reconcile.Interval
+			ytSvc.ReconcileDryRun = conf.YouTube.Reconcile.DryRun
+		}
+		if conf.YouTube.Refresh.Interval > 0 {
+			log.Printf("[INFO] metadata refresh enabled, interval %s, max age %s",
+				conf.YouTube.Refresh.Interval, conf.YouTube.Refresh.MaxAge)
+			ytSvc.RefreshInterval = conf.YouTube.Refresh.Interval
+			ytSvc.RefreshAge = conf.YouTube.Refresh.MaxAge
+		}
+		if conf.YouTube.WebSub.Enabled {
+			secret := conf.YouTube.WebSub.Secret
+			if secret == "" {
+				secret = uuid.New().String() // subscriptions are renewed with the new secret on restart
+			}
+			log.Printf("[INFO] websub push notifications enabled, hub %s, callback %s",
+				conf.YouTube.WebSub.HubURL, conf.YouTube.WebSub.CallbackURL)
+			ytSvc.WebSub = &ytfeed.WebSub{Client: &http.Client{Timeout: 10 * time.Second},
+				HubURL: conf.YouTube.WebSub.HubURL, CallbackURL: conf.YouTube.WebSub.CallbackURL,
+				TopicURL: "https://www.youtube.com/xml/feeds/videos.xml?channel_id=", Secret: secret,
+				Lease: conf.YouTube.WebSub.Lease}
+		}
 
 		go func() {
 			if conf.YouTube.DisableUpdates {
@@ -158,11 +222,23 @@ func main() {
 		Store:        procStore,
 		YoutubeStore: ytStore,
 		YoutubeSvc:   &ytSvc,
+		WebhookLog:   webhooks,
+		Outbox:       outbox,
 		AdminPasswd:  opts.AdminPasswd,
 	}
 	server.Run(context.Background(), opts.Port)
 }
 
+// hasRuntimeChannels checks if any youtube channel was added or changed at runtime
+func hasRuntimeChannels(db *bolt.DB) bool {
+	recs, err := (&store.BoltDB{DB: db}).ListChannels()
+	if err != nil {
+		log.Printf("[WARN] can't list runtime channels, %v", err)
+		return false
+	}
+	return len(recs) > 0
+}
+
 func makeBoltDB(dbFile string) (*bolt.DB, error) {
 	log.Printf("[INFO] bolt (persistent) store, %s", dbFile)
 	if dbFile == "" {
@@ -179,21 +255,55 @@ func makeBoltDB(dbFile string) (*bolt.DB, error) {
 	return db, nil
 }
 
-func makeTwitter(opts options) *proc.TwitterClient {
-	twitterFmtFn := func(item rssfeed.Item) string {
-		b1 := bytes.Buffer{}
-		if err := template.Must(template.New("twi").Parse(opts.TwitterTemplate)).Execute(&b1, item); err != nil {
-			// template failed to parse record, backup predefined format
-			return fmt.Sprintf("%s - %s", item.Title, item.Link)
+// makeNotifiers registers all notifiers, feeds use all of them or the ones listed in feed's notify
+func makeNotifiers(opts options, conf *config.Conf, webhooks *proc.WebhookClient, digests *proc.Digests) (*proc.Notifiers, error) {
+	telegramNotif, err := proc.NewTelegramClient(opts.TelegramToken, opts.TelegramServer, opts.TelegramTimeout,
+		&duration.Service{}, &proc.TelegramSenderImpl{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize telegram client: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	res := proc.NewNotifiers().
+		Register("telegram", &proc.TelegramNotifier{Client: telegramNotif}).
+		Register("twitter", &proc.TwitterNotifier{Client: makeTwitter(opts, config.Twitter{}),
+			FeedClient: func(tw config.Twitter) proc.TwitterNotif { return makeTwitter(opts, tw) },
+			Enabled: opts.TwitterConsumerKey != "" && opts.TwitterConsumerSecret != "" &&
+				opts.TwitterAccessToken != "" && opts.TwitterAccessSecret != ""}).
+		Register("mastodon", &proc.MastodonClient{Client: httpClient}).
+		Register("bluesky", &proc.BlueskyClient{Client: httpClient}).
+		Register("discord", &proc.DiscordClient{Client: httpClient}).
+		Register("slack", &proc.SlackClient{Client: httpClient}).
+		Register("matrix", &proc.MatrixClient{Client: httpClient}).
+		Register("webhook", webhooks)
+	if len(conf.Digests) > 0 { // digest notifier only needed with configured digests
+		res.Register("digest", digests)
+	}
+	if unknown := res.Unknown(conf.Feeds); len(unknown) > 0 {
+		log.Printf("[WARN] unknown notifiers %v in feeds, registered: %v", unknown, res.Names())
+	}
+	return res, nil
+}
+
+// makeTwitter makes twitter client with feed's account and template, global ones used for unset fields
+func makeTwitter(opts options, tw config.Twitter) *proc.TwitterClient {
+	orDefault := func(val, def string) string {
+		if val != "" {
+			return val
 		}
-		return strings.ReplaceAll(proc.CleanText(b1.String(), 280), `\n`, "\n") // \n in template
+		return def
+	}
+
+	tmpl := orDefault(tw.Template, opts.TwitterTemplate)
+	twitterFmtFn := func(item rssfeed.Item) string {
+		return proc.FormatItem(tmpl, item, 280)
 	}
 
 	twiAuth := proc.TwitterAuth{
-		ConsumerKey:    opts.TwitterConsumerKey,
-		ConsumerSecret: opts.TwitterConsumerSecret,
-		AccessToken:    opts.TwitterAccessToken,
-		AccessSecret:   opts.TwitterAccessSecret,
+		ConsumerKey:    orDefault(tw.ConsumerKey, opts.TwitterConsumerKey),
+		ConsumerSecret: orDefault(tw.ConsumerSecret, opts.TwitterConsumerSecret),
+		AccessToken:    orDefault(tw.AccessToken, opts.TwitterAccessToken),
+		AccessSecret:   orDefault(tw.AccessSecret, opts.TwitterAccessSecret),
 	}
 
 	twitPoster := anaconda.NewTwitterApiWithCredentials(twiAuth.AccessToken, twiAuth.AccessSecret, twiAuth.ConsumerKey, twiAuth.ConsumerSecret)
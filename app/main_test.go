@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/umputun/feed-master/app/config"
 )
 
 func TestMakeTwitter(t *testing.T) {
@@ -14,10 +16,17 @@ func TestMakeTwitter(t *testing.T) {
 		TwitterAccessSecret:   "d",
 	}
 
-	client := makeTwitter(opts)
+	client := makeTwitter(opts, config.Twitter{})
 
 	assert.Equal(t, "a", client.ConsumerKey)
 	assert.Equal(t, "b", client.ConsumerSecret)
 	assert.Equal(t, "c", client.AccessToken)
 	assert.Equal(t, "d", client.AccessSecret)
+
+	client = makeTwitter(opts, config.Twitter{AccessToken: "e", AccessSecret: "f"})
+
+	assert.Equal(t, "a", client.ConsumerKey, "global app key")
+	assert.Equal(t, "b", client.ConsumerSecret)
+	assert.Equal(t, "e", client.AccessToken, "feed's account")
+	assert.Equal(t, "f", client.AccessSecret)
 }
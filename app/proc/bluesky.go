@@ -0,0 +1,262 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+const (
+	blueskyPDS      = "https://bsky.social"
+	blueskyTemplate = "{{.Title}} - {{.Link}}"
+	blueskyMaxChars = 300 // the limit is in graphemes, runes counted by CleanText are never fewer
+)
+
+var (
+	blueskyLinkRe = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+	// errBlueskyAuth returned for expired or invalid session, the request repeated with the new session
+	errBlueskyAuth = errors.New("bluesky session expired")
+)
+
+// BlueskyClient posts new items to bluesky accounts of feeds, with link facets and the external embed card.
+// Sessions are made with app passwords and kept per account, feeds without the account skipped.
+type BlueskyClient struct {
+	Client *http.Client
+
+	lock     sync.Mutex
+	sessions map[string]blueskySession // by pds and handle
+}
+
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+}
+
+// blueskyFacet marks a link in the post text, indexes are byte offsets of utf-8 text
+type blueskyFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []map[string]string `json:"features"`
+}
+
+// Configured checks if the feed has bluesky handle and app password
+func (b *BlueskyClient) Configured(_ string, fm config.Feed) bool {
+	return fm.Bluesky.Handle != "" && fm.Bluesky.AppPassword != ""
+}
+
+// Send posts the item to the feed's bluesky account
+func (b *BlueskyClient) Send(ctx context.Context, n Notification) error {
+	conf := n.Feed.Bluesky
+	if !b.Configured(n.FeedName, n.Feed) {
+		return nil
+	}
+	if conf.PDS == "" {
+		conf.PDS = blueskyPDS
+	}
+	conf.PDS = strings.TrimSuffix(conf.PDS, "/")
+
+	err := b.post(ctx, conf, n)
+	if errors.Is(err, errBlueskyAuth) {
+		b.resetSession(conf)
+		err = b.post(ctx, conf, n)
+	}
+	return err
+}
+
+func (b *BlueskyClient) post(ctx context.Context, conf config.Bluesky, n Notification) error {
+	sess, err := b.session(ctx, conf)
+	if err != nil {
+		return err
+	}
+
+	tmpl := conf.Template
+	if tmpl == "" {
+		tmpl = blueskyTemplate
+	}
+	text := FormatItem(tmpl, n.Item, blueskyMaxChars)
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	if facets := blueskyFacets(text); len(facets) > 0 {
+		record["facets"] = facets
+	}
+	if n.Feed.Language != "" {
+		record["langs"] = []string{n.Feed.Language}
+	}
+	if embed := b.embed(ctx, conf, sess, n); embed != nil {
+		record["embed"] = embed
+	}
+
+	req := map[string]any{"repo": sess.DID, "collection": "app.bsky.feed.post", "record": record}
+	var resp struct {
+		URI string `json:"uri"`
+	}
+	if err := b.call(ctx, conf, sess.AccessJwt, "com.atproto.repo.createRecord", req, &resp); err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+	log.Printf("[INFO] published to bluesky %s, %s", resp.URI, n.Item.Title)
+	return nil
+}
+
+// embed makes the external link card of the item, with thumbnail from item's or feed's image if available.
+// Returns nil for items without link.
+func (b *BlueskyClient) embed(ctx context.Context, conf config.Bluesky, sess blueskySession, n Notification) map[string]any {
+	link := itemLink(n.Item)
+	if link == "" {
+		return nil
+	}
+	external := map[string]any{
+		"uri":         link,
+		"title":       n.Item.Title,
+		"description": CleanText(string(n.Item.Description), blueskyMaxChars),
+	}
+
+	if imageURL := itemImage(n); imageURL != "" {
+		blob, err := b.uploadImage(ctx, conf, sess, imageURL)
+		if err != nil {
+			log.Printf("[WARN] failed to upload bluesky thumbnail %s, card without it: %v", imageURL, err)
+		} else {
+			external["thumb"] = blob
+		}
+	}
+	return map[string]any{"$type": "app.bsky.embed.external", "external": external}
+}
+
+// uploadImage downloads the image and uploads it as blob, returns blob reference for the record
+func (b *BlueskyClient) uploadImage(ctx context.Context, conf config.Bluesky, sess blueskySession, imageURL string) (json.RawMessage, error) {
+	imgReq, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make image request: %w", err)
+	}
+	imgResp, err := b.Client.Do(imgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image: %w", err)
+	}
+	defer imgResp.Body.Close()
+	if imgResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get image, status %d", imgResp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(imgResp.Body, 1<<20)) // blob limit of the card thumbnail is 1MB
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.PDS+"/xrpc/com.atproto.repo.uploadBlob", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make upload request: %w", err)
+	}
+	contentType := imgResp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+sess.AccessJwt)
+	var resp struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	if err := b.do(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return resp.Blob, nil
+}
+
+// session returns the session of the account, made on the first use
+func (b *BlueskyClient) session(ctx context.Context, conf config.Bluesky) (blueskySession, error) {
+	key := conf.PDS + "::" + conf.Handle
+	b.lock.Lock()
+	sess, ok := b.sessions[key]
+	b.lock.Unlock()
+	if ok {
+		return sess, nil
+	}
+
+	req := map[string]string{"identifier": conf.Handle, "password": conf.AppPassword}
+	if err := b.call(ctx, conf, "", "com.atproto.server.createSession", req, &sess); err != nil {
+		return sess, fmt.Errorf("failed to create bluesky session for %s: %w", conf.Handle, err)
+	}
+	b.lock.Lock()
+	if b.sessions == nil {
+		b.sessions = map[string]blueskySession{}
+	}
+	b.sessions[key] = sess
+	b.lock.Unlock()
+	return sess, nil
+}
+
+func (b *BlueskyClient) resetSession(conf config.Bluesky) {
+	b.lock.Lock()
+	delete(b.sessions, conf.PDS+"::"+conf.Handle)
+	b.lock.Unlock()
+}
+
+// call posts json request to xrpc procedure of the pds
+func (b *BlueskyClient) call(ctx context.Context, conf config.Bluesky, token, method string, req, res any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.PDS+"/xrpc/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to make %s request: %w", method, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	return b.do(httpReq, res)
+}
+
+// do sends the request and decodes json response. Expired and invalid tokens reported as errBlueskyAuth.
+func (b *BlueskyClient) do(req *http.Request, res any) error {
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+			return fmt.Errorf("failed to decode response of %s: %w", req.URL.Path, err)
+		}
+		return nil
+	}
+
+	var errResp struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&errResp)
+	if req.Header.Get("Authorization") != "" &&
+		(resp.StatusCode == http.StatusUnauthorized || errResp.Error == "ExpiredToken" || errResp.Error == "InvalidToken") {
+		return fmt.Errorf("%w: %s", errBlueskyAuth, errResp.Error)
+	}
+	return fmt.Errorf("unexpected status %d for %s: %s %s", resp.StatusCode, req.URL.Path, errResp.Error, errResp.Message)
+}
+
+// blueskyFacets returns link facets for urls in the text, trailing punctuation is not a part of the link
+func blueskyFacets(text string) []blueskyFacet {
+	res := []blueskyFacet{}
+	for _, loc := range blueskyLinkRe.FindAllStringIndex(text, -1) {
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)")
+		facet := blueskyFacet{Features: []map[string]string{{"$type": "app.bsky.richtext.facet#link", "uri": link}}}
+		facet.Index.ByteStart, facet.Index.ByteEnd = loc[0], loc[0]+len(link)
+		res = append(res, facet)
+	}
+	return res
+}
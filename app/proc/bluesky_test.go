@@ -0,0 +1,143 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestBlueskyClient_Send(t *testing.T) {
+	var lock sync.Mutex
+	var records []map[string]any
+	var uploads []string
+	sessions, expire := 0, false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("image data"))
+		case "/bad-image.png":
+			w.WriteHeader(http.StatusNotFound)
+		case "/xrpc/com.atproto.server.createSession":
+			var req map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req["password"] != "pass1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"AuthenticationRequired","message":"Invalid identifier or password"}`))
+				return
+			}
+			sessions++
+			_, _ = w.Write([]byte(`{"accessJwt":"jwt` + string(rune('0'+sessions)) + `","did":"did:plc:feed"}`))
+		case "/xrpc/com.atproto.repo.uploadBlob":
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			uploads = append(uploads, r.Header.Get("Content-Type")+":"+string(data))
+			_, _ = w.Write([]byte(`{"blob":{"$type":"blob","ref":{"$link":"bafk1"},"mimeType":"image/png","size":10}}`))
+		case "/xrpc/com.atproto.repo.createRecord":
+			if expire {
+				expire = false
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"ExpiredToken","message":"Token has expired"}`))
+				return
+			}
+			assert.Equal(t, "Bearer jwt"+string(rune('0'+sessions)), r.Header.Get("Authorization"))
+			var req map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "did:plc:feed", req["repo"])
+			assert.Equal(t, "app.bsky.feed.post", req["collection"])
+			records = append(records, req["record"].(map[string]any))
+			_, _ = w.Write([]byte(`{"uri":"at://did:plc:feed/app.bsky.feed.post/1","cid":"cid1"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	b := &BlueskyClient{Client: ts.Client()}
+	item := feed.Item{Title: "Радио-Т 798", Link: "https://radio-t.com/p/798", Description: "<p>some <b>description</b></p>",
+		Image: &feed.ItemImage{URL: ts.URL + "/image.png"}}
+	conf := config.Bluesky{PDS: ts.URL + "/", Handle: "feed.bsky.social", AppPassword: "pass1"}
+
+	t.Run("no account, skipped", func(t *testing.T) {
+		require.NoError(t, b.Send(context.Background(), Notification{Item: item}))
+		assert.Empty(t, records)
+	})
+
+	t.Run("post with card", func(t *testing.T) {
+		require.NoError(t, b.Send(context.Background(), Notification{Feed: config.Feed{Bluesky: conf, Language: "ru"}, Item: item}))
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, records, 1)
+		rec := records[0]
+		assert.Equal(t, "app.bsky.feed.post", rec["$type"])
+		assert.Equal(t, "Радио-Т 798 - https://radio-t.com/p/798", rec["text"])
+		assert.Equal(t, []any{"ru"}, rec["langs"])
+		assert.NotEmpty(t, rec["createdAt"])
+
+		facets := rec["facets"].([]any)
+		require.Len(t, facets, 1)
+		facet := facets[0].(map[string]any)
+		idx := len("Радио-Т 798 - ")
+		assert.Equal(t, map[string]any{"byteStart": float64(idx), "byteEnd": float64(idx + len(item.Link))}, facet["index"])
+		assert.Equal(t, []any{map[string]any{"$type": "app.bsky.richtext.facet#link", "uri": item.Link}}, facet["features"])
+
+		embed := rec["embed"].(map[string]any)
+		assert.Equal(t, "app.bsky.embed.external", embed["$type"])
+		external := embed["external"].(map[string]any)
+		assert.Equal(t, item.Link, external["uri"])
+		assert.Equal(t, "Радио-Т 798", external["title"])
+		assert.Equal(t, "some description", external["description"])
+		assert.Equal(t, "bafk1", external["thumb"].(map[string]any)["ref"].(map[string]any)["$link"])
+		assert.Equal(t, []string{"image/png:image data"}, uploads)
+		assert.Equal(t, 1, sessions)
+	})
+
+	t.Run("expired session renewed, card without thumbnail", func(t *testing.T) {
+		it := item
+		it.Image = &feed.ItemImage{URL: ts.URL + "/bad-image.png"}
+		lock.Lock()
+		records, expire = nil, true
+		lock.Unlock()
+		require.NoError(t, b.Send(context.Background(), Notification{Feed: config.Feed{Bluesky: conf}, Item: it}))
+		require.Len(t, records, 1)
+		assert.Equal(t, 2, sessions)
+		assert.Nil(t, records[0]["langs"])
+		external := records[0]["embed"].(map[string]any)["external"].(map[string]any)
+		assert.Nil(t, external["thumb"])
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		c := conf
+		c.Handle, c.AppPassword = "other.bsky.social", "bad"
+		err := b.Send(context.Background(), Notification{Feed: config.Feed{Bluesky: c}, Item: item})
+		require.EqualError(t, err, "failed to create bluesky session for other.bsky.social: unexpected status 401 for "+
+			"/xrpc/com.atproto.server.createSession: AuthenticationRequired Invalid identifier or password")
+	})
+}
+
+func TestBlueskyFacets(t *testing.T) {
+	text := "Новый выпуск https://radio-t.com/p/798. Архив: http://example.com/a?b=1"
+	res := blueskyFacets(text)
+	require.Len(t, res, 2)
+	assert.Equal(t, "https://radio-t.com/p/798", text[res[0].Index.ByteStart:res[0].Index.ByteEnd])
+	assert.Equal(t, "https://radio-t.com/p/798", res[0].Features[0]["uri"])
+	assert.Equal(t, "http://example.com/a?b=1", text[res[1].Index.ByteStart:res[1].Index.ByteEnd])
+	assert.Empty(t, blueskyFacets("no links"))
+
+	long := FormatItem("{{.Title}} {{.Link}}", feed.Item{Title: strings.Repeat("слово ", 100), Link: "https://example.com"},
+		blueskyMaxChars)
+	assert.LessOrEqual(t, len([]rune(long)), blueskyMaxChars)
+}
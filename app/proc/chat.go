@@ -0,0 +1,118 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/umputun/feed-master/app/feed"
+)
+
+var (
+	chatAnchorRe = regexp.MustCompile(`(?s)<a href="([^"]*)"[^>]*>(.*?)</a>`)
+	chatBreakRe  = regexp.MustCompile(`(?i)<br\s*/?>|</p>`)
+)
+
+// markup converts item's description to the markup of chat platform, like getMessageHTML does for telegram.
+// Only links are kept from html of the description, all other tags removed.
+type markup struct {
+	text func(s string) string          // escapes plain text for the platform
+	link func(text, href string) string // makes link in the platform's markup
+}
+
+// description returns item's description in the markup, or the rendered template if set.
+// Description exceeding maximum is shrunk and sent as plain text.
+func (m markup) description(tmpl string, item feed.Item, maximum int) string {
+	if tmpl != "" {
+		return m.text(FormatItem(tmpl, item, maximum))
+	}
+	desc := string(item.Description)
+	desc = strings.TrimPrefix(desc, "<![CDATA[")
+	desc = strings.TrimSuffix(desc, "]]>")
+	desc = chatBreakRe.ReplaceAllString(html.UnescapeString(desc), "\n")
+
+	p := bluemonday.NewPolicy()
+	p.AllowAttrs("href").OnElements("a")
+	desc = strings.TrimSpace(p.Sanitize(desc))
+	if plain := html.UnescapeString(chatAnchorRe.ReplaceAllString(desc, "$2")); len([]rune(plain)) > maximum {
+		return m.text(CleanText(plain, maximum))
+	}
+
+	res := strings.Builder{}
+	last := 0
+	for _, loc := range chatAnchorRe.FindAllStringSubmatchIndex(desc, -1) {
+		res.WriteString(m.text(html.UnescapeString(desc[last:loc[0]])))
+		res.WriteString(m.link(html.UnescapeString(desc[loc[4]:loc[5]]), html.UnescapeString(desc[loc[2]:loc[3]])))
+		last = loc[1]
+	}
+	res.WriteString(m.text(html.UnescapeString(desc[last:])))
+	return res.String()
+}
+
+// itemLink returns link of the item, enclosure url for items without link
+func itemLink(item feed.Item) string {
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Enclosure.URL
+}
+
+// itemImage returns absolute url of item's image, or feed's image if the item has none
+func itemImage(n Notification) string {
+	res := n.Feed.Image
+	if n.Item.Image != nil && n.Item.Image.URL != "" {
+		res = n.Item.Image.URL
+	}
+	if strings.HasPrefix(res, "http://") || strings.HasPrefix(res, "https://") {
+		return res
+	}
+	return ""
+}
+
+// itemDuration returns human-readable duration of the item, i.e. 1h2m3s, empty if unknown
+func itemDuration(item feed.Item) string {
+	if item.Duration == "" {
+		return ""
+	}
+	d, err := time.ParseDuration(item.Duration + "s")
+	if err != nil || d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// sendJSON sends json body to the url, authorized with bearer token if set. Any 2xx status is a success.
+func sendJSON(ctx context.Context, client *http.Client, method, uri, token string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d for %s: %s", resp.StatusCode, req.URL.Path, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,84 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestMarkup_description(t *testing.T) {
+	item := feed.Item{Title: "title", Link: "https://example.com/1",
+		Description: `<![CDATA[<p>Темы <b>выпуска</b> &amp; ссылки</p><p><a href="https://radio-t.com/p/1?a=1&amp;b=2">первая</a> и ` +
+			`<a href="https://example.com">https://example.com</a></p>]]>`}
+
+	tbl := []struct {
+		name string
+		m    markup
+		tmpl string
+		res  string
+	}{
+		{name: "discord", m: discordMarkup,
+			res: "Темы выпуска & ссылки\n[первая](https://radio-t.com/p/1?a=1&b=2) и https://example.com"},
+		{name: "slack", m: slackMarkup,
+			res: "Темы выпуска &amp; ссылки\n<https://radio-t.com/p/1?a=1&b=2|первая> и <https://example.com|https://example.com>"},
+		{name: "matrix", m: matrixMarkup,
+			res: `Темы выпуска &amp; ссылки<br><a href="https://radio-t.com/p/1?a=1&amp;b=2">первая</a> и ` +
+				`<a href="https://example.com">https://example.com</a>`},
+		{name: "plain", m: plainMarkup, res: "Темы выпуска & ссылки\nпервая (https://radio-t.com/p/1?a=1&b=2) и https://example.com"},
+		{name: "template", m: slackMarkup, tmpl: "{{.Title}} & {{.Link}}", res: "title &amp; https://example.com/1"},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.res, tt.m.description(tt.tmpl, item, 1000))
+		})
+	}
+
+	item.Description = template.HTML(`<p>` + strings.Repeat("word ", 100) + `<a href="https://example.com">link</a></p>`) //nolint:gosec // test data
+	res := slackMarkup.description("", item, 50)
+	assert.LessOrEqual(t, len([]rune(res)), 50)
+	assert.True(t, strings.HasSuffix(res, " ..."), "shrunk as plain text")
+}
+
+func TestItemHelpers(t *testing.T) {
+	assert.Equal(t, "https://example.com/1", itemLink(feed.Item{Link: "https://example.com/1"}))
+	assert.Equal(t, "https://example.com/1.mp3", itemLink(feed.Item{Enclosure: feed.Enclosure{URL: "https://example.com/1.mp3"}}))
+
+	assert.Equal(t, "https://example.com/i.png", itemImage(Notification{Item: feed.Item{Image: &feed.ItemImage{URL: "https://example.com/i.png"}},
+		Feed: config.Feed{Image: "https://example.com/feed.png"}}))
+	assert.Equal(t, "https://example.com/feed.png", itemImage(Notification{Feed: config.Feed{Image: "https://example.com/feed.png"}}))
+	assert.Empty(t, itemImage(Notification{Feed: config.Feed{Image: "images/feed.png"}}), "relative image skipped")
+
+	assert.Equal(t, "1h2m3s", itemDuration(feed.Item{Duration: "3723"}))
+	assert.Empty(t, itemDuration(feed.Item{Duration: "1:02:03"}))
+	assert.Empty(t, itemDuration(feed.Item{}))
+}
+
+func TestSendJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("invalid_token\n"))
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, map[string]string{"k": "v"}, req)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	require.NoError(t, sendJSON(context.Background(), ts.Client(), http.MethodPost, ts.URL+"/hook", "token1", map[string]string{"k": "v"}))
+	err := sendJSON(context.Background(), ts.Client(), http.MethodPost, ts.URL+"/hook", "", map[string]string{"k": "v"})
+	require.EqualError(t, err, "unexpected status 403 for /hook: invalid_token")
+}
@@ -0,0 +1,449 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+var (
+	digestsBkt    = []byte("digests")
+	digestSentKey = []byte("sent")
+	digestItemBkt = []byte("items")
+)
+
+const (
+	digestSubject = "{{.Name}}: {{.Count}} new items"
+
+	digestHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>{{.Name}}</h2>
+{{range .Feeds}}<h3>{{.Title}}</h3>
+<ul>
+{{range .Items}}<li><a href="{{.Link}}">{{.Title}}</a>{{with clean .Description 300}}<br><small>{{.}}</small>{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`
+
+	digestText = `{{.Name}}
+{{range .Feeds}}
+{{.Title}}
+{{range .Items}}
+- {{.Title}}
+  {{.Link}}
+{{end}}{{end}}`
+)
+
+// MailSender sends emails
+type MailSender interface {
+	Send(ctx context.Context, m Mail) error
+}
+
+// DigestData is passed to subject and body templates of the digest
+type DigestData struct {
+	Name  string
+	Count int // number of items in all feeds
+	Feeds []DigestFeed
+}
+
+// DigestFeed is a feed of the digest with its new items, newest first
+type DigestFeed struct {
+	Name  string
+	Title string
+	Items []feed.Item
+}
+
+// Digests accumulates new items of feeds in bolt and sends them as email digests on schedule.
+// Works as a notifier, items of feeds not included in any digest ignored.
+type Digests struct {
+	DB       *bolt.DB
+	Conf     *config.Conf
+	Sender   MailSender
+	Interval time.Duration // interval of schedule checks, 1m if not set
+
+	retryAt map[string]time.Time // digests failed to send, not retried till the time
+}
+
+// digestItem is an item waiting for the digest
+type digestItem struct {
+	Feed  string    `json:"feed"`
+	Item  feed.Item `json:"item"`
+	Added time.Time `json:"added"`
+}
+
+// Validate checks schedules, recipients and templates of all digests
+func (d *Digests) Validate() error {
+	names := map[string]bool{}
+	for _, dg := range d.Conf.Digests {
+		if dg.Name == "" {
+			return errors.New("digest without name")
+		}
+		if names[dg.Name] {
+			return fmt.Errorf("duplicate digest %q", dg.Name)
+		}
+		names[dg.Name] = true
+		if len(dg.To) == 0 {
+			return fmt.Errorf("no recipients of digest %q", dg.Name)
+		}
+		if _, err := parseDigestSchedule(dg.Schedule); err != nil {
+			return fmt.Errorf("digest %q: %w", dg.Name, err)
+		}
+		if _, _, _, err := digestTemplates(dg); err != nil {
+			return fmt.Errorf("digest %q: %w", dg.Name, err)
+		}
+	}
+	return nil
+}
+
+// Configured checks if any digest includes the feed
+func (d *Digests) Configured(feedName string, _ config.Feed) bool {
+	return slices.ContainsFunc(d.Conf.Digests, func(dg config.Digest) bool {
+		return len(dg.Feeds) == 0 || slices.Contains(dg.Feeds, feedName)
+	})
+}
+
+// Send adds the item to all digests including its feed
+func (d *Digests) Send(_ context.Context, n Notification) error {
+	now := time.Now()
+	err := d.DB.Update(func(tx *bolt.Tx) error {
+		for _, dg := range d.Conf.Digests {
+			if len(dg.Feeds) > 0 && !slices.Contains(dg.Feeds, n.FeedName) {
+				continue
+			}
+			bucket, err := digestBucket(tx, dg.Name)
+			if err != nil {
+				return err
+			}
+			items, err := bucket.CreateBucketIfNotExists(digestItemBkt)
+			if err != nil {
+				return fmt.Errorf("create items bucket: %w", err)
+			}
+			data, err := json.Marshal(digestItem{Feed: n.FeedName, Item: n.Item, Added: now})
+			if err != nil {
+				return fmt.Errorf("marshal item %s: %w", n.Item.GUID, err)
+			}
+			if err = items.Put([]byte(n.FeedName+"::"+n.Item.GUID), data); err != nil {
+				return fmt.Errorf("put item %s: %w", n.Item.GUID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	return nil
+}
+
+// Run sends digests on their schedules till the context canceled. The time of the last digest is kept in the db,
+// so a digest missed while the app was down is sent on the start.
+func (d *Digests) Run(ctx context.Context) {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	log.Printf("[INFO] digests worker started for %d digests", len(d.Conf.Digests))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, dg := range d.Conf.Digests {
+			if err := d.check(ctx, dg, time.Now()); err != nil {
+				log.Printf("[WARN] failed to send digest %s, %v", dg.Name, err)
+				if d.retryAt == nil {
+					d.retryAt = map[string]time.Time{}
+				}
+				d.retryAt[dg.Name] = time.Now().Add(10 * interval)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("[INFO] digests worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check sends the digest if its scheduled time passed since the last one. The first check only remembers the time.
+func (d *Digests) check(ctx context.Context, dg config.Digest, now time.Time) error {
+	if now.Before(d.retryAt[dg.Name]) {
+		return nil
+	}
+	sched, err := parseDigestSchedule(dg.Schedule)
+	if err != nil {
+		return err
+	}
+	last, err := d.lastSent(dg.Name)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		return d.setSent(dg.Name, now)
+	}
+	if sched.next(last).After(now) {
+		return nil
+	}
+	if err := d.send(ctx, dg); err != nil {
+		return err
+	}
+	delete(d.retryAt, dg.Name)
+	return d.setSent(dg.Name, now)
+}
+
+// send emails accumulated items of the digest and removes them, nothing sent if there are no items
+func (d *Digests) send(ctx context.Context, dg config.Digest) error {
+	items, keys, err := d.items(dg.Name)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		log.Printf("[DEBUG] no new items for digest %s", dg.Name)
+		return nil
+	}
+
+	data := DigestData{Name: dg.Name, Count: len(items)}
+	for _, it := range items {
+		if len(data.Feeds) == 0 || data.Feeds[len(data.Feeds)-1].Name != it.Feed {
+			title := d.Conf.Feeds[it.Feed].Title
+			if title == "" {
+				title = it.Feed
+			}
+			data.Feeds = append(data.Feeds, DigestFeed{Name: it.Feed, Title: title})
+		}
+		item := it.Item
+		item.Link = itemLink(item)
+		data.Feeds[len(data.Feeds)-1].Items = append(data.Feeds[len(data.Feeds)-1].Items, item)
+	}
+
+	subjTmpl, htmlTmpl, textTmpl, err := digestTemplates(dg)
+	if err != nil {
+		return err
+	}
+	subj, html, text := bytes.Buffer{}, bytes.Buffer{}, bytes.Buffer{}
+	if err = subjTmpl.Execute(&subj, data); err != nil {
+		return fmt.Errorf("execute subject template: %w", err)
+	}
+	if err = htmlTmpl.Execute(&html, data); err != nil {
+		return fmt.Errorf("execute html template: %w", err)
+	}
+	if err = textTmpl.Execute(&text, data); err != nil {
+		return fmt.Errorf("execute text template: %w", err)
+	}
+
+	mail := Mail{To: dg.To, Subject: strings.TrimSpace(subj.String()), HTML: html.String(), Text: text.String()}
+	if err = d.Sender.Send(ctx, mail); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	log.Printf("[INFO] digest %s with %d items sent to %v", dg.Name, len(items), dg.To)
+
+	// only sent items removed, the ones added while sending wait for the next digest
+	err = d.DB.Update(func(tx *bolt.Tx) error {
+		bucket, e := digestBucket(tx, dg.Name)
+		if e != nil {
+			return e
+		}
+		itemsBkt := bucket.Bucket(digestItemBkt)
+		for _, k := range keys {
+			if e = itemsBkt.Delete(k); e != nil {
+				return fmt.Errorf("delete %s: %w", string(k), e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("remove sent items of digest %s: %w", dg.Name, err)
+	}
+	return nil
+}
+
+// items returns accumulated items of the digest sorted by feed and newest first, with their keys
+func (d *Digests) items(name string) (res []digestItem, keys [][]byte, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(digestsBkt)
+		if bucket == nil || bucket.Bucket([]byte(name)) == nil || bucket.Bucket([]byte(name)).Bucket(digestItemBkt) == nil {
+			return nil
+		}
+		return bucket.Bucket([]byte(name)).Bucket(digestItemBkt).ForEach(func(k, v []byte) error {
+			var it digestItem
+			if e := json.Unmarshal(v, &it); e != nil {
+				log.Printf("[WARN] failed to unmarshal digest item %s, %v", string(k), e)
+				return nil
+			}
+			res = append(res, it)
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("load items of digest %s: %w", name, err)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Feed != res[j].Feed {
+			return res[i].Feed < res[j].Feed
+		}
+		if !res[i].Item.DT.Equal(res[j].Item.DT) {
+			return res[i].Item.DT.After(res[j].Item.DT)
+		}
+		return res[i].Added.After(res[j].Added)
+	})
+	return res, keys, nil
+}
+
+func (d *Digests) lastSent(name string) (res time.Time, err error) {
+	err = d.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(digestsBkt)
+		if bucket == nil || bucket.Bucket([]byte(name)) == nil {
+			return nil
+		}
+		if v := bucket.Bucket([]byte(name)).Get(digestSentKey); v != nil {
+			return res.UnmarshalText(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load last time of digest %s: %w", name, err)
+	}
+	return res, nil
+}
+
+func (d *Digests) setSent(name string, t time.Time) error {
+	err := d.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := digestBucket(tx, name)
+		if err != nil {
+			return err
+		}
+		data, err := t.MarshalText()
+		if err != nil {
+			return fmt.Errorf("marshal time: %w", err)
+		}
+		return bucket.Put(digestSentKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("save last time of digest %s: %w", name, err)
+	}
+	return nil
+}
+
+func digestBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	bucket, err := tx.CreateBucketIfNotExists(digestsBkt)
+	if err != nil {
+		return nil, fmt.Errorf("create bucket %s: %w", digestsBkt, err)
+	}
+	res, err := bucket.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("create bucket of digest %s: %w", name, err)
+	}
+	return res, nil
+}
+
+// digestTemplates parses subject, html and text templates of the digest, html one is read from the file if set
+func digestTemplates(dg config.Digest) (subj *template.Template, html *htmltemplate.Template, text *template.Template, err error) {
+	subjSrc := dg.Subject
+	if subjSrc == "" {
+		subjSrc = digestSubject
+	}
+	if subj, err = template.New("subject").Parse(subjSrc); err != nil {
+		return nil, nil, nil, fmt.Errorf("parse subject template: %w", err)
+	}
+
+	htmlSrc := digestHTML
+	if dg.Template != "" {
+		data, e := os.ReadFile(dg.Template)
+		if e != nil {
+			return nil, nil, nil, fmt.Errorf("read template: %w", e)
+		}
+		htmlSrc = string(data)
+	}
+	funcs := htmltemplate.FuncMap{"clean": func(s htmltemplate.HTML, maximum int) string { return CleanText(string(s), maximum) }}
+	if html, err = htmltemplate.New("html").Funcs(funcs).Parse(htmlSrc); err != nil {
+		return nil, nil, nil, fmt.Errorf("parse html template: %w", err)
+	}
+	if text, err = template.New("text").Parse(digestText); err != nil {
+		return nil, nil, nil, fmt.Errorf("parse text template: %w", err)
+	}
+	return subj, html, text, nil
+}
+
+// digestSchedule is a daily or weekly time of the digest
+type digestSchedule struct {
+	weekly  bool
+	weekday time.Weekday
+	hour    int
+	minute  int
+}
+
+// parseDigestSchedule parses "daily", "daily 08:00", "weekly mon" or "weekly mon 08:00"
+func parseDigestSchedule(s string) (res digestSchedule, err error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return res, errors.New("empty schedule")
+	}
+	switch fields[0] {
+	case "daily":
+		fields = fields[1:]
+	case "weekly":
+		if len(fields) < 2 {
+			return res, fmt.Errorf("no weekday in schedule %q", s)
+		}
+		res.weekly = true
+		found := false
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			name := strings.ToLower(wd.String())
+			if fields[1] == name || fields[1] == name[:3] {
+				res.weekday, found = wd, true
+				break
+			}
+		}
+		if !found {
+			return res, fmt.Errorf("invalid weekday %q in schedule %q", fields[1], s)
+		}
+		fields = fields[2:]
+	default:
+		return res, fmt.Errorf("invalid schedule %q, daily or weekly expected", s)
+	}
+
+	switch len(fields) {
+	case 0:
+		return res, nil
+	case 1:
+		hm := strings.Split(fields[0], ":")
+		if len(hm) != 2 {
+			return res, fmt.Errorf("invalid time %q in schedule %q", fields[0], s)
+		}
+		h, errH := strconv.Atoi(hm[0])
+		m, errM := strconv.Atoi(hm[1])
+		if errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+			return res, fmt.Errorf("invalid time %q in schedule %q", fields[0], s)
+		}
+		res.hour, res.minute = h, m
+		return res, nil
+	default:
+		return res, fmt.Errorf("invalid schedule %q", s)
+	}
+}
+
+// next returns the first scheduled time after t, in t's location
+func (s digestSchedule) next(t time.Time) time.Time {
+	res := time.Date(t.Year(), t.Month(), t.Day(), s.hour, s.minute, 0, 0, t.Location())
+	for !res.After(t) || (s.weekly && res.Weekday() != s.weekday) {
+		res = time.Date(res.Year(), res.Month(), res.Day()+1, s.hour, s.minute, 0, 0, t.Location())
+	}
+	return res
+}
@@ -0,0 +1,196 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+type mailSenderFunc func(ctx context.Context, m Mail) error
+
+func (f mailSenderFunc) Send(ctx context.Context, m Mail) error { return f(ctx, m) }
+
+func TestDigests(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	var sent []Mail
+	var sendErr error
+	sender := mailSenderFunc(func(_ context.Context, m Mail) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		sent = append(sent, m)
+		return nil
+	})
+	conf := &config.Conf{
+		Feeds: map[string]config.Feed{"feed1": {Title: "Feed One"}, "feed2": {}, "feed3": {}},
+		Digests: []config.Digest{
+			{Name: "all", To: []string{"u1@example.com"}, Schedule: "daily 08:00"},
+			{Name: "some", Feeds: []string{"feed2"}, To: []string{"u2@example.com"}, Schedule: "weekly mon 08:00",
+				Subject: "digest {{.Name}} with {{.Count}}"},
+		},
+	}
+	d := &Digests{DB: db, Conf: conf, Sender: sender}
+	require.NoError(t, d.Validate())
+
+	dt := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, d.Send(context.Background(), Notification{FeedName: "feed1",
+		Item: feed.Item{GUID: "g1", Title: "title1", Link: "https://example.com/1", DT: dt}}))
+	require.NoError(t, d.Send(context.Background(), Notification{FeedName: "feed1",
+		Item: feed.Item{GUID: "g2", Title: "title <2>", Link: "https://example.com/2", Description: "<p>desc2</p>", DT: dt.Add(time.Hour)}}))
+	require.NoError(t, d.Send(context.Background(), Notification{FeedName: "feed2",
+		Item: feed.Item{GUID: "g3", Title: "title3", Enclosure: feed.Enclosure{URL: "https://example.com/3.mp3"}}}))
+
+	items, _, err := d.items("all")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, "g2", items[0].Item.GUID, "newest first")
+	items, _, err = d.items("some")
+	require.NoError(t, err)
+	require.Len(t, items, 1, "only feed2 in digest")
+
+	// first check only remembers the time
+	now := time.Date(2026, 10, 5, 7, 0, 0, 0, time.Local) // monday
+	require.NoError(t, d.check(context.Background(), conf.Digests[0], now))
+	require.NoError(t, d.check(context.Background(), conf.Digests[1], now))
+	assert.Empty(t, sent)
+
+	// not scheduled yet
+	require.NoError(t, d.check(context.Background(), conf.Digests[0], now.Add(30*time.Minute)))
+	assert.Empty(t, sent)
+
+	// send failed, items kept
+	sendErr = errors.New("smtp down")
+	err = d.check(context.Background(), conf.Digests[0], now.Add(time.Hour))
+	require.ErrorContains(t, err, "smtp down")
+	items, _, err = d.items("all")
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	sendErr = nil
+	require.NoError(t, d.check(context.Background(), conf.Digests[0], now.Add(time.Hour)))
+	require.NoError(t, d.check(context.Background(), conf.Digests[1], now.Add(time.Hour)))
+	require.Len(t, sent, 2)
+
+	assert.Equal(t, []string{"u1@example.com"}, sent[0].To)
+	assert.Equal(t, "all: 3 new items", sent[0].Subject)
+	assert.Contains(t, sent[0].HTML, "<h3>Feed One</h3>")
+	assert.Contains(t, sent[0].HTML, `<a href="https://example.com/2">title &lt;2&gt;</a>`)
+	assert.Contains(t, sent[0].HTML, "<small>desc2</small>")
+	assert.Contains(t, sent[0].HTML, `<a href="https://example.com/3.mp3">title3</a>`, "enclosure link if no link")
+	assert.Less(t, strings.Index(sent[0].HTML, "title &lt;2&gt;"), strings.Index(sent[0].HTML, "title1"))
+	assert.Contains(t, sent[0].Text, "- title <2>\n  https://example.com/2\n")
+	assert.Contains(t, sent[0].Text, "feed2\n")
+
+	assert.Equal(t, []string{"u2@example.com"}, sent[1].To)
+	assert.Equal(t, "digest some with 1", sent[1].Subject)
+	assert.NotContains(t, sent[1].HTML, "title1")
+
+	items, _, err = d.items("all")
+	require.NoError(t, err)
+	assert.Empty(t, items, "sent items removed")
+
+	// nothing to send, time updated anyway
+	require.NoError(t, d.check(context.Background(), conf.Digests[0], now.Add(25*time.Hour)))
+	assert.Len(t, sent, 2)
+	last, err := d.lastSent("all")
+	require.NoError(t, err)
+	assert.True(t, last.Equal(now.Add(25*time.Hour)))
+}
+
+func TestDigests_Template(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tmpl := filepath.Join(t.TempDir(), "digest.html")
+	require.NoError(t, os.WriteFile(tmpl, []byte(`{{range .Feeds}}{{range .Items}}[{{.Title}}]{{end}}{{end}}`), 0o600))
+
+	var sent []Mail
+	conf := &config.Conf{Digests: []config.Digest{{Name: "custom", To: []string{"u1@example.com"}, Schedule: "daily", Template: tmpl}}}
+	d := &Digests{DB: db, Conf: conf, Sender: mailSenderFunc(func(_ context.Context, m Mail) error {
+		sent = append(sent, m)
+		return nil
+	})}
+	require.NoError(t, d.Validate())
+	require.NoError(t, d.Send(context.Background(), Notification{FeedName: "feed1", Item: feed.Item{GUID: "g1", Title: "title1"}}))
+	require.NoError(t, d.send(context.Background(), conf.Digests[0]))
+	require.Len(t, sent, 1)
+	assert.Equal(t, "[title1]", sent[0].HTML)
+}
+
+func TestDigests_Validate(t *testing.T) {
+	tbl := []struct {
+		digests []config.Digest
+		err     string
+	}{
+		{digests: []config.Digest{{Name: "d1", To: []string{"a@example.com"}, Schedule: "daily"}}},
+		{digests: []config.Digest{{To: []string{"a@example.com"}, Schedule: "daily"}}, err: "digest without name"},
+		{digests: []config.Digest{{Name: "d1", Schedule: "daily"}}, err: `no recipients of digest "d1"`},
+		{digests: []config.Digest{{Name: "d1", To: []string{"a@example.com"}, Schedule: "hourly"}},
+			err: `digest "d1": invalid schedule "hourly", daily or weekly expected`},
+		{digests: []config.Digest{{Name: "d1", To: []string{"a@example.com"}, Schedule: "daily", Subject: "{{.Bad"}},
+			err: `digest "d1": parse subject template`},
+		{digests: []config.Digest{{Name: "d1", To: []string{"a@example.com"}, Schedule: "daily", Template: "/no/such/file"}},
+			err: `digest "d1": read template`},
+		{digests: []config.Digest{{Name: "d1", To: []string{"a@example.com"}, Schedule: "daily"},
+			{Name: "d1", To: []string{"a@example.com"}, Schedule: "daily"}}, err: `duplicate digest "d1"`},
+	}
+	for i, tt := range tbl {
+		d := &Digests{Conf: &config.Conf{Digests: tt.digests}}
+		err := d.Validate()
+		if tt.err == "" {
+			assert.NoError(t, err, "case %d", i)
+			continue
+		}
+		assert.ErrorContains(t, err, tt.err, "case %d", i)
+	}
+}
+
+func TestDigestSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 7, 10, 30, 0, 0, time.UTC) // wednesday
+	tbl := []struct {
+		schedule string
+		next     time.Time
+		err      string
+	}{
+		{schedule: "daily", next: time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)},
+		{schedule: "daily 08:00", next: time.Date(2026, 10, 8, 8, 0, 0, 0, time.UTC)},
+		{schedule: "Daily 12:15", next: time.Date(2026, 10, 7, 12, 15, 0, 0, time.UTC)},
+		{schedule: "daily 10:30", next: time.Date(2026, 10, 8, 10, 30, 0, 0, time.UTC)},
+		{schedule: "weekly mon 08:00", next: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)},
+		{schedule: "weekly wednesday 11:00", next: time.Date(2026, 10, 7, 11, 0, 0, 0, time.UTC)},
+		{schedule: "weekly wed 09:00", next: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{schedule: "weekly sun", next: time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)},
+		{schedule: "", err: "empty schedule"},
+		{schedule: "weekly", err: `no weekday in schedule "weekly"`},
+		{schedule: "weekly xyz", err: `invalid weekday "xyz"`},
+		{schedule: "daily 25:00", err: `invalid time "25:00"`},
+		{schedule: "daily 8", err: `invalid time "8"`},
+		{schedule: "daily 08:00 extra", err: `invalid schedule "daily 08:00 extra"`},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.schedule, func(t *testing.T) {
+			s, err := parseDigestSchedule(tt.schedule)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.next, s.next(now))
+		})
+	}
+}
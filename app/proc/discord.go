@@ -0,0 +1,67 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+const discordMaxChars = 4096 // limit of embed description
+
+// discordMarkup converts description to discord markdown, links as [text](url)
+var discordMarkup = markup{
+	text: func(s string) string { return s },
+	link: func(text, href string) string {
+		if text == "" || text == href {
+			return href
+		}
+		return fmt.Sprintf("[%s](%s)", strings.NewReplacer("[", "(", "]", ")").Replace(text), href)
+	},
+}
+
+// DiscordClient announces new items with discord webhooks of feeds, feeds without the webhook skipped
+type DiscordClient struct {
+	Client *http.Client
+}
+
+// Configured checks if the feed has discord webhook
+func (d *DiscordClient) Configured(_ string, fm config.Feed) bool {
+	return fm.Discord.Webhook != ""
+}
+
+// Send posts the item as embed with title, description, duration and thumbnail to the feed's discord webhook
+func (d *DiscordClient) Send(ctx context.Context, n Notification) error {
+	conf := n.Feed.Discord
+	if !d.Configured(n.FeedName, n.Feed) {
+		return nil
+	}
+
+	embed := map[string]any{
+		"title":       CleanText(n.Item.Title, 256),
+		"description": discordMarkup.description(conf.Template, n.Item, discordMaxChars),
+	}
+	if link := itemLink(n.Item); link != "" {
+		embed["url"] = link
+	}
+	if image := itemImage(n); image != "" {
+		embed["thumbnail"] = map[string]string{"url": image}
+	}
+	if dur := itemDuration(n.Item); dur != "" {
+		embed["fields"] = []map[string]any{{"name": "Duration", "value": dur, "inline": true}}
+	}
+	msg := map[string]any{"embeds": []any{embed}}
+	if conf.Username != "" {
+		msg["username"] = conf.Username
+	}
+
+	if err := sendJSON(ctx, d.Client, http.MethodPost, conf.Webhook, "", msg); err != nil {
+		return fmt.Errorf("failed to post to discord: %w", err)
+	}
+	log.Printf("[INFO] published to discord, %s", n.Item.Title)
+	return nil
+}
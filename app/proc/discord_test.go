@@ -0,0 +1,74 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestDiscordClient_Send(t *testing.T) {
+	var msgs []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/webhooks/1/token1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Unknown Webhook", "code": 10015}`))
+			return
+		}
+		var msg map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	d := &DiscordClient{Client: ts.Client()}
+	item := feed.Item{Title: "Радио-Т 798", Link: "https://radio-t.com/p/798", Duration: "7384",
+		Description: `<p>Темы: <a href="https://example.com/1">первая</a></p>`, Image: &feed.ItemImage{URL: "https://radio-t.com/798.png"}}
+
+	t.Run("no webhook, skipped", func(t *testing.T) {
+		require.NoError(t, d.Send(context.Background(), Notification{Item: item}))
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("embed", func(t *testing.T) {
+		conf := config.Discord{Webhook: ts.URL + "/api/webhooks/1/token1", Username: "Radio-T"}
+		require.NoError(t, d.Send(context.Background(), Notification{Feed: config.Feed{Discord: conf}, Item: item}))
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "Radio-T", msgs[0]["username"])
+		embeds := msgs[0]["embeds"].([]any)
+		require.Len(t, embeds, 1)
+		assert.Equal(t, map[string]any{
+			"title":       "Радио-Т 798",
+			"url":         "https://radio-t.com/p/798",
+			"description": "Темы: [первая](https://example.com/1)",
+			"thumbnail":   map[string]any{"url": "https://radio-t.com/798.png"},
+			"fields":      []any{map[string]any{"name": "Duration", "value": "2h3m4s", "inline": true}},
+		}, embeds[0])
+	})
+
+	t.Run("template, no image and duration", func(t *testing.T) {
+		msgs = nil
+		conf := config.Discord{Webhook: ts.URL + "/api/webhooks/1/token1", Template: "Новый выпуск {{.Title}}"}
+		it := feed.Item{Title: "Радио-Т 799", Link: "https://radio-t.com/p/799"}
+		require.NoError(t, d.Send(context.Background(), Notification{Feed: config.Feed{Discord: conf}, Item: it}))
+		require.Len(t, msgs, 1)
+		assert.Nil(t, msgs[0]["username"])
+		assert.Equal(t, map[string]any{"title": "Радио-Т 799", "url": "https://radio-t.com/p/799",
+			"description": "Новый выпуск Радио-Т 799"}, msgs[0]["embeds"].([]any)[0])
+	})
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		conf := config.Discord{Webhook: ts.URL + "/api/webhooks/2/bad"}
+		err := d.Send(context.Background(), Notification{Feed: config.Feed{Discord: conf}, Item: item})
+		require.EqualError(t, err, `failed to post to discord: unexpected status 404 for /api/webhooks/2/bad: `+
+			`{"message": "Unknown Webhook", "code": 10015}`)
+	})
+}
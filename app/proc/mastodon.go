@@ -0,0 +1,231 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // not for security, idempotency key only
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+const (
+	mastodonTemplate = "{{.Title}} - {{.Link}}"
+	mastodonMaxChars = 500
+	mastodonURLChars = 23 // mastodon counts any link as 23 characters
+)
+
+var mastodonURLRe = regexp.MustCompile(`https?://\S+`)
+
+// MastodonClient posts new items as statuses to mastodon accounts of feeds, feeds without the account skipped
+type MastodonClient struct {
+	Client     *http.Client
+	MediaWait  time.Duration // max wait for processing of the uploaded image, 10s if not set
+	mediaCheck time.Duration // interval of processing checks, 1s if not set
+}
+
+// Configured checks if the feed has mastodon server and token
+func (m *MastodonClient) Configured(_ string, fm config.Feed) bool {
+	return fm.Mastodon.Server != "" && fm.Mastodon.Token != ""
+}
+
+// Send posts the item to the feed's mastodon account, with item's image uploaded as media if enabled.
+// The status is posted without image if upload failed.
+func (m *MastodonClient) Send(ctx context.Context, n Notification) error {
+	conf := n.Feed.Mastodon
+	if !m.Configured(n.FeedName, n.Feed) {
+		return nil
+	}
+
+	form := url.Values{}
+	form.Set("status", mastodonStatus(conf, n.Item))
+	if conf.Visibility != "" {
+		form.Set("visibility", conf.Visibility)
+	}
+	if conf.Spoiler != "" {
+		form.Set("spoiler_text", conf.Spoiler)
+	}
+	if conf.Media && n.Item.Image != nil && n.Item.Image.URL != "" {
+		mediaID, err := m.uploadMedia(ctx, conf, n.Item)
+		if err != nil {
+			log.Printf("[WARN] failed to upload image of %s to mastodon, posted without it: %v", n.Item.GUID, err)
+		} else {
+			form.Set("media_ids[]", mediaID)
+		}
+	}
+
+	req, err := m.request(ctx, conf, http.MethodPost, "/api/v1/statuses", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// the same key for retries of the same item, mastodon ignores duplicates
+	h := sha1.Sum([]byte(conf.Server + "::" + n.Item.GUID)) //nolint:gosec // not for security
+	req.Header.Set("Idempotency-Key", hex.EncodeToString(h[:]))
+
+	var status struct {
+		URL string `json:"url"`
+	}
+	if err := m.do(req, &status, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to post status: %w", err)
+	}
+	log.Printf("[INFO] published to mastodon %s, %s", status.URL, n.Item.Title)
+	return nil
+}
+
+// uploadMedia downloads item's image and uploads it to mastodon, returns id of the media attachment.
+// Waits for processing if the server accepted the image for async processing.
+func (m *MastodonClient) uploadMedia(ctx context.Context, conf config.Mastodon, item feed.Item) (string, error) {
+	imgReq, err := http.NewRequestWithContext(ctx, http.MethodGet, item.Image.URL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to make image request: %w", err)
+	}
+	imgResp, err := m.Client.Do(imgReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image %s: %w", item.Image.URL, err)
+	}
+	defer imgResp.Body.Close()
+	if imgResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get image %s, status %d", item.Image.URL, imgResp.StatusCode)
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", imageName(item.Image.URL))
+	if err != nil {
+		return "", fmt.Errorf("failed to make form: %w", err)
+	}
+	if _, err = io.Copy(fw, io.LimitReader(imgResp.Body, 16<<20)); err != nil {
+		return "", fmt.Errorf("failed to read image %s: %w", item.Image.URL, err)
+	}
+	if err = mw.WriteField("description", CleanText(item.Title, 1500)); err != nil {
+		return "", fmt.Errorf("failed to make form: %w", err)
+	}
+	if err = mw.Close(); err != nil {
+		return "", fmt.Errorf("failed to make form: %w", err)
+	}
+
+	req, err := m.request(ctx, conf, http.MethodPost, "/api/v2/media", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	var media struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err = m.do(req, &media, http.StatusOK, http.StatusAccepted); err != nil {
+		return "", fmt.Errorf("failed to upload media: %w", err)
+	}
+	if media.URL != "" {
+		return media.ID, nil
+	}
+	return media.ID, m.waitMedia(ctx, conf, media.ID)
+}
+
+// waitMedia checks media attachment till it is processed, url of processed media is set
+func (m *MastodonClient) waitMedia(ctx context.Context, conf config.Mastodon, id string) error {
+	wait, check := m.MediaWait, m.mediaCheck
+	if wait <= 0 {
+		wait = 10 * time.Second
+	}
+	if check <= 0 {
+		check = time.Second
+	}
+	deadline := time.Now().Add(wait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("media %s not processed: %w", id, ctx.Err())
+		case <-time.After(check):
+		}
+		req, err := m.request(ctx, conf, http.MethodGet, "/api/v1/media/"+url.PathEscape(id), http.NoBody)
+		if err != nil {
+			return err
+		}
+		var media struct {
+			URL string `json:"url"`
+		}
+		if err = m.do(req, &media, http.StatusOK, http.StatusPartialContent); err != nil {
+			return fmt.Errorf("failed to check media %s: %w", id, err)
+		}
+		if media.URL != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("media %s not processed in %v", id, wait)
+}
+
+func (m *MastodonClient) request(ctx context.Context, conf config.Mastodon, method, uri string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(conf.Server, "/")+uri, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request %s: %w", uri, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+conf.Token)
+	return req, nil
+}
+
+// do sends the request and decodes json response, the status must be one of expected
+func (m *MastodonClient) do(req *http.Request, res any, expected ...int) error {
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+				return fmt.Errorf("failed to decode response of %s: %w", req.URL.Path, err)
+			}
+			return nil
+		}
+	}
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&errResp)
+	return fmt.Errorf("unexpected status %d for %s: %s", resp.StatusCode, req.URL.Path, errResp.Error)
+}
+
+// mastodonStatus makes the text of the status with feed's template, shrunk to the instance limit.
+// Links are counted as mastodon does, 23 characters each regardless of the length.
+func mastodonStatus(conf config.Mastodon, item feed.Item) string {
+	tmpl, maxChars := conf.Template, conf.MaxChars
+	if tmpl == "" {
+		tmpl = mastodonTemplate
+	}
+	if maxChars <= 0 {
+		maxChars = mastodonMaxChars
+	}
+	text := FormatItem(tmpl, item, math.MaxInt32)
+	length := len([]rune(text))
+	for _, link := range mastodonURLRe.FindAllString(text, -1) {
+		length += mastodonURLChars - len([]rune(link))
+	}
+	if length <= maxChars {
+		return text
+	}
+	return CleanText(text, maxChars+len([]rune(text))-length)
+}
+
+// imageName returns the file name of image url, used as the name of uploaded file
+func imageName(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		return path.Base(u.Path)
+	}
+	return "cover.jpg"
+}
@@ -0,0 +1,134 @@
+package proc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestMastodonClient_Send(t *testing.T) {
+	var lock sync.Mutex
+	var statuses []*http.Request
+	var forms []map[string][]string
+	var uploads []string
+	mediaChecks := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case r.URL.Path == "/image.jpg":
+			_, _ = w.Write([]byte("image data"))
+		case r.URL.Path == "/bad-image.jpg":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/api/v2/media":
+			assert.Equal(t, "Bearer token1", r.Header.Get("Authorization"))
+			file, hdr, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, err := io.ReadAll(file)
+			require.NoError(t, err)
+			uploads = append(uploads, hdr.Filename+":"+string(data)+":"+r.FormValue("description"))
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"id":"media1","url":null}`))
+		case r.URL.Path == "/api/v1/media/media1":
+			mediaChecks++
+			if mediaChecks < 2 {
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write([]byte(`{"id":"media1","url":null}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"media1","url":"https://example.com/media1.jpg"}`))
+		case r.URL.Path == "/api/v1/statuses":
+			require.NoError(t, r.ParseForm())
+			if r.Header.Get("Authorization") != "Bearer token1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"The access token is invalid"}`))
+				return
+			}
+			statuses = append(statuses, r)
+			forms = append(forms, r.PostForm)
+			_, _ = w.Write([]byte(`{"id":"1","url":"https://mastodon.example.com/@feed/1"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	m := &MastodonClient{Client: ts.Client(), mediaCheck: time.Millisecond}
+	item := feed.Item{Title: "Радио-Т 798", Link: "https://radio-t.com/p/798", GUID: "guid1", Image: &feed.ItemImage{URL: ts.URL + "/image.jpg"}}
+
+	t.Run("no account, skipped", func(t *testing.T) {
+		require.NoError(t, m.Send(context.Background(), Notification{Item: item}))
+		assert.Empty(t, statuses)
+	})
+
+	t.Run("status with media", func(t *testing.T) {
+		conf := config.Mastodon{Server: ts.URL + "/", Token: "token1", Visibility: "unlisted", Spoiler: "new episode", Media: true,
+			Template: "{{.Title}}\\n{{.Link}}"}
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Mastodon: conf}, Item: item}))
+		lock.Lock()
+		defer lock.Unlock()
+		require.Len(t, statuses, 1)
+		assert.Equal(t, "Радио-Т 798\nhttps://radio-t.com/p/798", forms[0]["status"][0])
+		assert.Equal(t, "unlisted", forms[0]["visibility"][0])
+		assert.Equal(t, "new episode", forms[0]["spoiler_text"][0])
+		assert.Equal(t, []string{"media1"}, forms[0]["media_ids[]"])
+		assert.Len(t, statuses[0].Header.Get("Idempotency-Key"), 40)
+		assert.Equal(t, []string{"image.jpg:image data:Радио-Т 798"}, uploads)
+		assert.Equal(t, 2, mediaChecks)
+	})
+
+	t.Run("media failed, posted without it", func(t *testing.T) {
+		conf := config.Mastodon{Server: ts.URL, Token: "token1", Media: true}
+		it := item
+		it.Image = &feed.ItemImage{URL: ts.URL + "/bad-image.jpg"}
+		lock.Lock()
+		statuses, forms = nil, nil
+		lock.Unlock()
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Mastodon: conf}, Item: it}))
+		require.Len(t, forms, 1)
+		assert.Equal(t, "Радио-Т 798 - https://radio-t.com/p/798", forms[0]["status"][0])
+		assert.Empty(t, forms[0]["media_ids[]"])
+		assert.Empty(t, forms[0]["visibility"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		conf := config.Mastodon{Server: ts.URL, Token: "bad"}
+		err := m.Send(context.Background(), Notification{Feed: config.Feed{Mastodon: conf}, Item: item})
+		require.EqualError(t, err, "failed to post status: unexpected status 401 for /api/v1/statuses: The access token is invalid")
+	})
+}
+
+func TestMastodonStatus(t *testing.T) {
+	link := "https://example.com/" + strings.Repeat("a", 100)
+	item := feed.Item{Title: strings.Repeat("word ", 100), Link: link, Description: "<p>some <b>description</b></p>"}
+
+	res := mastodonStatus(config.Mastodon{Template: "{{.Description}}"}, item)
+	assert.Equal(t, "some description", res, "html removed")
+
+	res = mastodonStatus(config.Mastodon{Template: "{{.Link}} {{.Title}}"}, item)
+	assert.True(t, strings.HasPrefix(res, link), "link kept")
+	assert.True(t, strings.HasSuffix(res, " ..."))
+	length := len([]rune(res)) - len([]rune(link)) + mastodonURLChars
+	assert.LessOrEqual(t, length, 500, "fits the limit with the link counted as 23 chars")
+	assert.Greater(t, length, 480)
+
+	res = mastodonStatus(config.Mastodon{Template: "{{.Title}}", MaxChars: 50}, item)
+	assert.LessOrEqual(t, len([]rune(res)), 50)
+}
+
+func TestImageName(t *testing.T) {
+	assert.Equal(t, "cover.png", imageName("https://example.com/images/cover.png?x=1"))
+	assert.Equal(t, "cover.jpg", imageName("https://example.com/"))
+	assert.Equal(t, "cover.jpg", imageName("https://example.com"))
+}
@@ -0,0 +1,82 @@
+package proc
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // not for security, transaction id only
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+const matrixMaxChars = 4000
+
+// matrixMarkup converts description to html of matrix messages, links kept as anchors
+var matrixMarkup = markup{
+	text: func(s string) string { return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>") },
+	link: func(text, href string) string {
+		return fmt.Sprintf("<a href=%q>%s</a>", html.EscapeString(href), html.EscapeString(text))
+	},
+}
+
+// plainMarkup keeps description as plain text, for body of matrix messages
+var plainMarkup = markup{
+	text: func(s string) string { return s },
+	link: func(text, href string) string {
+		if text == "" || text == href {
+			return href
+		}
+		return text + " (" + href + ")"
+	},
+}
+
+// MatrixClient announces new items to matrix rooms of feeds, feeds without the room skipped
+type MatrixClient struct {
+	Client *http.Client
+}
+
+// Configured checks if the feed has matrix homeserver, token and room
+func (m *MatrixClient) Configured(_ string, fm config.Feed) bool {
+	return fm.Matrix.Homeserver != "" && fm.Matrix.Token != "" && fm.Matrix.Room != ""
+}
+
+// Send posts the item as html message to the feed's matrix room, with plain text body for clients without html
+func (m *MatrixClient) Send(ctx context.Context, n Notification) error {
+	conf := n.Feed.Matrix
+	if !m.Configured(n.FeedName, n.Feed) {
+		return nil
+	}
+
+	title := strings.TrimSpace(n.Item.Title)
+	header, body := html.EscapeString(title), title
+	if link := itemLink(n.Item); link != "" {
+		header = matrixMarkup.link(title, link)
+		body = title + "\n" + link
+	}
+	footer := ""
+	if dur := itemDuration(n.Item); dur != "" {
+		footer = "\n\nDuration: " + dur
+	}
+	msg := map[string]string{
+		"msgtype":        "m.text",
+		"body":           body + "\n\n" + plainMarkup.description(conf.Template, n.Item, matrixMaxChars) + footer,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": header + "<br><br>" + matrixMarkup.description(conf.Template, n.Item, matrixMaxChars) + matrixMarkup.text(footer),
+	}
+
+	// the same transaction id for retries of the same item, homeserver ignores duplicates
+	h := sha1.Sum([]byte(conf.Room + "::" + n.Item.GUID)) //nolint:gosec // not for security
+	uri := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(conf.Homeserver, "/"), url.PathEscape(conf.Room), hex.EncodeToString(h[:]))
+	if err := sendJSON(ctx, m.Client, http.MethodPut, uri, conf.Token, msg); err != nil {
+		return fmt.Errorf("failed to send to matrix room %s: %w", conf.Room, err)
+	}
+	log.Printf("[INFO] published to matrix room %s, %s", conf.Room, n.Item.Title)
+	return nil
+}
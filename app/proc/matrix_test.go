@@ -0,0 +1,78 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestMatrixClient_Send(t *testing.T) {
+	var paths []string
+	var msgs []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token passed."}`))
+			return
+		}
+		var msg map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		paths = append(paths, r.URL.EscapedPath())
+		msgs = append(msgs, msg)
+		_, _ = w.Write([]byte(`{"event_id":"$event1"}`))
+	}))
+	defer ts.Close()
+
+	m := &MatrixClient{Client: ts.Client()}
+	item := feed.Item{Title: "Радио-Т 798", Link: "https://radio-t.com/p/798", GUID: "guid1", Duration: "60",
+		Description: `<p>Темы: <a href="https://example.com/1">первая</a></p>`}
+
+	t.Run("no room, skipped", func(t *testing.T) {
+		conf := config.Matrix{Homeserver: ts.URL, Token: "token1"}
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Matrix: conf}, Item: item}))
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		conf := config.Matrix{Homeserver: ts.URL + "/", Token: "token1", Room: "!room1:matrix.org"}
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Matrix: conf}, Item: item}))
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Matrix: conf}, Item: item}))
+		require.Len(t, msgs, 2)
+		assert.True(t, strings.HasPrefix(paths[0], "/_matrix/client/v3/rooms/%21room1:matrix.org/send/m.room.message/"), paths[0])
+		assert.Equal(t, paths[0], paths[1], "the same transaction for the same item")
+		assert.Equal(t, map[string]string{
+			"msgtype": "m.text",
+			"body":    "Радио-Т 798\nhttps://radio-t.com/p/798\n\nТемы: первая (https://example.com/1)\n\nDuration: 1m0s",
+			"format":  "org.matrix.custom.html",
+			"formatted_body": `<a href="https://radio-t.com/p/798">Радио-Т 798</a><br><br>Темы: ` +
+				`<a href="https://example.com/1">первая</a><br><br>Duration: 1m0s`,
+		}, msgs[0])
+	})
+
+	t.Run("template", func(t *testing.T) {
+		msgs = nil
+		conf := config.Matrix{Homeserver: ts.URL, Token: "token1", Room: "!room1:matrix.org", Template: "<b>new</b> & {{.Title}}"}
+		it := feed.Item{Title: "Радио-Т 799", GUID: "guid2"}
+		require.NoError(t, m.Send(context.Background(), Notification{Feed: config.Feed{Matrix: conf}, Item: it}))
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "Радио-Т 799\n\nnew & Радио-Т 799", msgs[0]["body"])
+		assert.Equal(t, "Радио-Т 799<br><br>new &amp; Радио-Т 799", msgs[0]["formatted_body"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		conf := config.Matrix{Homeserver: ts.URL, Token: "bad", Room: "!room1:matrix.org"}
+		err := m.Send(context.Background(), Notification{Feed: config.Feed{Matrix: conf}, Item: item})
+		require.ErrorContains(t, err, "failed to send to matrix room !room1:matrix.org: unexpected status 401")
+		require.ErrorContains(t, err, "Invalid access token passed.")
+	})
+}
@@ -15,7 +15,7 @@ import (
 //
 // 		// make and configure a mocked proc.TelegramNotif
 // 		mockedTelegramNotif := &TelegramNotifMock{
-// 			SendFunc: func(chanID string, item feed.Item) error {
+// 			SendFunc: func(chanID string, item feed.Item, tmpl string) error {
 // 				panic("mock out the Send method")
 // 			},
 // 		}
@@ -26,7 +26,7 @@ import (
 // 	}
 type TelegramNotifMock struct {
 	// SendFunc mocks the Send method.
-	SendFunc func(chanID string, item feed.Item) error
+	SendFunc func(chanID string, item feed.Item, tmpl string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -36,27 +36,31 @@ type TelegramNotifMock struct {
 			ChanID string
 			// Item is the item argument value.
 			Item feed.Item
+			// Tmpl is the tmpl argument value.
+			Tmpl string
 		}
 	}
 	lockSend sync.RWMutex
 }
 
 // Send calls SendFunc.
-func (mock *TelegramNotifMock) Send(chanID string, item feed.Item) error {
+func (mock *TelegramNotifMock) Send(chanID string, item feed.Item, tmpl string) error {
 	if mock.SendFunc == nil {
 		panic("TelegramNotifMock.SendFunc: method is nil but TelegramNotif.Send was just called")
 	}
 	callInfo := struct {
 		ChanID string
 		Item   feed.Item
+		Tmpl   string
 	}{
 		ChanID: chanID,
 		Item:   item,
+		Tmpl:   tmpl,
 	}
 	mock.lockSend.Lock()
 	mock.calls.Send = append(mock.calls.Send, callInfo)
 	mock.lockSend.Unlock()
-	return mock.SendFunc(chanID, item)
+	return mock.SendFunc(chanID, item, tmpl)
 }
 
 // SendCalls gets all the calls that were made to Send.
@@ -65,10 +69,12 @@ func (mock *TelegramNotifMock) Send(chanID string, item feed.Item) error {
 func (mock *TelegramNotifMock) SendCalls() []struct {
 	ChanID string
 	Item   feed.Item
+	Tmpl   string
 } {
 	var calls []struct {
 		ChanID string
 		Item   feed.Item
+		Tmpl   string
 	}
 	mock.lockSend.RLock()
 	calls = mock.calls.Send
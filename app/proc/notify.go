@@ -0,0 +1,270 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/repeater"
+	"github.com/go-pkgz/repeater/strategy"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+// Notifier sends new items of the feed to some destination, like telegram channel or twitter account.
+// Feeds not configured for the destination, i.e. without telegram channel, are skipped with nil error.
+type Notifier interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// FeedChecker is implemented by notifiers able to tell if the feed is configured for them. Feeds not listing
+// notifiers explicitly are sent to the configured ones only, notifiers without the check are always used.
+type FeedChecker interface {
+	Configured(feedName string, fm config.Feed) bool
+}
+
+// Notification is a new item of the feed passed to notifiers
+type Notification struct {
+	FeedName string
+	Feed     config.Feed
+	Item     feed.Item
+}
+
+// NotifierFunc is an adapter to use ordinary functions as notifiers
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+// Send calls f(ctx, n)
+func (f NotifierFunc) Send(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// Notifiers is a registry of named notifiers. Sends new items to all notifiers of the feed,
+// each one retried with exponential backoff on failure.
+type Notifiers struct {
+	Attempts int           // send attempts per notifier, 3 if not set
+	Delay    time.Duration // delay before the first retry, doubled for each next one, 5s if not set
+
+	notifiers map[string]Notifier
+}
+
+// NewNotifiers makes empty registry with default retries
+func NewNotifiers() *Notifiers {
+	return &Notifiers{Attempts: 3, Delay: 5 * time.Second, notifiers: map[string]Notifier{}}
+}
+
+// Register adds notifier with the given name, replaces the registered one with the same name
+func (n *Notifiers) Register(name string, notifier Notifier) *Notifiers {
+	if n.notifiers == nil {
+		n.notifiers = map[string]Notifier{}
+	}
+	n.notifiers[name] = notifier
+	return n
+}
+
+// Names returns sorted names of registered notifiers
+func (n *Notifiers) Names() []string {
+	res := make([]string, 0, len(n.notifiers))
+	for name := range n.notifiers {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Unknown returns names of notifiers listed in feeds but not registered
+func (n *Notifiers) Unknown(feeds map[string]config.Feed) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, fm := range feeds {
+		for _, name := range fm.Notify {
+			if _, ok := n.notifiers[name]; !ok && !seen[name] {
+				seen[name] = true
+				res = append(res, name)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Send sends the item to notifiers of the feed, all registered notifiers configured for the feed used
+// if the feed doesn't list them.
+// Failures of one notifier don't affect others, returns the number of notifiers failed after all attempts.
+func (n *Notifiers) Send(ctx context.Context, msg Notification) (failed int) {
+	for _, name := range n.targets(msg.FeedName, msg.Feed) {
+		notifier, ok := n.notifiers[name]
+		if !ok {
+			log.Printf("[WARN] unknown notifier %q for %s", name, msg.FeedName)
+			failed++
+			continue
+		}
+		if err := n.send(ctx, name, notifier, msg); err != nil {
+			log.Printf("[WARN] failed to send %s notification after %d attempts: title=%q, size=%d bytes, url=%s, feed=%s, %v",
+				name, n.attempts(), msg.Item.Title, msg.Item.Enclosure.Length, msg.Item.Enclosure.URL, msg.FeedName, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// targets returns names of notifiers of the feed, all registered notifiers configured for the feed
+// if the feed doesn't list them
+func (n *Notifiers) targets(feedName string, fm config.Feed) []string {
+	if len(fm.Notify) > 0 {
+		return fm.Notify
+	}
+	res := []string{}
+	for _, name := range n.Names() {
+		if fc, ok := n.notifiers[name].(FeedChecker); ok && !fc.Configured(feedName, fm) {
+			continue
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+func (n *Notifiers) send(ctx context.Context, name string, notifier Notifier, msg Notification) error {
+	delay := n.Delay
+	if delay <= 0 {
+		delay = 5 * time.Second
+	}
+	rptr := repeater.New(&strategy.Backoff{Duration: delay, Repeats: n.attempts(), Factor: 2})
+	attemptNum := 0
+	err := rptr.Do(ctx, func() error {
+		attemptNum++
+		startTime := time.Now()
+		log.Printf("[DEBUG] sending %s notification (attempt %d/%d): title=%q, size=%d bytes, url=%s, feed=%s",
+			name, attemptNum, n.attempts(), msg.Item.Title, msg.Item.Enclosure.Length, msg.Item.Enclosure.URL, msg.FeedName)
+		if e := notifier.Send(ctx, msg); e != nil {
+			log.Printf("[WARN] failed attempt %d/%d to send %s notification after %v: title=%q, feed=%s, error=%v",
+				attemptNum, n.attempts(), name, time.Since(startTime), msg.Item.Title, msg.FeedName, e)
+			return fmt.Errorf("%s send: %w", name, e)
+		}
+		log.Printf("[INFO] sent %s notification in %v: title=%q, feed=%s", name, time.Since(startTime), msg.Item.Title, msg.FeedName)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+func (n *Notifiers) attempts() int {
+	if n.Attempts <= 0 {
+		return 3
+	}
+	return n.Attempts
+}
+
+// TelegramNotifier sends items to telegram channels of the feed with feed's template, feeds without channels skipped.
+// Channels got the item while others failed are skipped on the next attempt.
+type TelegramNotifier struct {
+	Client  TelegramNotif
+	MaxSent int // number of kept records of channels got the item, 1000 if not set
+
+	lock      sync.Mutex
+	sent      map[string]bool // channels got the item of the partially failed send, by feed, guid and channel
+	sentOrder []string        // keys of sent in order of addition, the oldest dropped above MaxSent
+}
+
+// Configured checks if the feed has telegram channels
+func (t *TelegramNotifier) Configured(_ string, fm config.Feed) bool {
+	return len(fm.TelegramChannels()) > 0
+}
+
+// Send item to feed's telegram channels
+func (t *TelegramNotifier) Send(_ context.Context, n Notification) error {
+	key := func(ch string) string { return n.FeedName + "::" + n.Item.GUID + "::" + ch }
+	channels := n.Feed.TelegramChannels()
+	var errs []error
+	var sent []string
+	for _, ch := range channels {
+		t.lock.Lock()
+		skip := t.sent[key(ch)]
+		t.lock.Unlock()
+		if skip {
+			continue
+		}
+		if err := t.Client.Send(ch, n.Item, n.Feed.Telegram.Template); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+			continue
+		}
+		sent = append(sent, key(ch))
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(errs) == 0 {
+		for _, ch := range channels {
+			delete(t.sent, key(ch))
+		}
+		t.sentOrder = slices.DeleteFunc(t.sentOrder, func(k string) bool { return !t.sent[k] })
+		return nil
+	}
+	if t.sent == nil {
+		t.sent = map[string]bool{}
+	}
+	for _, k := range sent {
+		if !t.sent[k] {
+			t.sentOrder = append(t.sentOrder, k)
+		}
+		t.sent[k] = true
+	}
+	maxSent := t.MaxSent
+	if maxSent <= 0 {
+		maxSent = 1000
+	}
+	for len(t.sentOrder) > maxSent {
+		delete(t.sent, t.sentOrder[0])
+		t.sentOrder = t.sentOrder[1:]
+	}
+	return fmt.Errorf("telegram: %w", errors.Join(errs...))
+}
+
+// TwitterNotifier sends items to twitter. Feeds with own twitter account or template use the client made
+// for them by FeedClient, others use the default client.
+type TwitterNotifier struct {
+	Client     TwitterNotif                         // client with global credentials and template
+	FeedClient func(tw config.Twitter) TwitterNotif // makes client for feed's settings, global values used for unset ones
+	Enabled    bool                                 // global credentials set, feeds with own credentials enabled anyway
+
+	lock    sync.Mutex
+	clients map[config.Twitter]TwitterNotif
+}
+
+// Configured checks if global twitter credentials set or the feed has own ones
+func (t *TwitterNotifier) Configured(_ string, fm config.Feed) bool {
+	tw := fm.Twitter
+	return t.Enabled || (tw.ConsumerKey != "" && tw.ConsumerSecret != "" && tw.AccessToken != "" && tw.AccessSecret != "")
+}
+
+// Send item to twitter
+func (t *TwitterNotifier) Send(_ context.Context, n Notification) error {
+	if err := t.client(n.Feed.Twitter).Send(n.Item); err != nil {
+		return fmt.Errorf("twitter: %w", err)
+	}
+	return nil
+}
+
+// client returns the client for feed's twitter settings, made once for each settings
+func (t *TwitterNotifier) client(tw config.Twitter) TwitterNotif {
+	if tw == (config.Twitter{}) || t.FeedClient == nil {
+		return t.Client
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if c, ok := t.clients[tw]; ok {
+		return c
+	}
+	if t.clients == nil {
+		t.clients = map[config.Twitter]TwitterNotif{}
+	}
+	t.clients[tw] = t.FeedClient(tw)
+	return t.clients[tw]
+}
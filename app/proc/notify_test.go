@@ -0,0 +1,176 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+	"github.com/umputun/feed-master/app/proc/mocks"
+)
+
+func TestNotifiers_Send(t *testing.T) {
+	var lock sync.Mutex
+	calls := map[string]int{}
+	notifier := func(name string, failures int) Notifier {
+		return NotifierFunc(func(_ context.Context, n Notification) error {
+			lock.Lock()
+			defer lock.Unlock()
+			calls[name]++
+			assert.Equal(t, "feed1", n.FeedName)
+			assert.Equal(t, "title1", n.Item.Title)
+			if calls[name] <= failures {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}
+	reset := func() {
+		lock.Lock()
+		calls = map[string]int{}
+		lock.Unlock()
+	}
+
+	n := NewNotifiers().Register("n1", notifier("n1", 0)).Register("n2", notifier("n2", 2)).Register("n3", notifier("n3", 5))
+	n.Delay = time.Millisecond
+	assert.Equal(t, []string{"n1", "n2", "n3"}, n.Names())
+	msg := Notification{FeedName: "feed1", Item: feed.Item{Title: "title1"}}
+
+	t.Run("all notifiers", func(t *testing.T) {
+		reset()
+		failed := n.Send(context.Background(), msg)
+		assert.Equal(t, 1, failed, "n3 failed all attempts")
+		assert.Equal(t, map[string]int{"n1": 1, "n2": 3, "n3": 3}, calls)
+	})
+
+	t.Run("feed's notifiers", func(t *testing.T) {
+		reset()
+		m := msg
+		m.Feed = config.Feed{Notify: []string{"n1", "unknown"}}
+		failed := n.Send(context.Background(), m)
+		assert.Equal(t, 1, failed, "unknown notifier")
+		assert.Equal(t, map[string]int{"n1": 1}, calls)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		reset()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		m := msg
+		m.Feed = config.Feed{Notify: []string{"n1"}}
+		assert.Equal(t, 1, n.Send(ctx, m))
+	})
+}
+
+func TestNotifiers_Unknown(t *testing.T) {
+	n := NewNotifiers().Register("telegram", NotifierFunc(func(context.Context, Notification) error { return nil }))
+	res := n.Unknown(map[string]config.Feed{
+		"f1": {Notify: []string{"telegram", "mastodon"}},
+		"f2": {Notify: []string{"slack", "mastodon"}},
+		"f3": {},
+	})
+	assert.Equal(t, []string{"mastodon", "slack"}, res)
+}
+
+func TestNotifiers_targets(t *testing.T) {
+	noop := NotifierFunc(func(context.Context, Notification) error { return nil })
+	n := NewNotifiers().Register("telegram", &TelegramNotifier{}).Register("twitter", &TwitterNotifier{}).
+		Register("mastodon", &MastodonClient{}).Register("webhook", &WebhookClient{}).Register("custom", noop).
+		Register("digest", &Digests{Conf: &config.Conf{Digests: []config.Digest{{Name: "d1", Feeds: []string{"f2"}}}}})
+
+	assert.Equal(t, []string{"custom"}, n.targets("f1", config.Feed{}), "only notifiers without the check")
+	assert.Equal(t, []string{"custom", "digest", "telegram", "webhook"}, n.targets("f2", config.Feed{TelegramChannel: "chan1",
+		Webhooks: []config.Webhook{{URL: "https://example.com/hook"}}}))
+	assert.Equal(t, []string{"custom", "mastodon", "twitter"}, n.targets("f1", config.Feed{
+		Mastodon: config.Mastodon{Server: "https://mastodon.example.com", Token: "token"},
+		Twitter:  config.Twitter{ConsumerKey: "k", ConsumerSecret: "s", AccessToken: "t", AccessSecret: "as"}}))
+	assert.Equal(t, []string{"mastodon", "unknown"}, n.targets("f1", config.Feed{Notify: []string{"mastodon", "unknown"}}),
+		"listed notifiers used as is")
+
+	n.Register("twitter", &TwitterNotifier{Enabled: true})
+	assert.Equal(t, []string{"custom", "twitter"}, n.targets("f1", config.Feed{}), "global twitter credentials")
+}
+
+func TestTelegramNotifier_Send(t *testing.T) {
+	tg := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item, string) error { return nil }}
+	notif := &TelegramNotifier{Client: tg}
+
+	require.NoError(t, notif.Send(context.Background(), Notification{Item: feed.Item{Title: "title1"}}))
+	assert.Empty(t, tg.SendCalls(), "no channel, skipped")
+
+	require.NoError(t, notif.Send(context.Background(), Notification{Feed: config.Feed{TelegramChannel: "chan1"}, Item: feed.Item{Title: "title1"}}))
+	require.Len(t, tg.SendCalls(), 1)
+	assert.Equal(t, "chan1", tg.SendCalls()[0].ChanID)
+	assert.Empty(t, tg.SendCalls()[0].Tmpl)
+
+	tg.SendFunc = func(string, feed.Item, string) error { return errors.New("failed") }
+	err := notif.Send(context.Background(), Notification{Feed: config.Feed{TelegramChannel: "chan1"}})
+	require.EqualError(t, err, "telegram: chan1: failed")
+}
+
+func TestTelegramNotifier_SendChannels(t *testing.T) {
+	failed := map[string]bool{"chan2": true}
+	tg := &mocks.TelegramNotifMock{SendFunc: func(ch string, _ feed.Item, _ string) error {
+		if failed[ch] {
+			return errors.New("failed")
+		}
+		return nil
+	}}
+	notif := &TelegramNotifier{Client: tg}
+	n := Notification{FeedName: "feed1", Item: feed.Item{GUID: "guid1"},
+		Feed: config.Feed{TelegramChannel: "chan1", Telegram: config.Telegram{Channels: []string{"chan2", "chan3"}, Template: "{{.Title}}"}}}
+
+	err := notif.Send(context.Background(), n)
+	require.EqualError(t, err, "telegram: chan2: failed")
+	require.Len(t, tg.SendCalls(), 3)
+	assert.Equal(t, "{{.Title}}", tg.SendCalls()[2].Tmpl, "feed's template")
+
+	failed["chan2"] = false
+	require.NoError(t, notif.Send(context.Background(), n))
+	require.Len(t, tg.SendCalls(), 4, "channels got the item skipped")
+	assert.Equal(t, "chan2", tg.SendCalls()[3].ChanID)
+	assert.Empty(t, notif.sent)
+
+	require.NoError(t, notif.Send(context.Background(), n))
+	assert.Len(t, tg.SendCalls(), 7, "sent to all channels again")
+
+	failed["chan2"] = true
+	notif.MaxSent = 2
+	for _, guid := range []string{"guid2", "guid3"} {
+		n.Item.GUID = guid
+		require.Error(t, notif.Send(context.Background(), n))
+	}
+	assert.Len(t, notif.sent, 2, "old records dropped")
+	assert.Equal(t, []string{"feed1::guid3::chan1", "feed1::guid3::chan3"}, notif.sentOrder)
+}
+
+func TestTwitterNotifier_Send(t *testing.T) {
+	tw := &mocks.TwitterNotifMock{SendFunc: func(feed.Item) error { return nil }}
+	feedTw := &mocks.TwitterNotifMock{SendFunc: func(feed.Item) error { return nil }}
+	var made []config.Twitter
+	notif := &TwitterNotifier{Client: tw, FeedClient: func(c config.Twitter) TwitterNotif {
+		made = append(made, c)
+		return feedTw
+	}}
+
+	require.NoError(t, notif.Send(context.Background(), Notification{Item: feed.Item{Title: "title1"}}))
+	require.Len(t, tw.SendCalls(), 1)
+	assert.Equal(t, "title1", tw.SendCalls()[0].Item.Title)
+	assert.Empty(t, made, "default client")
+
+	n := Notification{Feed: config.Feed{Twitter: config.Twitter{AccessToken: "token", Template: "{{.Title}}"}}, Item: feed.Item{Title: "title2"}}
+	require.NoError(t, notif.Send(context.Background(), n))
+	require.NoError(t, notif.Send(context.Background(), n))
+	assert.Len(t, tw.SendCalls(), 1)
+	assert.Len(t, feedTw.SendCalls(), 2)
+	assert.Equal(t, []config.Twitter{{AccessToken: "token", Template: "{{.Title}}"}}, made, "client made once")
+
+	feedTw.SendFunc = func(feed.Item) error { return errors.New("failed") }
+	require.EqualError(t, notif.Send(context.Background(), n), "twitter: failed")
+}
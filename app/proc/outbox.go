@@ -0,0 +1,270 @@
+package proc
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // not for security, entry id only
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+var outboxBkt = []byte("outbox")
+
+// ErrOutboxEntryNotFound returned for unknown outbox entry id
+var ErrOutboxEntryNotFound = errors.New("outbox entry not found")
+
+// OutboxEntry is a pending notification of the new item for one notifier
+type OutboxEntry struct {
+	ID        string    `json:"id"`
+	FeedName  string    `json:"feed"`
+	Notifier  string    `json:"notifier"`
+	Item      feed.Item `json:"item"`
+	Attempts  int       `json:"attempts"`
+	NextTry   time.Time `json:"next_try"`
+	LastError string    `json:"last_error,omitempty"`
+	Created   time.Time `json:"created"`
+	Failed    bool      `json:"failed"` // not retried automatically anymore, waits for manual retry or discard
+}
+
+// Outbox keeps notifications of new items in bolt till they are sent, so they survive restarts and outages
+// of destinations. Each notifier of the item has its own entry, retried by the worker with exponential backoff.
+// Feeds are looked up in the config on send, notifier settings with tokens are not kept in the outbox.
+type Outbox struct {
+	DB          *bolt.DB
+	Conf        *config.Conf
+	Notifiers   *Notifiers
+	MaxAttempts int           // attempts before the entry marked as failed, 12 if not set
+	Delay       time.Duration // delay before the first retry, doubled for each next one, 30s if not set
+	MaxDelay    time.Duration // max delay between retries, 1h if not set
+	Interval    time.Duration // interval of checks for due entries, 10s if not set
+}
+
+// Add puts notifications of the item for all notifiers of the feed, the entries already in the outbox kept as is
+func (o *Outbox) Add(feedName string, item feed.Item) error {
+	fm := o.Conf.Feeds[feedName]
+	now := time.Now()
+	err := o.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(outboxBkt)
+		if err != nil {
+			return fmt.Errorf("create bucket %s: %w", outboxBkt, err)
+		}
+		for _, name := range o.Notifiers.targets(feedName, fm) {
+			entry := OutboxEntry{ID: outboxID(feedName, item.GUID, name), FeedName: feedName, Notifier: name, Item: item,
+				NextTry: now, Created: now}
+			if bucket.Get([]byte(entry.ID)) != nil {
+				continue
+			}
+			if err := putOutboxEntry(bucket, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("add %s of %s to outbox: %w", item.GUID, feedName, err)
+	}
+	return nil
+}
+
+// Run sends due entries of the outbox till the context canceled
+func (o *Outbox) Run(ctx context.Context) {
+	interval := o.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	log.Printf("[INFO] outbox worker started, interval %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		o.sendDue(ctx)
+		select {
+		case <-ctx.Done():
+			log.Printf("[INFO] outbox worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List returns all entries of the outbox, pending and failed, oldest first
+func (o *Outbox) List() ([]OutboxEntry, error) {
+	res := []OutboxEntry{}
+	err := o.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBkt)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var entry OutboxEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				log.Printf("[WARN] failed to unmarshal outbox entry %s, %v", string(k), err)
+				return nil
+			}
+			res = append(res, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list outbox: %w", err)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Created.Before(res[j].Created) })
+	return res, nil
+}
+
+// Retry makes the entry due right away, failed entry gets all automatic attempts again
+func (o *Outbox) Retry(id string) error {
+	return o.update(id, func(entry *OutboxEntry) {
+		entry.Failed, entry.Attempts, entry.NextTry = false, 0, time.Now()
+	})
+}
+
+// Discard removes the entry from the outbox, the notification is not sent
+func (o *Outbox) Discard(id string) error {
+	err := o.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBkt)
+		if bucket == nil || bucket.Get([]byte(id)) == nil {
+			return ErrOutboxEntryNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("discard %s: %w", id, err)
+	}
+	log.Printf("[INFO] outbox entry %s discarded", id)
+	return nil
+}
+
+// sendDue sends entries with the next try passed. Sent entries removed, failed ones scheduled for the next try
+// or marked as failed after the last attempt.
+func (o *Outbox) sendDue(ctx context.Context) {
+	entries, err := o.List()
+	if err != nil {
+		log.Printf("[WARN] failed to load outbox, %v", err)
+		return
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if entry.Failed || entry.NextTry.After(time.Now()) {
+			continue
+		}
+
+		fm, ok := o.Conf.Feeds[entry.FeedName]
+		if !ok {
+			log.Printf("[WARN] feed %s of outbox entry %s not found, discarded", entry.FeedName, entry.ID)
+			if err := o.Discard(entry.ID); err != nil {
+				log.Printf("[WARN] failed to discard outbox entry, %v", err)
+			}
+			continue
+		}
+
+		sendErr := o.send(ctx, fm, entry)
+		if sendErr == nil {
+			log.Printf("[INFO] sent %s notification from outbox: title=%q, feed=%s", entry.Notifier, entry.Item.Title, entry.FeedName)
+			if err := o.Discard(entry.ID); err != nil && !errors.Is(err, ErrOutboxEntryNotFound) {
+				log.Printf("[WARN] failed to remove sent outbox entry, %v", err)
+			}
+			continue
+		}
+
+		err := o.update(entry.ID, func(e *OutboxEntry) {
+			e.Attempts++
+			e.LastError = sendErr.Error()
+			e.NextTry = time.Now().Add(o.backoff(e.Attempts))
+			e.Failed = e.Attempts >= o.maxAttempts()
+			if e.Failed {
+				log.Printf("[WARN] %s notification failed after %d attempts, waits for manual retry: title=%q, feed=%s, %v",
+					e.Notifier, e.Attempts, e.Item.Title, e.FeedName, sendErr)
+				return
+			}
+			log.Printf("[WARN] failed attempt %d/%d to send %s notification, next try at %s: title=%q, feed=%s, %v",
+				e.Attempts, o.maxAttempts(), e.Notifier, e.NextTry.Format(time.RFC3339), e.Item.Title, e.FeedName, sendErr)
+		})
+		if err != nil && !errors.Is(err, ErrOutboxEntryNotFound) { // discarded while sending
+			log.Printf("[WARN] failed to update outbox entry, %v", err)
+		}
+	}
+}
+
+func (o *Outbox) send(ctx context.Context, fm config.Feed, entry OutboxEntry) error {
+	notifier, ok := o.Notifiers.notifiers[entry.Notifier]
+	if !ok {
+		return fmt.Errorf("unknown notifier %q", entry.Notifier)
+	}
+	if err := notifier.Send(ctx, Notification{FeedName: entry.FeedName, Feed: fm, Item: entry.Item}); err != nil {
+		return fmt.Errorf("%s send: %w", entry.Notifier, err)
+	}
+	return nil
+}
+
+func (o *Outbox) update(id string, fn func(entry *OutboxEntry)) error {
+	err := o.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBkt)
+		if bucket == nil {
+			return ErrOutboxEntryNotFound
+		}
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return ErrOutboxEntryNotFound
+		}
+		var entry OutboxEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("unmarshal entry: %w", err)
+		}
+		fn(&entry)
+		return putOutboxEntry(bucket, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("update outbox entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// backoff returns delay before the next try after the given number of attempts
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay, maxDelay := o.Delay, o.MaxDelay
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Hour
+	}
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func (o *Outbox) maxAttempts() int {
+	if o.MaxAttempts <= 0 {
+		return 12
+	}
+	return o.MaxAttempts
+}
+
+func putOutboxEntry(bucket *bolt.Bucket, entry OutboxEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal entry %s: %w", entry.ID, err)
+	}
+	if err = bucket.Put([]byte(entry.ID), data); err != nil {
+		return fmt.Errorf("put entry %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// outboxID makes id of the notification, the same for the same item, feed and notifier
+func outboxID(feedName, guid, notifier string) string {
+	h := sha1.Sum([]byte(feedName + "::" + guid + "::" + notifier)) //nolint:gosec // not for security
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,163 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestOutbox(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	var lock sync.Mutex
+	var sent []string
+	fail := map[string]bool{"n2": true}
+	notifier := func(name string) Notifier {
+		return NotifierFunc(func(_ context.Context, n Notification) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if fail[name] {
+				return errors.New("not available")
+			}
+			sent = append(sent, name+":"+n.FeedName+":"+n.Item.GUID+":"+n.Feed.TelegramChannel)
+			return nil
+		})
+	}
+	notifiers := NewNotifiers().Register("n1", notifier("n1")).Register("n2", notifier("n2")).
+		Register("mastodon", &MastodonClient{}) // not configured for feeds, not added
+	conf := &config.Conf{Feeds: map[string]config.Feed{"feed1": {TelegramChannel: "chan1"}, "feed2": {Notify: []string{"n1"}}}}
+	o := &Outbox{DB: db, Conf: conf, Notifiers: notifiers, MaxAttempts: 2, Delay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	entries, err := o.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, o.Add("feed1", feed.Item{GUID: "guid1", Title: "title1"}))
+	require.NoError(t, o.Add("feed1", feed.Item{GUID: "guid1", Title: "title1"}), "duplicate ignored")
+	require.NoError(t, o.Add("feed2", feed.Item{GUID: "guid2", Title: "title2"}))
+	entries, err = o.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "entry per notifier")
+	assert.Equal(t, outboxID("feed1", "guid1", "n1"), entries[0].ID)
+
+	o.sendDue(context.Background())
+	assert.Equal(t, []string{"n1:feed1:guid1:chan1", "n1:feed2:guid2:"}, sent, "feed's settings from the config")
+	entries, err = o.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "n2", entries[0].Notifier)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.False(t, entries[0].Failed)
+	assert.Equal(t, "n2 send: not available", entries[0].LastError)
+
+	time.Sleep(5 * time.Millisecond)
+	o.sendDue(context.Background())
+	entries, err = o.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.True(t, entries[0].Failed, "failed after max attempts")
+
+	time.Sleep(5 * time.Millisecond)
+	o.sendDue(context.Background())
+	entries, err = o.List()
+	require.NoError(t, err)
+	assert.Equal(t, 2, entries[0].Attempts, "failed entry not retried automatically")
+
+	fail["n2"] = false
+	require.NoError(t, o.Retry(entries[0].ID))
+	entries, err = o.List()
+	require.NoError(t, err)
+	assert.False(t, entries[0].Failed)
+	assert.Equal(t, 0, entries[0].Attempts)
+	o.sendDue(context.Background())
+	assert.Equal(t, "n2:feed1:guid1:chan1", sent[len(sent)-1])
+	entries, err = o.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.ErrorIs(t, o.Retry("bad"), ErrOutboxEntryNotFound)
+	require.ErrorIs(t, o.Discard("bad"), ErrOutboxEntryNotFound)
+}
+
+func TestOutbox_discard(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	conf := &config.Conf{Feeds: map[string]config.Feed{"feed1": {Notify: []string{"unknown"}}, "feed2": {}}}
+	o := &Outbox{DB: db, Conf: conf, Notifiers: NewNotifiers().Register("n1", NotifierFunc(func(context.Context, Notification) error {
+		return errors.New("failed")
+	})), MaxAttempts: 1}
+
+	require.NoError(t, o.Add("feed1", feed.Item{GUID: "guid1"}))
+	require.NoError(t, o.Add("feed2", feed.Item{GUID: "guid2"}))
+	delete(conf.Feeds, "feed2")
+	o.sendDue(context.Background())
+	entries, err := o.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "entry of removed feed discarded")
+	assert.True(t, entries[0].Failed)
+	assert.Equal(t, `unknown notifier "unknown"`, entries[0].LastError)
+
+	require.NoError(t, o.Discard(entries[0].ID))
+	entries, err = o.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestOutbox_backoff(t *testing.T) {
+	o := &Outbox{}
+	assert.Equal(t, 30*time.Second, o.backoff(1))
+	assert.Equal(t, time.Minute, o.backoff(2))
+	assert.Equal(t, 16*time.Minute, o.backoff(6))
+	assert.Equal(t, time.Hour, o.backoff(8))
+	assert.Equal(t, time.Hour, o.backoff(100))
+	assert.Equal(t, 12, o.maxAttempts())
+
+	o = &Outbox{Delay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, 4*time.Second, o.backoff(3))
+	assert.Equal(t, 5*time.Second, o.backoff(4))
+}
+
+func TestOutbox_Run(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	sent := make(chan string, 1)
+	conf := &config.Conf{Feeds: map[string]config.Feed{"feed1": {}}}
+	o := &Outbox{DB: db, Conf: conf, Interval: 10 * time.Millisecond,
+		Notifiers: NewNotifiers().Register("n1", NotifierFunc(func(_ context.Context, n Notification) error {
+			sent <- n.Item.GUID
+			return nil
+		}))}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		o.Run(ctx)
+		close(done)
+	}()
+	require.NoError(t, o.Add("feed1", feed.Item{GUID: "guid1"}))
+	select {
+	case guid := <-sent:
+		assert.Equal(t, "guid1", guid)
+	case <-time.After(time.Second):
+		t.Fatal("not sent")
+	}
+	cancel()
+	<-done
+}
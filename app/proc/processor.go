@@ -8,7 +8,6 @@ import (
 	"time"
 
 	log "github.com/go-pkgz/lgr"
-	"github.com/go-pkgz/repeater"
 	"github.com/go-pkgz/syncs"
 
 	"github.com/umputun/feed-master/app/config"
@@ -20,7 +19,7 @@ import (
 
 // TelegramNotif is interface to send messages to telegram
 type TelegramNotif interface {
-	Send(chanID string, item feed.Item) error
+	Send(chanID string, item feed.Item, tmpl string) error
 }
 
 // TwitterNotif is interface to send message to twitter
@@ -30,10 +29,10 @@ type TwitterNotif interface {
 
 // Processor is a feed reader and store writer
 type Processor struct {
-	Conf          *config.Conf
-	Store         *BoltDB
-	TelegramNotif TelegramNotif
-	TwitterNotif  TwitterNotif
+	Conf      *config.Conf
+	Store     *BoltDB
+	Notifiers *Notifiers
+	Outbox    *Outbox // notifications put to the outbox if set, sent by notifiers right away otherwise
 }
 
 // Do activate loop of goroutine for each feed, concurrency limited by p.Conf.Concurrent
@@ -56,7 +55,7 @@ func (p *Processor) processFeeds(ctx context.Context) {
 	for name, fm := range p.Conf.Feeds {
 		for _, src := range fm.Sources {
 			swg.Go(func(context.Context) {
-				p.processFeed(name, src.URL, fm.TelegramChannel, p.Conf.System.MaxItems, fm.Filter)
+				p.processFeed(ctx, name, src.URL, fm, p.Conf.System.MaxItems)
 			})
 		}
 	}
@@ -65,7 +64,7 @@ func (p *Processor) processFeeds(ctx context.Context) {
 	time.Sleep(p.Conf.System.UpdateInterval)
 }
 
-func (p *Processor) processFeed(name, url, telegramChannel string, maximum int, filter config.Filter) {
+func (p *Processor) processFeed(ctx context.Context, name, url string, fm config.Feed, maximum int) {
 	rss, err := feed.Parse(url)
 	if err != nil {
 		log.Printf("[WARN] failed to parse %s, %v", url, err)
@@ -81,7 +80,7 @@ func (p *Processor) processFeed(name, url, telegramChannel string, maximum int,
 			continue
 		}
 
-		skip, err := filter.Skip(item)
+		skip, err := fm.Filter.Skip(item)
 		if err != nil {
 			log.Printf("[WARN] failed to filter %s (%s) to %s, save as is, %v", item.GUID, item.PubDate, name, err)
 		}
@@ -101,33 +100,15 @@ func (p *Processor) processFeed(name, url, telegramChannel string, maximum int,
 			continue
 		}
 
-		rptr := repeater.NewDefault(3, 5*time.Second)
-		attemptNum := 0
-		err = rptr.Do(context.Background(), func() error {
-			attemptNum++
-			startTime := time.Now()
-			log.Printf("[DEBUG] sending telegram message (attempt %d/3): title=%q, size=%d bytes, url=%s to channel=%s",
-				attemptNum, item.Title, item.Enclosure.Length, item.Enclosure.URL, telegramChannel)
-
-			if e := p.TelegramNotif.Send(telegramChannel, item); e != nil {
-				elapsed := time.Since(startTime)
-				log.Printf("[WARN] failed attempt %d/3 to send telegram message after %v: title=%q, size=%d bytes, url=%s to channel=%s, error=%v",
-					attemptNum, elapsed, item.Title, item.Enclosure.Length, item.Enclosure.URL, telegramChannel, e)
-				return fmt.Errorf("telegram send: %w", e)
+		if p.Outbox != nil {
+			outboxErr := p.Outbox.Add(name, item)
+			if outboxErr == nil {
+				continue
 			}
-
-			elapsed := time.Since(startTime)
-			log.Printf("[INFO] successfully sent telegram message in %v: title=%q, size=%d bytes",
-				elapsed, item.Title, item.Enclosure.Length)
-			return nil
-		})
-		if err != nil {
-			log.Printf("[WARN] failed to send telegram message after 3 attempts: title=%q, size=%d bytes, url=%s to channel=%s, final_error=%v",
-				item.Title, item.Enclosure.Length, item.Enclosure.URL, telegramChannel, err)
+			log.Printf("[WARN] failed to put %s to outbox, send right away, %v", item.GUID, outboxErr)
 		}
-
-		if err := p.TwitterNotif.Send(item); err != nil {
-			log.Printf("[WARN] failed send twitter message, url=%s, %v", item.Enclosure.URL, err)
+		if p.Notifiers != nil {
+			p.Notifiers.Send(ctx, Notification{FeedName: name, Feed: fm, Item: item})
 		}
 	}
 
@@ -18,12 +18,11 @@ import (
 	"github.com/umputun/feed-master/app/config"
 	"github.com/umputun/feed-master/app/feed"
 	"github.com/umputun/feed-master/app/proc/mocks"
-	"github.com/umputun/feed-master/app/youtube"
 )
 
 func TestProcessor_DoRemoveOldItems(t *testing.T) {
 	lgr.Setup(lgr.Debug)
-	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item) error {
+	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item, string) error {
 		return nil
 	}}
 
@@ -82,28 +81,10 @@ func TestProcessor_DoRemoveOldItems(t *testing.T) {
 				Concurrent:          1,
 				BaseURL:             "baseUrl",
 			},
-			YouTube: struct {
-				DlTemplate      string             `yaml:"dl_template"`
-				BaseChanURL     string             `yaml:"base_chan_url"`
-				BasePlaylistURL string             `yaml:"base_playlist_url"`
-				Channels        []youtube.FeedInfo `yaml:"channels"`
-				BaseURL         string             `yaml:"base_url"`
-				UpdateInterval  time.Duration      `yaml:"update"`
-				MaxItems        int                `yaml:"max_per_channel"`
-				FilesLocation   string             `yaml:"files_location"`
-				RSSLocation     string             `yaml:"rss_location"`
-				SkipShorts      time.Duration      `yaml:"skip_shorts"`
-				DisableUpdates  bool               `yaml:"disable_updates"`
-				YtDlpUpdate     struct {
-					Interval       time.Duration `yaml:"interval"`
-					Command        string        `yaml:"command"`
-					ForceOnStartup bool          `yaml:"force_on_startup"`
-				} `yaml:"ytdlp_update"`
-			}{},
 		},
-		Store:         boltStore,
-		TelegramNotif: tgNotif,
-		TwitterNotif:  twitterNotif,
+		Store: boltStore,
+		Notifiers: NewNotifiers().Register("telegram", &TelegramNotifier{Client: tgNotif}).
+			Register("twitter", &TwitterNotifier{Client: twitterNotif, Enabled: true}),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*900)
@@ -162,7 +143,7 @@ func TestProcessor_DoRemoveOldItems(t *testing.T) {
 }
 
 func TestProcessor_DoLoadMaxItems(t *testing.T) {
-	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item) error {
+	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item, string) error {
 		return nil
 	}}
 
@@ -221,28 +202,10 @@ func TestProcessor_DoLoadMaxItems(t *testing.T) {
 				Concurrent:          1,
 				BaseURL:             "baseUrl",
 			},
-			YouTube: struct {
-				DlTemplate      string             `yaml:"dl_template"`
-				BaseChanURL     string             `yaml:"base_chan_url"`
-				BasePlaylistURL string             `yaml:"base_playlist_url"`
-				Channels        []youtube.FeedInfo `yaml:"channels"`
-				BaseURL         string             `yaml:"base_url"`
-				UpdateInterval  time.Duration      `yaml:"update"`
-				MaxItems        int                `yaml:"max_per_channel"`
-				FilesLocation   string             `yaml:"files_location"`
-				RSSLocation     string             `yaml:"rss_location"`
-				SkipShorts      time.Duration      `yaml:"skip_shorts"`
-				DisableUpdates  bool               `yaml:"disable_updates"`
-				YtDlpUpdate     struct {
-					Interval       time.Duration `yaml:"interval"`
-					Command        string        `yaml:"command"`
-					ForceOnStartup bool          `yaml:"force_on_startup"`
-				} `yaml:"ytdlp_update"`
-			}{},
 		},
-		Store:         boltStore,
-		TelegramNotif: tgNotif,
-		TwitterNotif:  twitterNotif,
+		Store: boltStore,
+		Notifiers: NewNotifiers().Register("telegram", &TelegramNotifier{Client: tgNotif}).
+			Register("twitter", &TwitterNotifier{Client: twitterNotif, Enabled: true}),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*900)
@@ -264,7 +227,7 @@ func TestProcessor_DoLoadMaxItems(t *testing.T) {
 }
 
 func TestProcessor_DoSkipItems(t *testing.T) {
-	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item) error {
+	tgNotif := &mocks.TelegramNotifMock{SendFunc: func(string, feed.Item, string) error {
 		return nil
 	}}
 
@@ -325,28 +288,10 @@ func TestProcessor_DoSkipItems(t *testing.T) {
 				Concurrent:          1,
 				BaseURL:             "baseUrl",
 			},
-			YouTube: struct {
-				DlTemplate      string             `yaml:"dl_template"`
-				BaseChanURL     string             `yaml:"base_chan_url"`
-				BasePlaylistURL string             `yaml:"base_playlist_url"`
-				Channels        []youtube.FeedInfo `yaml:"channels"`
-				BaseURL         string             `yaml:"base_url"`
-				UpdateInterval  time.Duration      `yaml:"update"`
-				MaxItems        int                `yaml:"max_per_channel"`
-				FilesLocation   string             `yaml:"files_location"`
-				RSSLocation     string             `yaml:"rss_location"`
-				SkipShorts      time.Duration      `yaml:"skip_shorts"`
-				DisableUpdates  bool               `yaml:"disable_updates"`
-				YtDlpUpdate     struct {
-					Interval       time.Duration `yaml:"interval"`
-					Command        string        `yaml:"command"`
-					ForceOnStartup bool          `yaml:"force_on_startup"`
-				} `yaml:"ytdlp_update"`
-			}{},
 		},
-		Store:         boltStore,
-		TelegramNotif: tgNotif,
-		TwitterNotif:  twitterNotif,
+		Store: boltStore,
+		Notifiers: NewNotifiers().Register("telegram", &TelegramNotifier{Client: tgNotif}).
+			Register("twitter", &TwitterNotifier{Client: twitterNotif, Enabled: true}),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*900)
@@ -371,3 +316,42 @@ func TestProcessor_DoSkipItems(t *testing.T) {
 	assert.Equal(t, "Радио-Т 798", twitterNotif.SendCalls()[0].Item.Title)
 	assert.Equal(t, "Радио-Т 797", twitterNotif.SendCalls()[1].Item.Title)
 }
+
+func TestProcessor_processFeedOutbox(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	testFeed, err := os.ReadFile("./testdata/rss1.xml")
+	require.NoError(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, e := w.Write(testFeed)
+		assert.NoError(t, e)
+	}))
+	defer ts.Close()
+
+	sent := 0
+	notifiers := NewNotifiers().Register("n1", NotifierFunc(func(context.Context, Notification) error { sent++; return nil }))
+	fm := config.Feed{Title: "title1", Notify: []string{"n1"}}
+	conf := &config.Conf{Feeds: map[string]config.Feed{"feed1": fm}}
+	conf.System.MaxKeepInDB = 10
+	p := Processor{Conf: conf, Store: &BoltDB{DB: db}, Notifiers: notifiers, Outbox: &Outbox{DB: db, Conf: conf, Notifiers: notifiers}}
+
+	p.processFeed(context.Background(), "feed1", ts.URL, fm, 10)
+	assert.Equal(t, 0, sent, "not sent right away")
+	entries, err := p.Outbox.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "all new items in the outbox")
+	assert.Equal(t, "n1", entries[0].Notifier)
+
+	p.processFeed(context.Background(), "feed1", ts.URL, fm, 10)
+	entries, err = p.Outbox.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "saved items not added again")
+
+	p.Outbox.sendDue(context.Background())
+	assert.Equal(t, 3, sent)
+	entries, err = p.Outbox.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries, "sent entries removed")
+}
@@ -0,0 +1,73 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+const slackMaxChars = 2900 // limit of section text is 3000, some reserved for the title
+
+// slackEscaper escapes control characters of slack mrkdwn
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackMarkup converts description to slack mrkdwn, links as <url|text>
+var slackMarkup = markup{
+	text: slackEscaper.Replace,
+	link: func(text, href string) string {
+		if text == "" {
+			return "<" + href + ">"
+		}
+		return "<" + href + "|" + strings.ReplaceAll(slackEscaper.Replace(text), "|", "¦") + ">"
+	},
+}
+
+// SlackClient announces new items with slack incoming webhooks of feeds, feeds without the webhook skipped
+type SlackClient struct {
+	Client *http.Client
+}
+
+// Configured checks if the feed has slack webhook
+func (s *SlackClient) Configured(_ string, fm config.Feed) bool {
+	return fm.Slack.Webhook != ""
+}
+
+// Send posts the item as block kit message to the feed's slack webhook
+func (s *SlackClient) Send(ctx context.Context, n Notification) error {
+	conf := n.Feed.Slack
+	if !s.Configured(n.FeedName, n.Feed) {
+		return nil
+	}
+
+	title := "*" + slackEscaper.Replace(n.Item.Title) + "*"
+	if link := itemLink(n.Item); link != "" {
+		title = "*" + slackMarkup.link(n.Item.Title, link) + "*"
+	}
+	section := map[string]any{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": title + "\n\n" + slackMarkup.description(conf.Template, n.Item, slackMaxChars)},
+	}
+	if image := itemImage(n); image != "" {
+		section["accessory"] = map[string]string{"type": "image", "image_url": image, "alt_text": n.Item.Title}
+	}
+	blocks := []any{section}
+	if dur := itemDuration(n.Item); dur != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []map[string]string{{"type": "mrkdwn", "text": "Duration: " + dur}},
+		})
+	}
+	// text is the fallback for notifications and clients without blocks support
+	msg := map[string]any{"text": slackEscaper.Replace(FormatItem("{{.Title}} - {{.Link}}", n.Item, slackMaxChars)), "blocks": blocks}
+
+	if err := sendJSON(ctx, s.Client, http.MethodPost, conf.Webhook, "", msg); err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+	log.Printf("[INFO] published to slack, %s", n.Item.Title)
+	return nil
+}
@@ -0,0 +1,76 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestSlackClient_Send(t *testing.T) {
+	var msgs []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/T1/B1/token1" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("invalid_token"))
+			return
+		}
+		var msg map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	s := &SlackClient{Client: ts.Client()}
+	item := feed.Item{Title: "Радио-Т <798>", Link: "https://radio-t.com/p/798", Duration: "3600",
+		Description: `<p>Темы: <a href="https://example.com/1">первая</a></p>`}
+
+	t.Run("no webhook, skipped", func(t *testing.T) {
+		require.NoError(t, s.Send(context.Background(), Notification{Item: item}))
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("blocks", func(t *testing.T) {
+		conf := config.Slack{Webhook: ts.URL + "/services/T1/B1/token1"}
+		require.NoError(t, s.Send(context.Background(), Notification{Feed: config.Feed{Slack: conf, Image: "https://radio-t.com/logo.png"},
+			Item: item}))
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "Радио-Т &lt;798&gt; - https://radio-t.com/p/798", msgs[0]["text"], "fallback text")
+		assert.Equal(t, []any{
+			map[string]any{
+				"type": "section",
+				"text": map[string]any{"type": "mrkdwn",
+					"text": "*<https://radio-t.com/p/798|Радио-Т &lt;798&gt;>*\n\nТемы: <https://example.com/1|первая>"},
+				"accessory": map[string]any{"type": "image", "image_url": "https://radio-t.com/logo.png", "alt_text": "Радио-Т <798>"},
+			},
+			map[string]any{
+				"type":     "context",
+				"elements": []any{map[string]any{"type": "mrkdwn", "text": "Duration: 1h0m0s"}},
+			},
+		}, msgs[0]["blocks"])
+	})
+
+	t.Run("template", func(t *testing.T) {
+		msgs = nil
+		conf := config.Slack{Webhook: ts.URL + "/services/T1/B1/token1", Template: "new episode"}
+		it := feed.Item{Title: "Радио-Т 799", Link: "https://radio-t.com/p/799"}
+		require.NoError(t, s.Send(context.Background(), Notification{Feed: config.Feed{Slack: conf}, Item: it}))
+		require.Len(t, msgs, 1)
+		assert.Equal(t, []any{map[string]any{"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": "*<https://radio-t.com/p/799|Радио-Т 799>*\n\nnew episode"}}}, msgs[0]["blocks"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		conf := config.Slack{Webhook: ts.URL + "/services/T1/B1/bad"}
+		err := s.Send(context.Background(), Notification{Feed: config.Feed{Slack: conf}, Item: item})
+		require.EqualError(t, err, "failed to post to slack: unexpected status 403 for /services/T1/B1/bad: invalid_token")
+	})
+}
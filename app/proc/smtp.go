@@ -0,0 +1,171 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+// Mail is an email message with html and plain text versions of the body
+type Mail struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// SMTPSender sends emails with smtp server. Uses STARTTLS if the server supports it, implicit tls if set in config.
+type SMTPSender struct {
+	Conf config.SMTP
+}
+
+// Send sends the mail to all recipients in one message
+func (s SMTPSender) Send(ctx context.Context, m Mail) error {
+	if s.Conf.Host == "" {
+		return errors.New("smtp host not set")
+	}
+	if len(m.To) == 0 {
+		return errors.New("no recipients")
+	}
+	from, err := mail.ParseAddress(s.Conf.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", s.Conf.From, err)
+	}
+	msg, err := s.message(from, m)
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Conf.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !s.Conf.TLS {
+		if err = client.StartTLS(&tls.Config{ServerName: s.Conf.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Conf.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", s.Conf.Username, s.Conf.Password, s.Conf.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err = client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail from %s: %w", from.Address, err)
+	}
+	for _, to := range m.To {
+		if err = client.Rcpt(to); err != nil {
+			return fmt.Errorf("rcpt to %s: %w", to, err)
+		}
+	}
+	wr, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err = wr.Write(msg); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err = wr.Close(); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	if err = client.Quit(); err != nil {
+		return fmt.Errorf("quit: %w", err)
+	}
+	return nil
+}
+
+// client connects to the server, with implicit tls if set. The connection closed when the context is done.
+func (s SMTPSender) client(ctx context.Context) (*smtp.Client, error) {
+	port := s.Conf.Port
+	if port == 0 {
+		port = 25
+	}
+	addr := net.JoinHostPort(s.Conf.Host, strconv.Itoa(port))
+	var conn net.Conn
+	var err error
+	if s.Conf.TLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.Conf.Host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, s.Conf.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("smtp client for %s: %w", addr, err)
+	}
+	return client, nil
+}
+
+// message makes multipart/alternative message with plain text and html parts
+func (s SMTPSender) message(from *mail.Address, m Mail) ([]byte, error) {
+	body := bytes.Buffer{}
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, text string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create %s part: %w", part.contentType, err)
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(part.text)); err != nil {
+			return nil, fmt.Errorf("write %s part: %w", part.contentType, err)
+		}
+		if err = qw.Close(); err != nil {
+			return nil, fmt.Errorf("close %s part: %w", part.contentType, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close message: %w", err)
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+	hdr := bytes.Buffer{}
+	for _, h := range [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(m.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	} {
+		hdr.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	hdr.WriteString("\r\n")
+	return append(hdr.Bytes(), body.Bytes()...), nil
+}
@@ -0,0 +1,179 @@
+package proc
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+)
+
+func TestSMTPSender_Send(t *testing.T) {
+	srv := newFakeSMTP(t)
+	host, port, err := net.SplitHostPort(srv.addr)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	s := SMTPSender{Conf: config.SMTP{Host: host, Port: portNum, Username: "user", Password: "pass",
+		From: "Feed Master <feeds@example.com>", Timeout: time.Second}}
+	err = s.Send(context.Background(), Mail{To: []string{"u1@example.com", "u2@example.com"}, Subject: "новое: 2 items",
+		HTML: "<p>hello</p>", Text: "hello"})
+	require.NoError(t, err)
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	assert.Equal(t, "\x00user\x00pass", srv.auth)
+	assert.Equal(t, "<feeds@example.com>", srv.from)
+	assert.Equal(t, []string{"<u1@example.com>", "<u2@example.com>"}, srv.rcpt)
+
+	msg, err := mail.ReadMessage(strings.NewReader(srv.data))
+	require.NoError(t, err)
+	assert.Equal(t, `"Feed Master" <feeds@example.com>`, msg.Header.Get("From"))
+	assert.Equal(t, "u1@example.com, u2@example.com", msg.Header.Get("To"))
+	dec, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "новое: 2 items", dec)
+	assert.Contains(t, msg.Header.Get("Message-ID"), "@example.com>")
+
+	contentType := msg.Header.Get("Content-Type")
+	require.True(t, strings.HasPrefix(contentType, "multipart/alternative; boundary="))
+	mr := multipart.NewReader(msg.Body, strings.TrimPrefix(contentType, "multipart/alternative; boundary="))
+	var parts []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(p) // quoted-printable decoded by the reader
+		require.NoError(t, err)
+		parts = append(parts, p.Header.Get("Content-Type")+"|"+string(body))
+	}
+	assert.Equal(t, []string{"text/plain; charset=UTF-8|hello", "text/html; charset=UTF-8|<p>hello</p>"}, parts)
+}
+
+func TestSMTPSender_SendErrors(t *testing.T) {
+	srv := newFakeSMTP(t)
+	host, port, err := net.SplitHostPort(srv.addr)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	tbl := []struct {
+		conf config.SMTP
+		to   []string
+		err  string
+	}{
+		{conf: config.SMTP{From: "feeds@example.com"}, to: []string{"u1@example.com"}, err: "smtp host not set"},
+		{conf: config.SMTP{Host: host, Port: portNum, From: "feeds@example.com"}, err: "no recipients"},
+		{conf: config.SMTP{Host: host, Port: portNum, From: "bad"}, to: []string{"u1@example.com"}, err: `invalid from address "bad"`},
+		{conf: config.SMTP{Host: host, Port: portNum, From: "feeds@example.com"}, to: []string{"rejected@example.com"},
+			err: "rcpt to rejected@example.com: 550"},
+		{conf: config.SMTP{Host: "127.0.0.1", Port: 1, From: "feeds@example.com", Timeout: time.Second},
+			to: []string{"u1@example.com"}, err: "connect to 127.0.0.1:1"},
+	}
+	for i, tt := range tbl {
+		err := SMTPSender{Conf: tt.conf}.Send(context.Background(), Mail{To: tt.to, Subject: "subj", Text: "text"})
+		assert.ErrorContains(t, err, tt.err, "case %d", i)
+	}
+}
+
+// fakeSMTP is a minimal smtp server accepting one message per connection
+type fakeSMTP struct {
+	addr string
+	lock sync.Mutex
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	srv := &fakeSMTP{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	reply := func(msg string) { _, _ = io.WriteString(conn, msg+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.lock.Lock()
+		switch {
+		case cmd == "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case cmd == "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			s.auth = string(creds)
+			reply("235 authenticated")
+		case cmd == "MAIL":
+			s.from = strings.TrimPrefix(line, "MAIL FROM:")
+			if i := strings.Index(s.from, " "); i > 0 {
+				s.from = s.from[:i]
+			}
+			reply("250 ok")
+		case cmd == "RCPT" && strings.Contains(line, "rejected@"):
+			reply("550 no such user")
+		case cmd == "RCPT":
+			s.rcpt = append(s.rcpt, strings.TrimPrefix(line, "RCPT TO:"))
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			data := strings.Builder{}
+			for {
+				l, err := rd.ReadString('\n')
+				if err != nil {
+					s.lock.Unlock()
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			s.lock.Unlock()
+			return
+		default:
+			reply("250 ok")
+		}
+		s.lock.Unlock()
+	}
+}
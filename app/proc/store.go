@@ -4,6 +4,8 @@ import (
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/go-pkgz/lgr"
@@ -99,7 +101,7 @@ func (b BoltDB) Load(fmFeed string, maximum int, skipJunk bool) ([]feed.Item, er
 	return result, nil
 }
 
-// Remove deletes item matched by GUID from given feed
+// Remove deletes item matched by GUID from given feed, with all parts if the item is a split youtube video
 func (b BoltDB) Remove(fmFeed, guid string) error {
 	err := b.DB.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(fmFeed))
@@ -107,7 +109,8 @@ func (b BoltDB) Remove(fmFeed, guid string) error {
 			return fmt.Errorf("no bucket for %s", fmFeed)
 		}
 
-		// find the item by GUID
+		// find the item by GUID, parts of the split video matched by the video's GUID
+		var toDelete [][]byte
 		c := bucket.Cursor()
 		for k, v := c.First(); k != nil; k, v = c.Next() {
 			item := feed.Item{}
@@ -115,13 +118,21 @@ func (b BoltDB) Remove(fmFeed, guid string) error {
 				log.Printf("[WARN] failed to unmarshal during remove, %v", err)
 				continue
 			}
-			if item.GUID == guid {
-				log.Printf("[INFO] remove %s from %s", guid, fmFeed)
-				return bucket.Delete(k)
+			if item.GUID == guid || splitGroup(item.GUID) == guid {
+				toDelete = append(toDelete, append([]byte(nil), k...))
 			}
 		}
+		if len(toDelete) == 0 {
+			return fmt.Errorf("item %s not found in %s", guid, fmFeed)
+		}
 
-		return fmt.Errorf("item %s not found in %s", guid, fmFeed)
+		log.Printf("[INFO] remove %s from %s, %d item(s)", guid, fmFeed, len(toDelete))
+		for _, k := range toDelete {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("delete %s: %w", guid, err)
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("update db: %w", err)
@@ -139,10 +150,22 @@ func (b BoltDB) removeOld(fmFeed string, keep int) (int, error) {
 			return fmt.Errorf("no bucket for %s", fmFeed)
 		}
 
+		// parts of the split video counted as a single item, the video kept or removed as a whole
 		recs := 0
+		seen := map[string]bool{}
 		c := bucket.Cursor()
-		for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
-			recs++
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			item := feed.Item{}
+			if err := json.Unmarshal(v, &item); err != nil {
+				log.Printf("[WARN] failed to unmarshal during remove old, %v", err)
+			}
+			group := splitGroup(item.GUID)
+			if group == "" || !seen[group] {
+				recs++
+			}
+			if group != "" {
+				seen[group] = true
+			}
 			if recs > keep {
 				keyCopy := make([]byte, len(k))
 				copy(keyCopy, k)
@@ -165,3 +188,16 @@ func (b BoltDB) removeOld(fmFeed string, keep int) (int, error) {
 	}
 	return deleted, nil
 }
+
+// splitGroup returns GUID of the whole video for parts of the split youtube video with "channelID::videoID::N" GUID,
+// empty string for other items
+func splitGroup(guid string) string {
+	elems := strings.Split(guid, "::")
+	if len(elems) != 3 || elems[0] == "" || elems[1] == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(elems[2]); err != nil {
+		return ""
+	}
+	return elems[0] + "::" + elems[1]
+}
@@ -295,4 +295,58 @@ func TestStore_Remove(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "no bucket for non-existent-feed")
 	})
+
+	t.Run("split video removed with all parts", func(t *testing.T) {
+		db, err := bolt.Open(t.TempDir()+"/test.db", 0o600, &bolt.Options{Timeout: 1 * time.Second})
+		require.NoError(t, err)
+		defer db.Close()
+		bdb := &BoltDB{DB: db}
+
+		for _, guid := range []string{"ch1::vid1::1", "ch1::vid1::2", "ch1::vid10", "ch1::vid2"} {
+			_, err = bdb.Save("test-feed", feed.Item{PubDate: pubDate, GUID: guid})
+			require.NoError(t, err)
+		}
+		require.NoError(t, bdb.Remove("test-feed", "ch1::vid1"))
+		items, err := bdb.Load("test-feed", 10, false)
+		require.NoError(t, err)
+		guids := []string{}
+		for _, item := range items {
+			guids = append(guids, item.GUID)
+		}
+		assert.ElementsMatch(t, []string{"ch1::vid10", "ch1::vid2"}, guids)
+	})
+}
+
+func TestRemoveOldSplitParts(t *testing.T) {
+	db, err := bolt.Open(t.TempDir()+"/test.db", 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+	bdb := &BoltDB{DB: db}
+
+	baseTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	guids := []string{"ch1::old", "ch1::vid1::1", "ch1::vid1::2", "ch1::vid1::3", "ch1::new"}
+	for i, guid := range guids {
+		item := feed.Item{PubDate: baseTime.Add(time.Duration(i) * time.Minute).Format(time.RFC1123Z), GUID: guid}
+		_, err = bdb.Save("test-feed", item)
+		require.NoError(t, err)
+	}
+
+	count, err := bdb.removeOld("test-feed", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "split video counted as one item")
+	items, err := bdb.Load("test-feed", 10, false)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, "ch1::vid1::1", items[3].GUID)
+
+	count, err = bdb.removeOld("test-feed", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "all parts removed")
+}
+
+func TestSplitGroup(t *testing.T) {
+	assert.Equal(t, "ch1::vid1", splitGroup("ch1::vid1::2"))
+	assert.Empty(t, splitGroup("ch1::vid1"))
+	assert.Empty(t, splitGroup("ch1::vid1::x"))
+	assert.Empty(t, splitGroup("https://example.com/1"))
 }
@@ -1,12 +1,15 @@
 package proc
 
 import (
+	"bytes"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	log "github.com/go-pkgz/lgr"
@@ -70,15 +73,15 @@ func NewTelegramClient(token, apiURL string, timeout time.Duration, ds DurationS
 	return &result, nil
 }
 
-// Send message, skip if telegram token empty
-func (client TelegramClient) Send(channelID string, item feed.Item) (err error) {
+// Send message formatted with the template, built-in format used if the template is empty. Skip if telegram token empty
+func (client TelegramClient) Send(channelID string, item feed.Item, tmpl string) (err error) {
 	if client.Bot == nil || channelID == "" {
 		return nil
 	}
 
-	message, err := client.sendAudio(channelID, item)
+	message, err := client.sendAudio(channelID, item, tmpl)
 	if err != nil && strings.Contains(err.Error(), "Request Entity Too Large") {
-		message, err = client.sendText(channelID, item)
+		message, err = client.sendText(channelID, item, tmpl)
 	}
 
 	if err != nil {
@@ -89,10 +92,10 @@ func (client TelegramClient) Send(channelID string, item feed.Item) (err error)
 	return nil
 }
 
-func (client TelegramClient) sendText(channelID string, item feed.Item) (*tb.Message, error) {
+func (client TelegramClient) sendText(channelID string, item feed.Item, tmpl string) (*tb.Message, error) {
 	message, err := client.Bot.Send(
 		recipient{chatID: channelID},
-		client.getMessageHTML(item, htmlMessageParams{WithMp3Link: true}),
+		client.getMessageHTML(item, htmlMessageParams{WithMp3Link: true, Template: tmpl}),
 		tb.ModeHTML,
 		tb.NoPreview,
 	)
@@ -102,7 +105,7 @@ func (client TelegramClient) sendText(channelID string, item feed.Item) (*tb.Mes
 	return message, nil
 }
 
-func (client TelegramClient) sendAudio(channelID string, item feed.Item) (*tb.Message, error) {
+func (client TelegramClient) sendAudio(channelID string, item feed.Item, tmpl string) (*tb.Message, error) {
 	downloadStart := time.Now()
 	log.Printf("[DEBUG] starting audio download: size=%d bytes, timeout=%v, url=%s",
 		item.Enclosure.Length, client.Timeout, item.Enclosure.URL)
@@ -148,7 +151,7 @@ func (client TelegramClient) sendAudio(channelID string, item feed.Item) (*tb.Me
 		File:      tb.FromDisk(tmpFile.Name()),
 		FileName:  item.GetFilename(),
 		MIME:      "audio/mpeg",
-		Caption:   client.getMessageHTML(item, htmlMessageParams{TrimCaption: true}),
+		Caption:   client.getMessageHTML(item, htmlMessageParams{TrimCaption: true, Template: tmpl}),
 		Title:     item.Title,
 		Performer: item.Author,
 		Duration:  dur,
@@ -168,7 +171,10 @@ func (client TelegramClient) tagLinkOnlySupport(htmlText string) string {
 	return html.UnescapeString(p.Sanitize(htmlText))
 }
 
-type htmlMessageParams struct{ WithMp3Link, TrimCaption bool }
+type htmlMessageParams struct {
+	WithMp3Link, TrimCaption bool
+	Template                 string // replaces title and description, built-in format if empty
+}
 
 // getMessageHTML generates HTML message from provided feed.Item
 func (client TelegramClient) getMessageHTML(item feed.Item, params htmlMessageParams) string {
@@ -191,6 +197,15 @@ func (client TelegramClient) getMessageHTML(item feed.Item, params htmlMessagePa
 	description = client.tagLinkOnlySupport(html.UnescapeString(description))
 	description = strings.TrimSpace(description)
 
+	if params.Template != "" {
+		body, err := client.formatMessage(params.Template, item, description)
+		if err == nil {
+			header, description = "", body
+		} else {
+			log.Printf("[WARN] failed to format %s with telegram template, built-in format used, %v", item.GUID, err)
+		}
+	}
+
 	// https://limits.tginfo.me/en 1024 symbol limit for caption
 	if params.TrimCaption && len(header+description+footer) > 1024 {
 		description = CropText(description, 1024-len(header+footer))
@@ -199,6 +214,26 @@ func (client TelegramClient) getMessageHTML(item feed.Item, params htmlMessagePa
 	return header + description + footer
 }
 
+// formatMessage makes message with the template, description of the item replaced by the sanitized one,
+// other text fields escaped
+func (client TelegramClient) formatMessage(tmpl string, item feed.Item, description string) (string, error) {
+	t, err := template.New("telegram").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+	// text fields escaped, the message sent as html
+	for _, v := range []*string{&item.Title, &item.Link, &item.GUID, &item.PubDate, &item.Comments, &item.Author,
+		&item.Duration, &item.DurationFmt, &item.Enclosure.URL} {
+		*v = html.EscapeString(*v)
+	}
+	item.Description = htmltemplate.HTML(description) //nolint:gosec // sanitized, only links left
+	b := bytes.Buffer{}
+	if err = t.Execute(&b, item); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return strings.ReplaceAll(strings.TrimSpace(b.String()), `\n`, "\n"), nil // \n in template
+}
+
 type recipient struct {
 	chatID string
 }
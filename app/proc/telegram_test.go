@@ -48,7 +48,7 @@ func TestNewTelegramClientCheckTimeout(t *testing.T) {
 func TestSendIfBotIsNil(t *testing.T) {
 	client, err := NewTelegramClient("", "", 0, &duration.Service{}, &TelegramSenderImpl{})
 	require.NoError(t, err)
-	err = client.Send("@channel", feed.Item{})
+	err = client.Send("@channel", feed.Item{}, "")
 	assert.NoError(t, err)
 }
 
@@ -57,7 +57,7 @@ func TestSendIfChannelIDEmpty(t *testing.T) {
 		Bot: &tb.Bot{},
 	}
 
-	err := client.Send("", feed.Item{})
+	err := client.Send("", feed.Item{}, "")
 	assert.NoError(t, err)
 }
 
@@ -166,6 +166,31 @@ func TestGetMessageHTML(t *testing.T) {
 	assert.Equal(t, expected, msg)
 }
 
+func TestGetMessageHTMLWithTemplate(t *testing.T) {
+	item := feed.Item{
+		Title:       "Podcast",
+		Description: "<p>News <a href='/test'>Podcast Link</a></p>\n",
+		Enclosure:   feed.Enclosure{URL: "https://example.com"},
+		Link:        "https://example.com/xyz",
+	}
+	client := TelegramClient{}
+
+	msg := client.getMessageHTML(item, htmlMessageParams{WithMp3Link: true,
+		Template: `<b>{{.Title}}</b>\n{{.Description}}\n<a href="{{.Link}}">listen</a>`})
+	assert.Equal(t, "<b>Podcast</b>\nNews <a href=\"/test\">Podcast Link</a>\n<a href=\"https://example.com/xyz\">listen</a>"+
+		"\n\nhttps://example.com", msg)
+
+	item.Title, item.Link = "Q&A <live>", "https://example.com/xyz?a=1&b=2"
+	msg = client.getMessageHTML(item, htmlMessageParams{Template: `<b>{{.Title}}</b>\n<a href="{{.Link}}">listen</a>`})
+	assert.Equal(t, "<b>Q&amp;A &lt;live&gt;</b>\n<a href=\"https://example.com/xyz?a=1&amp;b=2\">listen</a>", msg,
+		"text fields escaped")
+
+	item.Title, item.Link = "Podcast", "https://example.com/xyz"
+	msg = client.getMessageHTML(item, htmlMessageParams{Template: "{{.Bad"})
+	assert.Equal(t, "<a href=\"https://example.com/xyz\">Podcast</a>\n\nNews <a href=\"/test\">Podcast Link</a>", msg,
+		"built-in format for broken template")
+}
+
 func TestRecipientChannelIDNotStartWithAt(t *testing.T) {
 	testData := []struct {
 		channel  string
@@ -209,18 +234,19 @@ func TestTelegramClient_sendAudio(t *testing.T) {
 	}
 
 	client := TelegramClient{DurationService: dur, TelegramSender: snd}
-	_, err := client.sendAudio("chan1", feed.Item{Duration: "5678", Enclosure: feed.Enclosure{URL: ts.URL}})
+	_, err := client.sendAudio("chan1", feed.Item{Duration: "5678", Enclosure: feed.Enclosure{URL: ts.URL}}, "")
 	require.NoError(t, err)
 
 	assert.Len(t, snd.SendCalls(), 1)
 	assert.Empty(t, dur.FileCalls(), "duration service is not used because item has duration")
 	assert.Equal(t, 5678, snd.SendCalls()[0].Audio.Duration)
 
-	_, err = client.sendAudio("chan2", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL}})
+	_, err = client.sendAudio("chan2", feed.Item{Title: "title2", Enclosure: feed.Enclosure{URL: ts.URL}}, "<b>{{.Title}}</b>")
 	require.NoError(t, err)
 	assert.Len(t, snd.SendCalls(), 2)
 	assert.Len(t, dur.FileCalls(), 1, "duration service used because item has no duration")
 	assert.Equal(t, 12345, snd.SendCalls()[1].Audio.Duration)
+	assert.Equal(t, "<b>title2</b>", snd.SendCalls()[1].Audio.Caption, "caption with the template")
 }
 
 func TestSendIfSendAudioFailed(t *testing.T) {
@@ -244,7 +270,7 @@ func TestSendIfSendAudioFailed(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, tc)
 
-	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}})
+	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}}, "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "can't send to telegram for")
 
@@ -272,7 +298,7 @@ func TestSend(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, tc)
 
-	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}})
+	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}}, "")
 	require.NoError(t, err)
 
 	require.Len(t, snd.SendCalls(), 1)
@@ -305,7 +331,7 @@ func TestSendTextIfAudioLarge(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, tc)
 
-	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}})
+	err = tc.Send("@channel", feed.Item{Enclosure: feed.Enclosure{URL: ts.URL + "/download/some.mp3"}}, "")
 	require.NoError(t, err)
 
 	require.Len(t, snd.SendCalls(), 1)
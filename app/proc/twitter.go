@@ -1,9 +1,11 @@
 package proc
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
 	"strings"
+	"text/template"
 
 	"github.com/ChimeraCoder/anaconda"
 	"github.com/denisbrodbeck/striphtmltags"
@@ -55,6 +57,22 @@ func (t *TwitterClient) Send(item feed.Item) error {
 	return nil
 }
 
+// FormatItem makes message text of the item with the template, html tags removed and the result shrunk to maximum.
+// Falls back to "title - link" if the template failed.
+func FormatItem(tmpl string, item feed.Item, maximum int) string {
+	b1 := bytes.Buffer{}
+	t, err := template.New("item").Parse(tmpl)
+	if err == nil {
+		err = t.Execute(&b1, item)
+	}
+	if err != nil {
+		// template failed to parse record, backup predefined format
+		log.Printf("[WARN] failed to format %s with template %q, %v", item.GUID, tmpl, err)
+		return fmt.Sprintf("%s - %s", item.Title, item.Link)
+	}
+	return strings.ReplaceAll(CleanText(b1.String(), maximum), `\n`, "\n") // \n in template
+}
+
 // CleanText removes html tags and shrinks result
 func CleanText(inp string, maximum int) string {
 	res := striphtmltags.StripTags(inp)
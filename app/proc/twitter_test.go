@@ -77,6 +77,14 @@ func TestCleanText(t *testing.T) {
 	}
 }
 
+func TestFormatItem(t *testing.T) {
+	item := feed.Item{Title: "title1", Link: "https://example.com/1", Description: "<p>some <b>description</b></p>"}
+	assert.Equal(t, "title1 - https://example.com/1", FormatItem("{{.Title}} - {{.Link}}", item, 280))
+	assert.Equal(t, "title1\nsome description", FormatItem("{{.Title}}\\n{{.Description}}", item, 280))
+	assert.Equal(t, "title1 ...", FormatItem("{{.Title}} {{.Description}}", item, 15))
+	assert.Equal(t, "title1 - https://example.com/1", FormatItem("{{.Unknown}}", item, 280), "failed template, default format")
+}
+
 func TestTwitterSend(t *testing.T) {
 	twitPoster := &mocks.TweetPosterMock{PostTweetFunc: func(string, url.Values) (anaconda.Tweet, error) {
 		return anaconda.Tweet{}, nil
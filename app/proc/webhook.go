@@ -0,0 +1,176 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // not for security, delivery id only
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+// WebhookSignatureHeader is the header with hmac-sha256 signature of the payload, as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Feed-Master-Signature"
+
+// WebhookPayload is a json body posted to webhooks for the new item
+type WebhookPayload struct {
+	Delivery string    `json:"delivery"` // the same for all attempts to deliver the item to the url
+	Feed     string    `json:"feed"`
+	Item     feed.Item `json:"item"`
+	Time     time.Time `json:"time"`
+}
+
+// WebhookDelivery is a record of the attempt to deliver the item to webhook
+type WebhookDelivery struct {
+	ID       string        `json:"id"`
+	Feed     string        `json:"feed"`
+	GUID     string        `json:"guid"`
+	Title    string        `json:"title"`
+	URL      string        `json:"url"`
+	Attempt  int           `json:"attempt"`
+	Status   int           `json:"status"` // http status, 0 if request failed
+	Error    string        `json:"error,omitempty"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Delivered returns true if the attempt succeeded
+func (d WebhookDelivery) Delivered() bool {
+	return d.Error == ""
+}
+
+// WebhookClient posts new items to webhooks of feeds as signed json, feeds without webhooks skipped.
+// Keeps records of recent delivery attempts, urls already got the item are skipped on retries.
+type WebhookClient struct {
+	Client        *http.Client
+	MaxDeliveries int // number of kept delivery records, 1000 if not set
+
+	lock       sync.Mutex
+	deliveries []WebhookDelivery // oldest first
+}
+
+// Configured checks if the feed has webhooks
+func (w *WebhookClient) Configured(_ string, fm config.Feed) bool {
+	return slices.ContainsFunc(fm.Webhooks, func(h config.Webhook) bool { return h.URL != "" })
+}
+
+// Send posts the item to all webhooks of the feed, returns error if any of them failed
+func (w *WebhookClient) Send(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, hook := range n.Feed.Webhooks {
+		if hook.URL == "" {
+			continue
+		}
+		if err := w.deliver(ctx, hook, n); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", hook.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Deliveries returns recent delivery attempts, newest first
+func (w *WebhookClient) Deliveries() []WebhookDelivery {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	res := make([]WebhookDelivery, 0, len(w.deliveries))
+	for i := len(w.deliveries) - 1; i >= 0; i-- {
+		res = append(res, w.deliveries[i])
+	}
+	return res
+}
+
+func (w *WebhookClient) deliver(ctx context.Context, hook config.Webhook, n Notification) error {
+	h := sha1.Sum([]byte(n.FeedName + "::" + n.Item.GUID + "::" + hook.URL)) //nolint:gosec // not for security
+	id := hex.EncodeToString(h[:])
+	attempts, delivered := w.attempts(id)
+	if delivered {
+		return nil
+	}
+
+	rec := WebhookDelivery{ID: id, Feed: n.FeedName, GUID: n.Item.GUID, Title: n.Item.Title, URL: hook.URL,
+		Attempt: attempts + 1, Time: time.Now()}
+	status, err := w.post(ctx, hook, WebhookPayload{Delivery: id, Feed: n.FeedName, Item: n.Item, Time: rec.Time})
+	rec.Status, rec.Duration = status, time.Since(rec.Time)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	w.record(rec)
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] delivered %s to webhook %s, %s", n.Item.GUID, hook.URL, n.Item.Title)
+	return nil
+}
+
+func (w *WebhookClient) post(ctx context.Context, hook config.Webhook, payload WebhookPayload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Feed-Master-Delivery", payload.Delivery)
+	if hook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, WebhookSignature(hook.Secret, body))
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, nil
+}
+
+// attempts returns number of recorded attempts of the delivery and whether one of them succeeded
+func (w *WebhookClient) attempts(id string) (attempts int, delivered bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, d := range w.deliveries {
+		if d.ID == id {
+			attempts++
+			delivered = delivered || d.Delivered()
+		}
+	}
+	return attempts, delivered
+}
+
+func (w *WebhookClient) record(d WebhookDelivery) {
+	maxDeliveries := w.MaxDeliveries
+	if maxDeliveries <= 0 {
+		maxDeliveries = 1000
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.deliveries = append(w.deliveries, d)
+	if len(w.deliveries) > maxDeliveries {
+		w.deliveries = w.deliveries[len(w.deliveries)-maxDeliveries:]
+	}
+}
+
+// WebhookSignature returns hmac-sha256 signature of the body with the secret, as sent in WebhookSignatureHeader
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
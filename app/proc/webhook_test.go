@@ -0,0 +1,95 @@
+package proc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/feed-master/app/config"
+	"github.com/umputun/feed-master/app/feed"
+)
+
+func TestWebhookClient_Send(t *testing.T) {
+	var lock sync.Mutex
+	var payloads []WebhookPayload
+	calls := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls[r.URL.Path]++
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		switch r.URL.Path {
+		case "/signed":
+			assert.Equal(t, WebhookSignature("secret1", body), r.Header.Get(WebhookSignatureHeader))
+		case "/flaky":
+			if calls[r.URL.Path] == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte("not ready"))
+				return
+			}
+			assert.Empty(t, r.Header.Get(WebhookSignatureHeader), "no secret, not signed")
+		}
+		var p WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &p))
+		assert.Equal(t, p.Delivery, r.Header.Get("X-Feed-Master-Delivery"))
+		payloads = append(payloads, p)
+	}))
+	defer ts.Close()
+
+	w := &WebhookClient{Client: ts.Client()}
+	item := feed.Item{Title: "Радио-Т 798", Link: "https://radio-t.com/p/798", GUID: "guid1", Duration: "3600"}
+	fm := config.Feed{Webhooks: []config.Webhook{{URL: ts.URL + "/signed", Secret: "secret1"}, {URL: ts.URL + "/flaky"}}}
+
+	require.NoError(t, w.Send(context.Background(), Notification{FeedName: "feed1", Item: item}), "no webhooks, skipped")
+	assert.Empty(t, calls)
+
+	err := w.Send(context.Background(), Notification{FeedName: "feed1", Feed: fm, Item: item})
+	require.EqualError(t, err, "webhook "+ts.URL+"/flaky: unexpected status 502: not ready")
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "feed1", payloads[0].Feed)
+	assert.Equal(t, item.Title, payloads[0].Item.Title)
+	assert.Equal(t, item.Duration, payloads[0].Item.Duration)
+
+	require.NoError(t, w.Send(context.Background(), Notification{FeedName: "feed1", Feed: fm, Item: item}), "retry")
+	assert.Equal(t, map[string]int{"/signed": 1, "/flaky": 2}, calls, "delivered url skipped on retry")
+	require.Len(t, payloads, 2)
+	assert.NotEqual(t, payloads[0].Delivery, payloads[1].Delivery, "delivery per url")
+
+	res := w.Deliveries()
+	require.Len(t, res, 3)
+	assert.Equal(t, ts.URL+"/flaky", res[0].URL, "newest first")
+	assert.Equal(t, 2, res[0].Attempt)
+	assert.True(t, res[0].Delivered())
+	assert.Equal(t, http.StatusOK, res[0].Status)
+	assert.Equal(t, 1, res[1].Attempt)
+	assert.False(t, res[1].Delivered())
+	assert.Equal(t, http.StatusBadGateway, res[1].Status)
+	assert.Equal(t, "unexpected status 502: not ready", res[1].Error)
+	assert.Equal(t, ts.URL+"/signed", res[2].URL)
+	assert.Equal(t, "guid1", res[2].GUID)
+	assert.Equal(t, "feed1", res[2].Feed)
+	assert.Equal(t, res[0].ID, res[1].ID)
+
+	w.MaxDeliveries = 2
+	it := item
+	it.GUID = "guid2"
+	require.NoError(t, w.Send(context.Background(), Notification{FeedName: "feed1", Feed: fm, Item: it}))
+	res = w.Deliveries()
+	require.Len(t, res, 2, "old records dropped")
+	assert.Equal(t, "guid2", res[0].GUID)
+	assert.Equal(t, "guid2", res[1].GUID)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	// echo -n '{"feed":"feed1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=0f498bd9ff79523bfa3306ed17d4eea82e31cf5125ba5b727c9aa9aeca325d1e",
+		WebhookSignature("secret", []byte(`{"feed":"feed1"}`)))
+}
@@ -0,0 +1,278 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+// ErrChannelNotFound returned for operations on unknown channel
+var ErrChannelNotFound = errors.New("channel not found")
+
+// channelIDRe is the charset of ids of channels added at runtime, the id is the name of channel's bucket in the db
+var channelIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// reservedIDs are names of buckets of other data in the shared db, can't be used as ids of channels.
+// Names of proc feeds are in Service.ReservedIDs.
+var reservedIDs = []string{"processed", "failures", "resolved", "channels", "videos_idx", "files_idx", ManualFeedID,
+	"outbox", "digests"}
+
+// channelRecord is the channel added or changed at runtime, persisted in the store and merged with configured channels
+type channelRecord struct {
+	Feed    FeedInfo  `json:"feed"`
+	Updated time.Time `json:"updated"`
+	Deleted bool      `json:"deleted,omitempty"` // tombstone, keeps configured channel deleted after restart
+}
+
+// Channels returns the current list of channels, configured and added at runtime
+func (s *Service) Channels() []FeedInfo {
+	s.feedsLock.RLock()
+	defer s.feedsLock.RUnlock()
+	return slices.Clone(s.Feeds)
+}
+
+// LoadChannels merges channels changed at runtime with configured ones. Changed channels replace configured
+// with the same id, deleted removed, and added appended in the order of addition. The change of configured
+// channel overrides its config till reverted with ResetChannel.
+func (s *Service) LoadChannels() error {
+	recs, err := s.Store.ListChannels()
+	if err != nil {
+		return fmt.Errorf("failed to load channels: %w", err)
+	}
+	records := make([]channelRecord, 0, len(recs))
+	for id, data := range recs {
+		var rec channelRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			log.Printf("[WARN] failed to unmarshal channel %s, %v", id, err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Updated.Before(records[j].Updated) })
+
+	s.feedsLock.Lock()
+	defer s.feedsLock.Unlock()
+	s.configured = slices.Clone(s.Feeds)
+	for _, rec := range records {
+		idx := slices.IndexFunc(s.Feeds, func(fi FeedInfo) bool { return fi.ID == rec.Feed.ID })
+		switch {
+		case rec.Deleted && idx >= 0:
+			s.Feeds = slices.Delete(s.Feeds, idx, idx+1)
+		case rec.Deleted: // deleted channel is not configured anymore, nothing to remove
+		case idx >= 0:
+			s.Feeds[idx] = rec.Feed
+		default:
+			s.Feeds = append(s.Feeds, rec.Feed)
+		}
+	}
+	log.Printf("[INFO] loaded %d runtime channel changes, %d channels", len(records), len(s.Feeds))
+	return nil
+}
+
+// ValidateChannels checks type, policies and split options of all channels. Channels of the config don't pass
+// checks of AddChannel and UpdateChannel, so this one called on startup.
+func (s *Service) ValidateChannels() error {
+	for _, fi := range s.Channels() {
+		if err := validateChannel(fi); err != nil {
+			return fmt.Errorf("channel %s (%s): %w", fi.ID, fi.Name, err)
+		}
+	}
+	return nil
+}
+
+// AddChannel adds a new channel. YouTube @handle or url in id resolved to channel or playlist id.
+func (s *Service) AddChannel(ctx context.Context, fi FeedInfo) (FeedInfo, error) {
+	if fi.ID == "" || fi.Name == "" {
+		return fi, errors.New("id and name are required")
+	}
+	if fi.Type.Listed() && fi.URL == "" {
+		return fi, fmt.Errorf("url is required for %s channel", fi.Type)
+	}
+	if err := validateChannel(fi); err != nil {
+		return fi, err
+	}
+	if fi.PostProcess.Command != "" {
+		return fi, errors.New("post-process command can be set in the config only")
+	}
+	if fi.Type.YouTube() && !ytfeed.IsRawID(fi.ID) {
+		resolved, err := s.Resolve(ctx, fi.ID)
+		if err != nil {
+			return fi, fmt.Errorf("failed to resolve %s: %w", fi.ID, err)
+		}
+		fi.Ref, fi.ID = fi.ID, resolved.ID
+		if resolved.Type != ytfeed.FTDefault {
+			fi.Type = resolved.Type
+		}
+	}
+
+	if err := s.validateID(fi.ID); err != nil {
+		return fi, err
+	}
+
+	s.feedsLock.Lock()
+	defer s.feedsLock.Unlock()
+	if slices.ContainsFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == fi.ID }) {
+		return fi, fmt.Errorf("channel %s already exists", fi.ID)
+	}
+	if err := s.saveChannel(channelRecord{Feed: fi}); err != nil {
+		return fi, err
+	}
+	s.Feeds = append(s.Feeds, fi)
+	log.Printf("[INFO] channel added %+v", fi)
+	return fi, nil
+}
+
+// UpdateChannel replaces settings of the existing channel, id and the original reference can't be changed
+func (s *Service) UpdateChannel(id string, fi FeedInfo) (FeedInfo, error) {
+	s.feedsLock.Lock()
+	defer s.feedsLock.Unlock()
+	idx := slices.IndexFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == id })
+	if idx < 0 {
+		return fi, ErrChannelNotFound
+	}
+	fi.ID, fi.Ref = id, s.Feeds[idx].Ref
+	if fi.Name == "" {
+		return fi, errors.New("name is required")
+	}
+	if fi.Type.Listed() && fi.URL == "" {
+		return fi, fmt.Errorf("url is required for %s channel", fi.Type)
+	}
+	if err := validateChannel(fi); err != nil {
+		return fi, err
+	}
+	// command is kept from the config, the one passed can only be the same
+	if fi.PostProcess.Command != "" && fi.PostProcess.Command != s.Feeds[idx].PostProcess.Command {
+		return fi, errors.New("post-process command can be set in the config only")
+	}
+	fi.PostProcess.Command = s.Feeds[idx].PostProcess.Command
+	if err := s.saveChannel(channelRecord{Feed: fi}); err != nil {
+		return fi, err
+	}
+	s.Feeds[idx] = fi
+	log.Printf("[INFO] channel updated %+v", fi)
+	return fi, nil
+}
+
+// PauseChannel stops or resumes downloads of the channel, the feed of paused channel is still served
+func (s *Service) PauseChannel(id string, paused bool) (FeedInfo, error) {
+	fi, ok := s.channel(id)
+	if !ok {
+		return fi, ErrChannelNotFound
+	}
+	fi.Paused = paused
+	return s.UpdateChannel(id, fi)
+}
+
+// ResetChannel reverts runtime changes of the configured channel, including deletion, to the config's settings
+func (s *Service) ResetChannel(id string) (FeedInfo, error) {
+	s.feedsLock.Lock()
+	defer s.feedsLock.Unlock()
+	cidx := slices.IndexFunc(s.configured, func(f FeedInfo) bool { return f.ID == id })
+	if cidx < 0 {
+		return FeedInfo{}, fmt.Errorf("%w in the config: %s", ErrChannelNotFound, id)
+	}
+	fi := s.configured[cidx]
+	if err := s.Store.RemoveChannel(id); err != nil {
+		return fi, fmt.Errorf("failed to remove channel %s: %w", id, err)
+	}
+	if idx := slices.IndexFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == id }); idx >= 0 {
+		s.Feeds[idx] = fi
+	} else {
+		s.Feeds = append(s.Feeds, fi)
+	}
+	log.Printf("[INFO] channel reset to config %+v", fi)
+	return fi, nil
+}
+
+// DeleteChannel removes the channel. With purge, all entries of the channel and their files deleted as well.
+// Returns the list of purged entries.
+func (s *Service) DeleteChannel(id string, purge bool) ([]ytfeed.Entry, error) {
+	s.feedsLock.Lock()
+	idx := slices.IndexFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == id })
+	if idx < 0 {
+		s.feedsLock.Unlock()
+		return nil, ErrChannelNotFound
+	}
+	fi := s.Feeds[idx]
+	if err := s.saveChannel(channelRecord{Feed: fi, Deleted: true}); err != nil {
+		s.feedsLock.Unlock()
+		return nil, err
+	}
+	s.Feeds = slices.Delete(s.Feeds, idx, idx+1)
+	s.feedsLock.Unlock()
+	log.Printf("[INFO] channel deleted %s (%s), purge: %v", fi.ID, fi.Name, purge)
+
+	if !purge {
+		return nil, nil
+	}
+	entries, err := s.Store.Load(id, math.MaxInt32)
+	if errors.Is(err, store.ErrNoBucket) {
+		return nil, nil // no entries
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entries of %s: %w", id, err)
+	}
+	files, err := s.Store.PurgeChannel(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge channel %s: %w", id, err)
+	}
+	removed := s.removeFiles(fi, files)
+	log.Printf("[INFO] purged %d entries and %d files of %s (%s)", len(entries), removed, fi.ID, fi.Name)
+	return entries, nil
+}
+
+// validateID checks the id of the channel added at runtime, it can't be a name of other data's bucket
+func (s *Service) validateID(id string) error {
+	if !channelIDRe.MatchString(id) {
+		return fmt.Errorf("invalid channel id %q, letters, digits, '_', '-' and '.' allowed", id)
+	}
+	if slices.Contains(reservedIDs, id) || slices.Contains(s.ReservedIDs, id) {
+		return fmt.Errorf("channel id %q is reserved", id)
+	}
+	return nil
+}
+
+// validateChannel checks type, policies and split options of the channel
+func validateChannel(fi FeedInfo) error {
+	if !fi.Type.Known() {
+		return fmt.Errorf("unknown channel type %q", fi.Type)
+	}
+	if err := validatePolicies(fi); err != nil {
+		return err
+	}
+	return fi.Split.validate()
+}
+
+// channel returns the channel by id
+func (s *Service) channel(id string) (FeedInfo, bool) {
+	s.feedsLock.RLock()
+	defer s.feedsLock.RUnlock()
+	idx := slices.IndexFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == id })
+	if idx < 0 {
+		return FeedInfo{}, false
+	}
+	return s.Feeds[idx], true
+}
+
+func (s *Service) saveChannel(rec channelRecord) error {
+	rec.Updated = time.Now()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal channel %s: %w", rec.Feed.ID, err)
+	}
+	if err := s.Store.SetChannel(rec.Feed.ID, data); err != nil {
+		return fmt.Errorf("failed to save channel %s: %w", rec.Feed.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_Channels(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	resolver := &mocks.ResolverServiceMock{
+		ResolveFunc: func(_ context.Context, ref string) (ytfeed.Resolved, error) {
+			if ref == "@handle1" {
+				return ytfeed.Resolved{ID: "UChandle1000000000000000", Type: ytfeed.FTChannel}, nil
+			}
+			return ytfeed.Resolved{}, errors.New("not found")
+		},
+	}
+	configured := []FeedInfo{{ID: "UCconf10000000000000000", Name: "conf1"}, {ID: "UCconf20000000000000000", Name: "conf2"}}
+	svc := Service{Feeds: configured, Store: boltStore, Resolver: resolver}
+
+	fi, err := svc.AddChannel(context.Background(), FeedInfo{ID: "@handle1", Name: "handle1", Keep: 5})
+	require.NoError(t, err)
+	assert.Equal(t, FeedInfo{ID: "UChandle1000000000000000", Ref: "@handle1", Name: "handle1", Type: ytfeed.FTChannel,
+		Keep: 5}, fi)
+
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "@handle1", Name: "handle1"})
+	require.EqualError(t, err, "channel UChandle1000000000000000 already exists")
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "@unknown", Name: "unknown"})
+	require.EqualError(t, err, "failed to resolve @unknown: failed to resolve @unknown: not found")
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "UCnoname0000000000000000"})
+	require.EqualError(t, err, "id and name are required")
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "vimeo1", Name: "vimeo", Type: ytfeed.FTYtDlp})
+	require.EqualError(t, err, "url is required for ytdlp channel")
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "UCbadmode000000000000000", Name: "bad", Published: "bad"})
+	require.EqualError(t, err, `unknown published mode "bad"`)
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "UCbadsplit00000000000000", Name: "bad", Split: SplitOpts{Threshold: time.Second}})
+	require.EqualError(t, err, "split threshold 1s is too short, at least 1m")
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "UCbadtype000000000000000", Name: "bad", Type: "podcast"})
+	require.EqualError(t, err, `unknown channel type "podcast"`)
+
+	fi, err = svc.UpdateChannel("UCconf10000000000000000", FeedInfo{ID: "other", Name: "conf1 updated", Keep: 10})
+	require.NoError(t, err)
+	assert.Equal(t, FeedInfo{ID: "UCconf10000000000000000", Name: "conf1 updated", Keep: 10}, fi, "id can't be changed")
+	_, err = svc.UpdateChannel("UCunknown000000000000000", FeedInfo{Name: "unknown"})
+	require.ErrorIs(t, err, ErrChannelNotFound)
+
+	fi, err = svc.PauseChannel("UChandle1000000000000000", true)
+	require.NoError(t, err)
+	assert.True(t, fi.Paused)
+	assert.Equal(t, "@handle1", fi.Ref, "ref kept")
+
+	entries, err := svc.DeleteChannel("UCconf20000000000000000", false)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	_, err = svc.DeleteChannel("UCconf20000000000000000", false)
+	require.ErrorIs(t, err, ErrChannelNotFound)
+
+	expected := []FeedInfo{
+		{ID: "UCconf10000000000000000", Name: "conf1 updated", Keep: 10},
+		{ID: "UChandle1000000000000000", Ref: "@handle1", Name: "handle1", Type: ytfeed.FTChannel, Keep: 5, Paused: true},
+	}
+	assert.Equal(t, expected, svc.Channels())
+	assert.Len(t, configured, 2, "configured channels not changed")
+
+	// restart with the same configured channels, runtime changes merged
+	restarted := Service{Feeds: []FeedInfo{{ID: "UCconf10000000000000000", Name: "conf1"},
+		{ID: "UCconf20000000000000000", Name: "conf2"}}, Store: boltStore}
+	require.NoError(t, restarted.LoadChannels())
+	assert.Equal(t, expected, restarted.Channels())
+
+	// runtime changes of configured channels reverted, the added one can't be reset
+	fi, err = restarted.ResetChannel("UCconf10000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, FeedInfo{ID: "UCconf10000000000000000", Name: "conf1"}, fi)
+	_, err = restarted.ResetChannel("UCconf20000000000000000")
+	require.NoError(t, err, "deleted channel restored")
+	_, err = restarted.ResetChannel("UChandle1000000000000000")
+	require.ErrorIs(t, err, ErrChannelNotFound)
+	expected = []FeedInfo{
+		{ID: "UCconf10000000000000000", Name: "conf1"},
+		{ID: "UChandle1000000000000000", Ref: "@handle1", Name: "handle1", Type: ytfeed.FTChannel, Keep: 5, Paused: true},
+		{ID: "UCconf20000000000000000", Name: "conf2"},
+	}
+	assert.Equal(t, expected, restarted.Channels())
+
+	restarted = Service{Feeds: []FeedInfo{{ID: "UCconf10000000000000000", Name: "conf1"},
+		{ID: "UCconf20000000000000000", Name: "conf2"}}, Store: boltStore}
+	require.NoError(t, restarted.LoadChannels())
+	assert.Equal(t, []FeedInfo{{ID: "UCconf10000000000000000", Name: "conf1"}, {ID: "UCconf20000000000000000", Name: "conf2"},
+		{ID: "UChandle1000000000000000", Ref: "@handle1", Name: "handle1", Type: ytfeed.FTChannel, Keep: 5, Paused: true}},
+		restarted.Channels(), "config used after reset")
+}
+
+func TestService_ChannelsValidation(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{Feeds: []FeedInfo{{ID: "UCconf10000000000000000", Name: "conf1", PostProcess: ytfeed.PostProcOpts{Command: "cmd1"}}},
+		Store: boltStore, ReservedIDs: []string{"radio-t"}}
+
+	tbl := []struct {
+		id  string
+		err string
+	}{
+		{id: "vimeo1"},
+		{id: "vimeo.show_1-a"},
+		{id: "processed", err: `channel id "processed" is reserved`},
+		{id: "outbox", err: `channel id "outbox" is reserved`},
+		{id: "manual", err: `channel id "manual" is reserved`},
+		{id: "radio-t", err: `channel id "radio-t" is reserved`},
+		{id: "../x", err: `invalid channel id "../x", letters, digits, '_', '-' and '.' allowed`},
+		{id: "a b", err: `invalid channel id "a b", letters, digits, '_', '-' and '.' allowed`},
+	}
+	for _, tt := range tbl {
+		_, err := svc.AddChannel(context.Background(), FeedInfo{ID: tt.id, Name: "name", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/1"})
+		if tt.err == "" {
+			assert.NoError(t, err, tt.id)
+			continue
+		}
+		assert.EqualError(t, err, tt.err, tt.id)
+	}
+
+	_, err = svc.AddChannel(context.Background(), FeedInfo{ID: "vimeo2", Name: "name", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/2",
+		PostProcess: ytfeed.PostProcOpts{Command: "rm -rf /"}})
+	require.EqualError(t, err, "post-process command can be set in the config only")
+
+	_, err = svc.UpdateChannel("UCconf10000000000000000", FeedInfo{Name: "conf1", PostProcess: ytfeed.PostProcOpts{Command: "rm -rf /"}})
+	require.EqualError(t, err, "post-process command can be set in the config only")
+	fi, err := svc.UpdateChannel("UCconf10000000000000000", FeedInfo{Name: "conf1 updated"})
+	require.NoError(t, err)
+	assert.Equal(t, "cmd1", fi.PostProcess.Command, "configured command kept")
+}
+
+func TestService_ValidateChannels(t *testing.T) {
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", Name: "name1", Published: PMOriginal, TitleTemplate: "{{.Channel}}: {{.Title}}"},
+		{ID: "ch2", Name: "name2", Split: SplitOpts{Threshold: time.Hour, Parts: 2}}}}
+	require.NoError(t, svc.ValidateChannels())
+
+	svc.Feeds = append(svc.Feeds, FeedInfo{ID: "ch3", Name: "name3", Published: "bad"})
+	require.EqualError(t, svc.ValidateChannels(), `channel ch3 (name3): unknown published mode "bad"`)
+
+	svc.Feeds[2] = FeedInfo{ID: "ch3", Name: "name3", TitleTemplate: "{{.Bad"}
+	require.ErrorContains(t, svc.ValidateChannels(), "channel ch3 (name3): invalid title template")
+
+	svc.Feeds[2] = FeedInfo{ID: "ch3", Name: "name3", Type: "podcast"}
+	require.EqualError(t, svc.ValidateChannels(), `channel ch3 (name3): unknown channel type "podcast"`)
+
+	svc.Feeds[2] = FeedInfo{ID: "ch3", Name: "name3", Split: SplitOpts{Threshold: time.Second}}
+	require.EqualError(t, svc.ValidateChannels(), "channel ch3 (name3): split threshold 1s is too short, at least 1m")
+}
+
+func TestService_DeleteChannelPurge(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	var files []string
+	for _, id := range []string{"vid1", "vid2"} {
+		file := filepath.Join(tempDir, id+".mp3")
+		require.NoError(t, os.WriteFile(file, []byte("data"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, id+".jpg"), []byte("thumb"), 0o600))
+		files = append(files, file)
+		entry := ytfeed.Entry{ChannelID: "ch1", VideoID: id, File: file, Published: time.Now()}
+		_, err = boltStore.Save(entry)
+		require.NoError(t, err)
+		require.NoError(t, boltStore.SetProcessed(entry))
+	}
+	keep := ytfeed.Entry{ChannelID: "ch2", VideoID: "vid3", Published: time.Now()}
+	_, err = boltStore.Save(keep)
+	require.NoError(t, err)
+
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", Name: "name1"}, {ID: "ch2", Name: "name2"}}, Store: boltStore}
+	entries, err := svc.DeleteChannel("ch1", true)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.ElementsMatch(t, []string{"vid1", "vid2"}, []string{entries[0].VideoID, entries[1].VideoID})
+
+	for _, f := range files {
+		assert.NoFileExists(t, f)
+		assert.NoFileExists(t, thumbFile(f))
+	}
+	_, err = boltStore.Load("ch1", 10)
+	require.Error(t, err, "channel's bucket removed")
+	processed, _, err := boltStore.CheckProcessed(ytfeed.Entry{ChannelID: "ch1", VideoID: "vid1"})
+	require.NoError(t, err)
+	assert.False(t, processed, "processed flag removed, re-added channel downloads again")
+
+	res, err := boltStore.Load("ch2", 10)
+	require.NoError(t, err)
+	assert.Len(t, res, 1, "other channel not affected")
+	assert.Equal(t, []FeedInfo{{ID: "ch2", Name: "name2"}}, svc.Channels())
+
+	// the other channel purged too
+	entries, err = svc.DeleteChannel("ch2", true)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, err = svc.DeleteChannel("ch2", true)
+	require.ErrorIs(t, err, ErrChannelNotFound)
+	assert.Empty(t, entries)
+}
+
+func TestService_procChannelsPaused(t *testing.T) {
+	chSvc := &mocks.ChannelServiceMock{
+		GetFunc: func(context.Context, string, ytfeed.Type) ([]ytfeed.Entry, error) {
+			return nil, nil
+		},
+	}
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", Name: "name1", Paused: true}, {ID: "ch2", Name: "name2"}},
+		ChannelService: chSvc, Store: &store.BoltDB{DB: db}}
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, chSvc.GetCalls(), 1, "paused channel skipped")
+	assert.Equal(t, "ch2", chSvc.GetCalls()[0].ChanID)
+}
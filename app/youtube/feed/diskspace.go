@@ -0,0 +1,20 @@
+//go:build !windows
+
+package feed
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// DiskSpace reports free disk space of the file system
+type DiskSpace struct{}
+
+// Free returns free space in bytes available to unprivileged user on the file system with the given path
+func (d DiskSpace) Free(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("failed to get file system stats for %s: %w", path, err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil //nolint:gosec,unconvert // block size and count fit int64
+}
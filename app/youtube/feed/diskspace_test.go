@@ -0,0 +1,19 @@
+//go:build !windows
+
+package feed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskSpace_Free(t *testing.T) {
+	free, err := DiskSpace{}.Free(t.TempDir())
+	require.NoError(t, err)
+	assert.Positive(t, free)
+
+	_, err = DiskSpace{}.Free("/no/such/dir")
+	require.Error(t, err)
+}
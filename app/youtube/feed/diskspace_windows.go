@@ -0,0 +1,13 @@
+//go:build windows
+
+package feed
+
+import "errors"
+
+// DiskSpace reports free disk space of the file system, not supported on windows
+type DiskSpace struct{}
+
+// Free always returns an error on windows, free space guard disabled
+func (d DiskSpace) Free(string) (int64, error) {
+	return 0, errors.New("free disk space is not supported on windows")
+}
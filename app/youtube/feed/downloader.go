@@ -3,15 +3,14 @@ package feed
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"text/template"
-
-	log "github.com/go-pkgz/lgr"
+	"time"
 )
 
 // ErrSkip is returned when the file is not downloaded
@@ -19,10 +18,28 @@ var ErrSkip = errors.New("skip")
 
 // Downloader executes an external command to download a video and extract its audio.
 type Downloader struct {
-	ytTemplate   string
-	logOutWriter io.Writer
-	logErrWriter io.Writer
-	destination  string
+	ytTemplate    string
+	probeTemplate string
+	logOutWriter  io.Writer
+	logErrWriter  io.Writer
+	destination   string
+}
+
+// Meta is video metadata reported by the probe command
+type Meta struct {
+	Duration     int       `json:"duration,omitempty"`     // seconds, 0 if unknown
+	LiveStatus   string    `json:"live_status,omitempty"`  // is_live, is_upcoming, post_live, was_live or not_live
+	Availability string    `json:"availability,omitempty"` // public, unlisted, subscriber_only, premium_only or needs_auth
+	UploadDate   time.Time `json:"upload_date,omitzero"`
+	Tags         []string  `json:"tags,omitempty"`
+	Chapters     []Chapter `json:"chapters,omitempty"`
+}
+
+// Chapter is a part of the video marked by its author, times in seconds from the start
+type Chapter struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title,omitempty"`
 }
 
 // NewDownloader creates a new Downloader with the given template (full command with placeholders for {{.ID}} and {{.Filename}}.
@@ -36,38 +53,166 @@ func NewDownloader(tmpl string, logOutWriter, logErrWriter io.Writer, destinatio
 	}
 }
 
-// Get downloads a video from youtube and extracts audio.
-// yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio "https://www.youtube.com/watch?v={{.ID}}" --no-progress -o {{.Filename}}
+// WithProbe sets the probe template, full command with placeholder for {{.ID}} printing video info as json.
+// Probe does nothing if not set.
+func (d *Downloader) WithProbe(tmpl string) *Downloader {
+	d.probeTemplate = tmpl
+	return d
+}
+
+// Get downloads a video and extracts audio. The id is youtube video id or the full url of the video page,
+// available in the template as {{.ID}} and {{.URL}}.
+// yt-dlp --extract-audio --audio-format=mp3 --audio-quality=0 -f m4a/bestaudio "{{.URL}}" --no-progress -o {{.Filename}}
 func (d *Downloader) Get(ctx context.Context, id, fname string) (file string, err error) {
 	if err := os.MkdirAll(d.destination, 0o750); err != nil {
 		return "", fmt.Errorf("failed to create directory %s: %w", d.destination, err)
 	}
 
-	tmplParams := struct {
-		ID       string
-		FileName string
-	}{
-		ID:       id,
-		FileName: fname,
-	}
-	b1 := bytes.Buffer{}
-	if err := template.Must(template.New("youtube-dl").Parse(d.ytTemplate)).Execute(&b1, tmplParams); err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+	cmd, err := shellCommand(ctx, "youtube-dl", d.ytTemplate, map[string]string{"ID": id, "URL": VideoURL(id), "FileName": fname})
+	if err != nil {
+		return "", err
 	}
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", b1.String()) //nolint:gosec // command template from config
+	// keep command's output to detect the reason of failed or skipped download
+	output := &bytes.Buffer{}
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = d.logOutWriter
-	cmd.Stderr = d.logErrWriter
+	cmd.Stdout = io.MultiWriter(d.logOutWriter, output)
+	cmd.Stderr = io.MultiWriter(d.logErrWriter, output)
 	cmd.Dir = d.destination
-	log.Printf("[DEBUG] executing command: %s", b1.String())
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to execute command: %w", err)
+		kind, msg := classify(output.String())
+		return "", &DownloadError{Kind: kind, Msg: msg, Err: fmt.Errorf("failed to execute command: %w", err)}
 	}
 
 	file = filepath.Join(d.destination, fname+".mp3")
 	if _, err := os.Stat(file); os.IsNotExist(err) {
+		// skipped by the command, i.e. by --match-filter. Live streams and premieres reported to be retried later
+		if kind, msg := classify(output.String()); kind == FKLive {
+			return file, &DownloadError{Kind: kind, Msg: msg, Err: ErrSkip}
+		}
 		return file, ErrSkip
 	}
 	return file, nil
 }
+
+// Probe gets video metadata without downloading. Returns empty Meta if the probe template not set.
+// yt-dlp --skip-download --dump-json --no-warnings "{{.URL}}"
+func (d *Downloader) Probe(ctx context.Context, id string) (Meta, error) {
+	if d.probeTemplate == "" {
+		return Meta{}, nil
+	}
+	info, err := d.probe(ctx, id)
+	if err != nil {
+		return Meta{}, err
+	}
+	return info.meta(), nil
+}
+
+// Info gets video details with the probe command and makes an entry for it, used for videos added manually.
+// Link.Href of the entry is the video page url. Returns error if the probe template not set.
+func (d *Downloader) Info(ctx context.Context, id string) (Entry, error) {
+	if d.probeTemplate == "" {
+		return Entry{}, errors.New("probe template is not set")
+	}
+	info, err := d.probe(ctx, id)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{VideoID: info.ID, Title: info.Title, Meta: info.meta()}
+	entry.Link.Href = info.WebpageURL
+	if entry.Link.Href == "" {
+		entry.Link.Href = VideoURL(id)
+	}
+	if entry.VideoID == "" {
+		entry.VideoID = urlHash(entry.Link.Href)
+	}
+	entry.Media.Description = htmltemplate.HTML(info.Description) //nolint:gosec // plain text, as in youtube feed
+	entry.Media.Thumbnail.URL = info.Thumbnail
+	entry.Author.Name, entry.Author.URI = info.Uploader, info.ChannelURL
+	if entry.Author.Name == "" {
+		entry.Author.Name = info.Channel
+	}
+	entry.Published = entry.Meta.UploadDate
+	if info.Timestamp > 0 {
+		entry.Published = time.Unix(int64(info.Timestamp), 0).UTC()
+	}
+	if entry.Published.IsZero() {
+		entry.Published = time.Now()
+	}
+	entry.Updated = entry.Published
+	return entry, nil
+}
+
+// probeInfo is a part of yt-dlp json dump used by probe
+type probeInfo struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	WebpageURL   string   `json:"webpage_url"`
+	Thumbnail    string   `json:"thumbnail"`
+	Uploader     string   `json:"uploader"`
+	Channel      string   `json:"channel"`
+	ChannelURL   string   `json:"channel_url"`
+	Timestamp    float64  `json:"timestamp"`
+	Duration     float64  `json:"duration"`
+	LiveStatus   string   `json:"live_status"`
+	Availability string   `json:"availability"`
+	UploadDate   string   `json:"upload_date"` // YYYYMMDD
+	Tags         []string `json:"tags"`
+	Chapters     []struct {
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+		Title     string  `json:"title"`
+	} `json:"chapters"`
+}
+
+func (d *Downloader) probe(ctx context.Context, id string) (probeInfo, error) {
+	cmd, err := shellCommand(ctx, "probe", d.probeTemplate, map[string]string{"ID": id, "URL": VideoURL(id)})
+	if err != nil {
+		return probeInfo{}, err
+	}
+
+	output, errOutput := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = io.MultiWriter(d.logErrWriter, errOutput)
+	if err := cmd.Run(); err != nil {
+		kind, msg := classify(errOutput.String())
+		return probeInfo{}, &DownloadError{Kind: kind, Msg: msg, Err: fmt.Errorf("failed to execute probe: %w", err)}
+	}
+
+	var info probeInfo
+	if err := json.Unmarshal(output.Bytes(), &info); err != nil {
+		return probeInfo{}, fmt.Errorf("failed to decode probe result for %s: %w", id, err)
+	}
+	return info, nil
+}
+
+func (p probeInfo) meta() Meta {
+	res := Meta{Duration: int(p.Duration), LiveStatus: p.LiveStatus, Availability: p.Availability, Tags: p.Tags}
+	for _, c := range p.Chapters {
+		res.Chapters = append(res.Chapters, Chapter{Start: c.StartTime, End: c.EndTime, Title: c.Title})
+	}
+	if p.UploadDate != "" {
+		if ts, err := time.Parse("20060102", p.UploadDate); err == nil {
+			res.UploadDate = ts
+		}
+	}
+	return res
+}
+
+// Unavailable returns DownloadError if the video can't be downloaded now, based on live status and availability.
+// Returns nil if the video looks downloadable or the status is unknown.
+func (m Meta) Unavailable() error {
+	switch m.LiveStatus {
+	case "is_live", "is_upcoming", "post_live":
+		return &DownloadError{Kind: FKLive, Msg: "live status " + m.LiveStatus, Err: ErrSkip}
+	}
+	switch m.Availability {
+	case "subscriber_only", "premium_only":
+		return &DownloadError{Kind: FKMembersOnly, Msg: "availability " + m.Availability, Err: ErrSkip}
+	case "needs_auth":
+		return &DownloadError{Kind: FKPermanent, Msg: "availability " + m.Availability, Err: ErrSkip}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -60,3 +61,125 @@ func TestDownloader_GetFailed(t *testing.T) {
 	require.EqualError(t, err, "skip")
 	assert.Equal(t, fh.Name(), res)
 }
+
+func TestDownloader_GetClassified(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+
+	t.Run("upcoming premiere skipped by filter", func(t *testing.T) {
+		d := NewDownloader(`echo "[youtube] {{.ID}}: Premieres in 5 hours"`, lw, lw, loc)
+		_, err := d.Get(context.Background(), "id1", "fname1")
+		require.ErrorIs(t, err, ErrSkip)
+		var dlErr *DownloadError
+		require.ErrorAs(t, err, &dlErr)
+		assert.Equal(t, FKLive, dlErr.Kind)
+		assert.Equal(t, "[youtube] id1: Premieres in 5 hours", dlErr.Msg)
+	})
+
+	t.Run("failed, members only", func(t *testing.T) {
+		d := NewDownloader(`echo "ERROR: [youtube] {{.ID}}: Join this channel to get access to members-only content" >&2; exit 1`, lw, lw, loc)
+		_, err := d.Get(context.Background(), "id1", "fname1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrSkip)
+		var dlErr *DownloadError
+		require.ErrorAs(t, err, &dlErr)
+		assert.Equal(t, FKMembersOnly, dlErr.Kind)
+		assert.Contains(t, lw.String(), "members-only", "output still passed to log writer")
+	})
+}
+
+func TestDownloader_Probe(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+
+	t.Run("no probe template", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir())
+		res, err := d.Probe(context.Background(), "id1")
+		require.NoError(t, err)
+		assert.Equal(t, Meta{}, res)
+	})
+
+	t.Run("probed", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir()).WithProbe("cat " + filepath.Join(mustAbs(t, "testdata"), "probe.json") + " # {{.ID}}")
+		res, err := d.Probe(context.Background(), "id1")
+		require.NoError(t, err)
+		assert.Equal(t, Meta{Duration: 1234, LiveStatus: "not_live", Availability: "public",
+			UploadDate: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Tags: []string{"golang", "podcast"},
+			Chapters: []Chapter{{Start: 0, End: 600, Title: "intro"}, {Start: 600, End: 1234.5, Title: "main part"}}}, res)
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir()).WithProbe("echo not-json")
+		_, err := d.Probe(context.Background(), "id1")
+		require.Error(t, err)
+	})
+
+	t.Run("failed, private video", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir()).WithProbe(`echo "ERROR: [youtube] {{.ID}}: Private video" >&2; exit 1`)
+		_, err := d.Probe(context.Background(), "id1")
+		var dlErr *DownloadError
+		require.ErrorAs(t, err, &dlErr)
+		assert.Equal(t, FKPermanent, dlErr.Kind)
+	})
+}
+
+func TestDownloader_Info(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+
+	t.Run("no probe template", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir())
+		_, err := d.Info(context.Background(), "abc123")
+		require.EqualError(t, err, "probe template is not set")
+	})
+
+	t.Run("info", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir()).WithProbe("cat " + filepath.Join(mustAbs(t, "testdata"), "probe.json") + " # {{.URL}}")
+		res, err := d.Info(context.Background(), "https://youtu.be/abc123")
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", res.VideoID)
+		assert.Equal(t, "some video", res.Title)
+		assert.Equal(t, "https://www.youtube.com/watch?v=abc123", res.Link.Href)
+		assert.Equal(t, "some <b>description</b>", string(res.Media.Description))
+		assert.Equal(t, "https://i.ytimg.com/vi/abc123/hq.jpg", res.Media.Thumbnail.URL)
+		assert.Equal(t, "some channel", res.Author.Name)
+		assert.Equal(t, "https://www.youtube.com/channel/UC1", res.Author.URI)
+		assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), res.Published)
+		assert.Equal(t, 1234, res.Meta.Duration)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		d := NewDownloader("echo", lw, lw, t.TempDir()).WithProbe(`echo "ERROR: [youtube] {{.ID}}: Private video" >&2; exit 1`)
+		_, err := d.Info(context.Background(), "abc123")
+		var dlErr *DownloadError
+		require.ErrorAs(t, err, &dlErr)
+		assert.Equal(t, FKPermanent, dlErr.Kind)
+	})
+}
+
+func TestMeta_Unavailable(t *testing.T) {
+	tbl := []struct {
+		meta Meta
+		kind FailureKind
+	}{
+		{Meta{}, ""},
+		{Meta{LiveStatus: "not_live", Availability: "public"}, ""},
+		{Meta{LiveStatus: "was_live", Availability: "unlisted"}, ""},
+		{Meta{LiveStatus: "is_upcoming", Availability: "public"}, FKLive},
+		{Meta{LiveStatus: "is_live"}, FKLive},
+		{Meta{Availability: "subscriber_only"}, FKMembersOnly},
+		{Meta{Availability: "needs_auth"}, FKPermanent},
+	}
+
+	for i, tt := range tbl {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := tt.meta.Unavailable()
+			if tt.kind == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var dlErr *DownloadError
+			require.ErrorAs(t, err, &dlErr)
+			assert.Equal(t, tt.kind, dlErr.Kind)
+			assert.ErrorIs(t, err, ErrSkip)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FailureKind defines the reason of failed or skipped download
+type FailureKind string
+
+// enum of all failure kinds
+const (
+	FKLive        FailureKind = "live"         // live stream or upcoming premiere, will be available later
+	FKMembersOnly FailureKind = "members-only" // available to channel members only
+	FKGeoBlocked  FailureKind = "geo-blocked"  // not available in the server's country
+	FKTransient   FailureKind = "transient"    // network errors, throttling and other temporary problems
+	FKPermanent   FailureKind = "permanent"    // removed, private or otherwise unavailable video, never retried
+)
+
+// retry delays for the first failure, doubled on each next attempt up to maxRetryDelay
+var retryDelays = map[FailureKind]time.Duration{
+	FKLive:        30 * time.Minute,
+	FKMembersOnly: 24 * time.Hour,
+	FKGeoBlocked:  24 * time.Hour,
+	FKTransient:   15 * time.Minute,
+}
+
+const maxRetryDelay = 7 * 24 * time.Hour
+
+// maxTransientAttempts limits retries of transient failures, the failure treated as permanent after it.
+// With backoff it gives up in about 5 days after the first failure.
+const maxTransientAttempts = 10
+
+// DownloadError is returned by Downloader when the download failed or skipped for a known reason
+type DownloadError struct {
+	Kind FailureKind
+	Msg  string // the relevant line from yt-dlp output
+	Err  error
+}
+
+func (e *DownloadError) Error() string {
+	if e.Msg == "" {
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Msg, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error { return e.Err }
+
+// Failure is a stored outcome of the failed download attempt for a given entry
+type Failure struct {
+	ChannelID   string      `json:"channel_id"`
+	VideoID     string      `json:"video_id"`
+	Title       string      `json:"title"`
+	Kind        FailureKind `json:"kind"`
+	Message     string      `json:"message"`
+	Attempts    int         `json:"attempts"`
+	LastAttempt time.Time   `json:"last_attempt"`
+	NextRetry   time.Time   `json:"next_retry"` // zero for permanent failures
+}
+
+// NewFailure makes a failure record for the entry from download error, prev is the previous failure of the same entry,
+// if any. Errors other than DownloadError considered transient.
+func NewFailure(entry Entry, err error, prev Failure, now time.Time) Failure {
+	res := Failure{ChannelID: entry.ChannelID, VideoID: entry.VideoID, Title: entry.Title,
+		Kind: FKTransient, Message: err.Error(), Attempts: prev.Attempts + 1, LastAttempt: now}
+
+	var dlErr *DownloadError
+	if errors.As(err, &dlErr) {
+		res.Kind = dlErr.Kind
+		if dlErr.Msg != "" {
+			res.Message = dlErr.Msg
+		}
+	}
+	if prev.Kind != res.Kind {
+		res.Attempts = 1
+	}
+
+	delay, ok := retryDelays[res.Kind]
+	if !ok {
+		return res // permanent, no retry
+	}
+	if res.Kind == FKTransient && res.Attempts >= maxTransientAttempts {
+		return res // retried too many times, not temporary anymore
+	}
+	for i := 1; i < res.Attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	res.NextRetry = now.Add(min(delay, maxRetryDelay))
+	return res
+}
+
+// Permanent returns true if the failure will not be retried
+func (f Failure) Permanent() bool {
+	return f.NextRetry.IsZero()
+}
+
+var failurePatterns = []struct {
+	kind FailureKind
+	re   *regexp.Regexp
+}{
+	{FKMembersOnly, regexp.MustCompile(`(?i)members[- ]only|join this channel|available to this channel's members`)},
+	{FKGeoBlocked, regexp.MustCompile(`(?i)available in your country|geo[- ]?restrict|blocked it in your country`)},
+	{FKLive, regexp.MustCompile(`(?i)premieres? in|live event will begin|is_live|is a live|currently live|is upcoming`)},
+	{FKTransient, regexp.MustCompile(`(?i)HTTP Error (429|5\d\d)|too many requests|timed out|temporary failure|` +
+		`connection (reset|refused)|unable to download (webpage|api page)|sign in to confirm`)},
+	{FKPermanent, regexp.MustCompile(`(?i)video unavailable|private video|has been removed|account .* terminated|` +
+		`does not exist|copyright claim|confirm your age`)},
+}
+
+// classify detects failure kind from yt-dlp output, returns the kind and the matched line.
+// Unrecognized failures considered transient, with the last error line as the message.
+func classify(output string) (kind FailureKind, msg string) {
+	lines := strings.Split(output, "\n")
+	for _, p := range failurePatterns {
+		for _, l := range lines {
+			if p.re.MatchString(l) {
+				return p.kind, strings.TrimSpace(l)
+			}
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], "ERROR") {
+			return FKTransient, strings.TrimSpace(lines[i])
+		}
+	}
+	return FKTransient, ""
+}
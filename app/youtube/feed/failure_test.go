@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailure_classify(t *testing.T) {
+	tbl := []struct {
+		output string
+		kind   FailureKind
+		msg    string
+	}{
+		{"[youtube] abc: Downloading webpage\nERROR: [youtube] abc: Premieres in 5 hours\n", FKLive,
+			"ERROR: [youtube] abc: Premieres in 5 hours"},
+		{"[youtube] abc: Downloading webpage\n[download] abc: This live event will begin in a few moments.\n", FKLive,
+			"[download] abc: This live event will begin in a few moments."},
+		{"ERROR: [youtube] abc: Join this channel to get access to members-only content like this video\n", FKMembersOnly,
+			"ERROR: [youtube] abc: Join this channel to get access to members-only content like this video"},
+		{"ERROR: [youtube] abc: The uploader has not made this video available in your country\n", FKGeoBlocked,
+			"ERROR: [youtube] abc: The uploader has not made this video available in your country"},
+		{"ERROR: unable to download video data: HTTP Error 503: Service Unavailable\n", FKTransient,
+			"ERROR: unable to download video data: HTTP Error 503: Service Unavailable"},
+		{"ERROR: [youtube] abc: Private video. Sign in if you've been granted access to this video\n", FKPermanent,
+			"ERROR: [youtube] abc: Private video. Sign in if you've been granted access to this video"},
+		{"ERROR: [youtube] abc: Video unavailable\n", FKPermanent, "ERROR: [youtube] abc: Video unavailable"},
+		{"some output\nERROR: something unexpected\nmore output\n", FKTransient, "ERROR: something unexpected"},
+		{"", FKTransient, ""},
+	}
+
+	for _, tt := range tbl {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			kind, msg := classify(tt.output)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.msg, msg)
+		})
+	}
+}
+
+func TestFailure_NewFailure(t *testing.T) {
+	now := time.Date(2025, 8, 3, 12, 0, 0, 0, time.UTC)
+	entry := Entry{ChannelID: "chan1", VideoID: "vid1", Title: "title1"}
+
+	t.Run("first live failure", func(t *testing.T) {
+		f := NewFailure(entry, &DownloadError{Kind: FKLive, Msg: "Premieres in 5 hours", Err: ErrSkip}, Failure{}, now)
+		assert.Equal(t, Failure{ChannelID: "chan1", VideoID: "vid1", Title: "title1", Kind: FKLive,
+			Message: "Premieres in 5 hours", Attempts: 1, LastAttempt: now, NextRetry: now.Add(30 * time.Minute)}, f)
+		assert.False(t, f.Permanent())
+	})
+
+	t.Run("backoff for repeated failure", func(t *testing.T) {
+		prev := Failure{Kind: FKTransient, Attempts: 2}
+		f := NewFailure(entry, errors.New("some error"), prev, now)
+		assert.Equal(t, FKTransient, f.Kind)
+		assert.Equal(t, "some error", f.Message)
+		assert.Equal(t, 3, f.Attempts)
+		assert.Equal(t, now.Add(time.Hour), f.NextRetry)
+	})
+
+	t.Run("backoff capped", func(t *testing.T) {
+		prev := Failure{Kind: FKGeoBlocked, Attempts: 20}
+		f := NewFailure(entry, &DownloadError{Kind: FKGeoBlocked, Err: errors.New("failed")}, prev, now)
+		assert.Equal(t, now.Add(maxRetryDelay), f.NextRetry)
+	})
+
+	t.Run("transient attempts capped", func(t *testing.T) {
+		prev := Failure{Kind: FKTransient, Attempts: maxTransientAttempts - 2}
+		f := NewFailure(entry, errors.New("network error"), prev, now)
+		assert.False(t, f.Permanent())
+		f = NewFailure(entry, errors.New("network error"), f, now)
+		assert.Equal(t, FKTransient, f.Kind)
+		assert.Equal(t, maxTransientAttempts, f.Attempts)
+		assert.True(t, f.Permanent(), "not retried after max attempts")
+
+		prev = Failure{Kind: FKLive, Attempts: 20}
+		f = NewFailure(entry, &DownloadError{Kind: FKLive, Err: ErrSkip}, prev, now)
+		assert.False(t, f.Permanent(), "other kinds retried")
+	})
+
+	t.Run("kind changed resets attempts", func(t *testing.T) {
+		prev := Failure{Kind: FKLive, Attempts: 5}
+		f := NewFailure(entry, errors.New("network error"), prev, now)
+		assert.Equal(t, 1, f.Attempts)
+		assert.Equal(t, now.Add(15*time.Minute), f.NextRetry)
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		f := NewFailure(entry, &DownloadError{Kind: FKPermanent, Msg: "Video unavailable", Err: errors.New("failed")}, Failure{}, now)
+		assert.True(t, f.Permanent())
+		assert.Equal(t, "Video unavailable", f.Message)
+	})
+}
+
+func TestDownloadError(t *testing.T) {
+	err := &DownloadError{Kind: FKLive, Msg: "Premieres in 5 hours", Err: ErrSkip}
+	assert.EqualError(t, err, "live: Premieres in 5 hours: skip")
+	assert.ErrorIs(t, err, ErrSkip)
+	assert.EqualError(t, &DownloadError{Kind: FKTransient, Err: errors.New("failed")}, "transient: failed")
+}
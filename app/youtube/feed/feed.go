@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -26,8 +27,34 @@ const (
 	FTDefault  = Type("")
 	FTChannel  = Type("channel")
 	FTPlaylist = Type("playlist")
+	FTYtDlp    = Type("ytdlp")  // any yt-dlp supported playlist url, i.e. vimeo showcase or soundcloud set
+	FTRSS      = Type("rss")    // rss or atom feed with links to video pages
+	FTManual   = Type("manual") // no source, videos added manually
 )
 
+// YouTube returns true for youtube channel and playlist types
+func (t Type) YouTube() bool {
+	return t == FTDefault || t == FTChannel || t == FTPlaylist
+}
+
+// Known returns true for supported types
+func (t Type) Known() bool {
+	return t.YouTube() || t.Listed() || t == FTManual
+}
+
+// Listed returns true for types with entries listed from the source url, i.e. yt-dlp playlist or rss feed
+func (t Type) Listed() bool {
+	return t == FTYtDlp || t == FTRSS
+}
+
+// VideoURL returns the url of the video page, ref is either youtube video id or the full url
+func VideoURL(ref string) string {
+	if strings.Contains(ref, "://") {
+		return ref
+	}
+	return "https://www.youtube.com/watch?v=" + ref
+}
+
 // Get xml/rss feed for channel
 // https://www.youtube.com/feeds/videos.xml?channel_id=UCPU28A9z_ka_R5dQfecHJlA
 func (c *Feed) Get(ctx context.Context, id string, feedType Type) ([]Entry, error) {
@@ -101,13 +128,21 @@ type Entry struct {
 		URI  string `xml:"uri"`
 	} `xml:"author"`
 
-	File        string
-	Duration    int    // seconds
-	DurationFmt string // used for ui only
+	File          string
+	Duration      int    // seconds
+	DurationFmt   string // used for ui only
+	Transcript    string // language of downloaded subtitles, empty if not downloaded
+	TranscriptURL string // used for ui only
+	Meta          Meta   `xml:"-"` // metadata from the probe, empty if not probed
+	Part          int    // number of the part of the split video, starting from 1, zero if not split
+	Parts         int    // total number of parts of the split video
 }
 
-// UID returns the unique identifier of the entry.
+// UID returns the unique identifier of the entry. Parts of the split video have the part number appended.
 func (e *Entry) UID() string {
+	if e.Part > 0 {
+		return fmt.Sprintf("%s::%s::%d", e.ChannelID, e.VideoID, e.Part)
+	}
 	return e.ChannelID + "::" + e.VideoID
 }
 
@@ -84,3 +84,38 @@ func TestFeed_url(t *testing.T) {
 		})
 	}
 }
+
+func TestType_YouTube(t *testing.T) {
+	assert.True(t, FTDefault.YouTube())
+	assert.True(t, FTChannel.YouTube())
+	assert.True(t, FTPlaylist.YouTube())
+	assert.False(t, FTYtDlp.YouTube())
+	assert.False(t, FTRSS.YouTube())
+	assert.False(t, FTManual.YouTube())
+}
+
+func TestType_Listed(t *testing.T) {
+	assert.False(t, FTChannel.Listed())
+	assert.False(t, FTManual.Listed())
+	assert.True(t, FTYtDlp.Listed())
+	assert.True(t, FTRSS.Listed())
+}
+
+func TestType_Known(t *testing.T) {
+	for _, tp := range []Type{FTDefault, FTChannel, FTPlaylist, FTYtDlp, FTRSS, FTManual} {
+		assert.True(t, tp.Known(), tp)
+	}
+	assert.False(t, Type("podcast").Known())
+}
+
+func TestVideoURL(t *testing.T) {
+	assert.Equal(t, "https://www.youtube.com/watch?v=vid1", VideoURL("vid1"))
+	assert.Equal(t, "https://vimeo.com/123456", VideoURL("https://vimeo.com/123456"))
+}
+
+func TestEntry_UID(t *testing.T) {
+	e := Entry{ChannelID: "chan1", VideoID: "vid1"}
+	assert.Equal(t, "chan1::vid1", e.UID())
+	e.Part, e.Parts = 2, 3
+	assert.Equal(t, "chan1::vid1::2", e.UID())
+}
@@ -0,0 +1,267 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // not used for security
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// Listing gets entries of non-youtube sources, either from yt-dlp playlist dump or from rss/atom feed
+// with links to video pages. Entries' Link.Href is the video page url passed to downloader.
+type Listing struct {
+	Client  *http.Client
+	Command string // command template with {{.URL}} printing playlist entries as json lines
+	LogErr  io.Writer
+}
+
+// List returns entries for the given source url, sorted from newest to oldest. ChannelID of entries set to id.
+func (l *Listing) List(ctx context.Context, id, listURL string, feedType Type) ([]Entry, error) {
+	var res []Entry
+	var err error
+	switch feedType {
+	case FTYtDlp:
+		res, err = l.listYtDlp(ctx, listURL)
+	case FTRSS:
+		res, err = l.listRSS(ctx, listURL)
+	default:
+		return nil, fmt.Errorf("unsupported listing type %q", feedType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", listURL, err)
+	}
+	// links come from third-party sources and passed to download commands, only http(s) urls accepted
+	valid := make([]Entry, 0, len(res))
+	for _, entry := range res {
+		if !isHTTPURL(entry.Link.Href) {
+			log.Printf("[WARN] invalid link %q of listing entry %q, skipped", entry.Link.Href, entry.Title)
+			continue
+		}
+		entry.ChannelID = id
+		valid = append(valid, entry)
+	}
+	return valid, nil
+}
+
+// listYtDlp runs yt-dlp command, i.e. yt-dlp --flat-playlist --dump-json "{{.URL}}", and parses json lines.
+// Entries without timestamp get published time in playlist order, as flat playlists usually have no dates.
+func (l *Listing) listYtDlp(ctx context.Context, listURL string) ([]Entry, error) {
+	cmd, err := shellCommand(ctx, "listing", l.Command, map[string]string{"URL": listURL})
+	if err != nil {
+		return nil, err
+	}
+	out := bytes.Buffer{}
+	cmd.Stdout = &out
+	cmd.Stderr = l.LogErr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	res := []Entry{}
+	now := time.Now()
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var info struct {
+			ID          string  `json:"id"`
+			URL         string  `json:"url"`
+			WebpageURL  string  `json:"webpage_url"`
+			Title       string  `json:"title"`
+			Description string  `json:"description"`
+			Timestamp   float64 `json:"timestamp"`
+			UploadDate  string  `json:"upload_date"`
+			Uploader    string  `json:"uploader"`
+			Channel     string  `json:"channel"`
+			ChannelURL  string  `json:"channel_url"`
+			Thumbnail   string  `json:"thumbnail"`
+			Thumbnails  []struct {
+				URL string `json:"url"`
+			} `json:"thumbnails"`
+		}
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			log.Printf("[WARN] failed to decode listing line %q: %v", line, err)
+			continue
+		}
+		pageURL := info.WebpageURL
+		if !strings.Contains(pageURL, "://") {
+			pageURL = info.URL
+		}
+		if !strings.Contains(pageURL, "://") {
+			log.Printf("[WARN] no url for listing entry %s (%s)", info.ID, info.Title)
+			continue
+		}
+
+		entry := Entry{VideoID: info.ID, Title: info.Title}
+		if entry.VideoID == "" {
+			entry.VideoID = urlHash(pageURL)
+		}
+		entry.Link.Href = pageURL
+		entry.Media.Description = template.HTML(template.HTMLEscapeString(info.Description)) //nolint:gosec // escaped
+		entry.Author.Name = info.Uploader
+		if entry.Author.Name == "" {
+			entry.Author.Name = info.Channel
+		}
+		entry.Author.URI = info.ChannelURL
+		entry.Media.Thumbnail.URL = info.Thumbnail
+		if entry.Media.Thumbnail.URL == "" && len(info.Thumbnails) > 0 {
+			entry.Media.Thumbnail.URL = info.Thumbnails[len(info.Thumbnails)-1].URL // the last one is the best
+		}
+		switch {
+		case info.Timestamp > 0:
+			entry.Published = time.Unix(int64(info.Timestamp), 0).UTC()
+		case info.UploadDate != "":
+			if ts, err := time.Parse("20060102", info.UploadDate); err == nil {
+				entry.Published = ts
+			}
+		}
+		if entry.Published.IsZero() {
+			entry.Published = now.Add(-time.Duration(len(res)) * time.Minute)
+		}
+		entry.Updated = entry.Published
+		res = append(res, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read command output: %w", err)
+	}
+	return res, nil
+}
+
+// listRSS gets rss 2.0 or atom feed and makes entries from items' links
+func (l *Listing) listRSS(ctx context.Context, listURL string) ([]Entry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", listURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", listURL, resp.Status)
+	}
+
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Text string `xml:",chardata"`
+	}
+	type thumbnail struct {
+		URL string `xml:"url,attr"`
+	}
+	var data struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+			Items []struct {
+				Title       string      `xml:"title"`
+				Link        string      `xml:"link"`
+				Description string      `xml:"description"`
+				PubDate     string      `xml:"pubDate"`
+				Author      string      `xml:"author"`
+				Thumbnail   []thumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+			} `xml:"item"`
+		} `xml:"channel"`
+		// atom
+		Title   string `xml:"title"`
+		Author  string `xml:"author>name"`
+		Entries []struct {
+			Title     string      `xml:"title"`
+			Links     []link      `xml:"link"`
+			Summary   string      `xml:"summary"`
+			Content   string      `xml:"content"`
+			Published string      `xml:"published"`
+			Updated   string      `xml:"updated"`
+			Author    string      `xml:"author>name"`
+			Thumbnail []thumbnail `xml:"http://search.yahoo.com/mrss/ group>thumbnail"`
+		} `xml:"entry"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", listURL, err)
+	}
+
+	res := []Entry{}
+	for _, itm := range data.Channel.Items {
+		entry := Entry{VideoID: urlHash(itm.Link), Title: itm.Title, Published: parseTime(itm.PubDate)}
+		entry.Link.Href = itm.Link
+		entry.Media.Description = template.HTML(itm.Description) //nolint:gosec // from the source feed, as is
+		entry.Author.Name, entry.Author.URI = itm.Author, data.Channel.Link
+		if len(itm.Thumbnail) > 0 {
+			entry.Media.Thumbnail.URL = itm.Thumbnail[0].URL
+		}
+		res = append(res, entry)
+	}
+	for _, itm := range data.Entries {
+		entry := Entry{Title: itm.Title, Published: parseTime(itm.Published)}
+		for _, l := range itm.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				entry.Link.Href = l.Href
+				break
+			}
+		}
+		entry.VideoID = urlHash(entry.Link.Href)
+		entry.Media.Description = template.HTML(itm.Summary) //nolint:gosec // from the source feed, as is
+		if entry.Media.Description == "" {
+			entry.Media.Description = template.HTML(itm.Content) //nolint:gosec // from the source feed, as is
+		}
+		entry.Author.Name = itm.Author
+		if entry.Author.Name == "" {
+			entry.Author.Name = data.Author
+		}
+		if len(itm.Thumbnail) > 0 {
+			entry.Media.Thumbnail.URL = itm.Thumbnail[0].URL
+		}
+		res = append(res, entry)
+	}
+
+	// drop entries without links and set missing timestamps
+	filtered := make([]Entry, 0, len(res))
+	for _, entry := range res {
+		if entry.Link.Href == "" {
+			continue
+		}
+		if entry.Published.IsZero() {
+			entry.Published = time.Now().Add(-time.Duration(len(filtered)) * time.Minute)
+		}
+		entry.Updated = entry.Published
+		filtered = append(filtered, entry)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Published.After(filtered[j].Published)
+	})
+	return filtered, nil
+}
+
+// urlHash makes a short stable id from the url, for entries without their own id
+func urlHash(u string) string {
+	h := sha1.Sum([]byte(u)) //nolint:gosec // not used for security
+	return hex.EncodeToString(h[:])[:16]
+}
+
+// parseTime parses rss and atom timestamps, returns zero time if the format is unknown
+func parseTime(ts string) time.Time {
+	ts = strings.TrimSpace(ts)
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822,
+		"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"} {
+		if t, err := time.Parse(layout, ts); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
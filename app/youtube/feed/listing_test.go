@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListing_ListYtDlp(t *testing.T) {
+	l := Listing{Command: `echo "{{.URL}}" | grep -q "^https://vimeo.com/showcase/1$" && cat testdata/listing.jsonl`}
+
+	res, err := l.List(context.Background(), "vimeo1", "https://vimeo.com/showcase/1", FTYtDlp)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	assert.Equal(t, "vimeo1", res[0].ChannelID)
+	assert.Equal(t, "111", res[0].VideoID)
+	assert.Equal(t, "first video", res[0].Title)
+	assert.Equal(t, "https://vimeo.com/111", res[0].Link.Href)
+	assert.Equal(t, "first &lt;b&gt;desc&lt;/b&gt;", string(res[0].Media.Description))
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), res[0].Published)
+	assert.Equal(t, "some author", res[0].Author.Name)
+	assert.Equal(t, "https://vimeo.com/someauthor", res[0].Author.URI)
+	assert.Equal(t, "https://i.vimeocdn.com/large.jpg", res[0].Media.Thumbnail.URL)
+
+	assert.Equal(t, "222", res[1].VideoID)
+	assert.Equal(t, "https://soundcloud.com/author/track-2", res[1].Link.Href)
+	assert.Equal(t, time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC), res[1].Published)
+	assert.Equal(t, "channel name", res[1].Author.Name)
+	assert.Equal(t, "https://i1.sndcdn.com/art.jpg", res[1].Media.Thumbnail.URL)
+
+	assert.Equal(t, "444", res[2].VideoID)
+	assert.Equal(t, "https://rumble.com/v444-video.html", res[2].Link.Href)
+	assert.WithinDuration(t, time.Now(), res[2].Published, time.Minute*5, "no date, set from playlist position")
+
+	_, err = l.List(context.Background(), "vimeo2", "https://vimeo.com/showcase/2", FTYtDlp)
+	require.Error(t, err, "command failed")
+
+	_, err = l.List(context.Background(), "yt", "https://www.youtube.com/channel/UC1", FTChannel)
+	require.EqualError(t, err, `unsupported listing type "channel"`)
+}
+
+func TestListing_ListRSS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel><title>rumble channel</title><link>https://rumble.com/c/chan</link>
+<item><title>old one</title><link>https://rumble.com/v1-old.html</link><pubDate>Mon, 06 Nov 2023 10:00:00 +0000</pubDate>
+<description>old desc</description></item>
+<item><title>new one</title><link>https://rumble.com/v2-new.html</link><pubDate>Wed, 08 Nov 2023 10:00:00 +0000</pubDate>
+<description>new desc</description><author>author1</author><media:thumbnail url="https://rumble.com/thumb2.jpg"/></item>
+<item><title>no link</title></item>
+<item><title>shell in link</title><link>https://rumble.com/v3.html"; touch pwned; echo "$(id)</link></item>
+<item><title>not http</title><link>file:///etc/passwd</link></item>
+</channel></rss>`))
+		case "/atom":
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom">
+<title>twitch vods</title><author><name>streamer</name></author>
+<entry><title>vod 1</title><link rel="alternate" href="https://www.twitch.tv/videos/1"/>
+<published>2023-11-08T10:00:00Z</published><summary>vod summary</summary></entry>
+</feed>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	l := Listing{Client: ts.Client()}
+
+	res, err := l.List(context.Background(), "rumble1", ts.URL+"/rss", FTRSS)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "new one", res[0].Title, "sorted from newest")
+	assert.Equal(t, "rumble1", res[0].ChannelID)
+	assert.Equal(t, urlHash("https://rumble.com/v2-new.html"), res[0].VideoID)
+	assert.Len(t, res[0].VideoID, 16)
+	assert.Equal(t, "https://rumble.com/v2-new.html", res[0].Link.Href)
+	assert.Equal(t, "new desc", string(res[0].Media.Description))
+	assert.Equal(t, "author1", res[0].Author.Name)
+	assert.Equal(t, "https://rumble.com/c/chan", res[0].Author.URI)
+	assert.Equal(t, "https://rumble.com/thumb2.jpg", res[0].Media.Thumbnail.URL)
+	assert.Equal(t, time.Date(2023, 11, 8, 10, 0, 0, 0, time.UTC), res[0].Published.UTC())
+	assert.Equal(t, "old one", res[1].Title)
+
+	res, err = l.List(context.Background(), "twitch1", ts.URL+"/atom", FTRSS)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "vod 1", res[0].Title)
+	assert.Equal(t, "https://www.twitch.tv/videos/1", res[0].Link.Href)
+	assert.Equal(t, "streamer", res[0].Author.Name)
+	assert.Equal(t, "vod summary", string(res[0].Media.Description))
+	assert.Equal(t, time.Date(2023, 11, 8, 10, 0, 0, 0, time.UTC), res[0].Published)
+
+	_, err = l.List(context.Background(), "bad", ts.URL+"/bad", FTRSS)
+	require.Error(t, err)
+}
@@ -0,0 +1,249 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// PostProcOpts defines audio post-processing steps applied to the downloaded file, all optional
+type PostProcOpts struct {
+	Loudnorm    bool    `yaml:"loudnorm" json:"loudnorm"`         // EBU R128 loudness normalization
+	TrimSilence bool    `yaml:"trim_silence" json:"trim_silence"` // remove silence at the start and pauses longer than 2s
+	Mono        bool    `yaml:"mono" json:"mono"`                 // downmix to a single channel
+	Bitrate     string  `yaml:"bitrate" json:"bitrate"`           // output bitrate, i.e. 64k
+	Speed       float64 `yaml:"speed" json:"speed"`               // tempo multiplier, i.e. 1.25
+	Command     string  `yaml:"command" json:"command"`           // custom command template with {{.Input}} and {{.Output}}, runs after ffmpeg steps
+}
+
+// Enabled returns true if any post-processing step is set
+func (o PostProcOpts) Enabled() bool {
+	return o.ffmpegEnabled() || o.Command != ""
+}
+
+func (o PostProcOpts) ffmpegEnabled() bool {
+	return o.Loudnorm || o.TrimSilence || o.Mono || o.Bitrate != "" || (o.Speed > 0 && o.Speed != 1)
+}
+
+// PostProcessor executes ffmpeg and custom commands to alter downloaded audio files in place.
+type PostProcessor struct {
+	ffmpeg       string
+	logOutWriter io.Writer
+	logErrWriter io.Writer
+}
+
+// NewPostProcessor creates a new PostProcessor with the given ffmpeg binary, "ffmpeg" used if empty.
+func NewPostProcessor(ffmpeg string, logOutWriter, logErrWriter io.Writer) *PostProcessor {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	return &PostProcessor{ffmpeg: ffmpeg, logOutWriter: logOutWriter, logErrWriter: logErrWriter}
+}
+
+// Process applies ffmpeg steps followed by the custom command, if any. The result replaces the original file.
+func (p *PostProcessor) Process(ctx context.Context, file string, opts PostProcOpts) error {
+	if opts.ffmpegEnabled() {
+		if err := p.replace(file, func(out string) error {
+			return p.run(ctx, exec.CommandContext(ctx, p.ffmpeg, ffmpegArgs(file, out, opts)...)) //nolint:gosec // ffmpeg from config
+		}); err != nil {
+			return fmt.Errorf("ffmpeg failed for %s: %w", file, err)
+		}
+	}
+
+	if opts.Command != "" {
+		if err := p.replace(file, func(out string) error {
+			cmd, err := shellCommand(ctx, "post-process", opts.Command, map[string]string{"Input": file, "Output": out})
+			if err != nil {
+				return err
+			}
+			return p.run(ctx, cmd)
+		}); err != nil {
+			return fmt.Errorf("post-process command failed for %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Cut removes given segments from the audio file, the result replaces the original file
+func (p *PostProcessor) Cut(ctx context.Context, file string, segments []Segment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	if err := p.replace(file, func(out string) error {
+		return p.run(ctx, exec.CommandContext(ctx, p.ffmpeg, cutArgs(file, out, segments)...)) //nolint:gosec // ffmpeg from config
+	}); err != nil {
+		return fmt.Errorf("failed to cut segments from %s: %w", file, err)
+	}
+	return nil
+}
+
+// SplitPart is a part of the audio file to extract, times in seconds, zero End means till the end of the file
+type SplitPart struct {
+	File  string
+	Start float64
+	End   float64
+}
+
+// Split extracts parts of the audio file to separate files without re-encoding, the original file is kept.
+// Files of already extracted parts removed on error.
+func (p *PostProcessor) Split(ctx context.Context, file string, parts []SplitPart) error {
+	for i, part := range parts {
+		err := p.run(ctx, exec.CommandContext(ctx, p.ffmpeg, splitArgs(file, part)...)) //nolint:gosec // ffmpeg from config
+		if err == nil {
+			if st, statErr := os.Stat(part.File); statErr != nil || st.Size() == 0 {
+				err = fmt.Errorf("no output produced to %s", part.File)
+			}
+		}
+		if err != nil {
+			for _, prev := range parts[:i+1] {
+				_ = os.Remove(prev.File)
+			}
+			return fmt.Errorf("failed to split %s, part %d: %w", file, i+1, err)
+		}
+	}
+	return nil
+}
+
+// silenceRe matches silencedetect filter's report lines, like "silence_end: 12.345 | silence_duration: 1.2"
+var silenceRe = regexp.MustCompile(`silence_(start|end): (-?[\d.]+)`)
+
+// Silences detects pauses of at least a second in the audio file, returns them as segments with "silence" category
+func (p *PostProcessor) Silences(ctx context.Context, file string) ([]Segment, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("context done: %w", ctx.Err())
+	}
+	// silencedetect reports to stderr at info level
+	output := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, p.ffmpeg, "-hide_banner", "-nostats", "-i", file, //nolint:gosec // ffmpeg from config
+		"-af", "silencedetect=noise=-35dB:d=1", "-f", "null", "-")
+	cmd.Stdout = p.logOutWriter
+	cmd.Stderr = output
+	log.Printf("[DEBUG] executing command: %s", cmd.String())
+	if err := cmd.Run(); err != nil {
+		_, _ = p.logErrWriter.Write(output.Bytes())
+		return nil, fmt.Errorf("failed to detect silences in %s: %w", file, err)
+	}
+	return parseSilences(output.String()), nil
+}
+
+// parseSilences makes segments from silencedetect's output, unfinished silence at the end of the file ignored
+func parseSilences(out string) []Segment {
+	res := []Segment{}
+	start := -1.0
+	for _, m := range silenceRe.FindAllStringSubmatch(out, -1) {
+		ts, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case m[1] == "start":
+			start = max(ts, 0)
+		case start >= 0:
+			res = append(res, Segment{Start: start, End: ts, Category: "silence"})
+			start = -1
+		}
+	}
+	return res
+}
+
+// replace calls fn with a temporary output file name and moves the result over the original file
+func (p *PostProcessor) replace(file string, fn func(out string) error) error {
+	ext := filepath.Ext(file)
+	out := strings.TrimSuffix(file, ext) + ".tmp" + ext
+	if err := fn(out); err != nil {
+		_ = os.Remove(out)
+		return err
+	}
+	if st, err := os.Stat(out); err != nil || st.Size() == 0 {
+		_ = os.Remove(out)
+		return fmt.Errorf("no output produced to %s", out)
+	}
+	if err := os.Rename(out, file); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", out, file, err)
+	}
+	return nil
+}
+
+func (p *PostProcessor) run(ctx context.Context, cmd *exec.Cmd) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("context done: %w", ctx.Err())
+	}
+	cmd.Stdout = p.logOutWriter
+	cmd.Stderr = p.logErrWriter
+	log.Printf("[DEBUG] executing command: %s", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to execute command: %w", err)
+	}
+	return nil
+}
+
+// ffmpegArgs makes ffmpeg arguments for the given options. Filters order: silence trim, tempo, loudness
+func ffmpegArgs(in, out string, opts PostProcOpts) []string {
+	filters := []string{}
+	if opts.TrimSilence {
+		filters = append(filters, "silenceremove=start_periods=1:start_threshold=-50dB:"+
+			"stop_periods=-1:stop_duration=2:stop_threshold=-50dB")
+	}
+	if opts.Speed > 0 && opts.Speed != 1 {
+		filters = append(filters, atempo(opts.Speed)...)
+	}
+	if opts.Loudnorm {
+		filters = append(filters, "loudnorm=I=-16:TP=-1.5:LRA=11")
+	}
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", in, "-map_metadata", "0"}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	if opts.Mono {
+		args = append(args, "-ac", "1")
+	}
+	if opts.Bitrate != "" {
+		args = append(args, "-b:a", opts.Bitrate)
+	}
+	return append(args, out)
+}
+
+// cutArgs makes ffmpeg arguments to drop all segments and close the gaps
+func cutArgs(in, out string, segments []Segment) []string {
+	between := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		between = append(between, fmt.Sprintf("between(t,%s,%s)",
+			strconv.FormatFloat(seg.Start, 'f', -1, 64), strconv.FormatFloat(seg.End, 'f', -1, 64)))
+	}
+	filter := fmt.Sprintf("aselect='not(%s)',asetpts=N/SR/TB", strings.Join(between, "+"))
+	return []string{"-hide_banner", "-loglevel", "error", "-y", "-i", in, "-map_metadata", "0", "-af", filter, out}
+}
+
+// splitArgs makes ffmpeg arguments to copy the part of the audio without re-encoding
+func splitArgs(in string, part SplitPart) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", in, "-map_metadata", "0",
+		"-ss", strconv.FormatFloat(part.Start, 'f', -1, 64)}
+	if part.End > 0 {
+		args = append(args, "-to", strconv.FormatFloat(part.End, 'f', -1, 64))
+	}
+	return append(args, "-c", "copy", part.File)
+}
+
+// atempo makes a chain of atempo filters, as a single atempo is limited to 0.5-2.0 range
+func atempo(speed float64) []string {
+	res := []string{}
+	for speed > 2.0 {
+		res = append(res, "atempo=2.0")
+		speed /= 2.0
+	}
+	for speed < 0.5 {
+		res = append(res, "atempo=0.5")
+		speed /= 0.5
+	}
+	return append(res, "atempo="+strconv.FormatFloat(speed, 'f', -1, 64))
+}
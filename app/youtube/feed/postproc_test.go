@@ -0,0 +1,177 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProcOpts_Enabled(t *testing.T) {
+	assert.False(t, PostProcOpts{}.Enabled())
+	assert.False(t, PostProcOpts{Speed: 1}.Enabled())
+	assert.True(t, PostProcOpts{Speed: 1.5}.Enabled())
+	assert.True(t, PostProcOpts{Loudnorm: true}.Enabled())
+	assert.True(t, PostProcOpts{Bitrate: "64k"}.Enabled())
+	assert.True(t, PostProcOpts{Command: "cp {{.Input}} {{.Output}}"}.Enabled())
+}
+
+func TestPostProcessor_ffmpegArgs(t *testing.T) {
+	tbl := []struct {
+		name string
+		opts PostProcOpts
+		res  []string
+	}{
+		{"mono", PostProcOpts{Mono: true},
+			[]string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0", "-ac", "1", "out.mp3"}},
+		{"loudnorm and bitrate", PostProcOpts{Loudnorm: true, Bitrate: "64k"},
+			[]string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0",
+				"-af", "loudnorm=I=-16:TP=-1.5:LRA=11", "-b:a", "64k", "out.mp3"}},
+		{"all filters", PostProcOpts{Loudnorm: true, TrimSilence: true, Speed: 3},
+			[]string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0",
+				"-af", "silenceremove=start_periods=1:start_threshold=-50dB:stop_periods=-1:stop_duration=2:stop_threshold=-50dB," +
+					"atempo=2.0,atempo=1.5,loudnorm=I=-16:TP=-1.5:LRA=11", "out.mp3"}},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.res, ffmpegArgs("in.mp3", "out.mp3", tt.opts))
+		})
+	}
+}
+
+func TestPostProcessor_atempo(t *testing.T) {
+	assert.Equal(t, []string{"atempo=1.25"}, atempo(1.25))
+	assert.Equal(t, []string{"atempo=2.0", "atempo=2.0", "atempo=1.25"}, atempo(5))
+	assert.Equal(t, []string{"atempo=0.5", "atempo=0.8"}, atempo(0.4))
+}
+
+func TestPostProcessor_Process(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+	file := filepath.Join(loc, "audio.mp3")
+	require.NoError(t, os.WriteFile(file, []byte("original"), 0o600))
+
+	t.Run("custom command", func(t *testing.T) {
+		p := NewPostProcessor("", lw, lw)
+		err := p.Process(context.Background(), file, PostProcOpts{Command: "echo -n processed > {{.Output}}"})
+		require.NoError(t, err)
+		data, err := os.ReadFile(file) //nolint:gosec // test file path
+		require.NoError(t, err)
+		assert.Equal(t, "processed", string(data))
+		assert.NoFileExists(t, filepath.Join(loc, "audio.tmp.mp3"))
+	})
+
+	t.Run("ffmpeg steps", func(t *testing.T) {
+		// fake ffmpeg writes all arguments to the output file, i.e. the last argument
+		ffmpeg := filepath.Join(loc, "ffmpeg.sh")
+		require.NoError(t, os.WriteFile(ffmpeg, []byte("#!/bin/sh\nfor last; do true; done\necho -n \"$@\" > $last\n"), 0o700)) //nolint:gosec // test script
+		p := NewPostProcessor(ffmpeg, lw, lw)
+		err := p.Process(context.Background(), file, PostProcOpts{Mono: true})
+		require.NoError(t, err)
+		data, err := os.ReadFile(file) //nolint:gosec // test file path
+		require.NoError(t, err)
+		assert.Equal(t, "-hide_banner -loglevel error -y -i "+file+" -map_metadata 0 -ac 1 "+filepath.Join(loc, "audio.tmp.mp3"), string(data))
+	})
+
+	t.Run("failed command keeps original", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("original"), 0o600))
+		p := NewPostProcessor("", lw, lw)
+		err := p.Process(context.Background(), file, PostProcOpts{Command: "false"})
+		require.Error(t, err)
+		data, err := os.ReadFile(file) //nolint:gosec // test file path
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data))
+	})
+
+	t.Run("empty output keeps original", func(t *testing.T) {
+		p := NewPostProcessor("", lw, lw)
+		err := p.Process(context.Background(), file, PostProcOpts{Command: "touch {{.Output}}"})
+		require.Error(t, err)
+		data, err := os.ReadFile(file) //nolint:gosec // test file path
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data))
+	})
+}
+
+func TestPostProcessor_cutArgs(t *testing.T) {
+	res := cutArgs("in.mp3", "out.mp3", []Segment{{Start: 1.5, End: 20, Category: "sponsor"}, {Start: 100, End: 130.25}})
+	assert.Equal(t, []string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0",
+		"-af", "aselect='not(between(t,1.5,20)+between(t,100,130.25))',asetpts=N/SR/TB", "out.mp3"}, res)
+}
+
+func TestPostProcessor_Cut(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+	file := filepath.Join(loc, "audio.mp3")
+	require.NoError(t, os.WriteFile(file, []byte("original"), 0o600))
+
+	ffmpeg := filepath.Join(loc, "ffmpeg.sh")
+	require.NoError(t, os.WriteFile(ffmpeg, []byte("#!/bin/sh\nfor last; do true; done\necho -n \"$@\" > $last\n"), 0o700)) //nolint:gosec // test script
+	p := NewPostProcessor(ffmpeg, lw, lw)
+
+	require.NoError(t, p.Cut(context.Background(), file, nil))
+	data, err := os.ReadFile(file) //nolint:gosec // test file path
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data), "nothing to cut")
+
+	require.NoError(t, p.Cut(context.Background(), file, []Segment{{Start: 10, End: 20}}))
+	data, err = os.ReadFile(file) //nolint:gosec // test file path
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "aselect='not(between(t,10,20))',asetpts=N/SR/TB")
+}
+
+func TestPostProcessor_splitArgs(t *testing.T) {
+	assert.Equal(t, []string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0",
+		"-ss", "0", "-to", "1800.5", "-c", "copy", "p1.mp3"}, splitArgs("in.mp3", SplitPart{File: "p1.mp3", End: 1800.5}))
+	assert.Equal(t, []string{"-hide_banner", "-loglevel", "error", "-y", "-i", "in.mp3", "-map_metadata", "0",
+		"-ss", "1800.5", "-c", "copy", "p2.mp3"}, splitArgs("in.mp3", SplitPart{File: "p2.mp3", Start: 1800.5}))
+}
+
+func TestPostProcessor_Split(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+	file := filepath.Join(loc, "audio.mp3")
+	require.NoError(t, os.WriteFile(file, []byte("original"), 0o600))
+	parts := []SplitPart{{File: filepath.Join(loc, "p1.mp3"), End: 100}, {File: filepath.Join(loc, "p2.mp3"), Start: 100}}
+
+	ffmpeg := filepath.Join(loc, "ffmpeg.sh")
+	require.NoError(t, os.WriteFile(ffmpeg, []byte("#!/bin/sh\nfor last; do true; done\necho -n \"$@\" > $last\n"), 0o700)) //nolint:gosec // test script
+	p := NewPostProcessor(ffmpeg, lw, lw)
+	require.NoError(t, p.Split(context.Background(), file, parts))
+	data, err := os.ReadFile(parts[1].File) //nolint:gosec // test file path
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "-ss 100 -c copy")
+	assert.FileExists(t, file, "original kept")
+
+	// fails on the second part, the first one removed
+	failing := filepath.Join(loc, "failing.sh")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\nfor last; do true; done\n"+ //nolint:gosec // test script
+		"case $last in *p2.mp3) exit 1;; esac\necho -n \"$@\" > $last\n"), 0o700))
+	require.NoError(t, os.Remove(parts[0].File))
+	require.NoError(t, os.Remove(parts[1].File))
+	err = NewPostProcessor(failing, lw, lw).Split(context.Background(), file, parts)
+	require.ErrorContains(t, err, "part 2")
+	assert.NoFileExists(t, parts[0].File)
+	assert.NoFileExists(t, parts[1].File)
+}
+
+func TestPostProcessor_Silences(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+	ffmpeg := filepath.Join(loc, "ffmpeg.sh")
+	out := "[silencedetect @ 0x1] silence_start: 10.5\n[silencedetect @ 0x1] silence_end: 12 | silence_duration: 1.5\n" +
+		"[silencedetect @ 0x1] silence_start: -0.01\n[silencedetect @ 0x1] silence_end: 1.25 | silence_duration: 1.26\n" +
+		"[silencedetect @ 0x1] silence_start: 3600\n"
+	require.NoError(t, os.WriteFile(ffmpeg, []byte("#!/bin/sh\nprintf '"+out+"' >&2\n"), 0o700)) //nolint:gosec // test script
+	res, err := NewPostProcessor(ffmpeg, lw, lw).Silences(context.Background(), "audio.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, []Segment{{Start: 10.5, End: 12, Category: "silence"}, {Start: 0, End: 1.25, Category: "silence"}}, res)
+
+	_, err = NewPostProcessor("/bin/false", lw, lw).Silences(context.Background(), "audio.mp3")
+	require.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Resolved is a channel or playlist id resolved from the handle or url
+type Resolved struct {
+	ID   string `json:"id"`
+	Type Type   `json:"type"`
+}
+
+// Resolver converts @handles, custom, channel, playlist and video urls into channel or playlist ids.
+// Channel id looked up by the optional command or by fetching the page and extracting the id from it.
+type Resolver struct {
+	Client  *http.Client
+	BaseURL string // i.e. https://www.youtube.com, can point to a local stand-in
+	Command string // optional command template with {{.URL}} printing channel id, i.e. yt-dlp --print channel_id
+	LogErr  io.Writer
+}
+
+var (
+	rawIDRe    = regexp.MustCompile(`^[\w-]{10,}$`)
+	channelIDs = []*regexp.Regexp{
+		regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[\w-]{22})"`),
+		regexp.MustCompile(`"externalId":"(UC[\w-]{22})"`),
+		regexp.MustCompile(`"channelId":"(UC[\w-]{22})"`),
+	}
+)
+
+// IsRawID returns true if ref looks like channel or playlist id, not a handle or url
+func IsRawID(ref string) bool {
+	return rawIDRe.MatchString(ref)
+}
+
+// Resolve returns channel or playlist id for the ref. Raw ids returned as-is with FTDefault type.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (Resolved, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Resolved{}, errors.New("empty reference")
+	}
+	if IsRawID(ref) {
+		return Resolved{ID: ref, Type: FTDefault}, nil
+	}
+
+	u, err := r.parseRef(ref)
+	if err != nil {
+		return Resolved{}, err
+	}
+
+	if list := u.Query().Get("list"); list != "" {
+		return Resolved{ID: list, Type: FTPlaylist}, nil
+	}
+	if id, ok := strings.CutPrefix(u.Path, "/channel/"); ok && IsRawID(strings.Trim(id, "/")) {
+		return Resolved{ID: strings.Trim(id, "/"), Type: FTChannel}, nil
+	}
+
+	// handles, custom urls and videos need a lookup of the channel id
+	pageURL := strings.TrimSuffix(r.BaseURL, "/") + u.Path
+	if u.Host == "youtu.be" {
+		pageURL = strings.TrimSuffix(r.BaseURL, "/") + "/watch?v=" + strings.Trim(u.Path, "/")
+	} else if u.RawQuery != "" {
+		pageURL += "?" + u.RawQuery
+	}
+
+	var id string
+	if r.Command != "" {
+		id, err = r.lookupCommand(ctx, pageURL)
+	} else {
+		id, err = r.lookupPage(ctx, pageURL)
+	}
+	if err != nil {
+		return Resolved{}, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	return Resolved{ID: id, Type: FTChannel}, nil
+}
+
+// parseRef makes url from the ref, @handle converted to the channel url
+func (r *Resolver) parseRef(ref string) (*url.URL, error) {
+	switch {
+	case strings.HasPrefix(ref, "@"):
+		ref = "https://www.youtube.com/" + ref
+	case !strings.Contains(ref, "://"):
+		ref = "https://" + ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ref, err)
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(u.Host, "www."), "m.")
+	if host != "youtube.com" && host != "youtu.be" && host != "music.youtube.com" {
+		return nil, fmt.Errorf("not a youtube url %s", ref)
+	}
+	u.Host = host
+	return u, nil
+}
+
+func (r *Resolver) lookupPage(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept-Language", "en")
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get %s: %s", pageURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", pageURL, err)
+	}
+	for _, re := range channelIDs {
+		if m := re.FindSubmatch(body); len(m) == 2 {
+			return string(m[1]), nil
+		}
+	}
+	return "", fmt.Errorf("no channel id found on %s", pageURL)
+}
+
+func (r *Resolver) lookupCommand(ctx context.Context, pageURL string) (string, error) {
+	cmd, err := shellCommand(ctx, "resolver", r.Command, map[string]string{"URL": pageURL})
+	if err != nil {
+		return "", err
+	}
+	out := bytes.Buffer{}
+	cmd.Stdout = &out
+	cmd.Stderr = r.LogErr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to execute command: %w", err)
+	}
+	// command may print the id for each video of the channel, the first line is enough
+	id, _, _ := strings.Cut(strings.TrimSpace(out.String()), "\n")
+	if !IsRawID(strings.TrimSpace(id)) {
+		return "", fmt.Errorf("unexpected command output %q", out.String())
+	}
+	return strings.TrimSpace(id), nil
+}
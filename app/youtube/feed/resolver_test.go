@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_IsRawID(t *testing.T) {
+	assert.True(t, IsRawID("UCWAIvx2yYLK_xTYD4F2mUNw"))
+	assert.True(t, IsRawID("PLZVQqcKxEn_6YaOniJmxATjODSVUbbMkd"))
+	assert.False(t, IsRawID("@umputun"))
+	assert.False(t, IsRawID("https://www.youtube.com/channel/UCWAIvx2yYLK_xTYD4F2mUNw"))
+	assert.False(t, IsRawID("short"))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/@handle1", "/c/custom1":
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="https://www.youtube.com/channel/UCaaaaaaaaaaaaaaaaaaaaaa">` +
+				`</head><body>{"channelId":"UCbbbbbbbbbbbbbbbbbbbbbb"}</body></html>`))
+		case "/watch?v=vid123":
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="https://www.youtube.com/watch?v=vid123"></head>` +
+				`<body>{"videoDetails":{"videoId":"vid123","channelId":"UCcccccccccccccccccccccc"}}</body></html>`))
+		case "/@empty":
+			_, _ = w.Write([]byte(`<html></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	r := Resolver{Client: &http.Client{Timeout: time.Second}, BaseURL: ts.URL}
+
+	tbl := []struct {
+		ref string
+		res Resolved
+		err bool
+	}{
+		{ref: "UCWAIvx2yYLK_xTYD4F2mUNw", res: Resolved{ID: "UCWAIvx2yYLK_xTYD4F2mUNw"}},
+		{ref: "https://www.youtube.com/channel/UCWAIvx2yYLK_xTYD4F2mUNw", res: Resolved{ID: "UCWAIvx2yYLK_xTYD4F2mUNw", Type: FTChannel}},
+		{ref: "https://www.youtube.com/playlist?list=PLZVQqcKxEn_6YaOniJmxATjODSVUbbMkd",
+			res: Resolved{ID: "PLZVQqcKxEn_6YaOniJmxATjODSVUbbMkd", Type: FTPlaylist}},
+		{ref: "youtube.com/watch?v=vid123&list=PLZVQqcKxEn_6YaOniJmxATjODSVUbbMkd",
+			res: Resolved{ID: "PLZVQqcKxEn_6YaOniJmxATjODSVUbbMkd", Type: FTPlaylist}},
+		{ref: "@handle1", res: Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: FTChannel}},
+		{ref: "https://m.youtube.com/@handle1", res: Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: FTChannel}},
+		{ref: "https://www.youtube.com/c/custom1", res: Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: FTChannel}},
+		{ref: "https://www.youtube.com/watch?v=vid123", res: Resolved{ID: "UCcccccccccccccccccccccc", Type: FTChannel}},
+		{ref: "https://youtu.be/vid123", res: Resolved{ID: "UCcccccccccccccccccccccc", Type: FTChannel}},
+		{ref: "@empty", err: true},
+		{ref: "@unknown", err: true},
+		{ref: "https://example.com/@handle1", err: true},
+		{ref: "", err: true},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.ref, func(t *testing.T) {
+			res, err := r.Resolve(context.Background(), tt.ref)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.res, res)
+		})
+	}
+}
+
+func TestResolver_ResolveCommand(t *testing.T) {
+	r := Resolver{BaseURL: "https://www.youtube.com",
+		Command: `echo "{{.URL}}" | grep -q "/@handle1$" && printf "UCaaaaaaaaaaaaaaaaaaaaaa\nUCaaaaaaaaaaaaaaaaaaaaaa\n"`}
+
+	res, err := r.Resolve(context.Background(), "@handle1")
+	require.NoError(t, err)
+	assert.Equal(t, Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: FTChannel}, res)
+
+	_, err = r.Resolve(context.Background(), "@handle2")
+	require.Error(t, err, "command failed")
+
+	r.Command = "echo something weird"
+	_, err = r.Resolve(context.Background(), "@handle1")
+	require.Error(t, err, "unexpected output")
+}
@@ -0,0 +1,51 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/template"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// shellCommand makes "sh -c" command from the template. Values of params are not spliced into the command text,
+// the template gets references to environment variables, i.e. {{.URL}} becomes ${FM_URL}, and the values passed
+// in the environment of the command. Quotes, $(...) or backticks in urls from third-party feeds are not interpreted
+// by the shell this way, and templates like "{{.URL}}" keep working.
+func shellCommand(ctx context.Context, name, tmpl string, params map[string]string) (*exec.Cmd, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	refs := make(map[string]string, len(params))
+	env := make([]string, 0, len(params))
+	for k, v := range params {
+		refs[k] = "${FM_" + strings.ToUpper(k) + "}"
+		env = append(env, "FM_"+strings.ToUpper(k)+"="+v)
+	}
+	sort.Strings(env)
+
+	b1 := bytes.Buffer{}
+	if err = t.Execute(&b1, refs); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", b1.String()) //nolint:gosec // command template from config, values in env
+	cmd.Env = append(os.Environ(), env...)
+	log.Printf("[DEBUG] executing command: %s %v", b1.String(), env)
+	return cmd, nil
+}
+
+// isHTTPURL checks if the link is an absolute http or https url without characters not allowed in urls unescaped
+func isHTTPURL(link string) bool {
+	if strings.ContainsAny(link, " \t\r\n\"'`\\<>{}|^") {
+		return false
+	}
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,46 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellCommand(t *testing.T) {
+	dir := t.TempDir()
+	value := `https://example.com/v1"; touch pwned1; echo "$(touch pwned2)` + "`touch pwned3`'"
+	cmd, err := shellCommand(context.Background(), "test", `echo "{{.URL}}" {{.FileName}}`,
+		map[string]string{"URL": value, "FileName": "file1"})
+	require.NoError(t, err)
+	out := bytes.Buffer{}
+	cmd.Stdout = &out
+	cmd.Dir = dir
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, value+" file1\n", out.String(), "value passed as is")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "nothing executed from the value")
+	assert.NoFileExists(t, filepath.Join(dir, "pwned1"))
+
+	_, err = shellCommand(context.Background(), "test", `echo {{.Unknown}}`, map[string]string{"URL": value})
+	require.ErrorContains(t, err, "failed to execute template")
+
+	_, err = shellCommand(context.Background(), "test", `echo {{.URL`, map[string]string{"URL": value})
+	require.ErrorContains(t, err, "failed to parse template")
+}
+
+func TestIsHTTPURL(t *testing.T) {
+	assert.True(t, isHTTPURL("https://example.com/v1?a=b"))
+	assert.True(t, isHTTPURL("http://example.com"))
+	assert.False(t, isHTTPURL("file:///etc/passwd"))
+	assert.False(t, isHTTPURL("example.com/v1"))
+	assert.False(t, isHTTPURL("https://exa mple.com/\"$(id)"))
+	assert.False(t, isHTTPURL(`https://example.com/v1"; touch pwned`))
+	assert.False(t, isHTTPURL(""))
+}
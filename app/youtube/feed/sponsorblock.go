@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Segment is a part of the video, times in seconds from the start
+type Segment struct {
+	Start    float64
+	End      float64
+	Category string
+}
+
+func (s Segment) String() string {
+	return fmt.Sprintf("%s %s-%s", s.Category, fmtSeconds(s.Start), fmtSeconds(s.End))
+}
+
+// SponsorBlock is a client for SponsorBlock API, see https://wiki.sponsor.ajay.app/w/API_Docs
+type SponsorBlock struct {
+	Client  *http.Client
+	BaseURL string // i.e. https://sponsor.ajay.app, can point to a local stand-in
+}
+
+// Segments returns skip-segments of given categories for the video, sorted by start time with overlaps merged.
+// Returns empty list if SponsorBlock has no segments for the video.
+func (s *SponsorBlock) Segments(ctx context.Context, videoID string, categories []string) ([]Segment, error) {
+	params := url.Values{}
+	params.Set("videoID", videoID)
+	for _, c := range categories {
+		params.Add("category", c)
+	}
+	reqURL := strings.TrimSuffix(s.BaseURL, "/") + "/api/skipSegments?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", videoID, err)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segments for %s: %w", videoID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound { // no segments for this video
+		return []Segment{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get segments for %s: %s", videoID, resp.Status)
+	}
+
+	var data []struct {
+		Category   string    `json:"category"`
+		ActionType string    `json:"actionType"`
+		Segment    []float64 `json:"segment"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode segments for %s: %w", videoID, err)
+	}
+
+	res := []Segment{}
+	for _, d := range data {
+		if len(d.Segment) != 2 || d.Segment[1] <= d.Segment[0] || (d.ActionType != "" && d.ActionType != "skip") {
+			continue
+		}
+		res = append(res, Segment{Start: d.Segment[0], End: d.Segment[1], Category: d.Category})
+	}
+	return mergeSegments(res), nil
+}
+
+// mergeSegments sorts segments by start time and merges overlapping ones
+func mergeSegments(segments []Segment) []Segment {
+	if len(segments) == 0 {
+		return segments
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+	res := []Segment{segments[0]}
+	for _, seg := range segments[1:] {
+		last := &res[len(res)-1]
+		if seg.Start <= last.End {
+			if seg.End > last.End {
+				last.End = seg.End
+			}
+			if !strings.Contains(last.Category, seg.Category) {
+				last.Category += "+" + seg.Category
+			}
+			continue
+		}
+		res = append(res, seg)
+	}
+	return res
+}
+
+// fmtSeconds formats seconds as h:mm:ss or m:ss
+func fmtSeconds(secs float64) string {
+	d := time.Duration(secs) * time.Second
+	h, m, sec := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
@@ -0,0 +1,53 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSponsorBlock_Segments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/skipSegments", r.URL.Path)
+		assert.Equal(t, []string{"sponsor", "selfpromo"}, r.URL.Query()["category"])
+		switch r.URL.Query().Get("videoID") {
+		case "vid1":
+			_, _ = w.Write([]byte(`[
+				{"category":"selfpromo","actionType":"skip","segment":[600.5,630],"UUID":"u3"},
+				{"category":"sponsor","actionType":"skip","segment":[60,120.2],"UUID":"u1"},
+				{"category":"selfpromo","actionType":"skip","segment":[100,130],"UUID":"u2"},
+				{"category":"sponsor","actionType":"mute","segment":[700,710],"UUID":"u4"}
+			]`))
+		case "vid2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	sb := SponsorBlock{Client: &http.Client{Timeout: time.Second}, BaseURL: ts.URL + "/"}
+
+	res, err := sb.Segments(context.Background(), "vid1", []string{"sponsor", "selfpromo"})
+	require.NoError(t, err)
+	assert.Equal(t, []Segment{{Start: 60, End: 130, Category: "sponsor+selfpromo"}, {Start: 600.5, End: 630, Category: "selfpromo"}}, res)
+	assert.Equal(t, "sponsor+selfpromo 1:00-2:10", res[0].String())
+
+	res, err = sb.Segments(context.Background(), "vid2", []string{"sponsor", "selfpromo"})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = sb.Segments(context.Background(), "vid3", []string{"sponsor", "selfpromo"})
+	require.Error(t, err)
+}
+
+func TestSponsorBlock_fmtSeconds(t *testing.T) {
+	assert.Equal(t, "0:05", fmtSeconds(5.7))
+	assert.Equal(t, "12:34", fmtSeconds(754))
+	assert.Equal(t, "1:02:03", fmtSeconds(3723))
+}
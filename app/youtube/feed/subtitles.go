@@ -0,0 +1,167 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Subtitles executes an external command to download video subtitles and converts them to srt and plain text.
+type Subtitles struct {
+	subsTemplate string
+	logOutWriter io.Writer
+	logErrWriter io.Writer
+	destination  string
+}
+
+// NewSubtitles creates a new Subtitles with the given template (full command with placeholders for {{.ID}}, {{.URL}},
+// {{.FileName}} and {{.Langs}}). The command expected to make {{.FileName}}.<lang>.vtt files in the destination directory.
+func NewSubtitles(tmpl string, logOutWriter, logErrWriter io.Writer, destination string) *Subtitles {
+	return &Subtitles{
+		subsTemplate: tmpl,
+		logOutWriter: logOutWriter,
+		logErrWriter: logErrWriter,
+		destination:  destination,
+	}
+}
+
+// Get downloads subtitles for the first available language from langs and stores them as fname.vtt, fname.srt
+// and fname.txt in the destination directory. Returns the language of stored subtitles or ErrSkip if none found.
+// yt-dlp --skip-download --write-subs --write-auto-subs --sub-langs {{.Langs}} --sub-format vtt/best --convert-subs vtt "{{.URL}}" --no-progress -o {{.FileName}}
+func (s *Subtitles) Get(ctx context.Context, id, fname string, langs []string) (lang string, err error) {
+	if err = os.MkdirAll(s.destination, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", s.destination, err)
+	}
+
+	cmd, err := shellCommand(ctx, "subtitles", s.subsTemplate,
+		map[string]string{"ID": id, "URL": VideoURL(id), "FileName": fname, "Langs": strings.Join(langs, ",")})
+	if err != nil {
+		return "", err
+	}
+	cmd.Stdout = s.logOutWriter
+	cmd.Stderr = s.logErrWriter
+	cmd.Dir = s.destination
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	// pick the first available language and remove the rest
+	vttFile := filepath.Join(s.destination, fname+".vtt")
+	for _, l := range langs {
+		f := filepath.Join(s.destination, fname+"."+l+".vtt")
+		if _, e := os.Stat(f); e != nil {
+			continue
+		}
+		if lang == "" {
+			if err = os.Rename(f, vttFile); err != nil {
+				return "", fmt.Errorf("failed to rename %s: %w", f, err)
+			}
+			lang = l
+			continue
+		}
+		_ = os.Remove(f)
+	}
+	if lang == "" {
+		return "", ErrSkip
+	}
+
+	data, err := os.ReadFile(vttFile) //nolint:gosec // file name made from caller's hash
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", vttFile, err)
+	}
+	cues := parseVTT(data)
+	if err = os.WriteFile(filepath.Join(s.destination, fname+".srt"), []byte(makeSRT(cues)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write srt for %s: %w", id, err)
+	}
+	if err = os.WriteFile(filepath.Join(s.destination, fname+".txt"), []byte(makeText(cues)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write text for %s: %w", id, err)
+	}
+	return lang, nil
+}
+
+// cue is a single subtitle entry with start and end timestamps in hh:mm:ss.ttt format
+type cue struct {
+	start, end string
+	lines      []string
+}
+
+var vttTagsRe = regexp.MustCompile(`<[^>]*>`)
+
+// parseVTT extracts cues from WebVTT data. Inline tags are removed, and lines repeated from the previous cue
+// (rolling captions made by youtube auto-subs) are dropped.
+func parseVTT(data []byte) []cue {
+	res := []cue{}
+	prevLines := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var current *cue
+	flush := func() {
+		if current != nil && len(current.lines) > 0 {
+			res = append(res, *current)
+			prevLines = map[string]bool{}
+			for _, l := range current.lines {
+				prevLines[l] = true
+			}
+		}
+		current = nil
+	}
+
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(raw)
+		switch {
+		case raw == "": // cues separated by empty lines, whitespace-only lines may be a part of the cue
+			flush()
+		case strings.Contains(line, "-->"):
+			flush()
+			parts := strings.Fields(line)
+			if len(parts) < 3 {
+				continue
+			}
+			current = &cue{start: normTimestamp(parts[0]), end: normTimestamp(parts[2])}
+		case current != nil:
+			text := strings.TrimSpace(vttTagsRe.ReplaceAllString(line, ""))
+			if text == "" || prevLines[text] {
+				continue
+			}
+			current.lines = append(current.lines, text)
+		}
+	}
+	flush()
+	return res
+}
+
+// normTimestamp converts vtt timestamp, possibly without hours, to hh:mm:ss.ttt
+func normTimestamp(ts string) string {
+	if strings.Count(ts, ":") == 1 {
+		return "00:" + ts
+	}
+	return ts
+}
+
+func makeSRT(cues []cue) string {
+	b := strings.Builder{}
+	for i, c := range cues {
+		b.WriteString(strconv.Itoa(i + 1))
+		b.WriteString("\n")
+		b.WriteString(strings.ReplaceAll(c.start, ".", ",") + " --> " + strings.ReplaceAll(c.end, ".", ","))
+		b.WriteString("\n")
+		b.WriteString(strings.Join(c.lines, "\n"))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+func makeText(cues []cue) string {
+	lines := make([]string, 0, len(cues))
+	for _, c := range cues {
+		lines = append(lines, c.lines...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
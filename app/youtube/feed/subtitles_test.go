@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtitles_Get(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	loc := t.TempDir()
+
+	// fake downloader copies test vtt as german and english subtitles
+	s := NewSubtitles("echo {{.ID}} {{.Langs}} && cp "+filepath.Join(mustAbs(t, "testdata"), "subs.vtt")+" {{.FileName}}.de.vtt && "+
+		"cp "+filepath.Join(mustAbs(t, "testdata"), "subs.vtt")+" {{.FileName}}.en.vtt", lw, lw, loc)
+
+	lang, err := s.Get(context.Background(), "id1", "abc", []string{"ru", "en", "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "en", lang, "first available from the preference list")
+	assert.Equal(t, "id1 ru,en,de\n", lw.String())
+	assert.FileExists(t, filepath.Join(loc, "abc.vtt"))
+	assert.NoFileExists(t, filepath.Join(loc, "abc.en.vtt"))
+	assert.NoFileExists(t, filepath.Join(loc, "abc.de.vtt"), "other languages removed")
+
+	srt, err := os.ReadFile(filepath.Join(loc, "abc.srt")) //nolint:gosec // test file path
+	require.NoError(t, err)
+	assert.Equal(t, "1\n00:00:00,400 --> 00:00:03,190\nhello and welcome\n\n"+
+		"2\n00:00:03,200 --> 00:00:05,510\nto the show\n\n"+
+		"3\n00:01:02,000 --> 00:01:04,500\nsecond speaker line\n\n", string(srt))
+
+	txt, err := os.ReadFile(filepath.Join(loc, "abc.txt")) //nolint:gosec // test file path
+	require.NoError(t, err)
+	assert.Equal(t, "hello and welcome\nto the show\nsecond speaker line\n", string(txt))
+}
+
+func TestSubtitles_GetSkip(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	s := NewSubtitles("echo {{.ID}}", lw, lw, t.TempDir())
+	_, err := s.Get(context.Background(), "id1", "abc", []string{"en"})
+	require.ErrorIs(t, err, ErrSkip)
+}
+
+func TestSubtitles_GetFailed(t *testing.T) {
+	lw := bytes.NewBuffer(nil)
+	s := NewSubtitles("false", lw, lw, t.TempDir())
+	_, err := s.Get(context.Background(), "id1", "abc", []string{"en"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSkip)
+}
+
+func mustAbs(t *testing.T, p string) string {
+	res, err := filepath.Abs(p)
+	require.NoError(t, err)
+	return res
+}
@@ -0,0 +1,51 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Thumbnail loads video thumbnail images and stores them locally, next to the audio files.
+type Thumbnail struct {
+	Client      *http.Client
+	Destination string
+}
+
+// Get downloads thumbnail image from imageURL and saves it as fname.jpg in the destination directory
+func (t *Thumbnail) Get(ctx context.Context, imageURL, fname string) (file string, err error) {
+	if err = os.MkdirAll(t.Destination, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", t.Destination, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", imageURL, err)
+	}
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get thumbnail %s: %w", imageURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get thumbnail %s: %s", imageURL, resp.Status)
+	}
+
+	file = filepath.Join(t.Destination, fname+".jpg")
+	fh, err := os.Create(file) //nolint:gosec // file name is a hash made by caller
+	if err != nil {
+		return "", fmt.Errorf("failed to create file %s: %w", file, err)
+	}
+	if _, err = io.Copy(fh, resp.Body); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(file)
+		return "", fmt.Errorf("failed to save thumbnail to %s: %w", file, err)
+	}
+	if err = fh.Close(); err != nil {
+		return "", fmt.Errorf("failed to close file %s: %w", file, err)
+	}
+	return file, nil
+}
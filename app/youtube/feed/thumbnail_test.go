@@ -0,0 +1,45 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThumbnail_Get(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vi/vid1/hqdefault.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, e := w.Write([]byte("jpeg-data"))
+		assert.NoError(t, e)
+	}))
+	defer ts.Close()
+
+	loc := t.TempDir()
+	th := Thumbnail{Client: &http.Client{Timeout: time.Second}, Destination: loc}
+
+	t.Run("downloaded", func(t *testing.T) {
+		file, err := th.Get(context.Background(), ts.URL+"/vi/vid1/hqdefault.jpg", "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(loc, "abc123.jpg"), file)
+		data, err := os.ReadFile(file) //nolint:gosec // test file path
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg-data", string(data))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := th.Get(context.Background(), ts.URL+"/vi/vid2/hqdefault.jpg", "def456")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404 Not Found")
+		assert.NoFileExists(t, filepath.Join(loc, "def456.jpg"))
+	})
+}
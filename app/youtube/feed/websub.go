@@ -0,0 +1,212 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // sha1 signature is the one used by youtube's hub
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websubPendingTimeout = 10 * time.Minute // subscription requested again if not verified within this time
+	websubDefaultLease   = 24 * time.Hour   // used if hub doesn't report the lease
+)
+
+// WebSub is a subscriber to channels' feeds with WebSub (PubSubHubbub) push notifications,
+// see https://www.w3.org/TR/websub/ and https://developers.google.com/youtube/v3/guides/push_notifications
+type WebSub struct {
+	Client      *http.Client
+	HubURL      string        // i.e. https://pubsubhubbub.appspot.com/subscribe, can point to a local stand-in
+	CallbackURL string        // public url of the callback, i.e. https://example.com/yt/websub
+	TopicURL    string        // topic prefix, i.e. https://www.youtube.com/xml/feeds/videos.xml?channel_id=
+	Secret      string        // hmac secret of notifications, signatures not checked if empty
+	Lease       time.Duration // requested lease, hub's default if zero
+
+	lock sync.Mutex
+	subs map[string]subscription // by channel id
+}
+
+type subscription struct {
+	unsubscribe bool          // unsubscribe requested
+	requested   time.Time     // time of the last request, zero if failed
+	expires     time.Time     // lease expiration, zero until verified
+	lease       time.Duration // lease granted by hub
+}
+
+// Renew subscribes channels not subscribed yet or with lease close to expiration, and unsubscribes
+// channels not in the list anymore. Verification comes from hub to the callback asynchronously.
+func (w *WebSub) Renew(ctx context.Context, channelIDs []string) error {
+	now := time.Now()
+	wanted := make(map[string]bool, len(channelIDs))
+	var subscribe, unsubscribe []string
+
+	w.lock.Lock()
+	if w.subs == nil {
+		w.subs = map[string]subscription{}
+	}
+	for _, id := range channelIDs {
+		wanted[id] = true
+		sub, ok := w.subs[id]
+		active := time.Until(sub.expires) >= sub.lease/5 // renewed in the last fifth of the lease
+		pending := now.Sub(sub.requested) < websubPendingTimeout
+		if ok && !sub.unsubscribe && (active || pending) {
+			continue
+		}
+		subscribe = append(subscribe, id)
+		w.subs[id] = subscription{requested: now, expires: sub.expires, lease: sub.lease}
+	}
+	for id, sub := range w.subs {
+		if !wanted[id] && !sub.unsubscribe {
+			unsubscribe = append(unsubscribe, id)
+			w.subs[id] = subscription{unsubscribe: true, requested: now}
+		}
+	}
+	w.lock.Unlock()
+
+	// requests sent without the lock, hub may verify before responding
+	var errs []error
+	for _, id := range subscribe {
+		if err := w.request(ctx, "subscribe", id); err != nil {
+			errs = append(errs, err)
+			w.lock.Lock()
+			sub := w.subs[id]
+			sub.requested = time.Time{} // retried on the next renewal
+			w.subs[id] = sub
+			w.lock.Unlock()
+		}
+	}
+	for _, id := range unsubscribe {
+		if err := w.request(ctx, "unsubscribe", id); err != nil {
+			errs = append(errs, err)
+			w.lock.Lock()
+			delete(w.subs, id) // lease expires on its own
+			w.lock.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Verify checks hub's verification request for the subscription or unsubscription requested before.
+// Returns the challenge to echo back to hub.
+func (w *WebSub) Verify(q url.Values) (challenge string, err error) {
+	mode, topic := q.Get("hub.mode"), q.Get("hub.topic")
+	id, ok := strings.CutPrefix(topic, w.TopicURL)
+	if !ok || id == "" {
+		return "", fmt.Errorf("unknown topic %q", topic)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	sub, ok := w.subs[id]
+	if !ok {
+		return "", fmt.Errorf("no subscription for %s", id)
+	}
+	switch mode {
+	case "subscribe":
+		if sub.unsubscribe {
+			return "", fmt.Errorf("unsubscribe requested for %s", id)
+		}
+		sub.lease = websubDefaultLease
+		if secs, err := strconv.Atoi(q.Get("hub.lease_seconds")); err == nil && secs > 0 {
+			sub.lease = time.Duration(secs) * time.Second
+		}
+		sub.expires = time.Now().Add(sub.lease)
+		w.subs[id] = sub
+	case "unsubscribe":
+		if !sub.unsubscribe {
+			return "", fmt.Errorf("unsubscribe not requested for %s", id)
+		}
+		delete(w.subs, id)
+	case "denied":
+		// requested again after pending timeout
+		return "", fmt.Errorf("subscription to %s denied: %s", id, q.Get("hub.reason"))
+	default:
+		return "", fmt.Errorf("unknown mode %q for %s", mode, id)
+	}
+	if q.Get("hub.challenge") == "" {
+		return "", fmt.Errorf("no challenge for %s", id)
+	}
+	return q.Get("hub.challenge"), nil
+}
+
+// Parse checks signature of the notification and returns its entries, the signature is the value of
+// X-Hub-Signature header, i.e. sha1=0123abcd
+func (w *WebSub) Parse(body []byte, signature string) ([]Entry, error) {
+	if w.Secret != "" {
+		if err := w.checkSignature(body, signature); err != nil {
+			return nil, err
+		}
+	}
+	data := struct {
+		Entry []Entry `xml:"entry"`
+	}{}
+	if err := xml.NewDecoder(bytes.NewReader(body)).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	return data.Entry, nil
+}
+
+func (w *WebSub) checkSignature(body []byte, signature string) error {
+	method, sig, ok := strings.Cut(signature, "=")
+	if !ok {
+		return errors.New("no signature")
+	}
+	hashes := map[string]func() hash.Hash{"sha1": sha1.New, "sha256": sha256.New, "sha384": sha512.New384,
+		"sha512": sha512.New}
+	newHash, ok := hashes[method]
+	if !ok {
+		return fmt.Errorf("unsupported signature method %q", method)
+	}
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	mac := hmac.New(newHash, []byte(w.Secret))
+	_, _ = mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func (w *WebSub) request(ctx context.Context, mode, channelID string) error {
+	params := url.Values{}
+	params.Set("hub.callback", w.CallbackURL)
+	params.Set("hub.mode", mode)
+	params.Set("hub.topic", w.TopicURL+channelID)
+	params.Set("hub.verify", "async")
+	if w.Secret != "" && mode == "subscribe" {
+		params.Set("hub.secret", w.Secret)
+	}
+	if w.Lease > 0 && mode == "subscribe" {
+		params.Set("hub.lease_seconds", strconv.Itoa(int(w.Lease.Seconds())))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.HubURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request for %s: %w", mode, channelID, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %w", mode, channelID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to %s %s: %s", mode, channelID, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // signature used by youtube's hub
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const topicURL = "https://www.youtube.com/xml/feeds/videos.xml?channel_id="
+
+func TestWebSub_Renew(t *testing.T) {
+	var lock sync.Mutex
+	var requests []url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		lock.Lock()
+		requests = append(requests, r.PostForm)
+		lock.Unlock()
+		if r.PostForm.Get("hub.topic") == topicURL+"UCbad" && r.PostForm.Get("hub.mode") == "subscribe" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	reset := func() []url.Values {
+		lock.Lock()
+		defer lock.Unlock()
+		res := requests
+		requests = nil
+		return res
+	}
+
+	ws := WebSub{Client: &http.Client{Timeout: time.Second}, HubURL: ts.URL, CallbackURL: "http://example.com/yt/websub",
+		TopicURL: topicURL, Secret: "secret", Lease: 48 * time.Hour}
+
+	err := ws.Renew(context.Background(), []string{"UCch1", "UCch2", "UCbad"})
+	require.EqualError(t, err, "failed to subscribe UCbad: 400 Bad Request")
+	reqs := reset()
+	require.Len(t, reqs, 3)
+	assert.Equal(t, url.Values{"hub.callback": {"http://example.com/yt/websub"}, "hub.mode": {"subscribe"},
+		"hub.topic": {topicURL + "UCch1"}, "hub.verify": {"async"}, "hub.secret": {"secret"},
+		"hub.lease_seconds": {"172800"}}, reqs[0])
+
+	// verification from hub
+	challenge, err := ws.Verify(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topicURL + "UCch1"},
+		"hub.challenge": {"abc"}, "hub.lease_seconds": {"172800"}})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", challenge)
+
+	// pending and verified not requested again, failed retried
+	require.Error(t, ws.Renew(context.Background(), []string{"UCch1", "UCch2", "UCbad"}))
+	reqs = reset()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, topicURL+"UCbad", reqs[0].Get("hub.topic"))
+
+	// lease close to expiration renewed
+	ws.lock.Lock()
+	sub := ws.subs["UCch1"]
+	sub.requested, sub.expires = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	ws.subs["UCch1"] = sub
+	ws.lock.Unlock()
+	require.NoError(t, ws.Renew(context.Background(), []string{"UCch1", "UCch2"}))
+	reqs = reset()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "subscribe", reqs[0].Get("hub.mode"))
+	assert.Equal(t, topicURL+"UCch1", reqs[0].Get("hub.topic"))
+	assert.Equal(t, "unsubscribe", reqs[1].Get("hub.mode"), "removed channel unsubscribed")
+	assert.Equal(t, topicURL+"UCbad", reqs[1].Get("hub.topic"))
+	assert.Empty(t, reqs[1].Get("hub.secret"))
+}
+
+func TestWebSub_Verify(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	ws := WebSub{Client: &http.Client{Timeout: time.Second}, HubURL: ts.URL, TopicURL: topicURL}
+	require.NoError(t, ws.Renew(context.Background(), []string{"UCch1", "UCch2"}))
+
+	tbl := []struct {
+		name  string
+		query url.Values
+		res   string
+		err   string
+	}{
+		{name: "subscribe", query: url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topicURL + "UCch1"},
+			"hub.challenge": {"c1"}}, res: "c1"},
+		{name: "unknown topic", query: url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://example.com/feed"},
+			"hub.challenge": {"c2"}}, err: `unknown topic "https://example.com/feed"`},
+		{name: "not subscribed", query: url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topicURL + "UCother"},
+			"hub.challenge": {"c3"}}, err: "no subscription for UCother"},
+		{name: "unsubscribe not requested", query: url.Values{"hub.mode": {"unsubscribe"},
+			"hub.topic": {topicURL + "UCch2"}, "hub.challenge": {"c4"}}, err: "unsubscribe not requested for UCch2"},
+		{name: "denied", query: url.Values{"hub.mode": {"denied"}, "hub.topic": {topicURL + "UCch2"},
+			"hub.reason": {"bad callback"}}, err: "subscription to UCch2 denied: bad callback"},
+		{name: "no challenge", query: url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topicURL + "UCch2"}},
+			err: "no challenge for UCch2"},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ws.Verify(tt.query)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.res, res)
+		})
+	}
+
+	ws.lock.Lock()
+	assert.Equal(t, websubDefaultLease, ws.subs["UCch1"].lease, "hub's default lease")
+	ws.lock.Unlock()
+
+	// unsubscribe removed channel
+	require.NoError(t, ws.Renew(context.Background(), []string{"UCch2"}))
+	res, err := ws.Verify(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topicURL + "UCch1"},
+		"hub.challenge": {"c5"}})
+	require.NoError(t, err)
+	assert.Equal(t, "c5", res)
+	_, err = ws.Verify(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topicURL + "UCch1"}, "hub.challenge": {"c6"}})
+	require.EqualError(t, err, "no subscription for UCch1")
+}
+
+func TestWebSub_Parse(t *testing.T) {
+	body := []byte(`<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns="http://www.w3.org/2005/Atom">
+  <link rel="hub" href="https://pubsubhubbub.appspot.com"/>
+  <title>YouTube video feed</title>
+  <entry>
+    <id>yt:video:vid1</id>
+    <yt:videoId>vid1</yt:videoId>
+    <yt:channelId>UCch1</yt:channelId>
+    <title>Video title</title>
+    <link rel="alternate" href="http://www.youtube.com/watch?v=vid1"/>
+    <author><name>Channel title</name><uri>http://www.youtube.com/channel/UCch1</uri></author>
+    <published>2015-03-06T21:40:57+00:00</published>
+    <updated>2015-03-09T19:05:24.552394234+00:00</updated>
+  </entry>
+</feed>`)
+	mac := hmac.New(sha1.New, []byte("secret"))
+	_, _ = mac.Write(body)
+	signature := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	ws := WebSub{Secret: "secret"}
+	entries, err := ws.Parse(body, signature)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "UCch1", entries[0].ChannelID)
+	assert.Equal(t, "vid1", entries[0].VideoID)
+	assert.Equal(t, "Video title", entries[0].Title)
+
+	_, err = ws.Parse(body, "sha1=0123")
+	require.EqualError(t, err, "signature mismatch")
+	_, err = ws.Parse(body, "")
+	require.EqualError(t, err, "no signature")
+	_, err = ws.Parse(body, "md5=0123")
+	require.EqualError(t, err, `unsupported signature method "md5"`)
+	_, err = ws.Parse(body, "sha256=xyz")
+	require.Error(t, err)
+
+	ws = WebSub{} // no secret, not signed
+	entries, err = ws.Parse(body, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	_, err = ws.Parse([]byte("not xml"), "")
+	require.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// maxPendingJobs limits the number of queued jobs, new jobs rejected above it
+const maxPendingJobs = 50
+
+// maxFinishedJobs limits the number of finished jobs kept for status checks, the oldest ones dropped
+const maxFinishedJobs = 100
+
+// ErrQueueFull returned if too many jobs are pending already
+var ErrQueueFull = errors.New("too many pending jobs")
+
+// JobKind is the kind of the queued job
+type JobKind string
+
+// enum of job kinds
+const (
+	JobAdd        JobKind = "add"        // download and add a single video
+	JobRedownload JobKind = "redownload" // download audio of the stored entry again
+)
+
+// JobStatus is the status of the queued job
+type JobStatus string
+
+// enum of job statuses
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// Job is a download requested with api, queued and made by Do loop one by one, with the scheduled processing
+type Job struct {
+	ID        string    `json:"id"`
+	Kind      JobKind   `json:"kind"`
+	ChannelID string    `json:"channel"`
+	VideoID   string    `json:"video"`
+	Status    JobStatus `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+
+	entry ytfeed.Entry // entry to add, made by NewEntry
+}
+
+// QueueAdd queues the entry made by NewEntry to be added with AddEntry
+func (s *Service) QueueAdd(entry ytfeed.Entry) (Job, error) {
+	return s.queueJob(Job{Kind: JobAdd, ChannelID: entry.ChannelID, VideoID: entry.VideoID, entry: entry})
+}
+
+// QueueRedownload queues re-download of the stored entry with RefreshEntry
+func (s *Service) QueueRedownload(channelID, videoID string) (Job, error) {
+	return s.queueJob(Job{Kind: JobRedownload, ChannelID: channelID, VideoID: videoID})
+}
+
+// Job returns the queued, running or recently finished job by id
+func (s *Service) Job(id string) (Job, bool) {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	for _, job := range s.jobs {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return Job{}, false
+}
+
+func (s *Service) queueJob(job Job) (Job, error) {
+	s.jobsLock.Lock()
+	pending := 0
+	for _, j := range s.jobs {
+		if j.Status == JobQueued {
+			pending++
+		}
+	}
+	if pending >= maxPendingJobs {
+		s.jobsLock.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	s.jobSeq++
+	job.ID = strconv.Itoa(s.jobSeq)
+	job.Status, job.Created, job.Updated = JobQueued, time.Now(), time.Now()
+	s.jobs = append(s.jobs, &job)
+	s.pruneJobs()
+	s.jobsLock.Unlock()
+
+	log.Printf("[INFO] queued %s job %s for %s in %s", job.Kind, job.ID, job.VideoID, job.ChannelID)
+	select {
+	case s.jobCh() <- struct{}{}:
+	default: // signal pending already
+	}
+	return job, nil
+}
+
+// procJobs runs queued jobs one by one, till the queue is empty
+func (s *Service) procJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, ok := s.nextJob()
+		if !ok {
+			return
+		}
+		err := s.runJob(ctx, job)
+		s.jobsLock.Lock()
+		job.Status, job.Updated = JobDone, time.Now()
+		if err != nil {
+			job.Status, job.Error = JobFailed, err.Error()
+			log.Printf("[WARN] %s job %s for %s in %s failed: %v", job.Kind, job.ID, job.VideoID, job.ChannelID, err)
+		}
+		s.pruneJobs()
+		s.jobsLock.Unlock()
+	}
+}
+
+// runJob makes the job, called by procJobs without the lock
+func (s *Service) runJob(ctx context.Context, job *Job) error {
+	switch job.Kind {
+	case JobAdd:
+		entry, err := s.AddEntry(ctx, job.entry)
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] added %s (%s) to %s", entry.VideoID, entry.Title, entry.ChannelID)
+		return nil
+	case JobRedownload:
+		_, err := s.RefreshEntry(ctx, job.ChannelID, job.VideoID, true)
+		return err
+	}
+	return fmt.Errorf("unknown job kind %q", job.Kind)
+}
+
+// nextJob marks the oldest queued job as running and returns it
+func (s *Service) nextJob() (*Job, bool) {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	for _, job := range s.jobs {
+		if job.Status == JobQueued {
+			job.Status, job.Updated = JobRunning, time.Now()
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+// pruneJobs drops the oldest finished jobs above maxFinishedJobs, called under the lock
+func (s *Service) pruneJobs() {
+	finished := 0
+	for _, job := range s.jobs {
+		if job.Status == JobDone || job.Status == JobFailed {
+			finished++
+		}
+	}
+	res := s.jobs[:0]
+	for _, job := range s.jobs {
+		if finished > maxFinishedJobs && (job.Status == JobDone || job.Status == JobFailed) {
+			finished--
+			continue
+		}
+		res = append(res, job)
+	}
+	s.jobs = res
+}
+
+// jobCh returns the channel signaling queued jobs, made on the first use
+func (s *Service) jobCh() chan struct{} {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	if s.jobSignal == nil {
+		s.jobSignal = make(chan struct{}, 1)
+	}
+	return s.jobSignal
+}
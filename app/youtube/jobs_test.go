@@ -0,0 +1,108 @@
+package youtube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_procJobs(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	downloader := &mocks.DownloaderServiceMock{
+		InfoFunc: func(_ context.Context, id string) (ytfeed.Entry, error) {
+			return ytfeed.Entry{VideoID: id, Title: "new title"}, nil
+		},
+		GetFunc: func(_ context.Context, _, fname string) (string, error) {
+			file := filepath.Join(tempDir, fname+".mp3")
+			return file, os.WriteFile(file, []byte("audio"), 0o600)
+		},
+	}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "UCchan", Name: "my channel"}},
+		Downloader:      downloader,
+		Store:           boltStore,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		KeepPerChannel:  10,
+	}
+
+	job1, err := svc.QueueAdd(ytfeed.Entry{ChannelID: "UCchan", VideoID: "vid1", Title: "title1"})
+	require.NoError(t, err)
+	assert.Equal(t, JobQueued, job1.Status)
+	job2, err := svc.QueueAdd(ytfeed.Entry{ChannelID: "unknown", VideoID: "vid2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, job1.ID, job2.ID)
+	select {
+	case <-svc.jobCh():
+	default:
+		t.Fatal("jobs not signaled")
+	}
+	assert.Empty(t, downloader.GetCalls(), "nothing made till processed")
+
+	svc.procJobs(context.Background())
+	require.Len(t, downloader.GetCalls(), 1)
+	res, ok := svc.Job(job1.ID)
+	require.True(t, ok)
+	assert.Equal(t, JobDone, res.Status)
+	assert.Empty(t, res.Error)
+	res, ok = svc.Job(job2.ID)
+	require.True(t, ok)
+	assert.Equal(t, JobFailed, res.Status)
+	assert.Contains(t, res.Error, "not found")
+
+	entries, err := boltStore.Load("UCchan", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "vid1", entries[0].VideoID)
+
+	job3, err := svc.QueueRedownload("UCchan", "vid1")
+	require.NoError(t, err)
+	svc.procJobs(context.Background())
+	require.Len(t, downloader.GetCalls(), 2, "downloaded again")
+	res, ok = svc.Job(job3.ID)
+	require.True(t, ok)
+	assert.Equal(t, JobRedownload, res.Kind)
+	assert.Equal(t, JobDone, res.Status)
+
+	_, ok = svc.Job("unknown")
+	assert.False(t, ok)
+}
+
+func TestService_queueJobLimits(t *testing.T) {
+	svc := Service{}
+	for i := range maxPendingJobs {
+		_, err := svc.QueueAdd(ytfeed.Entry{ChannelID: "ch1", VideoID: "vid" + strconv.Itoa(i)})
+		require.NoError(t, err)
+	}
+	_, err := svc.QueueAdd(ytfeed.Entry{ChannelID: "ch1", VideoID: "one more"})
+	require.ErrorIs(t, err, ErrQueueFull)
+
+	for _, job := range svc.jobs {
+		job.Status = JobDone
+	}
+	for i := range maxFinishedJobs {
+		_, err = svc.QueueAdd(ytfeed.Entry{ChannelID: "ch1", VideoID: "new" + strconv.Itoa(i)})
+		require.NoError(t, err)
+		svc.jobs[len(svc.jobs)-1].Status = JobFailed
+	}
+	svc.pruneJobs()
+	assert.Len(t, svc.jobs, maxFinishedJobs, "the oldest finished jobs dropped")
+	_, ok := svc.Job("1")
+	assert.False(t, ok)
+	_, ok = svc.Job(strconv.Itoa(maxPendingJobs + maxFinishedJobs))
+	assert.True(t, ok)
+}
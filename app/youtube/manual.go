@@ -0,0 +1,124 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// ManualFeedID is the id of the virtual feed for videos added manually without the target channel
+const ManualFeedID = "manual"
+
+// ErrEntryExists returned if the video is in the channel already
+var ErrEntryExists = errors.New("entry already exists")
+
+// NewEntry gets info of a single video to add with AddEntry. The ref is youtube video id or the url of the video page,
+// channelID is the target channel, empty or ManualFeedID for the virtual manual feed, created on the first use.
+// Returns ErrEntryExists if the channel has the video already.
+func (s *Service) NewEntry(ctx context.Context, ref, channelID string) (ytfeed.Entry, error) {
+	fi, err := s.entryTarget(channelID)
+	if err != nil {
+		return ytfeed.Entry{}, err
+	}
+
+	entry, err := s.Downloader.Info(ctx, ref)
+	if err != nil {
+		return entry, fmt.Errorf("failed to get info for %s: %w", ref, err)
+	}
+	if unavailErr := entry.Meta.Unavailable(); unavailErr != nil {
+		return entry, fmt.Errorf("video %s is not available: %w", ref, unavailErr)
+	}
+	entry.ChannelID = fi.ID
+	if err := s.checkNewEntry(entry); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// AddEntry downloads the video of the entry made by NewEntry and adds it to the channel's feed. Filters and
+// processed state are not checked, the entry is stored like a regular one and published at the time of addition.
+// Long video split into parts by channel's split options, the first part returned.
+func (s *Service) AddEntry(ctx context.Context, entry ytfeed.Entry) (ytfeed.Entry, error) {
+	fi, err := s.entryTarget(entry.ChannelID)
+	if err != nil {
+		return entry, err
+	}
+	if err = s.checkNewEntry(entry); err != nil { // the same video may be added concurrently
+		return entry, err
+	}
+	entry.Published, entry.Updated = time.Now(), time.Now()
+	log.Printf("[INFO] manual entry %s (%s) for %s (%s)", entry.VideoID, entry.Title, fi.ID, fi.Name)
+
+	// page url used for download as the video may be from a different site than the target channel
+	file, err := s.Downloader.Get(ctx, entry.Link.Href, s.makeFileName(entry))
+	if err != nil {
+		return entry, fmt.Errorf("failed to download %s: %w", entry.VideoID, err)
+	}
+	entry = s.processFile(ctx, file, entry.Link.Href, entry, fi)
+	entry = s.update(entry, file, fi)
+
+	parts := s.split(ctx, entry, fi)
+	for _, part := range parts {
+		ok, err := s.Store.Save(part)
+		if err != nil {
+			return part, fmt.Errorf("failed to save entry %s: %w", part.VideoID, err)
+		}
+		if !ok {
+			log.Printf("[WARN] manual entry %s already exists in %s", part.VideoID, fi.ID)
+		}
+		log.Printf("[INFO] saved manual entry %s (%s) to %s, channel: %s", part.VideoID, part.Title, part.File, fi.ID)
+	}
+	if procErr := s.Store.SetProcessed(entry); procErr != nil {
+		log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, procErr)
+	}
+	if rmErr := s.Store.RemoveFailure(entry); rmErr != nil {
+		log.Printf("[WARN] failed to remove failure status for %s: %v", entry.VideoID, rmErr)
+	}
+	s.removeOld(fi)
+	s.saveRSS(fi)
+	return parts[0], nil
+}
+
+// checkNewEntry returns ErrEntryExists if the entry's channel has the video already
+func (s *Service) checkNewEntry(entry ytfeed.Entry) error {
+	entries, err := s.Store.FindVideo(entry.VideoID)
+	if err != nil {
+		return fmt.Errorf("failed to find %s: %w", entry.VideoID, err)
+	}
+	if slices.ContainsFunc(entries, func(e ytfeed.Entry) bool { return e.ChannelID == entry.ChannelID }) {
+		return fmt.Errorf("%w: %s in %s", ErrEntryExists, entry.VideoID, entry.ChannelID)
+	}
+	return nil
+}
+
+// entryTarget returns the channel for the manually added entry, makes the manual feed if it doesn't exist yet
+func (s *Service) entryTarget(channelID string) (FeedInfo, error) {
+	if channelID == "" {
+		channelID = ManualFeedID
+	}
+	if fi, ok := s.channel(channelID); ok {
+		return fi, nil
+	}
+	if channelID != ManualFeedID {
+		return FeedInfo{}, ErrChannelNotFound
+	}
+
+	s.feedsLock.Lock()
+	defer s.feedsLock.Unlock()
+	if idx := slices.IndexFunc(s.Feeds, func(f FeedInfo) bool { return f.ID == ManualFeedID }); idx >= 0 {
+		return s.Feeds[idx], nil // added concurrently
+	}
+	fi := FeedInfo{ID: ManualFeedID, Name: "Manual", Type: ytfeed.FTManual}
+	if err := s.saveChannel(channelRecord{Feed: fi}); err != nil {
+		return FeedInfo{}, fmt.Errorf("failed to make manual feed: %w", err)
+	}
+	s.Feeds = append(s.Feeds, fi)
+	log.Printf("[INFO] manual feed added")
+	return fi, nil
+}
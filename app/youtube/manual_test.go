@@ -0,0 +1,133 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_AddEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	downloader := &mocks.DownloaderServiceMock{
+		InfoFunc: func(_ context.Context, id string) (ytfeed.Entry, error) {
+			switch id {
+			case "private":
+				return ytfeed.Entry{}, &ytfeed.DownloadError{Kind: ytfeed.FKPermanent, Msg: "private video"}
+			case "live":
+				return ytfeed.Entry{VideoID: "live", Meta: ytfeed.Meta{LiveStatus: "is_live"}}, nil
+			}
+			entry := ytfeed.Entry{VideoID: id, Title: "interview " + id,
+				Published: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+			entry.Link.Href = ytfeed.VideoURL(id)
+			entry.Author.Name = "other channel"
+			return entry, nil
+		},
+		GetFunc: func(_ context.Context, _, fname string) (string, error) {
+			file := filepath.Join(tempDir, fname+".mp3")
+			return file, os.WriteFile(file, []byte("audio"), 0o600)
+		},
+	}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "UCchan", Name: "my channel", Filter: FeedFilter{Exclude: "interview"}}},
+		Downloader:      downloader,
+		Store:           boltStore,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		KeepPerChannel:  10,
+	}
+	add := func(ref, channelID string) (ytfeed.Entry, error) {
+		entry, err := svc.NewEntry(context.Background(), ref, channelID)
+		if err != nil {
+			return entry, err
+		}
+		return svc.AddEntry(context.Background(), entry)
+	}
+
+	t.Run("to channel, bypassing filters", func(t *testing.T) {
+		entry, err := add("vid1", "UCchan")
+		require.NoError(t, err)
+		assert.Equal(t, "UCchan", entry.ChannelID)
+		assert.Equal(t, "my channel: interview vid1", entry.Title)
+		assert.Equal(t, 1234, entry.Duration)
+		assert.WithinDuration(t, time.Now(), entry.Published, time.Minute, "published at the time of addition")
+		assert.FileExists(t, entry.File)
+
+		require.Len(t, downloader.GetCalls(), 1)
+		assert.Equal(t, "https://www.youtube.com/watch?v=vid1", downloader.GetCalls()[0].ID)
+
+		entries, err := boltStore.Load("UCchan", 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "vid1", entries[0].VideoID)
+		processed, _, err := boltStore.CheckProcessed(entry)
+		require.NoError(t, err)
+		assert.True(t, processed)
+	})
+
+	t.Run("to manual feed", func(t *testing.T) {
+		entry, err := add("https://vimeo.com/123", "")
+		require.NoError(t, err)
+		assert.Equal(t, ManualFeedID, entry.ChannelID)
+		assert.Equal(t, "other channel: interview https://vimeo.com/123", entry.Title, "uploader in title")
+
+		fi, ok := svc.channel(ManualFeedID)
+		require.True(t, ok, "manual feed made")
+		assert.Equal(t, ytfeed.FTManual, fi.Type)
+		recs, err := boltStore.ListChannels()
+		require.NoError(t, err)
+		assert.Contains(t, recs, ManualFeedID, "manual feed persisted")
+
+		_, err = add("vid2", ManualFeedID)
+		require.NoError(t, err)
+		entries, err := boltStore.Load(ManualFeedID, 10)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Len(t, svc.Channels(), 2, "manual feed made once")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := svc.NewEntry(context.Background(), "vid1", "UCchan")
+		require.ErrorIs(t, err, ErrEntryExists)
+		entry, err := svc.NewEntry(context.Background(), "vid1", ManualFeedID)
+		require.NoError(t, err, "same video allowed in another channel")
+
+		_, err = svc.AddEntry(context.Background(), ytfeed.Entry{VideoID: "vid2", ChannelID: ManualFeedID})
+		require.ErrorIs(t, err, ErrEntryExists, "added after NewEntry")
+		_, err = add(entry.VideoID, ManualFeedID)
+		require.NoError(t, err)
+		entries, err := boltStore.Load(ManualFeedID, 10)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := add("vid1", "unknown")
+		require.ErrorIs(t, err, ErrChannelNotFound)
+
+		_, err = add("private", "UCchan")
+		var dlErr *ytfeed.DownloadError
+		require.ErrorAs(t, err, &dlErr)
+		assert.Equal(t, ytfeed.FKPermanent, dlErr.Kind)
+
+		_, err = add("live", "UCchan")
+		require.ErrorIs(t, err, ytfeed.ErrSkip)
+
+		downloader.GetFunc = func(context.Context, string, string) (string, error) { return "", errors.New("failed") }
+		_, err = add("vid3", "UCchan")
+		require.EqualError(t, err, "failed to download vid3: failed")
+	})
+}
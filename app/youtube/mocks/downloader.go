@@ -6,27 +6,41 @@ package mocks
 import (
 	"context"
 	"sync"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
 )
 
 // DownloaderServiceMock is a mock implementation of youtube.DownloaderService.
 //
-// 	func TestSomethingThatUsesDownloaderService(t *testing.T) {
+//	func TestSomethingThatUsesDownloaderService(t *testing.T) {
 //
-// 		// make and configure a mocked youtube.DownloaderService
-// 		mockedDownloaderService := &DownloaderServiceMock{
-// 			GetFunc: func(ctx context.Context, id string, fname string) (string, error) {
-// 				panic("mock out the Get method")
-// 			},
-// 		}
+//		// make and configure a mocked youtube.DownloaderService
+//		mockedDownloaderService := &DownloaderServiceMock{
+//			GetFunc: func(ctx context.Context, id string, fname string) (string, error) {
+//				panic("mock out the Get method")
+//			},
+//			InfoFunc: func(ctx context.Context, id string) (ytfeed.Entry, error) {
+//				panic("mock out the Info method")
+//			},
+//			ProbeFunc: func(ctx context.Context, id string) (ytfeed.Meta, error) {
+//				panic("mock out the Probe method")
+//			},
+//		}
 //
-// 		// use mockedDownloaderService in code that requires youtube.DownloaderService
-// 		// and then make assertions.
+//		// use mockedDownloaderService in code that requires youtube.DownloaderService
+//		// and then make assertions.
 //
-// 	}
+//	}
 type DownloaderServiceMock struct {
 	// GetFunc mocks the Get method.
 	GetFunc func(ctx context.Context, id string, fname string) (string, error)
 
+	// InfoFunc mocks the Info method.
+	InfoFunc func(ctx context.Context, id string) (ytfeed.Entry, error)
+
+	// ProbeFunc mocks the Probe method.
+	ProbeFunc func(ctx context.Context, id string) (ytfeed.Meta, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Get holds details about calls to the Get method.
@@ -38,8 +52,24 @@ type DownloaderServiceMock struct {
 			// Fname is the fname argument value.
 			Fname string
 		}
+		// Info holds details about calls to the Info method.
+		Info []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
+		// Probe holds details about calls to the Probe method.
+		Probe []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
 	}
-	lockGet sync.RWMutex
+	lockGet   sync.RWMutex
+	lockInfo  sync.RWMutex
+	lockProbe sync.RWMutex
 }
 
 // Get calls GetFunc.
@@ -64,7 +94,8 @@ func (mock *DownloaderServiceMock) Get(ctx context.Context, id string, fname str
 
 // GetCalls gets all the calls that were made to Get.
 // Check the length with:
-//     len(mockedDownloaderService.GetCalls())
+//
+//	len(mockedDownloaderService.GetCalls())
 func (mock *DownloaderServiceMock) GetCalls() []struct {
 	Ctx   context.Context
 	ID    string
@@ -80,3 +111,75 @@ func (mock *DownloaderServiceMock) GetCalls() []struct {
 	mock.lockGet.RUnlock()
 	return calls
 }
+
+// Info calls InfoFunc.
+func (mock *DownloaderServiceMock) Info(ctx context.Context, id string) (ytfeed.Entry, error) {
+	if mock.InfoFunc == nil {
+		panic("DownloaderServiceMock.InfoFunc: method is nil but DownloaderService.Info was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockInfo.Lock()
+	mock.calls.Info = append(mock.calls.Info, callInfo)
+	mock.lockInfo.Unlock()
+	return mock.InfoFunc(ctx, id)
+}
+
+// InfoCalls gets all the calls that were made to Info.
+// Check the length with:
+//
+//	len(mockedDownloaderService.InfoCalls())
+func (mock *DownloaderServiceMock) InfoCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockInfo.RLock()
+	calls = mock.calls.Info
+	mock.lockInfo.RUnlock()
+	return calls
+}
+
+// Probe calls ProbeFunc.
+func (mock *DownloaderServiceMock) Probe(ctx context.Context, id string) (ytfeed.Meta, error) {
+	if mock.ProbeFunc == nil {
+		panic("DownloaderServiceMock.ProbeFunc: method is nil but DownloaderService.Probe was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockProbe.Lock()
+	mock.calls.Probe = append(mock.calls.Probe, callInfo)
+	mock.lockProbe.Unlock()
+	return mock.ProbeFunc(ctx, id)
+}
+
+// ProbeCalls gets all the calls that were made to Probe.
+// Check the length with:
+//
+//	len(mockedDownloaderService.ProbeCalls())
+func (mock *DownloaderServiceMock) ProbeCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockProbe.RLock()
+	calls = mock.calls.Probe
+	mock.lockProbe.RUnlock()
+	return calls
+}
@@ -12,43 +12,79 @@ import (
 
 // StoreServiceMock is a mock implementation of youtube.StoreService.
 //
-// 	func TestSomethingThatUsesStoreService(t *testing.T) {
+//	func TestSomethingThatUsesStoreService(t *testing.T) {
 //
-// 		// make and configure a mocked youtube.StoreService
-// 		mockedStoreService := &StoreServiceMock{
-// 			CheckProcessedFunc: func(entry ytfeed.Entry) (bool, time.Time, error) {
-// 				panic("mock out the CheckProcessed method")
-// 			},
-// 			CountProcessedFunc: func() int {
-// 				panic("mock out the CountProcessed method")
-// 			},
-// 			ExistFunc: func(entry ytfeed.Entry) (bool, error) {
-// 				panic("mock out the Exist method")
-// 			},
-// 			LoadFunc: func(channelID string, max int) ([]ytfeed.Entry, error) {
-// 				panic("mock out the Load method")
-// 			},
-// 			RemoveFunc: func(entry ytfeed.Entry) error {
-// 				panic("mock out the Remove method")
-// 			},
-// 			RemoveOldFunc: func(channelID string, keep int) ([]string, error) {
-// 				panic("mock out the RemoveOld method")
-// 			},
-// 			ResetProcessedFunc: func(entry ytfeed.Entry) error {
-// 				panic("mock out the ResetProcessed method")
-// 			},
-// 			SaveFunc: func(entry ytfeed.Entry) (bool, error) {
-// 				panic("mock out the Save method")
-// 			},
-// 			SetProcessedFunc: func(entry ytfeed.Entry) error {
-// 				panic("mock out the SetProcessed method")
-// 			},
-// 		}
+//		// make and configure a mocked youtube.StoreService
+//		mockedStoreService := &StoreServiceMock{
+//			CheckProcessedFunc: func(entry ytfeed.Entry) (bool, time.Time, error) {
+//				panic("mock out the CheckProcessed method")
+//			},
+//			CountProcessedFunc: func() int {
+//				panic("mock out the CountProcessed method")
+//			},
+//			ExistFunc: func(entry ytfeed.Entry) (bool, error) {
+//				panic("mock out the Exist method")
+//			},
+//			FilesFunc: func() ([]string, error) {
+//				panic("mock out the Files method")
+//			},
+//			FindFileFunc: func(file string) ([]ytfeed.Entry, error) {
+//				panic("mock out the FindFile method")
+//			},
+//			FindVideoFunc: func(videoID string) ([]ytfeed.Entry, error) {
+//				panic("mock out the FindVideo method")
+//			},
+//			GetFailureFunc: func(entry ytfeed.Entry) (ytfeed.Failure, bool, error) {
+//				panic("mock out the GetFailure method")
+//			},
+//			GetResolvedFunc: func(ref string) (ytfeed.Resolved, bool, error) {
+//				panic("mock out the GetResolved method")
+//			},
+//			ListChannelsFunc: func() (map[string][]byte, error) {
+//				panic("mock out the ListChannels method")
+//			},
+//			LoadFunc: func(channelID string, max int) ([]ytfeed.Entry, error) {
+//				panic("mock out the Load method")
+//			},
+//			PurgeChannelFunc: func(channelID string) ([]string, error) {
+//				panic("mock out the PurgeChannel method")
+//			},
+//			RemoveFunc: func(entry ytfeed.Entry) error {
+//				panic("mock out the Remove method")
+//			},
+//			RemoveChannelFunc: func(id string) error {
+//				panic("mock out the RemoveChannel method")
+//			},
+//			RemoveFailureFunc: func(entry ytfeed.Entry) error {
+//				panic("mock out the RemoveFailure method")
+//			},
+//			RemoveOldFunc: func(channelID string, keep int) ([]string, error) {
+//				panic("mock out the RemoveOld method")
+//			},
+//			ResetProcessedFunc: func(entry ytfeed.Entry) error {
+//				panic("mock out the ResetProcessed method")
+//			},
+//			SaveFunc: func(entry ytfeed.Entry) (bool, error) {
+//				panic("mock out the Save method")
+//			},
+//			SetChannelFunc: func(id string, data []byte) error {
+//				panic("mock out the SetChannel method")
+//			},
+//			SetFailureFunc: func(failure ytfeed.Failure) error {
+//				panic("mock out the SetFailure method")
+//			},
+//			SetProcessedFunc: func(entry ytfeed.Entry) error {
+//				panic("mock out the SetProcessed method")
+//			},
+//			SetResolvedFunc: func(ref string, res ytfeed.Resolved) error {
+//				panic("mock out the SetResolved method")
+//			},
+//		}
 //
-// 		// use mockedStoreService in code that requires youtube.StoreService
-// 		// and then make assertions.
+//		// use mockedStoreService in code that requires youtube.StoreService
+//		// and then make assertions.
 //
-// 	}
+//	}
 type StoreServiceMock struct {
 	// CheckProcessedFunc mocks the CheckProcessed method.
 	CheckProcessedFunc func(entry ytfeed.Entry) (bool, time.Time, error)
@@ -59,12 +95,39 @@ type StoreServiceMock struct {
 	// ExistFunc mocks the Exist method.
 	ExistFunc func(entry ytfeed.Entry) (bool, error)
 
+	// FilesFunc mocks the Files method.
+	FilesFunc func() ([]string, error)
+
+	// FindFileFunc mocks the FindFile method.
+	FindFileFunc func(file string) ([]ytfeed.Entry, error)
+
+	// FindVideoFunc mocks the FindVideo method.
+	FindVideoFunc func(videoID string) ([]ytfeed.Entry, error)
+
+	// GetFailureFunc mocks the GetFailure method.
+	GetFailureFunc func(entry ytfeed.Entry) (ytfeed.Failure, bool, error)
+
+	// GetResolvedFunc mocks the GetResolved method.
+	GetResolvedFunc func(ref string) (ytfeed.Resolved, bool, error)
+
+	// ListChannelsFunc mocks the ListChannels method.
+	ListChannelsFunc func() (map[string][]byte, error)
+
 	// LoadFunc mocks the Load method.
 	LoadFunc func(channelID string, max int) ([]ytfeed.Entry, error)
 
+	// PurgeChannelFunc mocks the PurgeChannel method.
+	PurgeChannelFunc func(channelID string) ([]string, error)
+
 	// RemoveFunc mocks the Remove method.
 	RemoveFunc func(entry ytfeed.Entry) error
 
+	// RemoveChannelFunc mocks the RemoveChannel method.
+	RemoveChannelFunc func(id string) error
+
+	// RemoveFailureFunc mocks the RemoveFailure method.
+	RemoveFailureFunc func(entry ytfeed.Entry) error
+
 	// RemoveOldFunc mocks the RemoveOld method.
 	RemoveOldFunc func(channelID string, keep int) ([]string, error)
 
@@ -74,9 +137,18 @@ type StoreServiceMock struct {
 	// SaveFunc mocks the Save method.
 	SaveFunc func(entry ytfeed.Entry) (bool, error)
 
+	// SetChannelFunc mocks the SetChannel method.
+	SetChannelFunc func(id string, data []byte) error
+
+	// SetFailureFunc mocks the SetFailure method.
+	SetFailureFunc func(failure ytfeed.Failure) error
+
 	// SetProcessedFunc mocks the SetProcessed method.
 	SetProcessedFunc func(entry ytfeed.Entry) error
 
+	// SetResolvedFunc mocks the SetResolved method.
+	SetResolvedFunc func(ref string, res ytfeed.Resolved) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// CheckProcessed holds details about calls to the CheckProcessed method.
@@ -92,6 +164,32 @@ type StoreServiceMock struct {
 			// Entry is the entry argument value.
 			Entry ytfeed.Entry
 		}
+		// Files holds details about calls to the Files method.
+		Files []struct {
+		}
+		// FindFile holds details about calls to the FindFile method.
+		FindFile []struct {
+			// File is the file argument value.
+			File string
+		}
+		// FindVideo holds details about calls to the FindVideo method.
+		FindVideo []struct {
+			// VideoID is the videoID argument value.
+			VideoID string
+		}
+		// GetFailure holds details about calls to the GetFailure method.
+		GetFailure []struct {
+			// Entry is the entry argument value.
+			Entry ytfeed.Entry
+		}
+		// GetResolved holds details about calls to the GetResolved method.
+		GetResolved []struct {
+			// Ref is the ref argument value.
+			Ref string
+		}
+		// ListChannels holds details about calls to the ListChannels method.
+		ListChannels []struct {
+		}
 		// Load holds details about calls to the Load method.
 		Load []struct {
 			// ChannelID is the channelID argument value.
@@ -99,11 +197,26 @@ type StoreServiceMock struct {
 			// Max is the max argument value.
 			Max int
 		}
+		// PurgeChannel holds details about calls to the PurgeChannel method.
+		PurgeChannel []struct {
+			// ChannelID is the channelID argument value.
+			ChannelID string
+		}
 		// Remove holds details about calls to the Remove method.
 		Remove []struct {
 			// Entry is the entry argument value.
 			Entry ytfeed.Entry
 		}
+		// RemoveChannel holds details about calls to the RemoveChannel method.
+		RemoveChannel []struct {
+			// ID is the id argument value.
+			ID string
+		}
+		// RemoveFailure holds details about calls to the RemoveFailure method.
+		RemoveFailure []struct {
+			// Entry is the entry argument value.
+			Entry ytfeed.Entry
+		}
 		// RemoveOld holds details about calls to the RemoveOld method.
 		RemoveOld []struct {
 			// ChannelID is the channelID argument value.
@@ -121,21 +234,52 @@ type StoreServiceMock struct {
 			// Entry is the entry argument value.
 			Entry ytfeed.Entry
 		}
+		// SetChannel holds details about calls to the SetChannel method.
+		SetChannel []struct {
+			// ID is the id argument value.
+			ID string
+			// Data is the data argument value.
+			Data []byte
+		}
+		// SetFailure holds details about calls to the SetFailure method.
+		SetFailure []struct {
+			// Failure is the failure argument value.
+			Failure ytfeed.Failure
+		}
 		// SetProcessed holds details about calls to the SetProcessed method.
 		SetProcessed []struct {
 			// Entry is the entry argument value.
 			Entry ytfeed.Entry
 		}
+		// SetResolved holds details about calls to the SetResolved method.
+		SetResolved []struct {
+			// Ref is the ref argument value.
+			Ref string
+			// Res is the res argument value.
+			Res ytfeed.Resolved
+		}
 	}
 	lockCheckProcessed sync.RWMutex
 	lockCountProcessed sync.RWMutex
 	lockExist          sync.RWMutex
+	lockFiles          sync.RWMutex
+	lockFindFile       sync.RWMutex
+	lockFindVideo      sync.RWMutex
+	lockGetFailure     sync.RWMutex
+	lockGetResolved    sync.RWMutex
+	lockListChannels   sync.RWMutex
 	lockLoad           sync.RWMutex
+	lockPurgeChannel   sync.RWMutex
 	lockRemove         sync.RWMutex
+	lockRemoveChannel  sync.RWMutex
+	lockRemoveFailure  sync.RWMutex
 	lockRemoveOld      sync.RWMutex
 	lockResetProcessed sync.RWMutex
 	lockSave           sync.RWMutex
+	lockSetChannel     sync.RWMutex
+	lockSetFailure     sync.RWMutex
 	lockSetProcessed   sync.RWMutex
+	lockSetResolved    sync.RWMutex
 }
 
 // CheckProcessed calls CheckProcessedFunc.
@@ -156,7 +300,8 @@ func (mock *StoreServiceMock) CheckProcessed(entry ytfeed.Entry) (bool, time.Tim
 
 // CheckProcessedCalls gets all the calls that were made to CheckProcessed.
 // Check the length with:
-//     len(mockedStoreService.CheckProcessedCalls())
+//
+//	len(mockedStoreService.CheckProcessedCalls())
 func (mock *StoreServiceMock) CheckProcessedCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -184,7 +329,8 @@ func (mock *StoreServiceMock) CountProcessed() int {
 
 // CountProcessedCalls gets all the calls that were made to CountProcessed.
 // Check the length with:
-//     len(mockedStoreService.CountProcessedCalls())
+//
+//	len(mockedStoreService.CountProcessedCalls())
 func (mock *StoreServiceMock) CountProcessedCalls() []struct {
 } {
 	var calls []struct {
@@ -213,7 +359,8 @@ func (mock *StoreServiceMock) Exist(entry ytfeed.Entry) (bool, error) {
 
 // ExistCalls gets all the calls that were made to Exist.
 // Check the length with:
-//     len(mockedStoreService.ExistCalls())
+//
+//	len(mockedStoreService.ExistCalls())
 func (mock *StoreServiceMock) ExistCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -226,6 +373,188 @@ func (mock *StoreServiceMock) ExistCalls() []struct {
 	return calls
 }
 
+// Files calls FilesFunc.
+func (mock *StoreServiceMock) Files() ([]string, error) {
+	if mock.FilesFunc == nil {
+		panic("StoreServiceMock.FilesFunc: method is nil but StoreService.Files was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockFiles.Lock()
+	mock.calls.Files = append(mock.calls.Files, callInfo)
+	mock.lockFiles.Unlock()
+	return mock.FilesFunc()
+}
+
+// FilesCalls gets all the calls that were made to Files.
+// Check the length with:
+//
+//	len(mockedStoreService.FilesCalls())
+func (mock *StoreServiceMock) FilesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockFiles.RLock()
+	calls = mock.calls.Files
+	mock.lockFiles.RUnlock()
+	return calls
+}
+
+// FindFile calls FindFileFunc.
+func (mock *StoreServiceMock) FindFile(file string) ([]ytfeed.Entry, error) {
+	if mock.FindFileFunc == nil {
+		panic("StoreServiceMock.FindFileFunc: method is nil but StoreService.FindFile was just called")
+	}
+	callInfo := struct {
+		File string
+	}{
+		File: file,
+	}
+	mock.lockFindFile.Lock()
+	mock.calls.FindFile = append(mock.calls.FindFile, callInfo)
+	mock.lockFindFile.Unlock()
+	return mock.FindFileFunc(file)
+}
+
+// FindFileCalls gets all the calls that were made to FindFile.
+// Check the length with:
+//
+//	len(mockedStoreService.FindFileCalls())
+func (mock *StoreServiceMock) FindFileCalls() []struct {
+	File string
+} {
+	var calls []struct {
+		File string
+	}
+	mock.lockFindFile.RLock()
+	calls = mock.calls.FindFile
+	mock.lockFindFile.RUnlock()
+	return calls
+}
+
+// FindVideo calls FindVideoFunc.
+func (mock *StoreServiceMock) FindVideo(videoID string) ([]ytfeed.Entry, error) {
+	if mock.FindVideoFunc == nil {
+		panic("StoreServiceMock.FindVideoFunc: method is nil but StoreService.FindVideo was just called")
+	}
+	callInfo := struct {
+		VideoID string
+	}{
+		VideoID: videoID,
+	}
+	mock.lockFindVideo.Lock()
+	mock.calls.FindVideo = append(mock.calls.FindVideo, callInfo)
+	mock.lockFindVideo.Unlock()
+	return mock.FindVideoFunc(videoID)
+}
+
+// FindVideoCalls gets all the calls that were made to FindVideo.
+// Check the length with:
+//
+//	len(mockedStoreService.FindVideoCalls())
+func (mock *StoreServiceMock) FindVideoCalls() []struct {
+	VideoID string
+} {
+	var calls []struct {
+		VideoID string
+	}
+	mock.lockFindVideo.RLock()
+	calls = mock.calls.FindVideo
+	mock.lockFindVideo.RUnlock()
+	return calls
+}
+
+// GetFailure calls GetFailureFunc.
+func (mock *StoreServiceMock) GetFailure(entry ytfeed.Entry) (ytfeed.Failure, bool, error) {
+	if mock.GetFailureFunc == nil {
+		panic("StoreServiceMock.GetFailureFunc: method is nil but StoreService.GetFailure was just called")
+	}
+	callInfo := struct {
+		Entry ytfeed.Entry
+	}{
+		Entry: entry,
+	}
+	mock.lockGetFailure.Lock()
+	mock.calls.GetFailure = append(mock.calls.GetFailure, callInfo)
+	mock.lockGetFailure.Unlock()
+	return mock.GetFailureFunc(entry)
+}
+
+// GetFailureCalls gets all the calls that were made to GetFailure.
+// Check the length with:
+//
+//	len(mockedStoreService.GetFailureCalls())
+func (mock *StoreServiceMock) GetFailureCalls() []struct {
+	Entry ytfeed.Entry
+} {
+	var calls []struct {
+		Entry ytfeed.Entry
+	}
+	mock.lockGetFailure.RLock()
+	calls = mock.calls.GetFailure
+	mock.lockGetFailure.RUnlock()
+	return calls
+}
+
+// GetResolved calls GetResolvedFunc.
+func (mock *StoreServiceMock) GetResolved(ref string) (ytfeed.Resolved, bool, error) {
+	if mock.GetResolvedFunc == nil {
+		panic("StoreServiceMock.GetResolvedFunc: method is nil but StoreService.GetResolved was just called")
+	}
+	callInfo := struct {
+		Ref string
+	}{
+		Ref: ref,
+	}
+	mock.lockGetResolved.Lock()
+	mock.calls.GetResolved = append(mock.calls.GetResolved, callInfo)
+	mock.lockGetResolved.Unlock()
+	return mock.GetResolvedFunc(ref)
+}
+
+// GetResolvedCalls gets all the calls that were made to GetResolved.
+// Check the length with:
+//
+//	len(mockedStoreService.GetResolvedCalls())
+func (mock *StoreServiceMock) GetResolvedCalls() []struct {
+	Ref string
+} {
+	var calls []struct {
+		Ref string
+	}
+	mock.lockGetResolved.RLock()
+	calls = mock.calls.GetResolved
+	mock.lockGetResolved.RUnlock()
+	return calls
+}
+
+// ListChannels calls ListChannelsFunc.
+func (mock *StoreServiceMock) ListChannels() (map[string][]byte, error) {
+	if mock.ListChannelsFunc == nil {
+		panic("StoreServiceMock.ListChannelsFunc: method is nil but StoreService.ListChannels was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListChannels.Lock()
+	mock.calls.ListChannels = append(mock.calls.ListChannels, callInfo)
+	mock.lockListChannels.Unlock()
+	return mock.ListChannelsFunc()
+}
+
+// ListChannelsCalls gets all the calls that were made to ListChannels.
+// Check the length with:
+//
+//	len(mockedStoreService.ListChannelsCalls())
+func (mock *StoreServiceMock) ListChannelsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListChannels.RLock()
+	calls = mock.calls.ListChannels
+	mock.lockListChannels.RUnlock()
+	return calls
+}
+
 // Load calls LoadFunc.
 func (mock *StoreServiceMock) Load(channelID string, max int) ([]ytfeed.Entry, error) {
 	if mock.LoadFunc == nil {
@@ -246,7 +575,8 @@ func (mock *StoreServiceMock) Load(channelID string, max int) ([]ytfeed.Entry, e
 
 // LoadCalls gets all the calls that were made to Load.
 // Check the length with:
-//     len(mockedStoreService.LoadCalls())
+//
+//	len(mockedStoreService.LoadCalls())
 func (mock *StoreServiceMock) LoadCalls() []struct {
 	ChannelID string
 	Max       int
@@ -261,6 +591,38 @@ func (mock *StoreServiceMock) LoadCalls() []struct {
 	return calls
 }
 
+// PurgeChannel calls PurgeChannelFunc.
+func (mock *StoreServiceMock) PurgeChannel(channelID string) ([]string, error) {
+	if mock.PurgeChannelFunc == nil {
+		panic("StoreServiceMock.PurgeChannelFunc: method is nil but StoreService.PurgeChannel was just called")
+	}
+	callInfo := struct {
+		ChannelID string
+	}{
+		ChannelID: channelID,
+	}
+	mock.lockPurgeChannel.Lock()
+	mock.calls.PurgeChannel = append(mock.calls.PurgeChannel, callInfo)
+	mock.lockPurgeChannel.Unlock()
+	return mock.PurgeChannelFunc(channelID)
+}
+
+// PurgeChannelCalls gets all the calls that were made to PurgeChannel.
+// Check the length with:
+//
+//	len(mockedStoreService.PurgeChannelCalls())
+func (mock *StoreServiceMock) PurgeChannelCalls() []struct {
+	ChannelID string
+} {
+	var calls []struct {
+		ChannelID string
+	}
+	mock.lockPurgeChannel.RLock()
+	calls = mock.calls.PurgeChannel
+	mock.lockPurgeChannel.RUnlock()
+	return calls
+}
+
 // Remove calls RemoveFunc.
 func (mock *StoreServiceMock) Remove(entry ytfeed.Entry) error {
 	if mock.RemoveFunc == nil {
@@ -279,7 +641,8 @@ func (mock *StoreServiceMock) Remove(entry ytfeed.Entry) error {
 
 // RemoveCalls gets all the calls that were made to Remove.
 // Check the length with:
-//     len(mockedStoreService.RemoveCalls())
+//
+//	len(mockedStoreService.RemoveCalls())
 func (mock *StoreServiceMock) RemoveCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -292,6 +655,70 @@ func (mock *StoreServiceMock) RemoveCalls() []struct {
 	return calls
 }
 
+// RemoveChannel calls RemoveChannelFunc.
+func (mock *StoreServiceMock) RemoveChannel(id string) error {
+	if mock.RemoveChannelFunc == nil {
+		panic("StoreServiceMock.RemoveChannelFunc: method is nil but StoreService.RemoveChannel was just called")
+	}
+	callInfo := struct {
+		ID string
+	}{
+		ID: id,
+	}
+	mock.lockRemoveChannel.Lock()
+	mock.calls.RemoveChannel = append(mock.calls.RemoveChannel, callInfo)
+	mock.lockRemoveChannel.Unlock()
+	return mock.RemoveChannelFunc(id)
+}
+
+// RemoveChannelCalls gets all the calls that were made to RemoveChannel.
+// Check the length with:
+//
+//	len(mockedStoreService.RemoveChannelCalls())
+func (mock *StoreServiceMock) RemoveChannelCalls() []struct {
+	ID string
+} {
+	var calls []struct {
+		ID string
+	}
+	mock.lockRemoveChannel.RLock()
+	calls = mock.calls.RemoveChannel
+	mock.lockRemoveChannel.RUnlock()
+	return calls
+}
+
+// RemoveFailure calls RemoveFailureFunc.
+func (mock *StoreServiceMock) RemoveFailure(entry ytfeed.Entry) error {
+	if mock.RemoveFailureFunc == nil {
+		panic("StoreServiceMock.RemoveFailureFunc: method is nil but StoreService.RemoveFailure was just called")
+	}
+	callInfo := struct {
+		Entry ytfeed.Entry
+	}{
+		Entry: entry,
+	}
+	mock.lockRemoveFailure.Lock()
+	mock.calls.RemoveFailure = append(mock.calls.RemoveFailure, callInfo)
+	mock.lockRemoveFailure.Unlock()
+	return mock.RemoveFailureFunc(entry)
+}
+
+// RemoveFailureCalls gets all the calls that were made to RemoveFailure.
+// Check the length with:
+//
+//	len(mockedStoreService.RemoveFailureCalls())
+func (mock *StoreServiceMock) RemoveFailureCalls() []struct {
+	Entry ytfeed.Entry
+} {
+	var calls []struct {
+		Entry ytfeed.Entry
+	}
+	mock.lockRemoveFailure.RLock()
+	calls = mock.calls.RemoveFailure
+	mock.lockRemoveFailure.RUnlock()
+	return calls
+}
+
 // RemoveOld calls RemoveOldFunc.
 func (mock *StoreServiceMock) RemoveOld(channelID string, keep int) ([]string, error) {
 	if mock.RemoveOldFunc == nil {
@@ -312,7 +739,8 @@ func (mock *StoreServiceMock) RemoveOld(channelID string, keep int) ([]string, e
 
 // RemoveOldCalls gets all the calls that were made to RemoveOld.
 // Check the length with:
-//     len(mockedStoreService.RemoveOldCalls())
+//
+//	len(mockedStoreService.RemoveOldCalls())
 func (mock *StoreServiceMock) RemoveOldCalls() []struct {
 	ChannelID string
 	Keep      int
@@ -345,7 +773,8 @@ func (mock *StoreServiceMock) ResetProcessed(entry ytfeed.Entry) error {
 
 // ResetProcessedCalls gets all the calls that were made to ResetProcessed.
 // Check the length with:
-//     len(mockedStoreService.ResetProcessedCalls())
+//
+//	len(mockedStoreService.ResetProcessedCalls())
 func (mock *StoreServiceMock) ResetProcessedCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -376,7 +805,8 @@ func (mock *StoreServiceMock) Save(entry ytfeed.Entry) (bool, error) {
 
 // SaveCalls gets all the calls that were made to Save.
 // Check the length with:
-//     len(mockedStoreService.SaveCalls())
+//
+//	len(mockedStoreService.SaveCalls())
 func (mock *StoreServiceMock) SaveCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -389,6 +819,74 @@ func (mock *StoreServiceMock) SaveCalls() []struct {
 	return calls
 }
 
+// SetChannel calls SetChannelFunc.
+func (mock *StoreServiceMock) SetChannel(id string, data []byte) error {
+	if mock.SetChannelFunc == nil {
+		panic("StoreServiceMock.SetChannelFunc: method is nil but StoreService.SetChannel was just called")
+	}
+	callInfo := struct {
+		ID   string
+		Data []byte
+	}{
+		ID:   id,
+		Data: data,
+	}
+	mock.lockSetChannel.Lock()
+	mock.calls.SetChannel = append(mock.calls.SetChannel, callInfo)
+	mock.lockSetChannel.Unlock()
+	return mock.SetChannelFunc(id, data)
+}
+
+// SetChannelCalls gets all the calls that were made to SetChannel.
+// Check the length with:
+//
+//	len(mockedStoreService.SetChannelCalls())
+func (mock *StoreServiceMock) SetChannelCalls() []struct {
+	ID   string
+	Data []byte
+} {
+	var calls []struct {
+		ID   string
+		Data []byte
+	}
+	mock.lockSetChannel.RLock()
+	calls = mock.calls.SetChannel
+	mock.lockSetChannel.RUnlock()
+	return calls
+}
+
+// SetFailure calls SetFailureFunc.
+func (mock *StoreServiceMock) SetFailure(failure ytfeed.Failure) error {
+	if mock.SetFailureFunc == nil {
+		panic("StoreServiceMock.SetFailureFunc: method is nil but StoreService.SetFailure was just called")
+	}
+	callInfo := struct {
+		Failure ytfeed.Failure
+	}{
+		Failure: failure,
+	}
+	mock.lockSetFailure.Lock()
+	mock.calls.SetFailure = append(mock.calls.SetFailure, callInfo)
+	mock.lockSetFailure.Unlock()
+	return mock.SetFailureFunc(failure)
+}
+
+// SetFailureCalls gets all the calls that were made to SetFailure.
+// Check the length with:
+//
+//	len(mockedStoreService.SetFailureCalls())
+func (mock *StoreServiceMock) SetFailureCalls() []struct {
+	Failure ytfeed.Failure
+} {
+	var calls []struct {
+		Failure ytfeed.Failure
+	}
+	mock.lockSetFailure.RLock()
+	calls = mock.calls.SetFailure
+	mock.lockSetFailure.RUnlock()
+	return calls
+}
+
 // SetProcessed calls SetProcessedFunc.
 func (mock *StoreServiceMock) SetProcessed(entry ytfeed.Entry) error {
 	if mock.SetProcessedFunc == nil {
@@ -407,7 +905,8 @@ func (mock *StoreServiceMock) SetProcessed(entry ytfeed.Entry) error {
 
 // SetProcessedCalls gets all the calls that were made to SetProcessed.
 // Check the length with:
-//     len(mockedStoreService.SetProcessedCalls())
+//
+//	len(mockedStoreService.SetProcessedCalls())
 func (mock *StoreServiceMock) SetProcessedCalls() []struct {
 	Entry ytfeed.Entry
 } {
@@ -419,3 +918,39 @@ func (mock *StoreServiceMock) SetProcessedCalls() []struct {
 	mock.lockSetProcessed.RUnlock()
 	return calls
 }
+
+// SetResolved calls SetResolvedFunc.
+func (mock *StoreServiceMock) SetResolved(ref string, res ytfeed.Resolved) error {
+	if mock.SetResolvedFunc == nil {
+		panic("StoreServiceMock.SetResolvedFunc: method is nil but StoreService.SetResolved was just called")
+	}
+	callInfo := struct {
+		Ref string
+		Res ytfeed.Resolved
+	}{
+		Ref: ref,
+		Res: res,
+	}
+	mock.lockSetResolved.Lock()
+	mock.calls.SetResolved = append(mock.calls.SetResolved, callInfo)
+	mock.lockSetResolved.Unlock()
+	return mock.SetResolvedFunc(ref, res)
+}
+
+// SetResolvedCalls gets all the calls that were made to SetResolved.
+// Check the length with:
+//
+//	len(mockedStoreService.SetResolvedCalls())
+func (mock *StoreServiceMock) SetResolvedCalls() []struct {
+	Ref string
+	Res ytfeed.Resolved
+} {
+	var calls []struct {
+		Ref string
+		Res ytfeed.Resolved
+	}
+	mock.lockSetResolved.RLock()
+	calls = mock.calls.SetResolved
+	mock.lockSetResolved.RUnlock()
+	return calls
+}
@@ -0,0 +1,100 @@
+package youtube
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// PublishedMode defines how published time of the downloaded entry is set
+type PublishedMode string
+
+// enum of published time modes
+const (
+	PMDefault  = PublishedMode("")         // same as clamped
+	PMClamped  = PublishedMode("clamped")  // download time for videos published within the window, original for older
+	PMOriginal = PublishedMode("original") // published time from the source
+	PMDownload = PublishedMode("download") // time of the download
+)
+
+// defaultPublishedWindow is the window of clamped mode if not set for the channel
+const defaultPublishedWindow = 24 * time.Hour
+
+// titleData is passed to the title template, has all entry's fields and the name of the channel
+type titleData struct {
+	ytfeed.Entry
+	Channel string
+}
+
+// entryTitle returns entry's title made with channel's title template. Without template the title is prefixed
+// with channel name, unless the title already contains it.
+func entryTitle(entry ytfeed.Entry, fi FeedInfo) string {
+	name := fi.Name
+	if fi.Type == ytfeed.FTManual {
+		name = entry.Author.Name // manual feed collects videos of different channels
+	}
+	if fi.TitleTemplate != "" {
+		title, err := execTitleTemplate(fi.TitleTemplate, titleData{Entry: entry, Channel: name})
+		if err == nil {
+			return title
+		}
+		log.Printf("[WARN] failed to make title for %s with template %q, %v", entry.VideoID, fi.TitleTemplate, err)
+	}
+	if !strings.Contains(entry.Title, name) {
+		return name + ": " + entry.Title
+	}
+	return entry.Title
+}
+
+// publishedTime returns published time of the downloaded entry for channel's published mode
+func publishedTime(entry ytfeed.Entry, fi FeedInfo) time.Time {
+	switch fi.Published {
+	case PMOriginal:
+		return entry.Published
+	case PMDownload:
+		return time.Now()
+	}
+	window := fi.PublishedWindow
+	if window <= 0 {
+		window = defaultPublishedWindow
+	}
+	// only reset time if published not too long ago
+	// this is done to avoid initial set of entries added with a new channel to the top of the feed
+	if time.Since(entry.Published) < window {
+		return time.Now()
+	}
+	return entry.Published
+}
+
+// validatePolicies checks title template and published mode of the channel
+func validatePolicies(fi FeedInfo) error {
+	switch fi.Published {
+	case PMDefault, PMClamped, PMOriginal, PMDownload:
+	default:
+		return fmt.Errorf("unknown published mode %q", fi.Published)
+	}
+	if fi.TitleTemplate == "" {
+		return nil
+	}
+	if _, err := execTitleTemplate(fi.TitleTemplate, titleData{Channel: fi.Name}); err != nil {
+		return fmt.Errorf("invalid title template: %w", err)
+	}
+	return nil
+}
+
+func execTitleTemplate(tmpl string, data titleData) (string, error) {
+	t, err := template.New("title").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse title template: %w", err)
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute title template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
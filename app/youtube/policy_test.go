@@ -0,0 +1,71 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+func TestEntryTitle(t *testing.T) {
+	entry := ytfeed.Entry{VideoID: "vid1", Title: "some title", Published: time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)}
+	entry.Author.Name = "author1"
+
+	tbl := []struct {
+		name string
+		fi   FeedInfo
+		res  string
+	}{
+		{name: "default prefix", fi: FeedInfo{Name: "chan1"}, res: "chan1: some title"},
+		{name: "manual feed", fi: FeedInfo{Name: "Manual", Type: ytfeed.FTManual}, res: "author1: some title"},
+		{name: "title only", fi: FeedInfo{Name: "chan1", TitleTemplate: "{{.Title}}"}, res: "some title"},
+		{name: "with date", fi: FeedInfo{Name: "chan1", TitleTemplate: `{{.Published.Format "2006-01-02"}} {{.Title}} ({{.Channel}})`},
+			res: "2024-03-05 some title (chan1)"},
+		{name: "author", fi: FeedInfo{Name: "chan1", TitleTemplate: "{{.Author.Name}} - {{.Title}}"}, res: "author1 - some title"},
+		{name: "bad template, default", fi: FeedInfo{Name: "chan1", TitleTemplate: "{{.Unknown}}"}, res: "chan1: some title"},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.res, entryTitle(entry, tt.fi))
+		})
+	}
+}
+
+func TestPublishedTime(t *testing.T) {
+	recent, old := time.Now().Add(-time.Hour), time.Now().Add(-72*time.Hour)
+
+	tbl := []struct {
+		name      string
+		fi        FeedInfo
+		published time.Time
+		reset     bool
+	}{
+		{name: "default, recent", fi: FeedInfo{}, published: recent, reset: true},
+		{name: "default, old", fi: FeedInfo{}, published: old, reset: false},
+		{name: "clamped, wide window", fi: FeedInfo{Published: PMClamped, PublishedWindow: 96 * time.Hour}, published: old, reset: true},
+		{name: "clamped, narrow window", fi: FeedInfo{Published: PMClamped, PublishedWindow: 30 * time.Minute}, published: recent},
+		{name: "original", fi: FeedInfo{Published: PMOriginal}, published: recent, reset: false},
+		{name: "download", fi: FeedInfo{Published: PMDownload}, published: old, reset: true},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res := publishedTime(ytfeed.Entry{Published: tt.published}, tt.fi)
+			if tt.reset {
+				assert.WithinDuration(t, time.Now(), res, time.Second)
+				return
+			}
+			assert.Equal(t, tt.published, res)
+		})
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	require.NoError(t, validatePolicies(FeedInfo{Name: "chan1"}))
+	require.NoError(t, validatePolicies(FeedInfo{Name: "chan1", Published: PMOriginal, TitleTemplate: "{{.Channel}} {{.Title}}"}))
+	require.EqualError(t, validatePolicies(FeedInfo{Name: "chan1", Published: "bad"}), `unknown published mode "bad"`)
+	require.ErrorContains(t, validatePolicies(FeedInfo{Name: "chan1", TitleTemplate: "{{.Title"}), "invalid title template")
+	require.ErrorContains(t, validatePolicies(FeedInfo{Name: "chan1", TitleTemplate: "{{.Unknown}}"}), "invalid title template")
+}
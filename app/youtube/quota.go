@@ -0,0 +1,227 @@
+package youtube
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+	"gopkg.in/yaml.v3"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// ByteSize is a size in bytes, set in config as a plain number or with unit suffix, i.e. 500M, 10G or 1.5TB
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	mult   float64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseByteSize parses size with optional unit suffix, units are binary, i.e. 1K is 1024 bytes
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	mult := 1.0
+	for _, u := range byteUnits {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, mult = strings.TrimSpace(num), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(math.Round(v * mult)), nil
+}
+
+// UnmarshalYAML parses size from yaml scalar
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	v, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// UnmarshalJSON parses size from json number or string with unit suffix
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := ParseByteSize(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// String returns human-readable size, i.e. 1.5G
+func (b ByteSize) String() string {
+	for _, u := range byteUnits[4:8] {
+		if float64(b) >= u.mult {
+			return strconv.FormatFloat(float64(b)/u.mult, 'f', 1, 64) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Usage is disk usage of downloaded media files, including thumbnails and subtitles
+type Usage struct {
+	Total    ByteSize            // size of all channels
+	MaxTotal ByteSize            // global quota, zero if not limited
+	Free     ByteSize            // free space in files location, -1 if unknown
+	MinFree  ByteSize            // free space guard, downloads paused below it
+	Channels map[string]ByteSize // size per channel id
+}
+
+// Paused returns true if downloads paused due to low free disk space
+func (u Usage) Paused() bool {
+	return u.MinFree > 0 && u.Free >= 0 && u.Free < u.MinFree
+}
+
+// Usage returns current disk usage of all channels
+func (s *Service) Usage() Usage {
+	res := Usage{MaxTotal: s.MaxTotalSize, MinFree: s.MinFreeSpace, Free: s.freeSpace(),
+		Channels: map[string]ByteSize{}}
+	for _, fi := range s.Channels() {
+		entries, err := s.Store.Load(fi.ID, s.keep(fi))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			size := s.entrySize(entry)
+			res.Channels[fi.ID] += size
+			res.Total += size
+		}
+	}
+	return res
+}
+
+// evictByQuota removes the oldest entries of channels exceeding their own quota, and then the oldest entries
+// across all channels if the total size exceeds the global quota. The newest video of each channel always kept.
+// Evicted entries removed with Store.RemoveOld, so they stay processed and won't be downloaded again.
+// Returns the number of removed entries per channel, for all channels, not only the processed ones.
+func (s *Service) evictByQuota() map[string]int {
+	type sizedEntry struct {
+		ytfeed.Entry
+		size ByteSize
+		pos  int // position of the video in the channel, 0 for the newest, parts of the split video share it
+	}
+
+	keepN := map[string]int{}      // number of the newest videos to keep per channel
+	loaded := map[string]int{}     // number of loaded videos per channel
+	feeds := map[string]FeedInfo{} // feeds by id
+	var all []sizedEntry
+	for _, fi := range s.Channels() {
+		entries, err := s.Store.Load(fi.ID, s.keep(fi))
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+		feeds[fi.ID] = fi
+		var chanSize ByteSize
+		pos := -1
+		for i, entry := range entries {
+			if i == 0 || !sameSplitVideo(entries[i-1], entry) {
+				pos++ // parts of the split video kept or evicted together, as store's RemoveOld does
+			}
+			all = append(all, sizedEntry{Entry: entry, size: s.entrySize(entry), pos: pos})
+		}
+		loaded[fi.ID], keepN[fi.ID] = pos+1, pos+1
+		for _, e := range all[len(all)-len(entries):] {
+			chanSize += e.size
+			if fi.MaxSize > 0 && chanSize > fi.MaxSize && e.pos > 0 {
+				keepN[fi.ID] = e.pos
+				break
+			}
+		}
+	}
+
+	if s.MaxTotalSize > 0 {
+		kept := all[:0] // entries over channel's quota evicted anyway, not counted in total
+		for _, e := range all {
+			if e.pos < keepN[e.ChannelID] {
+				kept = append(kept, e)
+			}
+		}
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Published.After(kept[j].Published) })
+		var total ByteSize
+		for _, e := range kept {
+			total += e.size
+			if total > s.MaxTotalSize && e.pos > 0 && e.pos < keepN[e.ChannelID] {
+				keepN[e.ChannelID] = e.pos
+			}
+		}
+	}
+
+	res := map[string]int{}
+	for id, keep := range keepN {
+		if keep >= loaded[id] {
+			continue
+		}
+		fi := feeds[id]
+		files, err := s.Store.RemoveOld(id, keep)
+		if err != nil { // even with error we get a list of files to remove
+			log.Printf("[WARN] failed to remove some entries over quota for %s, %v", id, err)
+		}
+		log.Printf("[INFO] %d entries of %s (%s) over quota, keep %d", len(files), id, fi.Name, keep)
+		res[id] = s.removeFiles(fi, files)
+	}
+	return res
+}
+
+// sameSplitVideo checks if both entries are parts of the same split video
+func sameSplitVideo(a, b ytfeed.Entry) bool {
+	return a.Part > 0 && b.Part > 0 && a.ChannelID == b.ChannelID && a.VideoID == b.VideoID
+}
+
+// freeSpace returns free space in files location, -1 if unknown
+func (s *Service) freeSpace() ByteSize {
+	if s.DiskSpace == nil || s.FilesLocation == "" {
+		return -1
+	}
+	free, err := s.DiskSpace.Free(s.FilesLocation)
+	if err != nil {
+		log.Printf("[WARN] failed to get free space for %s: %v", s.FilesLocation, err)
+		return -1
+	}
+	return ByteSize(free)
+}
+
+// lowDiskSpace returns true if free space in files location is below the guard
+func (s *Service) lowDiskSpace() bool {
+	if s.MinFreeSpace <= 0 {
+		return false
+	}
+	free := s.freeSpace()
+	if free >= 0 && free < s.MinFreeSpace {
+		log.Printf("[WARN] free space %s in %s is below %s, downloads paused", free, s.FilesLocation, s.MinFreeSpace)
+		return true
+	}
+	return false
+}
+
+// entrySize returns the size of entry's audio file with all sidecar files
+func (s *Service) entrySize(entry ytfeed.Entry) (res ByteSize) {
+	if entry.File == "" {
+		return 0
+	}
+	for _, f := range append([]string{entry.File}, s.sidecarFiles(entry.File)...) {
+		if fi, err := os.Stat(f); err == nil {
+			res += ByteSize(fi.Size())
+		}
+	}
+	return res
+}
@@ -0,0 +1,287 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tbl := []struct {
+		in  string
+		res ByteSize
+		err bool
+	}{
+		{"", 0, false},
+		{"1024", 1024, false},
+		{"100B", 100, false},
+		{"2K", 2048, false},
+		{"500M", 500 << 20, false},
+		{"500mb", 500 << 20, false},
+		{"10G", 10 << 30, false},
+		{"1.5GB", 3 << 29, false},
+		{" 2 TB ", 2 << 40, false},
+		{"abc", 0, true},
+		{"-1G", 0, true},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.in, func(t *testing.T) {
+			res, err := ParseByteSize(tt.in)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.res, res)
+		})
+	}
+}
+
+func TestByteSize_UnmarshalYAML(t *testing.T) {
+	var v struct {
+		Size  ByteSize `yaml:"size"`
+		Plain ByteSize `yaml:"plain"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte("size: 5G\nplain: 12345"), &v))
+	assert.Equal(t, ByteSize(5<<30), v.Size)
+	assert.Equal(t, ByteSize(12345), v.Plain)
+
+	require.Error(t, yaml.Unmarshal([]byte("size: bad"), &v))
+}
+
+func TestByteSize_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		Size  ByteSize `json:"size"`
+		Plain ByteSize `json:"plain"`
+		Null  ByteSize `json:"null"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"size":"5G","plain":12345,"null":null}`), &v))
+	assert.Equal(t, ByteSize(5<<30), v.Size)
+	assert.Equal(t, ByteSize(12345), v.Plain)
+	assert.Equal(t, ByteSize(0), v.Null)
+
+	require.Error(t, json.Unmarshal([]byte(`{"size":"bad"}`), &v))
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "100B", ByteSize(100).String())
+	assert.Equal(t, "1.5K", ByteSize(1536).String())
+	assert.Equal(t, "500.0M", ByteSize(500<<20).String())
+	assert.Equal(t, "1.5G", ByteSize(3<<29).String())
+	assert.Equal(t, "2.0T", ByteSize(2<<40).String())
+}
+
+// quotaStore makes bolt store with entries of given sizes for each channel, entries published an hour apart,
+// the first one is the newest. Each entry gets audio file and thumbnail of 10 bytes.
+func quotaStore(t *testing.T, sizes map[string][]int) *store.BoltDB {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	chans := slices.Sorted(maps.Keys(sizes))
+	for offset, chanID := range chans { // channels offset by a minute to make the order of entries stable
+		for i, size := range sizes[chanID] {
+			file := filepath.Join(tempDir, fmt.Sprintf("%s-%d.mp3", chanID, i))
+			require.NoError(t, os.WriteFile(file, make([]byte, size), 0o600))
+			require.NoError(t, os.WriteFile(thumbFile(file), make([]byte, 10), 0o600))
+			entry := ytfeed.Entry{ChannelID: chanID, VideoID: fmt.Sprintf("%s-%d", chanID, i), File: file,
+				Published: base.Add(-time.Duration(i)*time.Hour - time.Duration(offset)*time.Minute)}
+			_, err = boltStore.Save(entry)
+			require.NoError(t, err)
+		}
+	}
+	return boltStore
+}
+
+func TestService_evictByQuota(t *testing.T) {
+	t.Run("no quotas", func(t *testing.T) {
+		boltStore := quotaStore(t, map[string][]int{"ch1": {100, 100, 100}})
+		svc := Service{Feeds: []FeedInfo{{ID: "ch1"}}, Store: boltStore, KeepPerChannel: 10}
+		assert.Empty(t, svc.evictByQuota())
+		assert.Equal(t, ByteSize(330), svc.Usage().Total)
+	})
+
+	t.Run("channel quota", func(t *testing.T) {
+		boltStore := quotaStore(t, map[string][]int{"ch1": {100, 100, 100, 100}, "ch2": {1000, 1000}})
+		svc := Service{Feeds: []FeedInfo{{ID: "ch1", MaxSize: 250}, {ID: "ch2", MaxSize: 500}}, Store: boltStore,
+			KeepPerChannel: 10}
+		assert.Equal(t, map[string]int{"ch1": 2, "ch2": 1}, svc.evictByQuota())
+
+		res, err := boltStore.Load("ch1", 10)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		assert.Equal(t, "ch1-0", res[0].VideoID)
+		assert.Equal(t, "ch1-1", res[1].VideoID)
+		_, err = os.Stat(filepath.Join(filepath.Dir(res[0].File), "ch1-2.mp3"))
+		assert.True(t, os.IsNotExist(err), "audio file removed")
+		_, err = os.Stat(filepath.Join(filepath.Dir(res[0].File), "ch1-2.jpg"))
+		assert.True(t, os.IsNotExist(err), "thumbnail removed")
+
+		res, err = boltStore.Load("ch2", 10)
+		require.NoError(t, err)
+		require.Len(t, res, 1, "the newest entry kept even above quota")
+
+		usage := svc.Usage()
+		assert.Equal(t, ByteSize(220), usage.Channels["ch1"])
+		assert.Equal(t, ByteSize(1010), usage.Channels["ch2"])
+		assert.Equal(t, ByteSize(1230), usage.Total)
+	})
+
+	t.Run("total quota", func(t *testing.T) {
+		boltStore := quotaStore(t, map[string][]int{"ch1": {100, 100, 100}, "ch2": {100, 100, 100}})
+		svc := Service{Feeds: []FeedInfo{{ID: "ch1"}, {ID: "ch2"}}, Store: boltStore, KeepPerChannel: 10,
+			MaxTotalSize: 450}
+		assert.Equal(t, map[string]int{"ch1": 1, "ch2": 1}, svc.evictByQuota(), "the oldest across channels evicted")
+		assert.Equal(t, ByteSize(440), svc.Usage().Total)
+
+		svc.MaxTotalSize = 10
+		assert.Equal(t, map[string]int{"ch1": 1, "ch2": 1}, svc.evictByQuota())
+		assert.Equal(t, ByteSize(220), svc.Usage().Total, "the newest entry of each channel kept")
+	})
+
+	t.Run("evicted stay processed", func(t *testing.T) {
+		boltStore := quotaStore(t, map[string][]int{"ch1": {100, 100}})
+		require.NoError(t, boltStore.SetProcessed(ytfeed.Entry{ChannelID: "ch1", VideoID: "ch1-1"}))
+		svc := Service{Feeds: []FeedInfo{{ID: "ch1", MaxSize: 150}}, Store: boltStore, KeepPerChannel: 10}
+		assert.Equal(t, map[string]int{"ch1": 1}, svc.evictByQuota())
+		found, _, err := boltStore.CheckProcessed(ytfeed.Entry{ChannelID: "ch1", VideoID: "ch1-1"})
+		require.NoError(t, err)
+		assert.True(t, found)
+	})
+}
+
+func TestService_evictByQuotaSplitVideo(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	save := func(entry ytfeed.Entry) {
+		entry.File = filepath.Join(tempDir, entry.VideoID+strconv.Itoa(entry.Part)+".mp3")
+		require.NoError(t, os.WriteFile(entry.File, make([]byte, 100), 0o600))
+		_, err := boltStore.Save(entry)
+		require.NoError(t, err)
+	}
+	save(ytfeed.Entry{ChannelID: "ch1", VideoID: "old", Published: base.Add(-time.Hour)})
+	for k := 1; k <= 3; k++ {
+		save(ytfeed.Entry{ChannelID: "ch1", VideoID: "split", Part: k, Parts: 3, Published: base.Add(time.Duration(k) * time.Second)})
+	}
+
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", MaxSize: 350}}, Store: boltStore, KeepPerChannel: 10}
+	assert.Equal(t, map[string]int{"ch1": 1}, svc.evictByQuota(), "the old one evicted")
+	res, err := boltStore.Load("ch1", 10)
+	require.NoError(t, err)
+	assert.Len(t, res, 3, "parts of the newest video kept")
+
+	svc.Feeds[0].MaxSize = 150
+	assert.Empty(t, svc.evictByQuota(), "the newest video kept as a whole even above quota")
+}
+
+func TestService_procFeedsEvictedRSS(t *testing.T) {
+	tempDir := t.TempDir()
+	boltStore := quotaStore(t, map[string][]int{"ch1": {100, 100}, "ch2": {100, 100, 100}})
+	svc := Service{
+		Feeds:          []FeedInfo{{ID: "ch1", Name: "name1"}, {ID: "ch2", Name: "name2", MaxSize: 150}},
+		ChannelService: &mocks.ChannelServiceMock{GetFunc: func(context.Context, string, ytfeed.Type) ([]ytfeed.Entry, error) { return nil, nil }},
+		Store:          boltStore,
+		KeepPerChannel: 10,
+		RSSFileStore:   RSSFileStore{Enabled: true, Location: tempDir},
+	}
+
+	require.NoError(t, svc.procFeeds(context.Background(), []FeedInfo{svc.Feeds[0]}), "only ch1 processed, i.e. on push")
+	res, err := boltStore.Load("ch2", 10)
+	require.NoError(t, err)
+	assert.Len(t, res, 1, "evicted over channel's quota")
+	rss, err := os.ReadFile(filepath.Join(tempDir, "ch2.xml"))
+	require.NoError(t, err, "rss of evicted channel updated")
+	assert.Equal(t, 1, strings.Count(string(rss), "<item>"))
+	_, err = os.Stat(filepath.Join(tempDir, "ch1.xml"))
+	assert.True(t, os.IsNotExist(err), "nothing changed in ch1")
+}
+
+func TestService_Usage(t *testing.T) {
+	boltStore := quotaStore(t, map[string][]int{"ch1": {100}})
+	disk := &mocks.DiskSpaceServiceMock{FreeFunc: func(string) (int64, error) { return 1000, nil }}
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1"}}, Store: boltStore, KeepPerChannel: 10, DiskSpace: disk,
+		FilesLocation: "/some/dir", MaxTotalSize: 5000, MinFreeSpace: 500}
+
+	usage := svc.Usage()
+	assert.Equal(t, Usage{Total: 110, MaxTotal: 5000, Free: 1000, MinFree: 500, Channels: map[string]ByteSize{"ch1": 110}}, usage)
+	assert.False(t, usage.Paused())
+	assert.Equal(t, "/some/dir", disk.FreeCalls()[0].Path)
+
+	svc.MinFreeSpace = 2000
+	assert.True(t, svc.Usage().Paused())
+
+	svc.DiskSpace = nil
+	assert.Equal(t, ByteSize(-1), svc.Usage().Free)
+	assert.False(t, svc.Usage().Paused(), "unknown free space doesn't pause")
+}
+
+func TestService_procChannelsLowDiskSpace(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{
+				{ChannelID: chanID, VideoID: chanID + "-1", Title: "first", Published: time.Now()},
+				{ChannelID: chanID, VideoID: chanID + "-2", Title: "second", Published: time.Now().Add(-time.Minute)},
+			}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	free := int64(2000)
+	disk := &mocks.DiskSpaceServiceMock{FreeFunc: func(string) (int64, error) {
+		free -= 600 // each check after a download shows less space
+		return free, nil
+	}}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "ch1", Name: "name1"}, {ID: "ch2", Name: "name2"}},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DiskSpace:       disk,
+		FilesLocation:   tempDir,
+		MinFreeSpace:    1000,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 3600 }},
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.GetCalls(), 1, "paused after the first download")
+	assert.Len(t, chans.GetCalls(), 1, "the next channel not processed")
+
+	found, _, err := boltStore.CheckProcessed(ytfeed.Entry{ChannelID: "ch1", VideoID: "ch1-2"})
+	require.NoError(t, err)
+	assert.False(t, found, "not downloaded entry will be retried")
+}
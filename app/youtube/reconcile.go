@@ -0,0 +1,219 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// reconcileExts are extensions of downloaded audio and its sidecar files, other files are never removed by reconciler
+var reconcileExts = []string{".mp3", ".jpg", ".vtt", ".srt", ".txt"}
+
+// tempSuffixes are suffixes of audio files made by download and post-processing, left over if interrupted
+var tempSuffixes = []string{".tmp", ".refresh"}
+
+// hashNameRe matches names made by makeFileName, sha1 in hex
+var hashNameRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// reconcileGrace is the minimal age of a file to be considered orphan, newer files may belong to in-progress downloads
+const reconcileGrace = time.Hour
+
+// ReconcileReport is the result of the reconciliation of files location with the store
+type ReconcileReport struct {
+	Time     time.Time        `json:"time"`
+	DryRun   bool             `json:"dry_run"`
+	Orphans  []string         `json:"orphans"`  // files not referenced by any entry, removed unless dry run
+	Repaired []ReconcileEntry `json:"repaired"` // entries with file moved to files location, updated unless dry run
+	Requeued []ReconcileEntry `json:"requeued"` // entries with missing file, removed to download again unless dry run
+	Errors   []string         `json:"errors,omitempty"`
+}
+
+// ReconcileEntry is an entry with missing file found by reconciler
+type ReconcileEntry struct {
+	ChannelID string `json:"channel_id"`
+	VideoID   string `json:"video_id"`
+	Title     string `json:"title"`
+	File      string `json:"file"`
+}
+
+// Reconcile compares files location with the store. It removes orphan files not referenced by entries of configured
+// channels, and fixes entries with missing files. Only audio and sidecar files named by hash, as downloaded ones are,
+// can be removed, and files with the same name as the file of any stored entry are kept. Temporary audio files left by
+// interrupted downloads and refreshes removed as well. Files newer than reconcileGrace are never removed. Entry with the file found in files location under the same name
+// updated with the new path, other entries removed and reset to be downloaded again. With dryRun nothing is changed,
+// the report shows what would be done.
+func (s *Service) Reconcile(dryRun bool) (ReconcileReport, error) {
+	s.reconcileLock.Lock()
+	defer s.reconcileLock.Unlock()
+
+	report := ReconcileReport{Time: time.Now(), DryRun: dryRun, Orphans: []string{},
+		Repaired: []ReconcileEntry{}, Requeued: []ReconcileEntry{}}
+	if s.FilesLocation == "" {
+		return report, errors.New("files location is not set")
+	}
+	files, err := os.ReadDir(s.FilesLocation)
+	if err != nil {
+		return report, fmt.Errorf("failed to read files location %s: %w", s.FilesLocation, err)
+	}
+
+	referenced := map[string]bool{}
+	reference := func(file string) {
+		for _, f := range append([]string{file}, s.sidecarFiles(file)...) {
+			referenced[absPath(f)] = true
+		}
+	}
+	addErr := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		log.Printf("[WARN] reconcile, %s", msg)
+		report.Errors = append(report.Errors, msg)
+	}
+
+	for _, fi := range s.Channels() {
+		entries, loadErr := s.Store.Load(fi.ID, math.MaxInt32)
+		if loadErr != nil {
+			continue // no bucket for a channel without entries
+		}
+		changed := false
+		for _, entry := range entries {
+			if entry.File == "" {
+				continue
+			}
+			if _, statErr := os.Stat(entry.File); statErr == nil {
+				reference(entry.File)
+				continue
+			}
+
+			item := ReconcileEntry{ChannelID: entry.ChannelID, VideoID: entry.VideoID, Title: entry.Title, File: entry.File}
+			moved := filepath.Join(s.FilesLocation, filepath.Base(entry.File))
+			if _, statErr := os.Stat(moved); statErr == nil && absPath(moved) != absPath(entry.File) {
+				reference(moved)
+				report.Repaired = append(report.Repaired, item)
+				if dryRun {
+					continue
+				}
+				if err := s.repairEntry(entry, moved); err != nil {
+					addErr("failed to repair %s: %v", entry.VideoID, err)
+					continue
+				}
+				changed = true
+				continue
+			}
+
+			report.Requeued = append(report.Requeued, item)
+			if dryRun {
+				continue
+			}
+			if err := s.Store.Remove(entry); err != nil {
+				addErr("failed to remove %s: %v", entry.VideoID, err)
+				continue
+			}
+			if err := s.Store.ResetProcessed(entry); err != nil {
+				addErr("failed to reset processed %s: %v", entry.VideoID, err)
+			}
+			changed = true
+		}
+		if changed {
+			s.saveRSS(fi)
+		}
+	}
+
+	// files of entries in any bucket kept, i.e. of channels deleted without purge
+	storedFiles, err := s.Store.Files()
+	if err != nil {
+		return report, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	stored := map[string]bool{}
+	for _, f := range storedFiles {
+		stored[fileStem(f)] = true
+	}
+
+	for _, f := range files {
+		temp := tempFile(f.Name())
+		if f.IsDir() || (!reconcilable(f.Name()) && !temp) {
+			continue
+		}
+		file := filepath.Join(s.FilesLocation, f.Name())
+		if !temp && (referenced[absPath(file)] || stored[fileStem(file)]) {
+			continue
+		}
+		info, infoErr := f.Info()
+		if infoErr != nil || time.Since(info.ModTime()) < reconcileGrace {
+			continue
+		}
+		report.Orphans = append(report.Orphans, file)
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			addErr("failed to remove orphan file %s: %v", file, err)
+		}
+	}
+
+	log.Printf("[INFO] reconciled %s, dry run: %v, orphans: %d, repaired: %d, requeued: %d, errors: %d",
+		s.FilesLocation, dryRun, len(report.Orphans), len(report.Repaired), len(report.Requeued), len(report.Errors))
+	s.lastReconcile = &report
+	return report, nil
+}
+
+// LastReconcile returns the report of the last reconciliation, false if it wasn't run yet
+func (s *Service) LastReconcile() (ReconcileReport, bool) {
+	s.reconcileLock.Lock()
+	defer s.reconcileLock.Unlock()
+	if s.lastReconcile == nil {
+		return ReconcileReport{}, false
+	}
+	return *s.lastReconcile, true
+}
+
+// repairEntry replaces entry's file with the new one, entry is re-saved as Save doesn't override existing entries
+func (s *Service) repairEntry(entry ytfeed.Entry, file string) error {
+	if err := s.Store.Remove(entry); err != nil {
+		return fmt.Errorf("failed to remove entry: %w", err)
+	}
+	entry.File = file
+	if _, err := s.Store.Save(entry); err != nil {
+		return fmt.Errorf("failed to save entry: %w", err)
+	}
+	log.Printf("[INFO] repaired %s, file %s", entry.VideoID, file)
+	return nil
+}
+
+// reconcilable checks if the file is audio or sidecar file named by hash, as downloaded files are
+func reconcilable(name string) bool {
+	return slices.Contains(reconcileExts, filepath.Ext(name)) && hashNameRe.MatchString(fileStem(name))
+}
+
+// tempFile checks if the file is a temporary audio file named by hash, e.g. hash.tmp.mp3 or hash.refresh.mp3
+func tempFile(name string) bool {
+	if filepath.Ext(name) != ".mp3" {
+		return false
+	}
+	stem, found := fileStem(name), false
+	for slices.Contains(tempSuffixes, filepath.Ext(stem)) {
+		stem, found = strings.TrimSuffix(stem, filepath.Ext(stem)), true
+	}
+	return found && hashNameRe.MatchString(stem)
+}
+
+// fileStem returns the base name of the file without extension
+func fileStem(file string) string {
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func absPath(file string) string {
+	if res, err := filepath.Abs(file); err == nil {
+		return res
+	}
+	return filepath.Clean(file)
+}
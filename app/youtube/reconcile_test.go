@@ -0,0 +1,143 @@
+package youtube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/umputun/feed-master/app/youtube/store"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+func TestTempFile(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	assert.True(t, tempFile(hash+".tmp.mp3"))
+	assert.True(t, tempFile(hash+".refresh.mp3"))
+	assert.True(t, tempFile(hash+".refresh.tmp.mp3"))
+	assert.False(t, tempFile(hash+".mp3"))
+	assert.False(t, tempFile(hash+".tmp.jpg"))
+	assert.False(t, tempFile("notes.tmp.mp3"))
+}
+
+func TestService_Reconcile(t *testing.T) {
+	tempDir := t.TempDir()
+	filesDir := filepath.Join(tempDir, "yt")
+	require.NoError(t, os.MkdirAll(filesDir, 0o750))
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	old := time.Now().Add(-2 * time.Hour)
+	writeFile := func(name string) string {
+		f := filepath.Join(filesDir, name)
+		require.NoError(t, os.WriteFile(f, []byte("data"), 0o600))
+		require.NoError(t, os.Chtimes(f, old, old))
+		return f
+	}
+
+	// entry with the file and thumbnail in place
+	good := ytfeed.Entry{ChannelID: "ch1", VideoID: "good", File: writeFile("good.mp3"), Published: old}
+	writeFile("good.jpg")
+	// entry with the file moved from the old location
+	moved := ytfeed.Entry{ChannelID: "ch1", VideoID: "moved", File: filepath.Join(tempDir, "old", "moved.mp3"),
+		Published: old.Add(time.Minute)}
+	writeFile("moved.mp3")
+	// entry with the lost file
+	lost := ytfeed.Entry{ChannelID: "ch1", VideoID: "lost", File: filepath.Join(filesDir, "lost.mp3"),
+		Published: old.Add(2 * time.Minute)}
+	for _, e := range []ytfeed.Entry{good, moved, lost} {
+		_, err = boltStore.Save(e)
+		require.NoError(t, err)
+		require.NoError(t, boltStore.SetProcessed(e))
+	}
+	orphan := writeFile("0123456789abcdef0123456789abcdef01234567.mp3")
+	orphanThumb := writeFile("0123456789abcdef0123456789abcdef01234567.jpg")
+	// files of the user, rss file and files of the channel deleted without purge kept
+	userFiles := []string{writeFile("notes.mp3"), writeFile("ch1.xml"), writeFile("0123456789abcdef0123456789abcdef01234567.mp4"),
+		writeFile("89abcdef0123456789abcdef0123456789abcdef.mp3"), writeFile("89abcdef0123456789abcdef0123456789abcdef.srt")}
+	_, err = boltStore.Save(ytfeed.Entry{ChannelID: "deleted", VideoID: "vid1", Published: old,
+		File: filepath.Join(tempDir, "other", "89abcdef0123456789abcdef0123456789abcdef.mp3")})
+	require.NoError(t, err)
+	inProgress := filepath.Join(filesDir, "downloading.mp3.part")
+	require.NoError(t, os.WriteFile(inProgress, []byte("data"), 0o600))
+	// leftovers of interrupted download and refresh removed, even for the stored file, in-flight refresh kept
+	leftovers := []string{writeFile("0123456789abcdef0123456789abcdef01234567.tmp.mp3"),
+		writeFile("89abcdef0123456789abcdef0123456789abcdef.refresh.mp3"),
+		writeFile("89abcdef0123456789abcdef0123456789abcdef.refresh.tmp.mp3")}
+	refreshing := filepath.Join(filesDir, "fedcba9876543210fedcba9876543210fedcba98.refresh.mp3")
+	require.NoError(t, os.WriteFile(refreshing, []byte("data"), 0o600))
+
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", Name: "name1"}}, Store: boltStore, FilesLocation: filesDir}
+	_, ok := svc.LastReconcile()
+	assert.False(t, ok)
+
+	// dry run reports without changes
+	report, err := svc.Reconcile(true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.ElementsMatch(t, append([]string{orphan, orphanThumb}, leftovers...), report.Orphans)
+	require.Len(t, report.Repaired, 1)
+	assert.Equal(t, "moved", report.Repaired[0].VideoID)
+	require.Len(t, report.Requeued, 1)
+	assert.Equal(t, "lost", report.Requeued[0].VideoID)
+	assert.Empty(t, report.Errors)
+	assert.FileExists(t, orphan)
+	entries, err := boltStore.Load("ch1", 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	last, ok := svc.LastReconcile()
+	assert.True(t, ok)
+	assert.Equal(t, report, last)
+
+	// real run
+	report, err = svc.Reconcile(false)
+	require.NoError(t, err)
+	assert.False(t, report.DryRun)
+	assert.Len(t, report.Orphans, 5)
+	assert.Len(t, report.Repaired, 1)
+	assert.Len(t, report.Requeued, 1)
+	assert.NoFileExists(t, orphan)
+	assert.NoFileExists(t, orphanThumb)
+	assert.FileExists(t, inProgress, "recent file kept")
+	assert.FileExists(t, refreshing, "recent temp file kept")
+	for _, f := range leftovers {
+		assert.NoFileExists(t, f)
+	}
+	assert.FileExists(t, good.File)
+	assert.FileExists(t, filepath.Join(filesDir, "good.jpg"))
+	for _, f := range userFiles {
+		assert.FileExists(t, f)
+	}
+
+	entries, err = boltStore.Load("ch1", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "moved", entries[0].VideoID)
+	assert.Equal(t, filepath.Join(filesDir, "moved.mp3"), entries[0].File, "file path repaired")
+	assert.Equal(t, "good", entries[1].VideoID)
+
+	processed, _, err := boltStore.CheckProcessed(lost)
+	require.NoError(t, err)
+	assert.False(t, processed, "lost entry re-queued")
+	processed, _, err = boltStore.CheckProcessed(moved)
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	// nothing to do on the next run
+	report, err = svc.Reconcile(false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Orphans)
+	assert.Empty(t, report.Repaired)
+	assert.Empty(t, report.Requeued)
+
+	svc.FilesLocation = filepath.Join(tempDir, "no-such-dir")
+	_, err = svc.Reconcile(false)
+	require.Error(t, err)
+}
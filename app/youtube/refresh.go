@@ -0,0 +1,186 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// removedSegmentsPrefix starts the list of segments cut by SponsorBlock, appended to entry's description
+const removedSegmentsPrefix = "\n\nRemoved segments: "
+
+// removedSegmentsDescription makes the list of removed segments appended to entry's description.
+// Segments escaped, as their categories come from SponsorBlock api.
+func removedSegmentsDescription(removed []string) template.HTML {
+	escaped := make([]string, 0, len(removed))
+	for _, seg := range removed {
+		escaped = append(escaped, template.HTMLEscapeString(seg))
+	}
+	return template.HTML(removedSegmentsPrefix + strings.Join(escaped, ", ")) //nolint:gosec // escaped
+}
+
+// storedSegments returns the list of removed segments from the stored description, unescaped
+func storedSegments(removed string) []string {
+	res := strings.Split(removed, ", ")
+	for i, seg := range res {
+		res[i] = html.UnescapeString(seg)
+	}
+	return res
+}
+
+// RefreshEntry re-fetches metadata of the stored entry and, with redownload, downloads its audio again in place.
+// The entry keeps its id, file name and published time, so podcast apps don't see it as a new episode.
+func (s *Service) RefreshEntry(ctx context.Context, channelID, videoID string, redownload bool) (ytfeed.Entry, error) {
+	fi, ok := s.channel(channelID)
+	if !ok {
+		fi = FeedInfo{ID: channelID}
+	}
+	entries, err := s.Store.Load(channelID, s.keep(fi))
+	if err != nil {
+		return ytfeed.Entry{}, fmt.Errorf("failed to load entries for %s: %w", channelID, err)
+	}
+	// parts of the split video refreshed together, the first refreshed part returned
+	var res ytfeed.Entry
+	found, changed := false, false
+	for i := len(entries) - 1; i >= 0; i-- { // the oldest first, i.e. parts in order
+		entry := entries[i]
+		if entry.VideoID != videoID {
+			continue
+		}
+		refreshed, ok, err := s.refreshEntry(ctx, entry, fi, redownload)
+		if err != nil {
+			if changed {
+				s.saveRSS(fi)
+			}
+			return entry, err
+		}
+		if !found {
+			res, found = refreshed, true
+		}
+		changed = changed || ok
+	}
+	if !found {
+		return ytfeed.Entry{}, fmt.Errorf("entry %s not found in %s", videoID, channelID)
+	}
+	if changed {
+		s.saveRSS(fi)
+	}
+	return res, nil
+}
+
+// refreshRecent re-fetches metadata of entries published within RefreshAge, to pick up fixed titles and descriptions
+func (s *Service) refreshRecent(ctx context.Context) {
+	refreshed := 0
+	for _, fi := range s.Channels() {
+		entries, err := s.Store.Load(fi.ID, s.keep(fi))
+		if err != nil {
+			continue
+		}
+		changed := false
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(entry.Published) > s.RefreshAge {
+				continue
+			}
+			_, ok, err := s.refreshEntry(ctx, entry, fi, false)
+			if err != nil {
+				log.Printf("[WARN] failed to refresh %s: %v", entry.VideoID, err)
+				continue
+			}
+			if ok {
+				changed = true
+				refreshed++
+			}
+		}
+		if changed {
+			s.saveRSS(fi)
+		}
+	}
+	log.Printf("[INFO] refreshed metadata of recent entries, changed: %d", refreshed)
+}
+
+// refreshEntry updates title, description and thumbnail of the entry from the source, and with redownload replaces
+// its file with the new download. Returns true if the entry changed.
+func (s *Service) refreshEntry(ctx context.Context, entry ytfeed.Entry, fi FeedInfo, redownload bool) (ytfeed.Entry, bool, error) {
+	if redownload && entry.Parts > 0 {
+		return entry, false, fmt.Errorf("can't re-download part %d/%d of %s, remove the entry to download it again",
+			entry.Part, entry.Parts, entry.VideoID)
+	}
+	ref := entry.Link.Href // page url works for any source, including videos added manually
+	if ref == "" {
+		ref = videoRef(entry, fi)
+	}
+	info, err := s.Downloader.Info(ctx, ref)
+	if err != nil {
+		return entry, false, fmt.Errorf("failed to get info for %s: %w", entry.VideoID, err)
+	}
+
+	res := entry
+	description, removedSegments, _ := strings.Cut(string(entry.Media.Description), removedSegmentsPrefix)
+	if info.Title != "" {
+		res.Title = info.Title
+		if res.Author.Name == "" {
+			res.Author = info.Author
+		}
+		res.Title = partTitle(entryTitle(res, fi), res.Part, res.Parts)
+	}
+	if info.Media.Description != "" {
+		description = string(info.Media.Description)
+	}
+	if info.Media.Thumbnail.URL != "" {
+		res.Media.Thumbnail.URL = info.Media.Thumbnail.URL
+	}
+	res.Meta = info.Meta
+	res.Media.Description = template.HTML(description) //nolint:gosec // from the source, as for new entries
+
+	if redownload {
+		// download to a temporary name first, the current file is kept if download failed
+		file, dlErr := s.Downloader.Get(ctx, ref, s.makeFileName(entry)+".refresh")
+		if dlErr != nil {
+			return entry, false, fmt.Errorf("failed to download %s: %w", entry.VideoID, dlErr)
+		}
+		res = s.processFile(ctx, file, ref, res, fi) // segments cut again and listed in the description
+		target := entry.File
+		if target == "" {
+			target = filepath.Join(filepath.Dir(file), s.makeFileName(entry)+".mp3")
+		}
+		if err := os.Rename(file, target); err != nil {
+			return entry, false, fmt.Errorf("failed to replace %s with %s: %w", target, file, err)
+		}
+		res.File = target
+		res.Duration = s.DurationService.File(target)
+		log.Printf("[INFO] re-downloaded %s (%s) to %s", entry.VideoID, res.Title, target)
+	} else if removedSegments != "" {
+		res.Media.Description += removedSegmentsDescription(storedSegments(removedSegments))
+	}
+	if res.File != "" && (redownload || res.Title != entry.Title) {
+		if tagsErr := s.updateMp3Tags(res.File, res, fi); tagsErr != nil {
+			log.Printf("[WARN] failed to update metadata for %s: %s", entry.VideoID, tagsErr)
+		}
+	}
+
+	if reflect.DeepEqual(res, entry) {
+		return entry, false, nil
+	}
+	// entry is re-saved as Save doesn't override existing entries, the key is the same as published time kept
+	if err := s.Store.Remove(entry); err != nil {
+		return entry, false, fmt.Errorf("failed to remove entry %s: %w", entry.VideoID, err)
+	}
+	if _, err := s.Store.Save(res); err != nil {
+		return entry, false, fmt.Errorf("failed to save entry %s: %w", entry.VideoID, err)
+	}
+	log.Printf("[INFO] refreshed %s, title %q", entry.VideoID, res.Title)
+	return res, true, nil
+}
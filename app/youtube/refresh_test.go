@@ -0,0 +1,139 @@
+package youtube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_RefreshEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	published := time.Now().Add(-time.Hour).Truncate(time.Second)
+	file := filepath.Join(tempDir, "vid1.mp3")
+	require.NoError(t, os.WriteFile(file, []byte("truncated"), 0o600))
+	entry := ytfeed.Entry{ChannelID: "ch1", VideoID: "vid1", Title: "name1: typo titel", Published: published,
+		File: file, Duration: 10}
+	entry.Link.Href = "https://www.youtube.com/watch?v=vid1"
+	entry.Media.Description = "old description" + removedSegmentsPrefix + "sponsor 1:00-2:00"
+	_, err = boltStore.Save(entry)
+	require.NoError(t, err)
+
+	downloader := &mocks.DownloaderServiceMock{
+		InfoFunc: func(context.Context, string) (ytfeed.Entry, error) {
+			res := ytfeed.Entry{VideoID: "vid1", Title: "typo title", Published: time.Now()}
+			res.Media.Description = "new description"
+			return res, nil
+		},
+		GetFunc: func(_ context.Context, _, fname string) (string, error) {
+			f := filepath.Join(tempDir, fname+".mp3")
+			return f, os.WriteFile(f, []byte("full audio"), 0o600)
+		},
+	}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "ch1", Name: "name1"}},
+		Downloader:      downloader,
+		Store:           boltStore,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		KeepPerChannel:  10,
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		res, err := svc.RefreshEntry(context.Background(), "ch1", "vid1", false)
+		require.NoError(t, err)
+		assert.Equal(t, "name1: typo title", res.Title)
+		assert.Equal(t, "new description"+removedSegmentsPrefix+"sponsor 1:00-2:00", string(res.Media.Description),
+			"removed segments kept")
+		assert.Equal(t, 10, res.Duration)
+		assert.Empty(t, downloader.GetCalls())
+
+		entries, err := boltStore.Load("ch1", 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "entry replaced")
+		assert.Equal(t, "name1: typo title", entries[0].Title)
+		assert.True(t, published.Equal(entries[0].Published), "published time kept")
+	})
+
+	t.Run("redownload", func(t *testing.T) {
+		res, err := svc.RefreshEntry(context.Background(), "ch1", "vid1", true)
+		require.NoError(t, err)
+		assert.Equal(t, file, res.File, "file replaced in place")
+		assert.Equal(t, 1234, res.Duration)
+		assert.Equal(t, "new description", string(res.Media.Description))
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(data), "full audio"), "new audio with mp3 tags")
+		assert.NoFileExists(t, filepath.Join(tempDir, svc.makeFileName(entry)+".refresh.mp3"), "temp file renamed")
+		require.Len(t, downloader.GetCalls(), 1)
+		assert.Equal(t, "https://www.youtube.com/watch?v=vid1", downloader.GetCalls()[0].ID)
+
+		entries, err := boltStore.Load("ch1", 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 1234, entries[0].Duration)
+		assert.True(t, published.Equal(entries[0].Published), "published time kept")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := svc.RefreshEntry(context.Background(), "ch1", "vid2", false)
+		require.EqualError(t, err, "entry vid2 not found in ch1")
+	})
+}
+
+func TestService_refreshRecent(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+
+	for i, age := range []time.Duration{time.Hour, 2 * time.Hour, 72 * time.Hour} {
+		entry := ytfeed.Entry{ChannelID: "ch1", VideoID: "vid" + string(rune('1'+i)), Title: "name1: old",
+			Published: time.Now().Add(-age)}
+		_, err = boltStore.Save(entry)
+		require.NoError(t, err)
+	}
+
+	downloader := &mocks.DownloaderServiceMock{
+		InfoFunc: func(_ context.Context, id string) (ytfeed.Entry, error) {
+			if id == "vid2" {
+				return ytfeed.Entry{Title: "old"}, nil // not changed
+			}
+			return ytfeed.Entry{Title: "new"}, nil
+		},
+	}
+	svc := Service{Feeds: []FeedInfo{{ID: "ch1", Name: "name1"}}, Downloader: downloader, Store: boltStore,
+		KeepPerChannel: 10, RefreshAge: 48 * time.Hour}
+	svc.refreshRecent(context.Background())
+
+	require.Len(t, downloader.InfoCalls(), 2, "old entry not refreshed")
+	entries, err := boltStore.Load("ch1", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	titles := map[string]string{}
+	for _, e := range entries {
+		titles[e.VideoID] = e.Title
+	}
+	assert.Equal(t, map[string]string{"vid1": "name1: new", "vid2": "name1: old", "vid3": "name1: old"}, titles)
+}
+
+func TestRemovedSegmentsDescription(t *testing.T) {
+	desc := removedSegmentsDescription([]string{"sponsor 1:00-2:00", "<script>x</script> 3:00-4:00"})
+	assert.Equal(t, removedSegmentsPrefix+"sponsor 1:00-2:00, &lt;script&gt;x&lt;/script&gt; 3:00-4:00", string(desc))
+
+	_, removed, ok := strings.Cut(string(desc), removedSegmentsPrefix)
+	require.True(t, ok)
+	assert.Equal(t, desc, removedSegmentsDescription(storedSegments(removed)), "not escaped twice")
+}
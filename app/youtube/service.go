@@ -8,13 +8,16 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bogem/id3v2/v2"
@@ -29,6 +32,14 @@ import (
 //go:generate moq -out mocks/channel.go -pkg mocks -skip-ensure -fmt goimports . ChannelService
 //go:generate moq -out mocks/store.go -pkg mocks -skip-ensure -fmt goimports . StoreService
 //go:generate moq -out mocks/duration.go -pkg mocks -skip-ensure -fmt goimports . DurationService
+//go:generate moq -out mocks/thumbnail.go -pkg mocks -skip-ensure -fmt goimports . ThumbnailService
+//go:generate moq -out mocks/post_processor.go -pkg mocks -skip-ensure -fmt goimports . PostProcessorService
+//go:generate moq -out mocks/sponsor_block.go -pkg mocks -skip-ensure -fmt goimports . SponsorBlockService
+//go:generate moq -out mocks/subtitles.go -pkg mocks -skip-ensure -fmt goimports . SubtitlesService
+//go:generate moq -out mocks/resolver.go -pkg mocks -skip-ensure -fmt goimports . ResolverService
+//go:generate moq -out mocks/listing.go -pkg mocks -skip-ensure -fmt goimports . ListingService
+//go:generate moq -out mocks/disk_space.go -pkg mocks -skip-ensure -fmt goimports . DiskSpaceService
+//go:generate moq -out mocks/websub.go -pkg mocks -skip-ensure -fmt goimports . WebSubService
 
 // Service loads audio from youtube channels
 type Service struct {
@@ -39,34 +50,82 @@ type Service struct {
 	CheckDuration   time.Duration
 	RSSFileStore    RSSFileStore
 	DurationService DurationService
+	Thumbnails      ThumbnailService
+	PostProcessor   PostProcessorService
+	SponsorBlock    SponsorBlockService
+	Subtitles       SubtitlesService
+	Resolver        ResolverService
+	Listing         ListingService
+	DiskSpace       DiskSpaceService
+	WebSub          WebSubService // push notifications of youtube channels, polling only if nil
 	KeepPerChannel  int
+	FilesLocation   string
+	ReservedIDs     []string // ids can't be used by channels added at runtime, i.e. names of proc feeds in the shared db
+	MaxTotalSize    ByteSize // global quota for all channels' files, zero for no limit
+	MinFreeSpace    ByteSize // downloads paused if free space in FilesLocation is below it
 	RootURL         string
 	SkipShorts      time.Duration
+	Probe           bool // probe metadata before download, one more yt-dlp call for each new entry
 
 	YtDlpUpdDuration time.Duration
 	YtDlpUpdCommand  string
 	YtDlpUpdOnStart  bool
+
+	ReconcileInterval time.Duration // periodic reconciliation of files location with the store, disabled if zero
+	ReconcileDryRun   bool          // report only, don't change anything on periodic reconciliation
+
+	RefreshInterval time.Duration // periodic metadata refresh of recent entries, disabled if zero
+	RefreshAge      time.Duration // entries published within this age refreshed periodically
+
+	reconcileLock sync.Mutex
+	lastReconcile *ReconcileReport
+	feedsLock     sync.RWMutex // protects Feeds changed at runtime
+	configured    []FeedInfo   // channels of the config, without runtime changes, set by LoadChannels
+
+	pushLock   sync.Mutex
+	pushed     map[string]bool // channels with push notifications, not processed yet
+	pushSignal chan struct{}
+
+	jobsLock  sync.Mutex
+	jobs      []*Job // queued, running and recently finished jobs, oldest first
+	jobSeq    int
+	jobSignal chan struct{}
 }
 
 // FeedInfo contains channel or feed ID, readable name and other per-feed info
 type FeedInfo struct {
-	Name     string      `yaml:"name"`
-	ID       string      `yaml:"id"`
-	Type     ytfeed.Type `yaml:"type"`
-	Keep     int         `yaml:"keep"`
-	Language string      `yaml:"lang"`
-	Filter   FeedFilter  `yaml:"filter"`
+	Name     string      `yaml:"name" json:"name"`
+	ID       string      `yaml:"id" json:"id"`           // channel or playlist id, @handle or youtube url resolved to id on start
+	Ref      string      `yaml:"-" json:"ref,omitempty"` // original @handle or url, empty if configured with id
+	Type     ytfeed.Type `yaml:"type" json:"type"`
+	URL      string      `yaml:"url" json:"url"` // source url for ytdlp and rss types, i.e. vimeo showcase or soundcloud set
+	Keep     int         `yaml:"keep" json:"keep"`
+	Language string      `yaml:"lang" json:"lang"`
+	Filter   FeedFilter  `yaml:"filter" json:"filter"`
+	MaxSize  ByteSize    `yaml:"max_size" json:"max_size"` // quota for channel's files, i.e. 5G, the oldest entries evicted above it
+	Paused   bool        `yaml:"paused" json:"paused"`     // no downloads for paused channel, its feed still served
+
+	PostProcess  ytfeed.PostProcOpts `yaml:"post_process" json:"post_process"`
+	SponsorBlock []string            `yaml:"sponsorblock" json:"sponsorblock"` // SponsorBlock categories to cut, i.e. sponsor, selfpromo
+	Subtitles    []string            `yaml:"subtitles" json:"subtitles"`       // preferred subtitles languages, i.e. en, en-orig, ru
+	Split        SplitOpts           `yaml:"split" json:"split"`               // split long downloads into parts
+
+	TitleTemplate   string        `yaml:"title_template" json:"title_template"`     // i.e. "{{.Channel}}: {{.Title}}", channel name prefix if empty
+	Published       PublishedMode `yaml:"published" json:"published"`               // published time mode, clamped if empty
+	PublishedWindow time.Duration `yaml:"published_window" json:"published_window"` // window of clamped mode, 24h if zero
 }
 
 // FeedFilter contains filter criteria for the feed
 type FeedFilter struct {
-	Include string `yaml:"include"`
-	Exclude string `yaml:"exclude"`
+	Include string `yaml:"include" json:"include"`
+	Exclude string `yaml:"exclude" json:"exclude"`
 }
 
 // DownloaderService is an interface for downloading audio from youtube
 type DownloaderService interface {
 	Get(ctx context.Context, id string, fname string) (file string, err error)
+	Probe(ctx context.Context, id string) (ytfeed.Meta, error)
+	Info(ctx context.Context, id string) (ytfeed.Entry, error)
 }
 
 // ChannelService is an interface for getting channel entries, i.e. the list of videos
@@ -85,6 +144,18 @@ type StoreService interface {
 	ResetProcessed(entry ytfeed.Entry) error
 	CheckProcessed(entry ytfeed.Entry) (found bool, ts time.Time, err error)
 	CountProcessed() (count int)
+	SetFailure(failure ytfeed.Failure) error
+	GetFailure(entry ytfeed.Entry) (failure ytfeed.Failure, found bool, err error)
+	RemoveFailure(entry ytfeed.Entry) error
+	SetResolved(ref string, res ytfeed.Resolved) error
+	GetResolved(ref string) (res ytfeed.Resolved, found bool, err error)
+	SetChannel(id string, data []byte) error
+	ListChannels() (map[string][]byte, error)
+	RemoveChannel(id string) error
+	PurgeChannel(channelID string) ([]string, error)
+	FindVideo(videoID string) ([]ytfeed.Entry, error)
+	FindFile(file string) ([]ytfeed.Entry, error)
+	Files() ([]string, error)
 }
 
 // DurationService is an interface for getting duration of audio file
@@ -92,23 +163,71 @@ type DurationService interface {
 	File(fname string) int
 }
 
+// ThumbnailService is an interface for downloading video thumbnails next to the audio files
+type ThumbnailService interface {
+	Get(ctx context.Context, imageURL, fname string) (file string, err error)
+}
+
+// PostProcessorService is an interface for altering downloaded audio, i.e. loudness normalization
+type PostProcessorService interface {
+	Process(ctx context.Context, file string, opts ytfeed.PostProcOpts) error
+	Cut(ctx context.Context, file string, segments []ytfeed.Segment) error
+	Split(ctx context.Context, file string, parts []ytfeed.SplitPart) error
+	Silences(ctx context.Context, file string) ([]ytfeed.Segment, error)
+}
+
+// SponsorBlockService is an interface for getting sponsor and other skippable segments of the video
+type SponsorBlockService interface {
+	Segments(ctx context.Context, videoID string, categories []string) ([]ytfeed.Segment, error)
+}
+
+// SubtitlesService is an interface for downloading subtitles, returns language of the stored subtitles
+type SubtitlesService interface {
+	Get(ctx context.Context, id, fname string, langs []string) (lang string, err error)
+}
+
+// ResolverService is an interface for resolving handles and urls into channel or playlist ids
+type ResolverService interface {
+	Resolve(ctx context.Context, ref string) (ytfeed.Resolved, error)
+}
+
+// ListingService is an interface for getting entries of non-youtube sources, i.e. yt-dlp playlists and rss feeds
+type ListingService interface {
+	List(ctx context.Context, id, listURL string, feedType ytfeed.Type) ([]ytfeed.Entry, error)
+}
+
+// DiskSpaceService is an interface for getting free disk space
+type DiskSpaceService interface {
+	Free(path string) (int64, error)
+}
+
+// WebSubService is an interface for WebSub (PubSubHubbub) subscriptions to youtube channels' feeds
+type WebSubService interface {
+	Renew(ctx context.Context, channelIDs []string) error
+	Verify(q url.Values) (challenge string, err error)
+	Parse(body []byte, signature string) ([]ytfeed.Entry, error)
+}
+
 // Do is a blocking function that downloads audio from youtube channels and updates metadata
 func (s *Service) Do(ctx context.Context) error {
 	log.Printf("[INFO] starting youtube service")
 	if s.YtDlpUpdOnStart && s.YtDlpUpdCommand != "" {
 		s.execYtdlpUpdate(ctx, s.YtDlpUpdCommand)
 	}
-	lastYtDlpUpdate := time.Now()
+	lastYtDlpUpdate, lastReconcile, lastRefresh := time.Now(), time.Now(), time.Now()
 	if s.SkipShorts > 0 {
 		log.Printf("[DEBUG] skip youtube episodes shorter than %v", s.SkipShorts)
 	}
-	for _, f := range s.Feeds {
+	for _, f := range s.Channels() {
 		log.Printf("[INFO] youtube feed %+v", f)
 	}
 
 	tick := time.NewTicker(s.CheckDuration)
 	defer tick.Stop()
+	pushed := s.pushCh()
+	jobs := s.jobCh()
 
+	s.renewSubscriptions(ctx)
 	if err := s.procChannels(ctx); err != nil {
 		return fmt.Errorf("failed to process channels: %w", err)
 	}
@@ -117,12 +236,33 @@ func (s *Service) Do(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("youtube service stopped: %w", ctx.Err())
+		case <-pushed:
+			// process channels with new videos right away, polling is a fallback for missed notifications
+			if err := s.procPushed(ctx); err != nil {
+				return fmt.Errorf("failed to process pushed channels: %w", err)
+			}
+		case <-jobs:
+			// downloads requested with api made here, not concurrently with the scheduled processing
+			s.procJobs(ctx)
 		case <-tick.C:
+			s.renewSubscriptions(ctx)
 			if s.YtDlpUpdDuration > 0 && time.Since(lastYtDlpUpdate) > s.YtDlpUpdDuration && s.YtDlpUpdCommand != "" {
 				// update yt-dlp binary once in a while
 				lastYtDlpUpdate = time.Now()
 				s.execYtdlpUpdate(ctx, s.YtDlpUpdCommand)
 			}
+			if s.ReconcileInterval > 0 && time.Since(lastReconcile) > s.ReconcileInterval {
+				// remove orphan files and fix entries with missing files once in a while
+				lastReconcile = time.Now()
+				if _, err := s.Reconcile(s.ReconcileDryRun); err != nil {
+					log.Printf("[WARN] failed to reconcile files: %v", err)
+				}
+			}
+			if s.RefreshInterval > 0 && time.Since(lastRefresh) > s.RefreshInterval {
+				// pick up fixed titles and descriptions of recent entries once in a while
+				lastRefresh = time.Now()
+				s.refreshRecent(ctx)
+			}
 			if err := s.procChannels(ctx); err != nil {
 				return fmt.Errorf("failed to process channels: %w", err)
 			}
@@ -142,6 +282,7 @@ func (s *Service) RSSFeed(fi FeedInfo) (string, error) {
 	}
 
 	items := []rssfeed.Item{}
+	hasTranscripts := false
 	for _, entry := range entries {
 		fileURL := s.RootURL + "/" + path.Base(entry.File)
 
@@ -157,20 +298,30 @@ func (s *Service) RSSFeed(fi FeedInfo) (string, error) {
 			duration = strconv.Itoa(entry.Duration)
 		}
 
+		var image *rssfeed.ItemImage
+		if imageURL := s.imageURL(entry); imageURL != "" {
+			image = &rssfeed.ItemImage{URL: imageURL}
+		}
+
+		transcripts := s.transcripts(entry)
+		hasTranscripts = hasTranscripts || len(transcripts) > 0
+
 		items = append(items, rssfeed.Item{
 			Title:       entry.Title,
 			Description: entry.Media.Description,
 			Link:        entry.Link.Href,
 			PubDate:     entry.Published.In(time.UTC).Format(time.RFC1123Z),
-			GUID:        entry.ChannelID + "::" + entry.VideoID,
+			GUID:        entry.UID(),
 			Author:      entry.Author.Name,
 			Enclosure: rssfeed.Enclosure{
 				URL:    fileURL,
 				Type:   "audio/mpeg",
 				Length: fileSize,
 			},
-			Duration: duration,
-			DT:       time.Now(),
+			Duration:    duration,
+			Image:       image,
+			Transcripts: transcripts,
+			DT:          time.Now(),
 		})
 	}
 
@@ -189,16 +340,22 @@ func (s *Service) RSSFeed(fi FeedInfo) (string, error) {
 		ItunesExplicit: "no",
 	}
 
-	// set image from channel as rss thumbnail
-	// TODO: we may want to load it locally in case if youtube doesn't like such remote usage of images
-	if image := entries[0].Media.Thumbnail.URL; image != "" {
+	// set image from the latest entry as rss thumbnail, local copy preferred
+	if image := s.imageURL(entries[0]); image != "" {
 		rss.ItunesImage = &rssfeed.ItunesImg{URL: image}
 		rss.MediaThumbnail = &rssfeed.MediaThumbnail{URL: image}
 	}
 
+	if hasTranscripts {
+		rss.NsPodcast = "https://podcastindex.org/namespace/1.0"
+	}
+
 	if fi.Type == ytfeed.FTPlaylist {
 		rss.Link = "https://www.youtube.com/playlist?list=" + fi.ID
 	}
+	if !fi.Type.YouTube() {
+		rss.Link = fi.URL
+	}
 
 	b, err := xml.MarshalIndent(&rss, "", "  ")
 	if err != nil {
@@ -209,17 +366,34 @@ func (s *Service) RSSFeed(fi FeedInfo) (string, error) {
 	// this hack to avoid having different items for marshal and unmarshal due to "itunes" namespace
 	res = strings.ReplaceAll(res, "<duration>", "<itunes:duration>")
 	res = strings.ReplaceAll(res, "</duration>", "</itunes:duration>")
+	res = strings.ReplaceAll(res, "<image href=", "<itunes:image href=")
+	res = strings.ReplaceAll(res, "</image>", "</itunes:image>")
+	res = strings.ReplaceAll(res, "<transcript ", "<podcast:transcript ")
+	res = strings.ReplaceAll(res, "</transcript>", "</podcast:transcript>")
 	return res, nil
 }
 
 // procChannels processes all channels, downloads audio, updates metadata and stores RSS
+func (s *Service) procChannels(ctx context.Context) error {
+	return s.procFeeds(ctx, s.Channels())
+}
+
+// procFeeds processes given channels, downloads audio, updates metadata and stores RSS
 //
 //nolint:gocyclo // complex but clear sequential processing logic
-func (s *Service) procChannels(ctx context.Context) error {
+func (s *Service) procFeeds(ctx context.Context, feeds []FeedInfo) error {
 	var allStats stats
 
-	for _, feedInfo := range s.Feeds {
-		entries, err := s.ChannelService.Get(ctx, feedInfo.ID, feedInfo.Type)
+	paused := false // set on low disk space, no more downloads in this run
+	for _, feedInfo := range feeds {
+		if paused {
+			break
+		}
+		if feedInfo.Paused {
+			log.Printf("[DEBUG] channel %s (%s) is paused, skipped", feedInfo.ID, feedInfo.Name)
+			continue
+		}
+		entries, err := s.entries(ctx, feedInfo)
 		if err != nil {
 			log.Printf("[WARN] failed to get channel entries for %s: %s", feedInfo.ID, err)
 			continue
@@ -272,16 +446,88 @@ func (s *Service) procChannels(ctx context.Context) error {
 				continue
 			}
 
+			// previously failed entry retried only after its next retry time
+			failure, failed, failErr := s.Store.GetFailure(entry)
+			if failErr != nil {
+				log.Printf("[WARN] failed to get failure status for %s: %v", entry.VideoID, failErr)
+			}
+			if failed && time.Now().Before(failure.NextRetry) {
+				allStats.ignored++
+				log.Printf("[DEBUG] deferred %s (%s) till %s, attempts: %d", entry.VideoID, failure.Kind,
+					failure.NextRetry.Format(time.RFC3339), failure.Attempts)
+				continue
+			}
+
+			// the same video already downloaded for another channel, its file reused without download
+			if shared, found := s.sharedEntry(entry, feedInfo); found {
+				entry.Meta = shared.Meta
+				if skipReason, skip := s.skipByMeta(entry, feedInfo); skip {
+					allStats.ignored++
+					log.Printf("[INFO] skip shared %s, %s: %s", entry.VideoID, skipReason, entry.String())
+					if procErr := s.Store.SetProcessed(entry); procErr != nil {
+						log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, procErr)
+					}
+					continue
+				}
+				entry = s.reuse(entry, shared, feedInfo)
+				if err := s.saveEntry(entry, failed); err != nil {
+					return err
+				}
+				processed++
+				changed = true
+				allStats.added++
+				log.Printf("[INFO] saved %s (%s) with file %s shared with %s, channel: %+v",
+					entry.VideoID, entry.Title, entry.File, shared.ChannelID, feedInfo)
+				continue
+			}
+
+			if s.lowDiskSpace() {
+				paused = true
+				break
+			}
+
+			if s.Probe {
+				// probe metadata to skip lives, shorts and filtered by tags entries before download
+				meta, probeErr := s.Downloader.Probe(ctx, videoRef(entry, feedInfo))
+				var probeDlErr *ytfeed.DownloadError
+				if probeErr != nil && errors.As(probeErr, &probeDlErr) && probeDlErr.Kind != ytfeed.FKTransient {
+					allStats.ignored++
+					log.Printf("[INFO] probe rejected %s: %v", entry.VideoID, probeErr)
+					s.recordFailure(entry, probeErr, failure)
+					continue
+				}
+				if probeErr != nil {
+					log.Printf("[WARN] failed to probe %s, continue with download: %v", entry.VideoID, probeErr)
+				}
+				entry.Meta = meta
+				if unavailErr := meta.Unavailable(); unavailErr != nil {
+					allStats.ignored++
+					log.Printf("[INFO] skipping unavailable %s: %v", entry.VideoID, unavailErr)
+					s.recordFailure(entry, unavailErr, failure)
+					continue
+				}
+				if skipReason, skip := s.skipByMeta(entry, feedInfo); skip {
+					allStats.ignored++
+					log.Printf("[INFO] skip %s before download, %s: %s", entry.VideoID, skipReason, entry.String())
+					if procErr := s.Store.SetProcessed(entry); procErr != nil {
+						log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, procErr)
+					}
+					continue
+				}
+			}
+
 			log.Printf("[INFO] new entry [%d] %s, %s, %s, %s", i+1, entry.VideoID, entry.Title, feedInfo.Name, entry.String())
 
-			file, downErr := s.Downloader.Get(ctx, entry.VideoID, s.makeFileName(entry))
+			file, downErr := s.Downloader.Get(ctx, videoRef(entry, feedInfo), s.makeFileName(entry))
 			if downErr != nil {
 				allStats.ignored++
-				if errors.Is(downErr, ytfeed.ErrSkip) { // downloader decided to skip this entry
+				var dlErr *ytfeed.DownloadError
+				if errors.Is(downErr, ytfeed.ErrSkip) && !errors.As(downErr, &dlErr) { // downloader decided to skip this entry
 					log.Printf("[INFO] skipping %s", entry.String())
 					continue
 				}
 				log.Printf("[WARN] failed to download %s: %s", entry.VideoID, downErr)
+				s.recordFailure(entry, downErr, failure)
 				continue
 			}
 
@@ -297,10 +543,7 @@ func (s *Service) procChannels(ctx context.Context) error {
 				continue
 			}
 
-			// update metadata
-			if tagsErr := s.updateMp3Tags(file, entry, feedInfo); tagsErr != nil {
-				log.Printf("[WARN] failed to update metadata for %s: %s", entry.VideoID, tagsErr)
-			}
+			entry = s.processFile(ctx, file, videoRef(entry, feedInfo), entry, feedInfo)
 
 			processed++
 
@@ -315,19 +558,14 @@ func (s *Service) procChannels(ctx context.Context) error {
 
 			entry = s.update(entry, file, feedInfo)
 
-			ok, saveErr := s.Store.Save(entry)
-			if saveErr != nil {
-				return fmt.Errorf("failed to save entry %+v: %w", entry, saveErr)
-			}
-			if !ok {
-				log.Printf("[WARN] attempt to save dup entry %+v", entry)
+			for _, part := range s.split(ctx, entry, feedInfo) {
+				if err := s.saveEntry(part, failed); err != nil {
+					return err
+				}
+				allStats.added++
+				log.Printf("[INFO] saved %s (%s) to %s, channel: %+v", part.VideoID, part.Title, part.File, feedInfo)
 			}
 			changed = true
-			if procErr := s.Store.SetProcessed(entry); procErr != nil {
-				log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, procErr)
-			}
-			allStats.added++
-			log.Printf("[INFO] saved %s (%s) to %s, channel: %+v", entry.VideoID, entry.Title, file, feedInfo)
 		}
 		allStats.processed += processed
 
@@ -336,19 +574,20 @@ func (s *Service) procChannels(ctx context.Context) error {
 			allStats.removed += removed
 
 			// save rss feed to fs if there are new entries
-			rss, rssErr := s.RSSFeed(feedInfo)
-			if rssErr != nil {
-				log.Printf("[WARN] failed to generate rss for %s: %s", feedInfo.Name, rssErr)
-			} else {
-				if err := s.RSSFileStore.Save(feedInfo.ID, rss); err != nil {
-					log.Printf("[WARN] failed to save rss for %s: %s", feedInfo.Name, err)
-				}
-			}
+			s.saveRSS(feedInfo)
+		}
+	}
+
+	// evict the oldest entries above quotas and update feeds of affected channels, any channel may be affected
+	for id, removed := range s.evictByQuota() {
+		allStats.removed += removed
+		if feedInfo, ok := s.channel(id); ok {
+			s.saveRSS(feedInfo)
 		}
 	}
 
-	log.Printf("[INFO] all channels processed - channels: %d, %s, lifetime: %d, feed size: %d",
-		len(s.Feeds), allStats.String(), s.Store.CountProcessed(), s.countAllEntries())
+	log.Printf("[INFO] channels processed - channels: %d, %s, lifetime: %d, feed size: %d",
+		len(feeds), allStats.String(), s.Store.CountProcessed(), s.countAllEntries())
 
 	newestEntry := s.newestEntry()
 	log.Printf("[INFO] last entry: %s", newestEntry.String())
@@ -356,6 +595,96 @@ func (s *Service) procChannels(ctx context.Context) error {
 	return nil
 }
 
+// saveEntry stores the downloaded entry, marks it processed and removes the failure record of previous attempts
+func (s *Service) saveEntry(entry ytfeed.Entry, failed bool) error {
+	ok, saveErr := s.Store.Save(entry)
+	if saveErr != nil {
+		return fmt.Errorf("failed to save entry %+v: %w", entry, saveErr)
+	}
+	if !ok {
+		log.Printf("[WARN] attempt to save dup entry %+v", entry)
+	}
+	if procErr := s.Store.SetProcessed(entry); procErr != nil {
+		log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, procErr)
+	}
+	if failed {
+		if rmErr := s.Store.RemoveFailure(entry); rmErr != nil {
+			log.Printf("[WARN] failed to remove failure status for %s: %v", entry.VideoID, rmErr)
+		}
+	}
+	return nil
+}
+
+// processFile runs post-download steps: cuts sponsored segments, post-processes audio, gets thumbnail
+// and subtitles. The ref is the video id or url passed to downloader.
+func (s *Service) processFile(ctx context.Context, file, ref string, entry ytfeed.Entry, fi FeedInfo) ytfeed.Entry {
+	if removed := s.cutSegments(ctx, file, entry, fi); len(removed) > 0 {
+		entry.Media.Description += removedSegmentsDescription(removed)
+	}
+
+	if s.PostProcessor != nil && fi.PostProcess.Enabled() {
+		if ppErr := s.PostProcessor.Process(ctx, file, fi.PostProcess); ppErr != nil {
+			log.Printf("[WARN] failed to post-process %s for %s: %v", file, entry.VideoID, ppErr)
+		}
+	}
+
+	if s.Thumbnails != nil && entry.Media.Thumbnail.URL != "" {
+		if _, thumbErr := s.Thumbnails.Get(ctx, entry.Media.Thumbnail.URL, s.makeFileName(entry)); thumbErr != nil {
+			log.Printf("[WARN] failed to download thumbnail for %s: %v", entry.VideoID, thumbErr)
+		}
+	}
+
+	if s.Subtitles != nil && len(fi.Subtitles) > 0 {
+		lang, subsErr := s.Subtitles.Get(ctx, ref, s.makeFileName(entry), fi.Subtitles)
+		switch {
+		case errors.Is(subsErr, ytfeed.ErrSkip):
+			log.Printf("[DEBUG] no subtitles for %s in %v", entry.VideoID, fi.Subtitles)
+		case subsErr != nil:
+			log.Printf("[WARN] failed to download subtitles for %s: %v", entry.VideoID, subsErr)
+		default:
+			entry.Transcript = lang
+		}
+	}
+	return entry
+}
+
+// entries returns the list of feed entries, youtube feeds loaded by channel service, other sources by listing
+func (s *Service) entries(ctx context.Context, fi FeedInfo) ([]ytfeed.Entry, error) {
+	if fi.Type.YouTube() {
+		return s.ChannelService.Get(ctx, fi.ID, fi.Type)
+	}
+	if fi.Type == ytfeed.FTManual {
+		return nil, nil // no source, entries added with AddEntry
+	}
+	if s.Listing == nil {
+		return nil, fmt.Errorf("no listing service for %s feed %s", fi.Type, fi.ID)
+	}
+	if fi.URL == "" {
+		return nil, fmt.Errorf("no url for %s feed %s", fi.Type, fi.ID)
+	}
+	return s.Listing.List(ctx, fi.ID, fi.URL, fi.Type)
+}
+
+// videoRef returns the reference passed to downloader, video id for youtube and the page url for other sources
+func videoRef(entry ytfeed.Entry, fi FeedInfo) string {
+	if fi.Type.YouTube() {
+		return entry.VideoID
+	}
+	return entry.Link.Href
+}
+
+// saveRSS generates and saves rss feed of the channel to fs
+func (s *Service) saveRSS(fi FeedInfo) {
+	rss, err := s.RSSFeed(fi)
+	if err != nil {
+		log.Printf("[WARN] failed to generate rss for %s: %s", fi.Name, err)
+		return
+	}
+	if err := s.RSSFileStore.Save(fi.ID, rss); err != nil {
+		log.Printf("[WARN] failed to save rss for %s: %s", fi.Name, err)
+	}
+}
+
 // StoreRSS saves RSS feed to file
 func (s *Service) StoreRSS(chanID, rss string) error {
 	return s.RSSFileStore.Save(chanID, rss)
@@ -365,7 +694,7 @@ func (s *Service) StoreRSS(chanID, rss string) error {
 func (s *Service) RemoveEntry(entry ytfeed.Entry) error {
 	// find FeedInfo for this channel to get correct keep limit
 	fi := FeedInfo{ID: entry.ChannelID}
-	for _, f := range s.Feeds {
+	for _, f := range s.Channels() {
 		if f.ID == entry.ChannelID {
 			fi = f
 			break
@@ -378,32 +707,130 @@ func (s *Service) RemoveEntry(entry ytfeed.Entry) error {
 		return fmt.Errorf("failed to load entries for %s: %w", entry.ChannelID, err)
 	}
 
-	var fullEntry ytfeed.Entry
+	// split video stored as several entries, all parts removed
+	fullEntries := []ytfeed.Entry{}
 	for _, e := range entries {
 		if e.VideoID == entry.VideoID {
-			fullEntry = e
-			break
+			fullEntries = append(fullEntries, e)
 		}
 	}
+	if len(fullEntries) == 0 {
+		fullEntries = append(fullEntries, entry)
+	}
 
 	if err := s.Store.ResetProcessed(entry); err != nil {
 		return fmt.Errorf("failed to reset processed entry %s: %w", entry.VideoID, err)
 	}
-	if err := s.Store.Remove(entry); err != nil {
-		return fmt.Errorf("failed to remove entry %s: %w", entry.VideoID, err)
-	}
+	for _, fullEntry := range fullEntries {
+		if err := s.Store.Remove(fullEntry); err != nil {
+			return fmt.Errorf("failed to remove entry %s: %w", entry.VideoID, err)
+		}
 
-	// delete audio file if exists
-	if fullEntry.File != "" {
-		if err := os.Remove(fullEntry.File); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove file %s: %w", fullEntry.File, err)
+		// delete audio file if exists and not shared with other channels
+		if fullEntry.File != "" && !s.fileShared(fullEntry.File) {
+			if err := os.Remove(fullEntry.File); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove file %s: %w", fullEntry.File, err)
+			}
+			log.Printf("[INFO] removed audio file %s for %s", fullEntry.File, entry.VideoID)
+			s.removeSidecars(fullEntry.File)
 		}
-		log.Printf("[INFO] removed audio file %s for %s", fullEntry.File, entry.VideoID)
 	}
 
 	return nil
 }
 
+// cutSegments removes SponsorBlock segments of configured categories from the file, youtube feeds only.
+// Returns the list of removed segments, empty if nothing was removed.
+func (s *Service) cutSegments(ctx context.Context, file string, entry ytfeed.Entry, fi FeedInfo) []string {
+	if s.SponsorBlock == nil || s.PostProcessor == nil || len(fi.SponsorBlock) == 0 || !fi.Type.YouTube() {
+		return nil
+	}
+	segments, err := s.SponsorBlock.Segments(ctx, entry.VideoID, fi.SponsorBlock)
+	if err != nil {
+		log.Printf("[WARN] failed to get sponsorblock segments for %s: %v", entry.VideoID, err)
+		return nil
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+	if err := s.PostProcessor.Cut(ctx, file, segments); err != nil {
+		log.Printf("[WARN] failed to cut sponsorblock segments from %s for %s: %v", file, entry.VideoID, err)
+		return nil
+	}
+	res := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		res = append(res, seg.String())
+	}
+	log.Printf("[INFO] removed %d segments from %s for %s: %s", len(segments), file, entry.VideoID, strings.Join(res, ", "))
+	return res
+}
+
+// ResolveFeeds replaces @handles and urls in feeds' ids with channel or playlist ids, keeping the original in Ref.
+// Feeds failed to resolve are dropped from the list, non-youtube feeds kept as is.
+func (s *Service) ResolveFeeds(ctx context.Context) {
+	feeds := s.Channels()
+	res := make([]FeedInfo, 0, len(feeds))
+	for _, fi := range feeds {
+		if ytfeed.IsRawID(fi.ID) || !fi.Type.YouTube() {
+			res = append(res, fi)
+			continue
+		}
+		resolved, err := s.Resolve(ctx, fi.ID)
+		if err != nil {
+			log.Printf("[ERROR] failed to resolve %s (%s), channel ignored: %v", fi.ID, fi.Name, err)
+			continue
+		}
+		log.Printf("[INFO] resolved %s (%s) to %s %s", fi.ID, fi.Name, resolved.Type, resolved.ID)
+		fi.Ref, fi.ID = fi.ID, resolved.ID
+		if resolved.Type != ytfeed.FTDefault {
+			fi.Type = resolved.Type
+		}
+		res = append(res, fi)
+	}
+	s.feedsLock.Lock()
+	s.Feeds = res
+	s.feedsLock.Unlock()
+}
+
+// Resolve returns channel or playlist id for @handle or url, resolved once and cached in the store
+func (s *Service) Resolve(ctx context.Context, ref string) (ytfeed.Resolved, error) {
+	if ytfeed.IsRawID(ref) {
+		return ytfeed.Resolved{ID: ref}, nil
+	}
+	if cached, found, err := s.Store.GetResolved(ref); err == nil && found {
+		return cached, nil
+	}
+	if s.Resolver == nil {
+		return ytfeed.Resolved{}, fmt.Errorf("no resolver for %s", ref)
+	}
+	resolved, err := s.Resolver.Resolve(ctx, ref)
+	if err != nil {
+		return ytfeed.Resolved{}, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if err := s.Store.SetResolved(ref, resolved); err != nil {
+		log.Printf("[WARN] failed to cache resolved %s: %v", ref, err)
+	}
+	return resolved, nil
+}
+
+// recordFailure stores the failed download attempt with the next retry time.
+// Permanent failures marked as processed to never be retried.
+func (s *Service) recordFailure(entry ytfeed.Entry, downErr error, prev ytfeed.Failure) {
+	failure := ytfeed.NewFailure(entry, downErr, prev, time.Now())
+	if err := s.Store.SetFailure(failure); err != nil {
+		log.Printf("[WARN] failed to save failure status for %s: %v", entry.VideoID, err)
+	}
+	if failure.Permanent() {
+		log.Printf("[INFO] permanent failure for %s, %q", entry.String(), failure.Message)
+		if err := s.Store.SetProcessed(entry); err != nil {
+			log.Printf("[WARN] failed to set processed status for %s: %v", entry.VideoID, err)
+		}
+		return
+	}
+	log.Printf("[INFO] %s failure for %s, attempt %d, next retry at %s", failure.Kind, entry.VideoID,
+		failure.Attempts, failure.NextRetry.Format(time.RFC3339))
+}
+
 // isNew checks if entry already processed
 func (s *Service) isNew(entry ytfeed.Entry, fi FeedInfo) (ok bool, err error) {
 	// check if entry already exists in store
@@ -433,9 +860,23 @@ func (s *Service) isNew(entry ytfeed.Entry, fi FeedInfo) (ok bool, err error) {
 
 // isAllowed checks if entry matches all filters for the channel feed
 func (s *Service) isAllowed(entry ytfeed.Entry, fi FeedInfo) (ok bool, err error) {
+	// filters applied to the title and to the tags, if probed
+	matchAny := func(re string) (bool, error) {
+		for _, v := range append([]string{entry.Title}, entry.Meta.Tags...) {
+			matched, e := regexp.MatchString(re, v)
+			if e != nil {
+				return false, e
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
 	matchedIncludeFilter := true
 	if fi.Filter.Include != "" {
-		matchedIncludeFilter, err = regexp.MatchString(fi.Filter.Include, entry.Title)
+		matchedIncludeFilter, err = matchAny(fi.Filter.Include)
 		if err != nil {
 			return false, fmt.Errorf("failed to check if entry %s matches include filter: %w", entry.VideoID, err)
 		}
@@ -443,7 +884,7 @@ func (s *Service) isAllowed(entry ytfeed.Entry, fi FeedInfo) (ok bool, err error
 
 	matchedExcludeFilter := false
 	if fi.Filter.Exclude != "" {
-		matchedExcludeFilter, err = regexp.MatchString(fi.Filter.Exclude, entry.Title)
+		matchedExcludeFilter, err = matchAny(fi.Filter.Exclude)
 		if err != nil {
 			return false, fmt.Errorf("failed to check if entry %s matches exclude filter: %w", entry.VideoID, err)
 		}
@@ -452,6 +893,20 @@ func (s *Service) isAllowed(entry ytfeed.Entry, fi FeedInfo) (ok bool, err error
 	return matchedIncludeFilter && !matchedExcludeFilter, nil
 }
 
+// skipByMeta checks probed metadata for shorts and for filters matching tags.
+// Returns the reason and true if the entry should be skipped.
+func (s *Service) skipByMeta(entry ytfeed.Entry, fi FeedInfo) (reason string, skip bool) {
+	if s.SkipShorts.Seconds() > 0 && entry.Meta.Duration > 0 && entry.Meta.Duration < int(s.SkipShorts.Seconds()) {
+		return fmt.Sprintf("short, %v", time.Duration(entry.Meta.Duration)*time.Second), true
+	}
+	if len(entry.Meta.Tags) > 0 {
+		if ok, err := s.isAllowed(entry, fi); err == nil && !ok {
+			return fmt.Sprintf("filtered by tags %v", entry.Meta.Tags), true
+		}
+	}
+	return "", false
+}
+
 func (s *Service) isShort(file string) (bool, time.Duration) {
 	if s.SkipShorts.Seconds() > 0 {
 		// skip shorts if duration is less than SkipShorts
@@ -463,50 +918,106 @@ func (s *Service) isShort(file string) (bool, time.Duration) {
 	return false, 0
 }
 
-// update sets entry file name and reset published ts
+// update sets entry file name, title and published time by channel's policies, duration and mp3 tags
 func (s *Service) update(entry ytfeed.Entry, file string, fi FeedInfo) ytfeed.Entry {
 	entry.File = file
 
-	// only reset time if published not too long ago
-	// this is done to avoid initial set of entries added with a new channel to the top of the feed
-	if time.Since(entry.Published) < time.Hour*24 {
+	if published := publishedTime(entry, fi); !published.Equal(entry.Published) {
 		log.Printf("[DEBUG] reset published time for %s, from %s to %s (%v), %s",
-			entry.VideoID, entry.Published.Format(time.RFC3339), time.Now().Format(time.RFC3339),
+			entry.VideoID, entry.Published.Format(time.RFC3339), published.Format(time.RFC3339),
 			time.Since(entry.Published), entry.String())
-		entry.Published = time.Now() // reset published ts to prevent possible out-of-order entries
+		entry.Published = published // reset published ts to prevent possible out-of-order entries
 	} else {
 		log.Printf("[DEBUG] keep published time for %s, %s", entry.VideoID, entry.Published.Format(time.RFC3339))
 	}
 
-	if !strings.Contains(entry.Title, fi.Name) { // if title doesn't contains channel name add it
-		entry.Title = fi.Name + ": " + entry.Title
-	}
-
+	entry.Title = entryTitle(entry, fi)
 	entry.Duration = s.DurationService.File(file)
+
+	// tags set after title and published time, to match the feed
+	if tagsErr := s.updateMp3Tags(file, entry, fi); tagsErr != nil {
+		log.Printf("[WARN] failed to update metadata for %s: %s", entry.VideoID, tagsErr)
+	}
 	log.Printf("[DEBUG] updated entry: %s", entry.String())
 	return entry
 }
 
 // removeOld deletes old entries from store and corresponding files
 func (s *Service) removeOld(fi FeedInfo) int {
-	removed := 0
 	keep := s.keep(fi)
 	files, err := s.Store.RemoveOld(fi.ID, keep+1)
 	if err != nil { // even with error we get a list of files to remove
 		log.Printf("[WARN] failed to remove some old meta data for %s, %v", fi.ID, err)
 	}
+	return s.removeFiles(fi, files)
+}
 
+// removeFiles deletes audio files with their sidecars, files still referenced by other entries kept.
+// Returns the number of removed audio files.
+func (s *Service) removeFiles(fi FeedInfo, files []string) int {
+	removed := 0
 	for _, f := range files {
+		if s.fileShared(f) {
+			log.Printf("[INFO] keep %s of %s (%s), shared with other channels", f, fi.ID, fi.Name)
+			continue
+		}
 		if e := os.Remove(f); e != nil {
 			log.Printf("[WARN] failed to remove file %s: %v", f, e)
 			continue
 		}
 		removed++
 		log.Printf("[INFO] removed %s for %s (%s)", f, fi.ID, fi.Name)
+		s.removeSidecars(f)
 	}
 	return removed
 }
 
+// sidecarFiles returns the list of files stored next to the audio file, like thumbnail image and transcripts
+func (s *Service) sidecarFiles(file string) []string {
+	base := strings.TrimSuffix(file, filepath.Ext(file))
+	return []string{thumbFile(file), base + ".vtt", base + ".srt", base + ".txt"}
+}
+
+// transcripts returns podcast:transcript elements for entry's subtitles, if downloaded
+func (s *Service) transcripts(entry ytfeed.Entry) []rssfeed.Transcript {
+	if entry.Transcript == "" || entry.File == "" {
+		return nil
+	}
+	base := strings.TrimSuffix(path.Base(entry.File), filepath.Ext(entry.File))
+	if _, err := os.Stat(strings.TrimSuffix(entry.File, filepath.Ext(entry.File)) + ".vtt"); err != nil {
+		return nil
+	}
+	return []rssfeed.Transcript{
+		{URL: s.RootURL + "/" + base + ".vtt", Type: "text/vtt", Language: entry.Transcript, Rel: "captions"},
+		{URL: s.RootURL + "/" + base + ".srt", Type: "application/x-subrip", Language: entry.Transcript, Rel: "captions"},
+		{URL: s.RootURL + "/" + base + ".txt", Type: "text/plain", Language: entry.Transcript},
+	}
+}
+
+// removeSidecars deletes all files stored next to the audio file, missing files are ignored
+func (s *Service) removeSidecars(file string) {
+	for _, f := range s.sidecarFiles(file) {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARN] failed to remove file %s: %v", f, err)
+		}
+	}
+}
+
+// imageURL returns the url of local thumbnail if downloaded, remote thumbnail url otherwise
+func (s *Service) imageURL(entry ytfeed.Entry) string {
+	if entry.File != "" {
+		if _, err := os.Stat(thumbFile(entry.File)); err == nil {
+			return s.RootURL + "/" + path.Base(thumbFile(entry.File))
+		}
+	}
+	return entry.Media.Thumbnail.URL
+}
+
+// thumbFile returns the name of thumbnail image for the given audio file
+func thumbFile(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + ".jpg"
+}
+
 func (s *Service) keep(fi FeedInfo) int {
 	keep := s.KeepPerChannel
 	if fi.Keep > 0 {
@@ -525,7 +1036,7 @@ func (s *Service) makeFileName(entry ytfeed.Entry) string {
 
 // totalEntriesToKeep returns total number of entries to keep, summing all channels' keep values
 func (s *Service) totalEntriesToKeep() (res int) {
-	for _, fi := range s.Feeds {
+	for _, fi := range s.Channels() {
 		res += s.keep(fi)
 	}
 	return res
@@ -534,7 +1045,7 @@ func (s *Service) totalEntriesToKeep() (res int) {
 // countAllEntries returns total number of entries across all channels, respects keep settings
 func (s *Service) countAllEntries() int {
 	var result int
-	for _, fi := range s.Feeds {
+	for _, fi := range s.Channels() {
 		if entries, err := s.Store.Load(fi.ID, s.keep(fi)); err == nil {
 			result += len(entries)
 		}
@@ -545,7 +1056,7 @@ func (s *Service) countAllEntries() int {
 // newestEntry returns the newest entry across all channels, respects keep settings
 func (s *Service) newestEntry() ytfeed.Entry {
 	entries := []ytfeed.Entry{}
-	for _, fi := range s.Feeds {
+	for _, fi := range s.Channels() {
 		if recs, err := s.Store.Load(fi.ID, 1); err == nil {
 			entries = append(entries, recs...)
 		}
@@ -562,7 +1073,7 @@ func (s *Service) newestEntry() ytfeed.Entry {
 // oldestEntry returns the oldest entry from all channels, respecting keep settings
 func (s *Service) oldestEntry() ytfeed.Entry {
 	entries := []ytfeed.Entry{}
-	for _, fi := range s.Feeds {
+	for _, fi := range s.Channels() {
 		if recs, err := s.Store.Load(fi.ID, s.keep(fi)); err == nil {
 			entries = append(entries, recs...)
 		}
@@ -590,6 +1101,16 @@ func (s *Service) updateMp3Tags(file string, entry ytfeed.Entry, fi FeedInfo) er
 	fh.SetYear(entry.Published.Format("2006"))
 	fh.AddTextFrame(fh.CommonID("Recording time"), fh.DefaultEncoding(), entry.Published.Format("20060102T150405"))
 
+	if img, imgErr := os.ReadFile(thumbFile(file)); imgErr == nil { //nolint:gosec // file name derived from the audio file
+		fh.AddAttachedPicture(id3v2.PictureFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			MimeType:    "image/jpeg",
+			PictureType: id3v2.PTFrontCover,
+			Description: "Front cover",
+			Picture:     img,
+		})
+	}
+
 	if err = fh.Save(); err != nil {
 		return fmt.Errorf("failed to close file %s: %w", file, err)
 	}
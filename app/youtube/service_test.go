@@ -2,12 +2,15 @@ package youtube
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/bogem/id3v2/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
@@ -92,6 +95,7 @@ func TestService_Do(t *testing.T) {
 		},
 	}
 	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
 		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
 			fpath := filepath.Join(tempDir, fname+".mp3")
 			_, err := os.Create(fpath) //nolint:gosec // test file path is safe
@@ -157,7 +161,12 @@ func TestService_Do(t *testing.T) {
 	assert.Equal(t, "vid2", res[1].VideoID)
 	assert.Equal(t, "vid1", res[2].VideoID)
 
-	require.Len(t, downloader.GetCalls(), 6)
+	require.Len(t, downloader.GetCalls(), 4, "vid1 and vid2 of channel2 reuse files of channel1")
+	ch1, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, ch1[0].File, res[1].File, "vid2 file shared")
+	assert.Equal(t, ch1[1].File, res[2].File, "vid1 file shared")
+	assert.Equal(t, "name2: title2", res[1].Title, "title of the channel")
 	require.Equal(t, "vid1", downloader.GetCalls()[0].ID)
 	require.NotEmpty(t, downloader.GetCalls()[0].Fname)
 
@@ -175,13 +184,12 @@ func TestService_Do(t *testing.T) {
 	assert.Contains(t, string(rssData), "<itunes:duration>1234</itunes:duration>")
 
 	t.Logf("%v", duration.FileCalls())
-	// durationService.File called 11 times: 5 in Service.update(), 6 in Service.isShort()
-	require.Len(t, duration.FileCalls(), 11)
+	// durationService.File called 7 times: 3 in Service.update(), 4 in Service.isShort(), not called for shared files
+	require.Len(t, duration.FileCalls(), 7)
 	assert.Equal(t, filepath.Join(tempDir, "e4650bb3d770eed60faad7ffbed5f33ffb1b89fa.mp3"), duration.FileCalls()[0].Fname)
 	assert.Equal(t, filepath.Join(tempDir, "4308c33c7ddb107c2d0c13a905e4c6962001bab4.mp3"), duration.FileCalls()[2].Fname)
 	assert.Equal(t, filepath.Join(tempDir, "122b672d10e77708b51c041f852615dc0eedf354.mp3"), duration.FileCalls()[4].Fname)
-	assert.Equal(t, filepath.Join(tempDir, "3be877c750abb87daee80c005fe87e7a3f824fed.mp3"), duration.FileCalls()[6].Fname)
-	assert.Equal(t, filepath.Join(tempDir, "648f79b3a05ececb8a37600aa0aee332f0374e01.mp3"), duration.FileCalls()[8].Fname)
+	assert.Equal(t, filepath.Join(tempDir, "fce4b6c43aa52f246545c89e2d2bd7e1d80fbfeb.mp3"), duration.FileCalls()[5].Fname)
 	assert.NoFileExists(t, shortVideo, "short video should be removed")
 	assert.FileExists(t, filepath.Join(tempDir, "e4650bb3d770eed60faad7ffbed5f33ffb1b89fa.mp3"), "non short video should exist")
 }
@@ -197,6 +205,7 @@ func TestService_DoIsAllowedFilter(t *testing.T) {
 		},
 	}
 	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
 		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
 			return "/tmp/" + fname + ".mp3", nil
 		},
@@ -432,6 +441,15 @@ func TestService_update(t *testing.T) {
 		assert.Less(t, time.Since(res.Published), time.Second, "published time was reset")
 		assert.Equal(t, "Сергей Пархоменко на канале “Живой Гвоздь” в программме “Персонально ваш”. 06.04.2022", res.Title)
 	}
+
+	{ // update with channel's title template and original published time
+		published := time.Now().Add(time.Hour * -1)
+		inpEntry := ytfeed.Entry{ChannelID: "chan1", VideoID: "vid1", Published: published, Title: "something"}
+		res := svc.update(inpEntry, "/tmp/audio.mp3", FeedInfo{ID: "f1", Name: "feed1", Published: PMOriginal,
+			TitleTemplate: "{{.Title}} [{{.Channel}}]"})
+		assert.Equal(t, published, res.Published, "published time kept")
+		assert.Equal(t, "something [feed1]", res.Title)
+	}
 }
 
 func TestService_totalEntriesToKeep(t *testing.T) {
@@ -584,6 +602,7 @@ func TestService_RemoveEntry(t *testing.T) {
 			},
 			ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
 			RemoveFunc:         func(ytfeed.Entry) error { return nil },
+			FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
 		}
 
 		svc := Service{Store: storeSvc, KeepPerChannel: 10}
@@ -623,6 +642,7 @@ func TestService_RemoveEntry(t *testing.T) {
 			},
 			ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
 			RemoveFunc:         func(ytfeed.Entry) error { return nil },
+			FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
 		}
 
 		svc := Service{
@@ -654,6 +674,7 @@ func TestService_RemoveEntry(t *testing.T) {
 			},
 			ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
 			RemoveFunc:         func(ytfeed.Entry) error { return nil },
+			FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
 		}
 
 		svc := Service{Store: storeSvc, KeepPerChannel: 10}
@@ -672,6 +693,7 @@ func TestService_RemoveEntry(t *testing.T) {
 			},
 			ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
 			RemoveFunc:         func(ytfeed.Entry) error { return nil },
+			FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
 		}
 
 		svc := Service{Store: storeSvc, KeepPerChannel: 10}
@@ -689,6 +711,7 @@ func TestService_RemoveEntry(t *testing.T) {
 			},
 			ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
 			RemoveFunc:         func(ytfeed.Entry) error { return nil },
+			FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
 		}
 
 		svc := Service{Store: storeSvc, KeepPerChannel: 10}
@@ -705,3 +728,539 @@ func TestService_RemoveEntry(t *testing.T) {
 		assert.Equal(t, "vid-not-found", storeSvc.RemoveCalls()[0].Entry.VideoID)
 	})
 }
+
+func TestService_RSSFeedWithThumbnails(t *testing.T) {
+	tempDir := t.TempDir()
+	file1, file2 := filepath.Join(tempDir, "file1.mp3"), filepath.Join(tempDir, "file2.mp3")
+	require.NoError(t, os.WriteFile(file1, []byte("audio1"), 0o600))
+	require.NoError(t, os.WriteFile(file2, []byte("audio2"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "file1.jpg"), []byte("image1"), 0o600))
+
+	storeSvc := &mocks.StoreServiceMock{
+		LoadFunc: func(string, int) ([]ytfeed.Entry, error) {
+			res := []ytfeed.Entry{
+				{ChannelID: "channel1", VideoID: "vid1", Title: "title1", File: file1},
+				{ChannelID: "channel1", VideoID: "vid2", Title: "title2", File: file2},
+			}
+			res[0].Media.Thumbnail.URL = "http://example.com/thumb1.jpg"
+			res[1].Media.Thumbnail.URL = "http://example.com/thumb2.jpg"
+			return res, nil
+		},
+	}
+
+	svc := Service{Store: storeSvc, RootURL: "http://localhost:8080/yt", KeepPerChannel: 10}
+	res, err := svc.RSSFeed(FeedInfo{ID: "channel1", Name: "name1", Type: ytfeed.FTChannel})
+	require.NoError(t, err)
+	t.Logf("%v", res)
+
+	assert.Contains(t, res, `<itunes:image href="http://localhost:8080/yt/file1.jpg"></itunes:image>`, "local channel image")
+	assert.Contains(t, res, `<media:thumbnail url="http://localhost:8080/yt/file1.jpg"></media:thumbnail>`)
+	assert.Equal(t, 2, strings.Count(res, `<itunes:image href="http://localhost:8080/yt/file1.jpg">`), "channel and item")
+	assert.Contains(t, res, `<itunes:image href="http://example.com/thumb2.jpg"></itunes:image>`, "remote item image")
+	assert.NotContains(t, res, "<image ")
+}
+
+func TestService_updateMp3TagsWithThumbnail(t *testing.T) {
+	tempDir := t.TempDir()
+	data, err := os.ReadFile("../duration/testdata/audio.mp3")
+	require.NoError(t, err)
+	file := filepath.Join(tempDir, "audio.mp3")
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "audio.jpg"), []byte("image-data"), 0o600))
+
+	svc := Service{}
+	entry := ytfeed.Entry{ChannelID: "chan1", VideoID: "vid1", Title: "title1", Published: time.Now()}
+	require.NoError(t, svc.updateMp3Tags(file, entry, FeedInfo{ID: "chan1", Name: "name1"}))
+
+	tag, err := id3v2.Open(file, id3v2.Options{Parse: true})
+	require.NoError(t, err)
+	defer tag.Close()
+	assert.Equal(t, "title1", tag.Title())
+	frames := tag.GetFrames(tag.CommonID("Attached picture"))
+	require.Len(t, frames, 1)
+	pic, ok := frames[0].(id3v2.PictureFrame)
+	require.True(t, ok)
+	assert.Equal(t, "image/jpeg", pic.MimeType)
+	assert.Equal(t, []byte("image-data"), pic.Picture)
+}
+
+func TestService_RemoveEntryWithThumbnail(t *testing.T) {
+	tempDir := t.TempDir()
+	audioFile, thumb := filepath.Join(tempDir, "audio.mp3"), filepath.Join(tempDir, "audio.jpg")
+	require.NoError(t, os.WriteFile(audioFile, []byte("audio"), 0o600))
+	require.NoError(t, os.WriteFile(thumb, []byte("image"), 0o600))
+	subs := filepath.Join(tempDir, "audio.vtt")
+	require.NoError(t, os.WriteFile(subs, []byte("WEBVTT"), 0o600))
+
+	storeSvc := &mocks.StoreServiceMock{
+		LoadFunc: func(string, int) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{{ChannelID: "chan1", VideoID: "vid1", File: audioFile}}, nil
+		},
+		ResetProcessedFunc: func(ytfeed.Entry) error { return nil },
+		FindFileFunc:       func(string) ([]ytfeed.Entry, error) { return nil, nil },
+		RemoveFunc:         func(ytfeed.Entry) error { return nil },
+	}
+	svc := Service{Store: storeSvc, KeepPerChannel: 10}
+	require.NoError(t, svc.RemoveEntry(ytfeed.Entry{ChannelID: "chan1", VideoID: "vid1"}))
+	assert.NoFileExists(t, audioFile)
+	assert.NoFileExists(t, thumb)
+	assert.NoFileExists(t, subs)
+}
+
+func TestService_procChannelsPostProcess(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{{ChannelID: chanID, VideoID: "vid1", Title: "title1", Published: time.Now()}}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	postProc := &mocks.PostProcessorServiceMock{
+		ProcessFunc: func(context.Context, string, ytfeed.PostProcOpts) error { return nil },
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	opts := ytfeed.PostProcOpts{Loudnorm: true, Mono: true}
+	svc := Service{
+		Feeds: []FeedInfo{
+			{ID: "channel1", Name: "name1", PostProcess: opts},
+			{ID: "channel2", Name: "name2"},
+		},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           &store.BoltDB{DB: db},
+		KeepPerChannel:  10,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		PostProcessor:   postProc,
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.GetCalls(), 2)
+	require.Len(t, postProc.ProcessCalls(), 1, "post-processing only for channel1")
+	assert.Equal(t, filepath.Join(tempDir, "e4650bb3d770eed60faad7ffbed5f33ffb1b89fa.mp3"), postProc.ProcessCalls()[0].File)
+	assert.Equal(t, opts, postProc.ProcessCalls()[0].Opts)
+}
+
+func TestService_procChannelsSponsorBlock(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			entry := ytfeed.Entry{ChannelID: chanID, VideoID: "vid1", Title: "title1", Published: time.Now()}
+			entry.Media.Description = "some description"
+			return []ytfeed.Entry{entry}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	postProc := &mocks.PostProcessorServiceMock{
+		CutFunc: func(context.Context, string, []ytfeed.Segment) error { return nil },
+	}
+	sponsorBlock := &mocks.SponsorBlockServiceMock{
+		SegmentsFunc: func(context.Context, string, []string) ([]ytfeed.Segment, error) {
+			return []ytfeed.Segment{{Start: 60, End: 120, Category: "sponsor"}, {Start: 600, End: 630, Category: "selfpromo"}}, nil
+		},
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds: []FeedInfo{
+			{ID: "channel1", Name: "name1", SponsorBlock: []string{"sponsor", "selfpromo"}},
+			{ID: "channel2", Name: "name2"},
+		},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		PostProcessor:   postProc,
+		SponsorBlock:    sponsorBlock,
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, sponsorBlock.SegmentsCalls(), 1, "sponsorblock only for channel1")
+	assert.Equal(t, "vid1", sponsorBlock.SegmentsCalls()[0].VideoID)
+	assert.Equal(t, []string{"sponsor", "selfpromo"}, sponsorBlock.SegmentsCalls()[0].Categories)
+	require.Len(t, postProc.CutCalls(), 1)
+	assert.Len(t, postProc.CutCalls()[0].Segments, 2)
+
+	res, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "some description\n\nRemoved segments: sponsor 1:00-2:00, selfpromo 10:00-10:30", string(res[0].Media.Description))
+
+	res, err = boltStore.Load("channel2", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "some description", string(res[0].Media.Description))
+}
+
+func TestService_procChannelsSubtitles(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{{ChannelID: chanID, VideoID: "vid-" + chanID, Title: "title1", Published: time.Now()}}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	subs := &mocks.SubtitlesServiceMock{
+		GetFunc: func(_ context.Context, id, _ string, _ []string) (string, error) {
+			if id == "vid-channel2" {
+				return "", ytfeed.ErrSkip
+			}
+			return "en", nil
+		},
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds: []FeedInfo{
+			{ID: "channel1", Name: "name1", Subtitles: []string{"en", "en-orig"}},
+			{ID: "channel2", Name: "name2", Subtitles: []string{"de"}},
+			{ID: "channel3", Name: "name3"},
+		},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		Subtitles:       subs,
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, subs.GetCalls(), 2, "no subtitles requested for channel3")
+	assert.Equal(t, "vid-channel1", subs.GetCalls()[0].ID)
+	assert.Equal(t, []string{"en", "en-orig"}, subs.GetCalls()[0].Langs)
+
+	res, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "en", res[0].Transcript)
+	assert.Equal(t, svc.makeFileName(res[0]), subs.GetCalls()[0].Fname)
+
+	res, err = boltStore.Load("channel2", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Empty(t, res[0].Transcript, "no subtitles found")
+}
+
+func TestService_RSSFeedWithTranscripts(t *testing.T) {
+	tempDir := t.TempDir()
+	file1, file2 := filepath.Join(tempDir, "file1.mp3"), filepath.Join(tempDir, "file2.mp3")
+	require.NoError(t, os.WriteFile(file1, []byte("audio1"), 0o600))
+	require.NoError(t, os.WriteFile(file2, []byte("audio2"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "file1.vtt"), []byte("WEBVTT\n"), 0o600))
+
+	storeSvc := &mocks.StoreServiceMock{
+		LoadFunc: func(string, int) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{
+				{ChannelID: "channel1", VideoID: "vid1", Title: "title1", File: file1, Transcript: "en"},
+				{ChannelID: "channel1", VideoID: "vid2", Title: "title2", File: file2, Transcript: "en"}, // vtt file missing
+			}, nil
+		},
+	}
+
+	svc := Service{Store: storeSvc, RootURL: "http://localhost:8080/yt", KeepPerChannel: 10}
+	res, err := svc.RSSFeed(FeedInfo{ID: "channel1", Name: "name1", Type: ytfeed.FTChannel})
+	require.NoError(t, err)
+	t.Logf("%v", res)
+
+	assert.Contains(t, res, `xmlns:podcast="https://podcastindex.org/namespace/1.0"`)
+	assert.Contains(t, res, `<podcast:transcript url="http://localhost:8080/yt/file1.vtt" type="text/vtt" language="en" rel="captions"></podcast:transcript>`)
+	assert.Contains(t, res, `<podcast:transcript url="http://localhost:8080/yt/file1.srt" type="application/x-subrip" language="en" rel="captions"></podcast:transcript>`)
+	assert.Contains(t, res, `<podcast:transcript url="http://localhost:8080/yt/file1.txt" type="text/plain" language="en"></podcast:transcript>`)
+	assert.NotContains(t, res, "file2.vtt")
+	assert.Equal(t, 3, strings.Count(res, "<podcast:transcript "))
+}
+
+func TestService_procChannelsDeferredRetry(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{
+				{ChannelID: chanID, VideoID: "live1", Title: "premiere", Published: time.Now()},
+				{ChannelID: chanID, VideoID: "gone1", Title: "private", Published: time.Now().Add(-time.Hour)},
+			}, nil
+		},
+	}
+	liveAvailable := false
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, id, fname string) (string, error) {
+			switch {
+			case id == "gone1":
+				return "", &ytfeed.DownloadError{Kind: ytfeed.FKPermanent, Msg: "Private video", Err: errors.New("failed")}
+			case !liveAvailable:
+				return "", &ytfeed.DownloadError{Kind: ytfeed.FKLive, Msg: "Premieres in 5 hours", Err: ytfeed.ErrSkip}
+			}
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "channel1", Name: "name1"}},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.GetCalls(), 2)
+	failures, err := boltStore.ListFailures("channel1")
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	live, found, err := boltStore.GetFailure(ytfeed.Entry{ChannelID: "channel1", VideoID: "live1"})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, ytfeed.FKLive, live.Kind)
+	assert.Equal(t, "Premieres in 5 hours", live.Message)
+	assert.True(t, live.NextRetry.After(time.Now()))
+	processed, _, err := boltStore.CheckProcessed(ytfeed.Entry{ChannelID: "channel1", VideoID: "gone1"})
+	require.NoError(t, err)
+	assert.True(t, processed, "permanent failure marked as processed")
+
+	// second run, live entry deferred, permanent one not retried
+	require.NoError(t, svc.procChannels(context.Background()))
+	assert.Len(t, downloader.GetCalls(), 2, "no new download attempts")
+
+	// retry time passed, live entry downloaded and its failure removed
+	live.NextRetry = time.Now().Add(-time.Minute)
+	require.NoError(t, boltStore.SetFailure(live))
+	liveAvailable = true
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.GetCalls(), 3)
+	_, found, err = boltStore.GetFailure(ytfeed.Entry{ChannelID: "channel1", VideoID: "live1"})
+	require.NoError(t, err)
+	assert.False(t, found)
+	res, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "live1", res[0].VideoID)
+}
+
+func TestService_procChannelsProbe(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{
+				{ChannelID: chanID, VideoID: "short1", Title: "short", Published: time.Now()},
+				{ChannelID: chanID, VideoID: "live1", Title: "live", Published: time.Now().Add(-time.Minute)},
+				{ChannelID: chanID, VideoID: "tagged1", Title: "tagged", Published: time.Now().Add(-2 * time.Minute)},
+				{ChannelID: chanID, VideoID: "good1", Title: "good", Published: time.Now().Add(-3 * time.Minute)},
+				{ChannelID: chanID, VideoID: "noprobe1", Title: "no probe", Published: time.Now().Add(-4 * time.Minute)},
+			}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(_ context.Context, id string) (ytfeed.Meta, error) {
+			switch id {
+			case "short1":
+				return ytfeed.Meta{Duration: 30, LiveStatus: "not_live", Availability: "public"}, nil
+			case "live1":
+				return ytfeed.Meta{LiveStatus: "is_upcoming", Availability: "public"}, nil
+			case "tagged1":
+				return ytfeed.Meta{Duration: 3600, Tags: []string{"music", "live performance"}}, nil
+			case "noprobe1":
+				return ytfeed.Meta{}, errors.New("probe failed")
+			}
+			return ytfeed.Meta{Duration: 3600, Tags: []string{"talk"}, UploadDate: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}, nil
+		},
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "channel1", Name: "name1", Filter: FeedFilter{Exclude: "^music$"}}},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		SkipShorts:      time.Minute,
+		Probe:           true,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 3600 }},
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.ProbeCalls(), 5)
+	require.Len(t, downloader.GetCalls(), 2, "only good1 and noprobe1 downloaded")
+	assert.Equal(t, "good1", downloader.GetCalls()[0].ID)
+	assert.Equal(t, "noprobe1", downloader.GetCalls()[1].ID, "failed probe doesn't prevent download")
+
+	res, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, []string{"talk"}, res[1].Meta.Tags, "metadata stored")
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), res[1].Meta.UploadDate)
+
+	for _, id := range []string{"short1", "tagged1"} {
+		processed, _, e := boltStore.CheckProcessed(ytfeed.Entry{ChannelID: "channel1", VideoID: id})
+		require.NoError(t, e)
+		assert.True(t, processed, "%s skipped and marked processed", id)
+	}
+	live, found, err := boltStore.GetFailure(ytfeed.Entry{ChannelID: "channel1", VideoID: "live1"})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, ytfeed.FKLive, live.Kind)
+
+	svc.Feeds, svc.Probe = []FeedInfo{{ID: "channel2", Name: "name2"}}, false
+	require.NoError(t, svc.procChannels(context.Background()))
+	assert.Len(t, downloader.ProbeCalls(), 5, "no probe if disabled")
+	assert.Len(t, downloader.GetCalls(), 7, "all entries of channel2 downloaded")
+}
+
+func TestService_ResolveFeeds(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	require.NoError(t, boltStore.SetResolved("@cached", ytfeed.Resolved{ID: "UCcached0000000000000000", Type: ytfeed.FTChannel}))
+
+	resolver := &mocks.ResolverServiceMock{
+		ResolveFunc: func(_ context.Context, ref string) (ytfeed.Resolved, error) {
+			switch ref {
+			case "@handle1":
+				return ytfeed.Resolved{ID: "UChandle1000000000000000", Type: ytfeed.FTChannel}, nil
+			case "https://www.youtube.com/playlist?list=PL1234567890":
+				return ytfeed.Resolved{ID: "PL1234567890", Type: ytfeed.FTPlaylist}, nil
+			}
+			return ytfeed.Resolved{}, errors.New("not found")
+		},
+	}
+
+	svc := Service{
+		Feeds: []FeedInfo{
+			{ID: "UCraw00000000000000000000", Name: "raw"},
+			{ID: "@handle1", Name: "handle"},
+			{ID: "https://www.youtube.com/playlist?list=PL1234567890", Name: "playlist"},
+			{ID: "@cached", Name: "cached"},
+			{ID: "@bad", Name: "bad"},
+			{ID: "vimeo.talks", Name: "vimeo", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/showcase/1"},
+		},
+		Store:    boltStore,
+		Resolver: resolver,
+	}
+
+	svc.ResolveFeeds(context.Background())
+	assert.Equal(t, []FeedInfo{
+		{ID: "UCraw00000000000000000000", Name: "raw"},
+		{ID: "UChandle1000000000000000", Ref: "@handle1", Name: "handle", Type: ytfeed.FTChannel},
+		{ID: "PL1234567890", Ref: "https://www.youtube.com/playlist?list=PL1234567890", Name: "playlist", Type: ytfeed.FTPlaylist},
+		{ID: "UCcached0000000000000000", Ref: "@cached", Name: "cached", Type: ytfeed.FTChannel},
+		{ID: "vimeo.talks", Name: "vimeo", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/showcase/1"},
+	}, svc.Feeds, "bad one dropped, non-youtube kept as is")
+	assert.Len(t, resolver.ResolveCalls(), 3, "raw and cached not resolved")
+
+	res, found, err := boltStore.GetResolved("@handle1")
+	require.NoError(t, err)
+	assert.True(t, found, "resolved id cached")
+	assert.Equal(t, "UChandle1000000000000000", res.ID)
+
+	// second resolve uses cache
+	_, err = svc.Resolve(context.Background(), "@handle1")
+	require.NoError(t, err)
+	assert.Len(t, resolver.ResolveCalls(), 3)
+}
+
+func TestService_procChannelsListing(t *testing.T) {
+	tempDir := t.TempDir()
+	listing := &mocks.ListingServiceMock{
+		ListFunc: func(_ context.Context, id, _ string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			res := []ytfeed.Entry{{ChannelID: id, VideoID: "111", Title: "vimeo video", Published: time.Now()}}
+			res[0].Link.Href = "https://vimeo.com/111"
+			return res, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(context.Context, string, ytfeed.Type) ([]ytfeed.Entry, error) {
+			return nil, errors.New("should not be called")
+		},
+	}
+	sponsorBlock := &mocks.SponsorBlockServiceMock{
+		SegmentsFunc: func(context.Context, string, []string) ([]ytfeed.Segment, error) { return nil, nil },
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	fi := FeedInfo{ID: "vimeo1", Name: "vimeo", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/showcase/1",
+		SponsorBlock: []string{"sponsor"}}
+	svc := Service{
+		Feeds:           []FeedInfo{fi, {ID: "rss1", Name: "no url", Type: ytfeed.FTRSS}},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Listing:         listing,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		SponsorBlock:    sponsorBlock,
+		PostProcessor:   &mocks.PostProcessorServiceMock{},
+		RootURL:         "http://localhost:8080/yt",
+		Probe:           true,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 3600 }},
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, listing.ListCalls(), 1, "feed without url not listed")
+	assert.Equal(t, "https://vimeo.com/showcase/1", listing.ListCalls()[0].ListURL)
+	assert.Equal(t, ytfeed.FTYtDlp, listing.ListCalls()[0].FeedType)
+	assert.Empty(t, chans.GetCalls())
+	assert.Empty(t, sponsorBlock.SegmentsCalls(), "sponsorblock is youtube only")
+
+	require.Len(t, downloader.ProbeCalls(), 1)
+	assert.Equal(t, "https://vimeo.com/111", downloader.ProbeCalls()[0].ID, "page url passed to downloader")
+	require.Len(t, downloader.GetCalls(), 1)
+	assert.Equal(t, "https://vimeo.com/111", downloader.GetCalls()[0].ID)
+
+	res, err := boltStore.Load("vimeo1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "111", res[0].VideoID)
+
+	rss, err := svc.RSSFeed(fi)
+	require.NoError(t, err)
+	assert.Contains(t, rss, "<link>https://vimeo.com/showcase/1</link>")
+	assert.Contains(t, rss, "<guid>vimeo1::111</guid>")
+}
@@ -0,0 +1,66 @@
+package youtube
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// sharedEntry returns the entry of the same video downloaded for another channel with the same processing,
+// with the file still in place
+func (s *Service) sharedEntry(entry ytfeed.Entry, fi FeedInfo) (ytfeed.Entry, bool) {
+	entries, err := s.Store.FindVideo(entry.VideoID)
+	if err != nil {
+		log.Printf("[WARN] failed to find %s in other channels: %v", entry.VideoID, err)
+		return ytfeed.Entry{}, false
+	}
+	for _, e := range entries {
+		if e.ChannelID == entry.ChannelID || e.File == "" || e.Parts > 0 {
+			continue // split videos not shared
+		}
+		other, ok := s.channel(e.ChannelID)
+		if !ok || !sameProcessing(fi, other) {
+			continue // file of unknown channel or processed differently
+		}
+		if _, statErr := os.Stat(e.File); statErr == nil {
+			return e, true
+		}
+	}
+	return ytfeed.Entry{}, false
+}
+
+// sameProcessing returns true if files of both channels made with the same post-processing, cut segments and split
+func sameProcessing(a, b FeedInfo) bool {
+	return a.PostProcess == b.PostProcess && slices.Equal(a.SponsorBlock, b.SponsorBlock) && a.Split == b.Split
+}
+
+// reuse makes the entry of the channel referencing the file of the shared entry. The file is used as is,
+// with post-processing and mp3 tags of the channel it was downloaded for. Title and published time are set
+// by channel's policies.
+func (s *Service) reuse(entry, shared ytfeed.Entry, fi FeedInfo) ytfeed.Entry {
+	entry.File = shared.File
+	entry.Duration = shared.Duration
+	entry.Transcript = shared.Transcript
+	entry.Meta = shared.Meta
+	if _, removed, ok := strings.Cut(string(shared.Media.Description), removedSegmentsPrefix); ok {
+		entry.Media.Description += removedSegmentsDescription(storedSegments(removed))
+	}
+	entry.Published = publishedTime(entry, fi)
+	entry.Title = entryTitle(entry, fi)
+	return entry
+}
+
+// fileShared returns true if the file is referenced by any stored entry. Called after the entry removed
+// from the store, so the file is shared with entries of other channels. On error the file is kept.
+func (s *Service) fileShared(file string) bool {
+	entries, err := s.Store.FindFile(file)
+	if err != nil {
+		log.Printf("[WARN] failed to check references of %s, kept: %v", file, err)
+		return true
+	}
+	return len(entries) > 0
+}
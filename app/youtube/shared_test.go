@@ -0,0 +1,113 @@
+package youtube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_procChannelsShared(t *testing.T) {
+	tempDir := t.TempDir()
+	published := time.Now().Add(-72 * time.Hour)
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{{ChannelID: chanID, VideoID: "vid1", Title: "title1", Published: published}}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	postProc := &mocks.PostProcessorServiceMock{
+		ProcessFunc: func(context.Context, string, ytfeed.PostProcOpts) error { return nil },
+	}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds: []FeedInfo{
+			{ID: "channel1", Name: "name1"},
+			{ID: "playlist1", Name: "name2", Type: ytfeed.FTPlaylist, TitleTemplate: "{{.Title}}", Published: PMDownload},
+			{ID: "channel3", Name: "name3", PostProcess: ytfeed.PostProcOpts{Mono: true}},
+		},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 1234 }},
+		PostProcessor:   postProc,
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	require.Len(t, downloader.GetCalls(), 2, "playlist reuses channel1's file, channel3 processed differently")
+	assert.Equal(t, "vid1", downloader.GetCalls()[0].ID)
+	require.Len(t, postProc.ProcessCalls(), 1)
+
+	res1, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res1, 1)
+	res2, err := boltStore.Load("playlist1", 10)
+	require.NoError(t, err)
+	require.Len(t, res2, 1)
+	res3, err := boltStore.Load("channel3", 10)
+	require.NoError(t, err)
+	require.Len(t, res3, 1)
+
+	assert.Equal(t, res1[0].File, res2[0].File, "file shared")
+	assert.NotEqual(t, res1[0].File, res3[0].File)
+	assert.Equal(t, 1234, res2[0].Duration)
+	assert.Equal(t, "name1: title1", res1[0].Title)
+	assert.Equal(t, "title1", res2[0].Title, "title made by playlist's template")
+	assert.Equal(t, published.Unix(), res1[0].Published.Unix(), "original time of old entry")
+	assert.WithinDuration(t, time.Now(), res2[0].Published, time.Minute, "download time for playlist")
+
+	// the file kept till removal of the last entry referencing it
+	require.NoError(t, svc.RemoveEntry(ytfeed.Entry{ChannelID: "channel1", VideoID: "vid1"}))
+	assert.FileExists(t, res1[0].File)
+	require.NoError(t, svc.RemoveEntry(ytfeed.Entry{ChannelID: "playlist1", VideoID: "vid1"}))
+	assert.NoFileExists(t, res1[0].File)
+	assert.FileExists(t, res3[0].File)
+}
+
+func TestService_removeFilesShared(t *testing.T) {
+	tempDir := t.TempDir()
+	shared, own := filepath.Join(tempDir, "shared.mp3"), filepath.Join(tempDir, "own.mp3")
+	require.NoError(t, os.WriteFile(shared, []byte("audio"), 0o600))
+	require.NoError(t, os.WriteFile(own, []byte("audio"), 0o600))
+
+	storeSvc := &mocks.StoreServiceMock{
+		FindFileFunc: func(file string) ([]ytfeed.Entry, error) {
+			if file == shared {
+				return []ytfeed.Entry{{ChannelID: "chan2", VideoID: "vid1", File: shared}}, nil
+			}
+			return nil, nil
+		},
+	}
+	svc := Service{Store: storeSvc}
+	assert.Equal(t, 1, svc.removeFiles(FeedInfo{ID: "chan1"}, []string{shared, own}))
+	assert.FileExists(t, shared)
+	assert.NoFileExists(t, own)
+}
+
+func TestSameProcessing(t *testing.T) {
+	assert.True(t, sameProcessing(FeedInfo{Name: "a"}, FeedInfo{Name: "b", TitleTemplate: "{{.Title}}"}))
+	assert.True(t, sameProcessing(FeedInfo{PostProcess: ytfeed.PostProcOpts{Mono: true}, SponsorBlock: []string{"sponsor"}},
+		FeedInfo{PostProcess: ytfeed.PostProcOpts{Mono: true}, SponsorBlock: []string{"sponsor"}}))
+	assert.False(t, sameProcessing(FeedInfo{}, FeedInfo{PostProcess: ytfeed.PostProcOpts{Bitrate: "64k"}}))
+	assert.False(t, sameProcessing(FeedInfo{}, FeedInfo{SponsorBlock: []string{"sponsor"}}))
+}
@@ -0,0 +1,157 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// SplitOpts defines splitting of long downloads into parts, each stored as a separate entry
+type SplitOpts struct {
+	Threshold time.Duration `yaml:"threshold" json:"threshold"` // split downloads longer than threshold, disabled if zero
+	Parts     int           `yaml:"parts" json:"parts"`         // number of parts, duration divided by threshold if not set
+}
+
+// chaptersTolerance is the max difference in seconds between the downloaded file and the source video,
+// chapters' times don't match the file cut by SponsorBlock or sped up
+const chaptersTolerance = 2
+
+// Enabled returns true if long downloads should be split
+func (o SplitOpts) Enabled() bool {
+	return o.Threshold > 0
+}
+
+// count returns the number of parts for the duration in seconds, 1 if the file should not be split
+func (o SplitOpts) count(duration int) int {
+	dur := time.Duration(duration) * time.Second
+	if !o.Enabled() || dur <= o.Threshold {
+		return 1
+	}
+	if o.Parts > 1 {
+		return o.Parts
+	}
+	return int(math.Ceil(float64(dur) / float64(o.Threshold)))
+}
+
+func (o SplitOpts) validate() error {
+	if o.Threshold < 0 || o.Parts < 0 {
+		return errors.New("split threshold and parts can't be negative")
+	}
+	if o.Threshold > 0 && o.Threshold < time.Minute {
+		return fmt.Errorf("split threshold %v is too short, at least 1m", o.Threshold)
+	}
+	return nil
+}
+
+// split cuts the downloaded entry's file into parts if it is longer than the channel's threshold.
+// Parts are cut at chapters' boundaries or silences near the even split points, published a second apart
+// to keep them in order. The original file removed after split, returns the entry as is if not split.
+func (s *Service) split(ctx context.Context, entry ytfeed.Entry, fi FeedInfo) []ytfeed.Entry {
+	n := fi.Split.count(entry.Duration)
+	if n < 2 || s.PostProcessor == nil || entry.File == "" {
+		return []ytfeed.Entry{entry}
+	}
+
+	points := splitPoints(float64(entry.Duration), n, s.splitCandidates(ctx, entry))
+	res := make([]ytfeed.Entry, n)
+	parts := make([]ytfeed.SplitPart, n)
+	for k := range n {
+		part := entry
+		part.Part, part.Parts = k+1, n
+		part.Published = entry.Published.Add(time.Duration(k) * time.Second)
+		part.Title = partTitle(entry.Title, part.Part, part.Parts)
+		part.Transcript = "" // subtitles of the whole video don't match the part
+		part.File = filepath.Join(filepath.Dir(entry.File), s.makeFileName(part)+filepath.Ext(entry.File))
+		res[k] = part
+
+		parts[k].File = part.File
+		if k > 0 {
+			parts[k].Start = points[k-1]
+		}
+		if k < n-1 {
+			parts[k].End = points[k]
+		}
+	}
+	if err := s.PostProcessor.Split(ctx, entry.File, parts); err != nil {
+		log.Printf("[WARN] failed to split %s into %d parts, kept whole: %v", entry.VideoID, n, err)
+		return []ytfeed.Entry{entry}
+	}
+
+	thumb, thumbErr := os.ReadFile(thumbFile(entry.File))
+	for k := range res {
+		if thumbErr == nil {
+			if err := os.WriteFile(thumbFile(res[k].File), thumb, 0o644); err != nil { //nolint:gosec // served as media
+				log.Printf("[WARN] failed to copy thumbnail of %s: %v", res[k].File, err)
+			}
+		}
+		res[k].Duration = s.DurationService.File(res[k].File)
+		if tagsErr := s.updateMp3Tags(res[k].File, res[k], fi); tagsErr != nil {
+			log.Printf("[WARN] failed to update metadata for %s: %s", res[k].File, tagsErr)
+		}
+	}
+
+	if err := os.Remove(entry.File); err != nil {
+		log.Printf("[WARN] failed to remove split file %s: %v", entry.File, err)
+	}
+	s.removeSidecars(entry.File)
+	log.Printf("[INFO] split %s (%s) into %d parts at %v", entry.VideoID, entry.Title, n, points)
+	return res
+}
+
+// splitCandidates returns preferred split points, chapters' starts if they match the file, silences otherwise
+func (s *Service) splitCandidates(ctx context.Context, entry ytfeed.Entry) []float64 {
+	res := []float64{}
+	if len(entry.Meta.Chapters) > 1 && math.Abs(float64(entry.Meta.Duration-entry.Duration)) <= chaptersTolerance {
+		for _, c := range entry.Meta.Chapters {
+			if c.Start > 0 {
+				res = append(res, c.Start)
+			}
+		}
+		return res
+	}
+	silences, err := s.PostProcessor.Silences(ctx, entry.File)
+	if err != nil {
+		log.Printf("[WARN] failed to detect silences in %s, split evenly: %v", entry.File, err)
+		return res
+	}
+	for _, seg := range silences {
+		res = append(res, (seg.Start+seg.End)/2)
+	}
+	return res
+}
+
+// splitPoints returns n-1 points to split the audio of the given duration into n parts. Each point is the candidate
+// nearest to the even split point within a quarter of the part's length, or the even split point itself.
+func splitPoints(duration float64, n int, candidates []float64) []float64 {
+	partLen := duration / float64(n)
+	res := make([]float64, 0, n-1)
+	prev := 0.0
+	for k := 1; k < n; k++ {
+		target := partLen * float64(k)
+		point, dist := target, partLen/4
+		for _, c := range candidates {
+			if d := math.Abs(c - target); c > prev && d <= dist {
+				point, dist = c, d
+			}
+		}
+		res = append(res, point)
+		prev = point
+	}
+	return res
+}
+
+// partTitle returns the title of the split video's part
+func partTitle(title string, part, parts int) string {
+	if parts < 2 {
+		return title
+	}
+	return fmt.Sprintf("%s (Part %d/%d)", title, part, parts)
+}
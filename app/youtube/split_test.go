@@ -0,0 +1,225 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestSplitOpts_count(t *testing.T) {
+	tbl := []struct {
+		name     string
+		opts     SplitOpts
+		duration int
+		res      int
+	}{
+		{name: "disabled", opts: SplitOpts{}, duration: 36000, res: 1},
+		{name: "below threshold", opts: SplitOpts{Threshold: 2 * time.Hour}, duration: 7200, res: 1},
+		{name: "by threshold", opts: SplitOpts{Threshold: time.Hour}, duration: 3*3600 + 10, res: 4},
+		{name: "fixed parts", opts: SplitOpts{Threshold: time.Hour, Parts: 2}, duration: 5 * 3600, res: 2},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.res, tt.opts.count(tt.duration))
+		})
+	}
+}
+
+func TestSplitOpts_validate(t *testing.T) {
+	require.NoError(t, SplitOpts{}.validate())
+	require.NoError(t, SplitOpts{Threshold: 2 * time.Hour, Parts: 3}.validate())
+	require.Error(t, SplitOpts{Threshold: -time.Hour}.validate())
+	require.Error(t, SplitOpts{Threshold: time.Hour, Parts: -1}.validate())
+	require.EqualError(t, SplitOpts{Threshold: 10 * time.Second}.validate(), "split threshold 10s is too short, at least 1m")
+}
+
+func TestSplitPoints(t *testing.T) {
+	tbl := []struct {
+		name       string
+		duration   float64
+		n          int
+		candidates []float64
+		res        []float64
+	}{
+		{name: "even", duration: 9000, n: 3, res: []float64{3000, 6000}},
+		{name: "nearest candidates", duration: 9000, n: 3, candidates: []float64{100, 2800, 2950, 5500, 6100, 8000},
+			res: []float64{2950, 6100}},
+		{name: "candidates too far", duration: 9000, n: 3, candidates: []float64{1000, 4500}, res: []float64{3000, 6000}},
+		{name: "candidate used once", duration: 400, n: 4, candidates: []float64{120}, res: []float64{120, 200, 300}},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.res, splitPoints(tt.duration, tt.n, tt.candidates))
+		})
+	}
+}
+
+func TestPartTitle(t *testing.T) {
+	assert.Equal(t, "title", partTitle("title", 0, 0))
+	assert.Equal(t, "title (Part 2/3)", partTitle("title", 2, 3))
+}
+
+func TestService_split(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "audio.mp3")
+	writeFiles := func() {
+		require.NoError(t, os.WriteFile(file, []byte("full audio"), 0o600))
+		require.NoError(t, os.WriteFile(thumbFile(file), []byte("image"), 0o600))
+	}
+	postProc := &mocks.PostProcessorServiceMock{
+		SplitFunc: func(_ context.Context, _ string, parts []ytfeed.SplitPart) error {
+			for _, p := range parts {
+				require.NoError(t, os.WriteFile(p.File, []byte("part audio"), 0o600))
+			}
+			return nil
+		},
+		SilencesFunc: func(context.Context, string) ([]ytfeed.Segment, error) {
+			return []ytfeed.Segment{{Start: 5000, End: 5002, Category: "silence"}}, nil
+		},
+	}
+	svc := Service{PostProcessor: postProc, DurationService: &mocks.DurationServiceMock{FileFunc: func(string) int { return 3600 }}}
+	fi := FeedInfo{ID: "chan1", Name: "name1", Split: SplitOpts{Threshold: 2 * time.Hour}}
+	published := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	entry := ytfeed.Entry{ChannelID: "chan1", VideoID: "vid1", Title: "name1: title1", File: file, Duration: 3 * 3600,
+		Published: published, Transcript: "en"}
+
+	t.Run("short, not split", func(t *testing.T) {
+		writeFiles()
+		e := entry
+		e.Duration = 3600
+		res := svc.split(context.Background(), e, fi)
+		assert.Equal(t, []ytfeed.Entry{e}, res)
+		assert.Empty(t, postProc.SplitCalls())
+	})
+
+	t.Run("split at silence", func(t *testing.T) {
+		writeFiles()
+		res := svc.split(context.Background(), entry, fi)
+		require.Len(t, res, 2)
+		require.Len(t, postProc.SplitCalls(), 1)
+		parts := postProc.SplitCalls()[0].Parts
+		require.Len(t, parts, 2)
+		assert.InDelta(t, 0, parts[0].Start, 0.001)
+		assert.InDelta(t, 5001, parts[0].End, 0.001)
+		assert.InDelta(t, 5001, parts[1].Start, 0.001)
+		assert.InDelta(t, 0, parts[1].End, 0.001, "till the end")
+
+		for k, part := range res {
+			assert.Equal(t, "vid1", part.VideoID)
+			assert.Equal(t, k+1, part.Part)
+			assert.Equal(t, 2, part.Parts)
+			assert.Equal(t, parts[k].File, part.File)
+			assert.Equal(t, published.Add(time.Duration(k)*time.Second), part.Published)
+			assert.Equal(t, 3600, part.Duration)
+			assert.Empty(t, part.Transcript)
+			assert.FileExists(t, thumbFile(part.File))
+		}
+		assert.Equal(t, "name1: title1 (Part 1/2)", res[0].Title)
+		assert.Equal(t, "name1: title1 (Part 2/2)", res[1].Title)
+		assert.NotEqual(t, res[0].File, res[1].File)
+		assert.NoFileExists(t, file, "original removed")
+		assert.NoFileExists(t, thumbFile(file))
+	})
+
+	t.Run("split at chapters", func(t *testing.T) {
+		writeFiles()
+		e := entry
+		e.Meta = ytfeed.Meta{Duration: 3*3600 + 1, Chapters: []ytfeed.Chapter{{Start: 0, End: 4800}, {Start: 4800, End: 10801}}}
+		res := svc.split(context.Background(), e, fi)
+		require.Len(t, res, 2)
+		require.Len(t, postProc.SplitCalls(), 2)
+		assert.InDelta(t, 4800, postProc.SplitCalls()[1].Parts[0].End, 0.001)
+		assert.Len(t, postProc.SilencesCalls(), 1, "no silence detection with chapters")
+	})
+
+	t.Run("failed split, kept whole", func(t *testing.T) {
+		writeFiles()
+		failing := &mocks.PostProcessorServiceMock{
+			SplitFunc:    func(context.Context, string, []ytfeed.SplitPart) error { return errors.New("failed") },
+			SilencesFunc: func(context.Context, string) ([]ytfeed.Segment, error) { return nil, errors.New("failed") },
+		}
+		res := (&Service{PostProcessor: failing}).split(context.Background(), entry, fi)
+		assert.Equal(t, []ytfeed.Entry{entry}, res)
+		assert.FileExists(t, file)
+	})
+}
+
+func TestService_procChannelsSplit(t *testing.T) {
+	tempDir := t.TempDir()
+	chans := &mocks.ChannelServiceMock{
+		GetFunc: func(_ context.Context, chanID string, _ ytfeed.Type) ([]ytfeed.Entry, error) {
+			return []ytfeed.Entry{{ChannelID: chanID, VideoID: "vid1", Title: "title1", Published: time.Now()}}, nil
+		},
+	}
+	downloader := &mocks.DownloaderServiceMock{
+		ProbeFunc: func(context.Context, string) (ytfeed.Meta, error) { return ytfeed.Meta{}, nil },
+		GetFunc: func(_ context.Context, _ string, fname string) (string, error) {
+			fpath := filepath.Join(tempDir, fname+".mp3")
+			require.NoError(t, os.WriteFile(fpath, []byte("audio"), 0o600))
+			return fpath, nil
+		},
+	}
+	postProc := &mocks.PostProcessorServiceMock{
+		SplitFunc: func(_ context.Context, _ string, parts []ytfeed.SplitPart) error {
+			for _, p := range parts {
+				require.NoError(t, os.WriteFile(p.File, []byte("part"), 0o600))
+			}
+			return nil
+		},
+		SilencesFunc: func(context.Context, string) ([]ytfeed.Segment, error) { return nil, nil },
+	}
+	duration := &mocks.DurationServiceMock{FileFunc: func(file string) int {
+		if data, err := os.ReadFile(file); err == nil && string(data) == "part" { //nolint:gosec // test file path
+			return 3600
+		}
+		return 3 * 3600
+	}}
+
+	db, err := bolt.Open(filepath.Join(tempDir, "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	boltStore := &store.BoltDB{DB: db}
+	svc := Service{
+		Feeds:           []FeedInfo{{ID: "channel1", Name: "name1", Split: SplitOpts{Threshold: time.Hour}}},
+		Downloader:      downloader,
+		ChannelService:  chans,
+		Store:           boltStore,
+		KeepPerChannel:  10,
+		DurationService: duration,
+		PostProcessor:   postProc,
+		RootURL:         "http://localhost:8080/yt/media",
+	}
+
+	require.NoError(t, svc.procChannels(context.Background()))
+	res, err := boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, "name1: title1 (Part 3/3)", res[0].Title, "the last part is the newest")
+	assert.Equal(t, "name1: title1 (Part 1/3)", res[2].Title)
+
+	rss, err := svc.RSSFeed(svc.Feeds[0])
+	require.NoError(t, err)
+	assert.Contains(t, rss, "<guid>channel1::vid1::1</guid>")
+	assert.Contains(t, rss, "<guid>channel1::vid1::3</guid>")
+
+	_, err = svc.RefreshEntry(context.Background(), "channel1", "vid1", true)
+	require.ErrorContains(t, err, "can't re-download part 1/3 of vid1")
+
+	require.NoError(t, svc.RemoveEntry(ytfeed.Entry{ChannelID: "channel1", VideoID: "vid1"}))
+	for _, part := range res {
+		assert.NoFileExists(t, part.File)
+	}
+	res, err = boltStore.Load("channel1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, res, "all parts removed")
+}
@@ -2,6 +2,7 @@
 package store
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"encoding/json"
 	"errors"
@@ -16,7 +17,19 @@ import (
 	"github.com/umputun/feed-master/app/youtube/feed"
 )
 
-var processedBkt = []byte("processed")
+var (
+	processedBkt = []byte("processed")
+	failuresBkt  = []byte("failures")
+	resolvedBkt  = []byte("resolved")
+	channelsBkt  = []byte("channels")
+
+	// index buckets with "value\x00channelID\x00key" keys of entries, built on the first lookup
+	videosIdxBkt = []byte("videos_idx") // by video id
+	filesIdxBkt  = []byte("files_idx")  // by file, number of keys is the number of entries referencing the file
+)
+
+// ErrNoBucket returned for channels without entries stored
+var ErrNoBucket = errors.New("no bucket")
 
 // BoltDB store for metadata related to downloaded YouTube audio.
 type BoltDB struct {
@@ -53,6 +66,9 @@ func (s *BoltDB) Save(entry feed.Entry) (bool, error) {
 		if e != nil {
 			return fmt.Errorf("save entry %s: %w", entry.VideoID, e)
 		}
+		if e = indexEntry(tx, entry, key, false); e != nil {
+			return e
+		}
 
 		created = true
 		return nil
@@ -92,14 +108,17 @@ func (s *BoltDB) Exist(entry feed.Entry) (bool, error) {
 	return found, nil
 }
 
-// Load entries from bolt for a given channel, up to max in reverse order (from newest to oldest)
+// Load entries from bolt for a given channel, up to max in reverse order (from newest to oldest).
+// Parts of the split video counted as a single entry.
 func (s *BoltDB) Load(channelID string, maximum int) ([]feed.Entry, error) {
 	var result []feed.Entry
+	var prev feed.Entry
+	videos := 0
 
 	err := s.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(channelID))
 		if bucket == nil {
-			return fmt.Errorf("no bucket for %s", channelID)
+			return fmt.Errorf("%w for %s", ErrNoBucket, channelID)
 		}
 		c := bucket.Cursor()
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
@@ -108,9 +127,13 @@ func (s *BoltDB) Load(channelID string, maximum int) ([]feed.Entry, error) {
 				log.Printf("[WARN] failed to unmarshal %s, %q: %v", channelID, string(v), err)
 				continue
 			}
-			if len(result) >= maximum {
-				break
+			if !isNextPart(prev, item) {
+				if videos >= maximum {
+					break
+				}
+				videos++
 			}
+			prev = item
 			result = append(result, item)
 		}
 		return nil
@@ -142,7 +165,8 @@ func (s *BoltDB) Last() (feed.Entry, error) {
 	return entries[0], nil
 }
 
-// RemoveOld removes old entries from bolt and returns the list of removed entry.File
+// RemoveOld removes old entries from bolt and returns the list of removed entry.File.
+// Parts of the split video counted as a single entry, the video kept or removed as a whole.
 // the caller should delete the files
 // important: this method returns the list of removed keys even if there was an error
 func (s *BoltDB) RemoveOld(channelID string, keep int) ([]string, error) {
@@ -153,22 +177,29 @@ func (s *BoltDB) RemoveOld(channelID string, keep int) ([]string, error) {
 		errs := new(multierror.Error)
 		bucket := tx.Bucket([]byte(channelID))
 		if bucket == nil {
-			return fmt.Errorf("no bucket for %s", channelID)
+			return fmt.Errorf("%w for %s", ErrNoBucket, channelID)
 		}
 		recs := 0
+		var prev feed.Entry
 		c := bucket.Cursor()
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
-			recs++
+			var item feed.Entry
+			if err := json.Unmarshal(v, &item); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			if !isNextPart(prev, item) {
+				recs++
+			}
+			prev = item
 			if recs > keep {
-				var item feed.Entry
-				if err := json.Unmarshal(v, &item); err != nil {
-					log.Printf("[WARN] failed to unmarshal, %v", err)
-					continue
-				}
 				if err := bucket.Delete(k); err != nil {
 					errs = multierror.Append(errs, fmt.Errorf("failed to delete %s (%s): %w", string(k), item.File, err))
 					continue
 				}
+				if err := indexEntry(tx, item, k, true); err != nil {
+					errs = multierror.Append(errs, err)
+				}
 				res = append(res, item.File)
 				deleted++
 			}
@@ -182,12 +213,12 @@ func (s *BoltDB) RemoveOld(channelID string, keep int) ([]string, error) {
 	return res, nil
 }
 
-// Remove entry matched by vidoID and channelID
+// Remove entry matched by vidoID and channelID, parts of the split video matched by the part number
 func (s *BoltDB) Remove(entry feed.Entry) error {
 	err := s.Update(func(tx *bolt.Tx) (e error) {
 		bucket := tx.Bucket([]byte(entry.ChannelID))
 		if bucket == nil {
-			return fmt.Errorf("no bucket for %s", entry.ChannelID)
+			return fmt.Errorf("%w for %s", ErrNoBucket, entry.ChannelID)
 		}
 		c := bucket.Cursor()
 		for k, v := c.Last(); k != nil; k, v = c.Prev() {
@@ -196,13 +227,16 @@ func (s *BoltDB) Remove(entry feed.Entry) error {
 				log.Printf("[WARN] failed to unmarshal, %v", err)
 				continue
 			}
-			if item.VideoID == entry.VideoID {
+			if item.VideoID == entry.VideoID && item.Part == entry.Part {
 				if err := bucket.Delete(k); err != nil {
 					return fmt.Errorf("failed to delete %s (%s): %w", string(k), item.VideoID, err)
 				}
+				if err := indexEntry(tx, item, k, true); err != nil {
+					return err
+				}
 				log.Printf("[INFO] delete %s - %s", string(k), item.String())
+				return nil
 			}
-			return nil
 		}
 		return nil
 	})
@@ -342,6 +376,433 @@ func (s *BoltDB) ListProcessed() (res []string, err error) {
 	return res, nil
 }
 
+// SetFailure saves the failure of the download attempt for a given channel+video, replaces previous failure if any
+func (s *BoltDB) SetFailure(failure feed.Failure) error {
+	key, keyErr := s.procKey(feed.Entry{ChannelID: failure.ChannelID, VideoID: failure.VideoID})
+	if keyErr != nil {
+		return fmt.Errorf("failed to generate key for %s: %w", failure.VideoID, keyErr)
+	}
+
+	err := s.Update(func(tx *bolt.Tx) error {
+		bucket, e := tx.CreateBucketIfNotExists(failuresBkt)
+		if e != nil {
+			return fmt.Errorf("create bucket %s: %w", failuresBkt, e)
+		}
+		jdata, jerr := json.Marshal(&failure)
+		if jerr != nil {
+			return fmt.Errorf("marshal failure %s: %w", failure.VideoID, jerr)
+		}
+		if e = bucket.Put(key, jdata); e != nil {
+			return fmt.Errorf("save failure %s: %w", failure.VideoID, e)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// GetFailure returns the failure stored for a given channel+video, found=false if the entry never failed
+func (s *BoltDB) GetFailure(entry feed.Entry) (failure feed.Failure, found bool, err error) {
+	key, keyErr := s.procKey(entry)
+	if keyErr != nil {
+		return feed.Failure{}, false, fmt.Errorf("failed to generate key for %s: %w", entry.VideoID, keyErr)
+	}
+
+	err = s.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(failuresBkt)
+		if bucket == nil {
+			return nil
+		}
+		res := bucket.Get(key)
+		if res == nil {
+			return nil
+		}
+		if e := json.Unmarshal(res, &failure); e != nil {
+			return fmt.Errorf("unmarshal failure %s: %w", entry.VideoID, e)
+		}
+		found = true
+		return nil
+	})
+
+	if err != nil {
+		return failure, found, fmt.Errorf("view store: %w", err)
+	}
+	return failure, found, nil
+}
+
+// RemoveFailure removes the failure for a given channel+video, does nothing if not found
+func (s *BoltDB) RemoveFailure(entry feed.Entry) error {
+	key, keyErr := s.procKey(entry)
+	if keyErr != nil {
+		return fmt.Errorf("failed to generate key for %s: %w", entry.VideoID, keyErr)
+	}
+
+	err := s.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(failuresBkt)
+		if bucket == nil {
+			return nil
+		}
+		if e := bucket.Delete(key); e != nil {
+			return fmt.Errorf("remove failure %s: %w", entry.VideoID, e)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// ListFailures returns failures for a given channel sorted by the last attempt, newest first
+func (s *BoltDB) ListFailures(channelID string) (res []feed.Failure, err error) {
+	err = s.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(failuresBkt)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var failure feed.Failure
+			if e := json.Unmarshal(v, &failure); e != nil {
+				log.Printf("[WARN] failed to unmarshal failure, %v", e)
+				return nil
+			}
+			if failure.ChannelID == channelID {
+				res = append(res, failure)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return res, fmt.Errorf("view store: %w", err)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].LastAttempt.After(res[j].LastAttempt) })
+	return res, nil
+}
+
+// SetResolved saves resolved channel or playlist id for the reference, i.e. @handle or url
+func (s *BoltDB) SetResolved(ref string, res feed.Resolved) error {
+	err := s.Update(func(tx *bolt.Tx) error {
+		bucket, e := tx.CreateBucketIfNotExists(resolvedBkt)
+		if e != nil {
+			return fmt.Errorf("create bucket %s: %w", resolvedBkt, e)
+		}
+		jdata, jerr := json.Marshal(&res)
+		if jerr != nil {
+			return fmt.Errorf("marshal resolved %s: %w", ref, jerr)
+		}
+		if e = bucket.Put([]byte(ref), jdata); e != nil {
+			return fmt.Errorf("save resolved %s: %w", ref, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// GetResolved returns resolved channel or playlist id for the reference, found=false if never resolved
+func (s *BoltDB) GetResolved(ref string) (res feed.Resolved, found bool, err error) {
+	err = s.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resolvedBkt)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get([]byte(ref))
+		if v == nil {
+			return nil
+		}
+		if e := json.Unmarshal(v, &res); e != nil {
+			return fmt.Errorf("unmarshal resolved %s: %w", ref, e)
+		}
+		found = true
+		return nil
+	})
+	if err != nil {
+		return res, found, fmt.Errorf("view store: %w", err)
+	}
+	return res, found, nil
+}
+
+// SetChannel saves the channel settings changed at runtime, data is an opaque json record of the channel
+func (s *BoltDB) SetChannel(id string, data []byte) error {
+	err := s.Update(func(tx *bolt.Tx) error {
+		bucket, e := tx.CreateBucketIfNotExists(channelsBkt)
+		if e != nil {
+			return fmt.Errorf("create bucket %s: %w", channelsBkt, e)
+		}
+		if e = bucket.Put([]byte(id), data); e != nil {
+			return fmt.Errorf("save channel %s: %w", id, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// RemoveChannel deletes the channel record saved with SetChannel, entries of the channel are kept
+func (s *BoltDB) RemoveChannel(id string) error {
+	err := s.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(channelsBkt)
+		if bucket == nil {
+			return nil
+		}
+		if e := bucket.Delete([]byte(id)); e != nil {
+			return fmt.Errorf("delete channel %s: %w", id, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// ListChannels returns all channel records saved with SetChannel, by channel id
+func (s *BoltDB) ListChannels() (res map[string][]byte, err error) {
+	res = map[string][]byte{}
+	err = s.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(channelsBkt)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			res[string(k)] = append([]byte(nil), v...) // values are valid during transaction only
+			return nil
+		})
+	})
+	if err != nil {
+		return res, fmt.Errorf("view store: %w", err)
+	}
+	return res, nil
+}
+
+// PurgeChannel deletes all entries of the channel with their processed and failure records.
+// Returns the list of removed entry.File, the caller should delete the files.
+func (s *BoltDB) PurgeChannel(channelID string) (res []string, err error) {
+	err = s.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(channelID))
+		if bucket == nil {
+			return nil
+		}
+		var entries []feed.Entry
+		var keys [][]byte
+		if e := bucket.ForEach(func(k, v []byte) error {
+			var item feed.Entry
+			if err := json.Unmarshal(v, &item); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				return nil
+			}
+			entries = append(entries, item)
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		}); e != nil {
+			return fmt.Errorf("read entries of %s: %w", channelID, e)
+		}
+
+		for i, entry := range entries {
+			if e := indexEntry(tx, entry, keys[i], true); e != nil {
+				return e
+			}
+			key, keyErr := s.procKey(entry)
+			if keyErr != nil {
+				return fmt.Errorf("failed to generate key for %s: %w", entry.VideoID, keyErr)
+			}
+			for _, bkt := range [][]byte{processedBkt, failuresBkt} {
+				if b := tx.Bucket(bkt); b != nil {
+					if e := b.Delete(key); e != nil {
+						return fmt.Errorf("delete %s of %s: %w", bkt, entry.VideoID, e)
+					}
+				}
+			}
+			if entry.File != "" {
+				res = append(res, entry.File)
+			}
+		}
+		if e := tx.DeleteBucket([]byte(channelID)); e != nil {
+			return fmt.Errorf("delete bucket %s: %w", channelID, e)
+		}
+		log.Printf("[INFO] purged %d entries of %s", len(entries), channelID)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update store: %w", err)
+	}
+	return res, nil
+}
+
+// FindVideo returns stored entries of the video in all channels
+func (s *BoltDB) FindVideo(videoID string) ([]feed.Entry, error) {
+	if videoID == "" {
+		return nil, nil
+	}
+	return s.find(videosIdxBkt, videoID)
+}
+
+// FindFile returns stored entries of all channels referencing the file
+func (s *BoltDB) FindFile(file string) ([]feed.Entry, error) {
+	if file == "" {
+		return nil, nil
+	}
+	return s.find(filesIdxBkt, file)
+}
+
+// Files returns files referenced by entries of all channels, including channels not configured anymore
+func (s *BoltDB) Files() (res []string, err error) {
+	if err = s.buildIndex(); err != nil {
+		return nil, err
+	}
+	err = s.View(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(filesIdxBkt)
+		if idx == nil {
+			return nil
+		}
+		return idx.ForEach(func(k, _ []byte) error {
+			file, _, _ := bytes.Cut(k, []byte{0})
+			if len(res) == 0 || res[len(res)-1] != string(file) { // keys sorted, records of the same file adjacent
+				res = append(res, string(file))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("view store: %w", err)
+	}
+	return res, nil
+}
+
+// find returns entries with the value in the index bucket, the index built first if missing
+func (s *BoltDB) find(idxBkt []byte, val string) (res []feed.Entry, err error) {
+	if err = s.buildIndex(); err != nil {
+		return nil, err
+	}
+	err = s.View(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(idxBkt)
+		if idx == nil {
+			return nil
+		}
+		prefix := []byte(val + "\x00")
+		c := idx.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			elems := bytes.SplitN(k[len(prefix):], []byte{0}, 2)
+			if len(elems) != 2 {
+				continue
+			}
+			bucket := tx.Bucket(elems[0])
+			if bucket == nil {
+				continue
+			}
+			v := bucket.Get(elems[1])
+			if v == nil {
+				continue
+			}
+			var item feed.Entry
+			if err := json.Unmarshal(v, &item); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			res = append(res, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("view store: %w", err)
+	}
+	return res, nil
+}
+
+// buildIndex makes index buckets from entries of all channels if not made yet, i.e. for the db made by
+// the previous version. Buckets of other data skipped, the db may be shared with other stores,
+// so values not parsed as entries of the bucket's channel ignored.
+func (s *BoltDB) buildIndex() error {
+	built := false
+	if err := s.View(func(tx *bolt.Tx) error {
+		built = tx.Bucket(videosIdxBkt) != nil && tx.Bucket(filesIdxBkt) != nil
+		return nil
+	}); err != nil || built {
+		return err
+	}
+
+	err := s.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(videosIdxBkt) != nil && tx.Bucket(filesIdxBkt) != nil {
+			return nil
+		}
+		var entries []feed.Entry
+		var keys [][]byte
+		err := tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			for _, bkt := range [][]byte{processedBkt, failuresBkt, resolvedBkt, channelsBkt, videosIdxBkt, filesIdxBkt} {
+				if bytes.Equal(name, bkt) {
+					return nil
+				}
+			}
+			return bucket.ForEach(func(k, v []byte) error {
+				var item feed.Entry
+				if json.Unmarshal(v, &item) != nil || item.VideoID == "" || item.ChannelID != string(name) {
+					return nil
+				}
+				entries = append(entries, item)
+				keys = append(keys, append([]byte(nil), k...))
+				return nil
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("read entries: %w", err)
+		}
+		for _, bkt := range [][]byte{videosIdxBkt, filesIdxBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return fmt.Errorf("create bucket %s: %w", bkt, err)
+			}
+		}
+		for i, entry := range entries {
+			if err := indexEntry(tx, entry, keys[i], false); err != nil {
+				return err
+			}
+		}
+		log.Printf("[INFO] built index of %d entries", len(entries))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("update store: %w", err)
+	}
+	return nil
+}
+
+// indexEntry adds index records of the entry stored with the key, or deletes them with remove.
+// Skipped if the index not built yet, it will be made from all entries on the first lookup.
+func indexEntry(tx *bolt.Tx, entry feed.Entry, key []byte, remove bool) error {
+	for _, idx := range []struct {
+		bkt []byte
+		val string
+	}{{videosIdxBkt, entry.VideoID}, {filesIdxBkt, entry.File}} {
+		bucket := tx.Bucket(idx.bkt)
+		if bucket == nil || idx.val == "" {
+			continue
+		}
+		k := bytes.Join([][]byte{[]byte(idx.val), []byte(entry.ChannelID), key}, []byte{0})
+		var err error
+		if remove {
+			err = bucket.Delete(k)
+		} else {
+			err = bucket.Put(k, []byte{})
+		}
+		if err != nil {
+			return fmt.Errorf("update index %s of %s: %w", idx.bkt, entry.VideoID, err)
+		}
+	}
+	return nil
+}
+
+// isNextPart checks if the entry is a part of the same split video as the previous one
+func isNextPart(prev, entry feed.Entry) bool {
+	return entry.Part > 0 && prev.Part > 0 && prev.ChannelID == entry.ChannelID && prev.VideoID == entry.VideoID
+}
+
 func (s *BoltDB) key(entry feed.Entry) ([]byte, error) {
 	h := sha1.New()
 	if _, err := h.Write([]byte(entry.VideoID)); err != nil {
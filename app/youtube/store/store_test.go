@@ -1,6 +1,8 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
@@ -99,6 +101,29 @@ func TestStore_Remove(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, res, 1)
 	assert.Equal(t, "vid1", res[0].VideoID)
+
+	// remove not the newest entry
+	_, err = s.Save(entry2)
+	require.NoError(t, err)
+	err = s.Remove(feed.Entry{ChannelID: "chan1", VideoID: "vid1"})
+	require.NoError(t, err)
+	res, err = s.Load("chan1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "vid2", res[0].VideoID)
+
+	// parts of the split video removed by the part number
+	for k := 1; k <= 2; k++ {
+		_, err = s.Save(feed.Entry{ChannelID: "chan1", VideoID: "vid3", Part: k, Parts: 2, Published: entry2.Published.Add(time.Duration(k) * time.Second)})
+		require.NoError(t, err)
+	}
+	err = s.Remove(feed.Entry{ChannelID: "chan1", VideoID: "vid3", Part: 1})
+	require.NoError(t, err)
+	res, err = s.Load("chan1", 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "vid3", res[0].VideoID)
+	assert.Equal(t, 2, res[0].Part)
 }
 
 func TestStore_Exist(t *testing.T) {
@@ -176,6 +201,37 @@ func TestBoldDB_RemoveOld(t *testing.T) {
 	assert.Equal(t, []string{"f2", "f1"}, res)
 }
 
+func TestBoltDB_SplitPartsCountedAsOne(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	s := BoltDB{DB: db}
+
+	published := time.Date(2022, time.March, 21, 16, 45, 22, 0, time.UTC)
+	_, err = s.Save(feed.Entry{ChannelID: "chan1", VideoID: "vid1", Published: published, File: "f1"})
+	require.NoError(t, err)
+	for k := 1; k <= 3; k++ {
+		_, err = s.Save(feed.Entry{ChannelID: "chan1", VideoID: "vid2", Part: k, Parts: 3,
+			Published: published.Add(time.Hour + time.Duration(k)*time.Second), File: fmt.Sprintf("f2-%d", k)})
+		require.NoError(t, err)
+	}
+	_, err = s.Save(feed.Entry{ChannelID: "chan1", VideoID: "vid3", Published: published.Add(2 * time.Hour), File: "f3"})
+	require.NoError(t, err)
+
+	res, err := s.Load("chan1", 2)
+	require.NoError(t, err)
+	require.Len(t, res, 4, "all parts of the split video loaded")
+	assert.Equal(t, "vid3", res[0].VideoID)
+	assert.Equal(t, 1, res[3].Part)
+
+	files, err := s.RemoveOld("chan1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f1"}, files, "parts not evicted by keep")
+
+	files, err = s.RemoveOld("chan1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f2-3", "f2-2", "f2-1"}, files, "split video removed as a whole")
+}
+
 func TestBoltDB_SetProcessed(t *testing.T) {
 	tmpfile := filepath.Join(t.TempDir(), "test.db")
 	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
@@ -298,3 +354,257 @@ func TestBoltDB_Last(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "vid3", res.VideoID)
 }
+
+func TestBoltDB_Failures(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test.db")
+	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	s := BoltDB{DB: db}
+
+	_, found, err := s.GetFailure(feed.Entry{ChannelID: "chan1", VideoID: "vid1"})
+	require.NoError(t, err)
+	assert.False(t, found, "no failures bucket yet")
+
+	ts := time.Date(2022, time.March, 21, 16, 45, 22, 0, time.UTC)
+	f1 := feed.Failure{ChannelID: "chan1", VideoID: "vid1", Title: "title1", Kind: feed.FKLive, Attempts: 1,
+		LastAttempt: ts, NextRetry: ts.Add(30 * time.Minute)}
+	f2 := feed.Failure{ChannelID: "chan1", VideoID: "vid2", Kind: feed.FKPermanent, Attempts: 1, LastAttempt: ts.Add(time.Hour)}
+	f3 := feed.Failure{ChannelID: "chan2", VideoID: "vid3", Kind: feed.FKTransient, Attempts: 1, LastAttempt: ts}
+	require.NoError(t, s.SetFailure(f1))
+	require.NoError(t, s.SetFailure(f2))
+	require.NoError(t, s.SetFailure(f3))
+
+	f1.Attempts = 2
+	require.NoError(t, s.SetFailure(f1), "replace existing")
+
+	res, found, err := s.GetFailure(feed.Entry{ChannelID: "chan1", VideoID: "vid1"})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, f1, res)
+
+	lst, err := s.ListFailures("chan1")
+	require.NoError(t, err)
+	assert.Equal(t, []feed.Failure{f2, f1}, lst, "newest first")
+
+	require.NoError(t, s.RemoveFailure(feed.Entry{ChannelID: "chan1", VideoID: "vid1"}))
+	_, found, err = s.GetFailure(feed.Entry{ChannelID: "chan1", VideoID: "vid1"})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	lst, err = s.ListFailures("chan1")
+	require.NoError(t, err)
+	assert.Equal(t, []feed.Failure{f2}, lst)
+}
+
+func TestBoltDB_Resolved(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test.db")
+	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	s := BoltDB{DB: db}
+
+	_, found, err := s.GetResolved("@handle1")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, s.SetResolved("@handle1", feed.Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: feed.FTChannel}))
+	require.NoError(t, s.SetResolved("https://www.youtube.com/playlist?list=PL123", feed.Resolved{ID: "PL123", Type: feed.FTPlaylist}))
+
+	res, found, err := s.GetResolved("@handle1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, feed.Resolved{ID: "UCaaaaaaaaaaaaaaaaaaaaaa", Type: feed.FTChannel}, res)
+
+	res, found, err = s.GetResolved("https://www.youtube.com/playlist?list=PL123")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, feed.Resolved{ID: "PL123", Type: feed.FTPlaylist}, res)
+}
+
+func TestBoltDB_Channels(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test.db")
+	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	s := BoltDB{DB: db}
+
+	res, err := s.ListChannels()
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	require.NoError(t, s.SetChannel("chan1", []byte(`{"id":"chan1"}`)))
+	require.NoError(t, s.SetChannel("chan2", []byte(`{"id":"chan2"}`)))
+	require.NoError(t, s.SetChannel("chan1", []byte(`{"id":"chan1","paused":true}`)))
+
+	res, err = s.ListChannels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"chan1": []byte(`{"id":"chan1","paused":true}`), "chan2": []byte(`{"id":"chan2"}`)}, res)
+
+	require.NoError(t, s.RemoveChannel("chan1"))
+	require.NoError(t, s.RemoveChannel("unknown"))
+	res, err = s.ListChannels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"chan2": []byte(`{"id":"chan2"}`)}, res)
+}
+
+func TestBoltDB_PurgeChannel(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test.db")
+	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	s := BoltDB{DB: db}
+
+	ts := time.Date(2022, time.March, 21, 16, 45, 22, 0, time.UTC)
+	entries := []feed.Entry{
+		{ChannelID: "chan1", VideoID: "vid1", File: "/tmp/vid1.mp3", Published: ts},
+		{ChannelID: "chan1", VideoID: "vid2", File: "/tmp/vid2.mp3", Published: ts.Add(time.Hour)},
+		{ChannelID: "chan2", VideoID: "vid3", File: "/tmp/vid3.mp3", Published: ts},
+	}
+	for _, e := range entries {
+		_, err = s.Save(e)
+		require.NoError(t, err)
+		require.NoError(t, s.SetProcessed(e))
+	}
+	require.NoError(t, s.SetFailure(feed.Failure{ChannelID: "chan1", VideoID: "vid1", Kind: feed.FKTransient}))
+
+	files, err := s.PurgeChannel("chan1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/tmp/vid1.mp3", "/tmp/vid2.mp3"}, files)
+
+	_, err = s.Load("chan1", 10)
+	require.Error(t, err, "no bucket")
+	found, _, err := s.CheckProcessed(entries[0])
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.GetFailure(entries[0])
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	res, err := s.Load("chan2", 10)
+	require.NoError(t, err)
+	assert.Len(t, res, 1, "other channel kept")
+	found, _, err = s.CheckProcessed(entries[2])
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	files, err = s.PurgeChannel("chan1")
+	require.NoError(t, err)
+	assert.Empty(t, files, "nothing to purge")
+}
+
+func TestBoltDB_FindVideoAndFile(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "test.db")
+	db, err := bolt.Open(tmpfile, 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+
+	s := BoltDB{DB: db}
+
+	ts := time.Date(2022, time.March, 21, 16, 45, 22, 0, time.UTC)
+	entries := []feed.Entry{
+		{ChannelID: "chan1", VideoID: "vid1", File: "/tmp/vid1.mp3", Published: ts},
+		{ChannelID: "chan1", VideoID: "vid2", File: "/tmp/vid2.mp3", Published: ts.Add(time.Hour)},
+		{ChannelID: "chan2", VideoID: "vid1", File: "/tmp/vid1.mp3", Published: ts},
+	}
+	for _, e := range entries {
+		_, err = s.Save(e)
+		require.NoError(t, err)
+		require.NoError(t, s.SetProcessed(e))
+	}
+	require.NoError(t, s.SetFailure(feed.Failure{ChannelID: "chan1", VideoID: "vid1", Kind: feed.FKTransient}))
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error { // bucket of another store in the shared db
+		bkt, e := tx.CreateBucketIfNotExists([]byte("other"))
+		if e != nil {
+			return e
+		}
+		return bkt.Put([]byte("key"), []byte("not an entry"))
+	}))
+
+	res, err := s.FindVideo("vid1")
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.ElementsMatch(t, []string{"chan1", "chan2"}, []string{res[0].ChannelID, res[1].ChannelID})
+
+	res, err = s.FindVideo("vid2")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "chan1", res[0].ChannelID)
+
+	res, err = s.FindFile("/tmp/vid1.mp3")
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+
+	require.NoError(t, s.Remove(entries[0]))
+	res, err = s.FindFile("/tmp/vid1.mp3")
+	require.NoError(t, err)
+	require.Len(t, res, 1, "still referenced by chan2")
+	assert.Equal(t, "chan2", res[0].ChannelID)
+
+	res, err = s.FindVideo("vid3")
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	res, err = s.FindFile("")
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// index updated on save, remove old and purge
+	_, err = s.Save(feed.Entry{ChannelID: "chan2", VideoID: "vid3", File: "/tmp/vid3.mp3", Published: ts.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	res, err = s.FindVideo("vid3")
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+	_, err = s.RemoveOld("chan2", 1)
+	require.NoError(t, err)
+	res, err = s.FindFile("/tmp/vid1.mp3")
+	require.NoError(t, err)
+	assert.Empty(t, res, "removed as old")
+	_, err = s.PurgeChannel("chan2")
+	require.NoError(t, err)
+	res, err = s.FindVideo("vid3")
+	require.NoError(t, err)
+	assert.Empty(t, res, "removed with purged channel")
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, 1, tx.Bucket(videosIdxBkt).Stats().KeyN, "only vid2 of chan1 left")
+		assert.Equal(t, 1, tx.Bucket(filesIdxBkt).Stats().KeyN)
+		return nil
+	}))
+}
+
+func TestBoltDB_FindBuildsIndex(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	s := BoltDB{DB: db}
+
+	ts := time.Date(2022, time.March, 21, 16, 45, 22, 0, time.UTC)
+	for _, e := range []feed.Entry{
+		{ChannelID: "chan1", VideoID: "vid1", File: "/tmp/vid1.mp3", Published: ts},
+		{ChannelID: "chan2", VideoID: "vid1", File: "/tmp/vid1.mp3", Published: ts},
+	} {
+		_, err = s.Save(e)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error { // db made before the index, with a bucket of another store
+		if e := tx.DeleteBucket(videosIdxBkt); e != nil && !errors.Is(e, bolt.ErrBucketNotFound) {
+			return e
+		}
+		if e := tx.DeleteBucket(filesIdxBkt); e != nil && !errors.Is(e, bolt.ErrBucketNotFound) {
+			return e
+		}
+		bkt, e := tx.CreateBucketIfNotExists([]byte("feed1"))
+		if e != nil {
+			return e
+		}
+		return bkt.Put([]byte("key"), []byte(`{"VideoID":"vid1","ChannelID":"chan1"}`))
+	}))
+
+	res, err := s.FindFile("/tmp/vid1.mp3")
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+	res, err = s.FindVideo("vid1")
+	require.NoError(t, err)
+	assert.Len(t, res, 2, "value of other bucket not indexed")
+
+	files, err := s.Files()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/vid1.mp3"}, files, "file of two entries listed once")
+}
@@ -0,0 +1,122 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+
+	log "github.com/go-pkgz/lgr"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+)
+
+// ErrWebSubDisabled returned for WebSub callbacks if push notifications are not enabled
+var ErrWebSubDisabled = errors.New("websub is disabled")
+
+// VerifySubscription checks hub's verification request, returns the challenge to echo back
+func (s *Service) VerifySubscription(q url.Values) (string, error) {
+	if s.WebSub == nil {
+		return "", ErrWebSubDisabled
+	}
+	challenge, err := s.WebSub.Verify(q)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify subscription: %w", err)
+	}
+	log.Printf("[INFO] websub %s verified for %s", q.Get("hub.mode"), q.Get("hub.topic"))
+	return challenge, nil
+}
+
+// Notify handles push notification from hub and schedules processing of the notified channels.
+// Returns ids of scheduled channels, unknown and paused channels ignored.
+func (s *Service) Notify(body []byte, signature string) ([]string, error) {
+	if s.WebSub == nil {
+		return nil, ErrWebSubDisabled
+	}
+	entries, err := s.WebSub.Parse(body, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notification: %w", err)
+	}
+
+	res := []string{}
+	for _, entry := range entries {
+		fi, ok := s.channel(entry.ChannelID)
+		if !ok || fi.Paused || slices.Contains(res, fi.ID) {
+			continue
+		}
+		log.Printf("[INFO] websub notification for %s (%s), video %s, %q", fi.ID, fi.Name, entry.VideoID, entry.Title)
+		res = append(res, fi.ID)
+	}
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	s.pushLock.Lock()
+	if s.pushed == nil {
+		s.pushed = map[string]bool{}
+	}
+	for _, id := range res {
+		s.pushed[id] = true
+	}
+	s.pushLock.Unlock()
+
+	select {
+	case s.pushCh() <- struct{}{}:
+	default: // already signaled, pending channels processed together
+	}
+	return res, nil
+}
+
+// renewSubscriptions subscribes active youtube channels to push notifications, renews leases
+// and unsubscribes deleted and paused channels
+func (s *Service) renewSubscriptions(ctx context.Context) {
+	if s.WebSub == nil {
+		return
+	}
+	ids := []string{}
+	for _, fi := range s.Channels() {
+		if fi.Paused || (fi.Type != ytfeed.FTChannel && fi.Type != ytfeed.FTDefault) { // hub has channels' feeds only
+			continue
+		}
+		ids = append(ids, fi.ID)
+	}
+	if err := s.WebSub.Renew(ctx, ids); err != nil {
+		log.Printf("[WARN] failed to renew websub subscriptions, %v", err)
+	}
+}
+
+// procPushed processes channels with push notifications received since the last run
+func (s *Service) procPushed(ctx context.Context) error {
+	s.pushLock.Lock()
+	ids := make([]string, 0, len(s.pushed))
+	for id := range s.pushed {
+		ids = append(ids, id)
+	}
+	s.pushed = nil
+	s.pushLock.Unlock()
+	sort.Strings(ids)
+
+	feeds := make([]FeedInfo, 0, len(ids))
+	for _, id := range ids {
+		if fi, ok := s.channel(id); ok {
+			feeds = append(feeds, fi)
+		}
+	}
+	if len(feeds) == 0 {
+		return nil
+	}
+	log.Printf("[INFO] processing %d channels with push notifications: %v", len(feeds), ids)
+	return s.procFeeds(ctx, feeds)
+}
+
+// pushCh returns the channel signaling pending push notifications, made on the first use
+func (s *Service) pushCh() chan struct{} {
+	s.pushLock.Lock()
+	defer s.pushLock.Unlock()
+	if s.pushSignal == nil {
+		s.pushSignal = make(chan struct{}, 1)
+	}
+	return s.pushSignal
+}
@@ -0,0 +1,101 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	ytfeed "github.com/umputun/feed-master/app/youtube/feed"
+	"github.com/umputun/feed-master/app/youtube/mocks"
+	"github.com/umputun/feed-master/app/youtube/store"
+)
+
+func TestService_Notify(t *testing.T) {
+	ws := &mocks.WebSubServiceMock{
+		ParseFunc: func(body []byte, _ string) ([]ytfeed.Entry, error) {
+			if string(body) == "bad" {
+				return nil, errors.New("signature mismatch")
+			}
+			return []ytfeed.Entry{{ChannelID: "ch1", VideoID: "vid1"}, {ChannelID: "ch2", VideoID: "vid2"},
+				{ChannelID: "ch1", VideoID: "vid3"}, {ChannelID: "unknown", VideoID: "vid4"}}, nil
+		},
+	}
+	chSvc := &mocks.ChannelServiceMock{
+		GetFunc: func(context.Context, string, ytfeed.Type) ([]ytfeed.Entry, error) { return nil, nil },
+	}
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0o600, &bolt.Options{Timeout: 1 * time.Second})
+	require.NoError(t, err)
+	svc := Service{
+		Feeds:          []FeedInfo{{ID: "ch1", Name: "name1"}, {ID: "ch2", Name: "name2", Paused: true}, {ID: "ch3", Name: "name3"}},
+		WebSub:         ws,
+		ChannelService: chSvc,
+		Store:          &store.BoltDB{DB: db},
+	}
+
+	_, err = svc.Notify([]byte("bad"), "sha1=123")
+	require.EqualError(t, err, "failed to parse notification: signature mismatch")
+	assert.Empty(t, svc.pushCh(), "nothing scheduled")
+
+	ids, err := svc.Notify([]byte("<feed/>"), "sha1=123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ch1"}, ids, "paused and unknown channels ignored")
+	require.Len(t, ws.ParseCalls(), 2)
+	assert.Equal(t, "sha1=123", ws.ParseCalls()[1].Signature)
+	_, err = svc.Notify([]byte("<feed/>"), "sha1=123")
+	require.NoError(t, err)
+	assert.Len(t, svc.pushCh(), 1, "signaled once")
+
+	require.NoError(t, svc.procPushed(context.Background()))
+	require.Len(t, chSvc.GetCalls(), 1, "only notified channel processed")
+	assert.Equal(t, "ch1", chSvc.GetCalls()[0].ChanID)
+	require.NoError(t, svc.procPushed(context.Background()))
+	assert.Len(t, chSvc.GetCalls(), 1, "pending channels cleared")
+
+	_, err = (&Service{}).Notify([]byte("<feed/>"), "")
+	require.ErrorIs(t, err, ErrWebSubDisabled)
+}
+
+func TestService_VerifySubscription(t *testing.T) {
+	ws := &mocks.WebSubServiceMock{
+		VerifyFunc: func(q url.Values) (string, error) {
+			if q.Get("hub.topic") == "bad" {
+				return "", errors.New("unknown topic")
+			}
+			return q.Get("hub.challenge"), nil
+		},
+	}
+	svc := Service{WebSub: ws}
+	res, err := svc.VerifySubscription(url.Values{"hub.topic": {"topic1"}, "hub.challenge": {"abc"}})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", res)
+
+	_, err = svc.VerifySubscription(url.Values{"hub.topic": {"bad"}, "hub.challenge": {"abc"}})
+	require.EqualError(t, err, "failed to verify subscription: unknown topic")
+
+	_, err = (&Service{}).VerifySubscription(url.Values{"hub.challenge": {"abc"}})
+	require.ErrorIs(t, err, ErrWebSubDisabled)
+}
+
+func TestService_renewSubscriptions(t *testing.T) {
+	ws := &mocks.WebSubServiceMock{
+		RenewFunc: func(context.Context, []string) error { return errors.New("failed") },
+	}
+	svc := Service{
+		Feeds: []FeedInfo{{ID: "ch1", Name: "name1"}, {ID: "ch2", Name: "name2", Paused: true},
+			{ID: "ch3", Name: "name3", Type: ytfeed.FTChannel}, {ID: "pl1", Name: "playlist", Type: ytfeed.FTPlaylist},
+			{ID: "vimeo", Name: "vimeo", Type: ytfeed.FTYtDlp, URL: "https://vimeo.com/showcase/1"}},
+		WebSub: ws,
+	}
+	svc.renewSubscriptions(context.Background())
+	require.Len(t, ws.RenewCalls(), 1)
+	assert.Equal(t, []string{"ch1", "ch3"}, ws.RenewCalls()[0].ChannelIDs)
+
+	(&Service{Feeds: svc.Feeds}).renewSubscriptions(context.Background()) // disabled, no panic
+}